@@ -0,0 +1,28 @@
+package cluster
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+)
+
+// NewClusterCommand returns a cobra command for `cluster` subcommands
+func NewClusterCommand(dockerCli command.Cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cluster",
+		Short: "Manage cluster-wide operations",
+		Args:  cli.NoArgs,
+		RunE:  command.ShowHelp(dockerCli.Err()),
+		Annotations: map[string]string{
+			"version": "1.24",
+			"swarm":   "manager",
+		},
+	}
+	cmd.AddCommand(
+		newUpgradePlanCommand(dockerCli),
+		newUpgradeNodeCommand(dockerCli),
+		newGraphCommand(dockerCli),
+	)
+	return cmd
+}
@@ -0,0 +1,128 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/graph"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type graphOptions struct {
+	output string
+}
+
+func newGraphCommand(dockerCli command.Cli) *cobra.Command {
+	opts := graphOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "graph [OPTIONS]",
+		Short: "Render the nodes, overlay networks and services of the swarm as a graph",
+		Long: `Render the nodes, overlay networks and services of the swarm as a graph.
+
+Each service is linked to the nodes running its tasks, with their number, and
+to its overlay networks. The graph is printed in the Graphviz dot language, as
+a Mermaid flowchart with -o mermaid, or as JSON with -o json.`,
+		Args: cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGraph(cmd.Context(), dockerCli, opts)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&opts.output, "output", "o", graph.FormatDot, `Output format ("dot", "mermaid", "json")`)
+	return cmd
+}
+
+func runGraph(ctx context.Context, dockerCli command.Cli, opts graphOptions) error {
+	switch opts.output {
+	case graph.FormatDot, graph.FormatMermaid, graph.FormatJSON:
+	default:
+		return errors.Errorf("invalid output format %q, must be one of dot, mermaid, json", opts.output)
+	}
+
+	client := dockerCli.Client()
+	nodes, err := client.NodeList(ctx, types.NodeListOptions{})
+	if err != nil {
+		return err
+	}
+	networks, err := client.NetworkList(ctx, types.NetworkListOptions{
+		Filters: filters.NewArgs(filters.Arg("driver", "overlay")),
+	})
+	if err != nil {
+		return err
+	}
+	services, err := client.ServiceList(ctx, types.ServiceListOptions{})
+	if err != nil {
+		return err
+	}
+	tasks, err := client.TaskList(ctx, types.TaskListOptions{
+		Filters: filters.NewArgs(filters.Arg("desired-state", "running")),
+	})
+	if err != nil {
+		return err
+	}
+
+	return graph.Write(dockerCli.Out(), clusterGraph(nodes, networks, services, tasks), opts.output)
+}
+
+// clusterGraph returns the graph of the swarm. Only the running tasks link
+// services to nodes.
+func clusterGraph(nodes []swarm.Node, networks []types.NetworkResource, services []swarm.Service, tasks []swarm.Task) *graph.Graph {
+	g := &graph.Graph{Name: "swarm"}
+	nodeIDs := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		role := "worker"
+		if node.ManagerStatus != nil {
+			role = "manager"
+			if node.ManagerStatus.Leader {
+				role = "leader"
+			}
+		}
+		status := string(node.Spec.Availability)
+		if node.Status.State != swarm.NodeStateReady {
+			status = string(node.Status.State)
+		}
+		label := fmt.Sprintf("%s (%s, %s)", node.Description.Hostname, role, status)
+		nodeIDs[node.ID] = g.AddNode(graph.KindNode, node.Description.Hostname, label)
+	}
+	networkIDs := make(map[string]string, len(networks))
+	for _, network := range networks {
+		networkIDs[network.ID] = g.AddNode(graph.KindNetwork, network.Name, network.Name)
+	}
+
+	running := make(map[string]map[string]int)
+	for _, task := range tasks {
+		if task.Status.State != swarm.TaskStateRunning || task.NodeID == "" {
+			continue
+		}
+		if running[task.ServiceID] == nil {
+			running[task.ServiceID] = make(map[string]int)
+		}
+		running[task.ServiceID][task.NodeID]++
+	}
+
+	for _, service := range services {
+		from := g.AddNode(graph.KindService, service.Spec.Name, service.Spec.Name)
+		for nodeID, count := range running[service.ID] {
+			if to, ok := nodeIDs[nodeID]; ok {
+				g.AddEdge(from, to, fmt.Sprintf("%d task(s)", count), false)
+			}
+		}
+		for _, attachment := range service.Spec.TaskTemplate.Networks {
+			if to, ok := networkIDs[attachment.Target]; ok {
+				g.AddEdge(from, to, "", true)
+			}
+		}
+	}
+	g.Sort()
+	return g
+}
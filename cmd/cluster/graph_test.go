@@ -0,0 +1,72 @@
+package cluster
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func graphClient(t *testing.T) *fakeClient {
+	runningTask := func(serviceID, nodeID string) swarm.Task {
+		return swarm.Task{
+			ServiceID: serviceID,
+			NodeID:    nodeID,
+			Status:    swarm.TaskStatus{State: swarm.TaskStateRunning},
+		}
+	}
+	return &fakeClient{
+		nodeListFunc: func() ([]swarm.Node, error) {
+			nodes := testCluster()[:2]
+			nodes[1].Spec.Availability = swarm.NodeAvailabilityDrain
+			return nodes, nil
+		},
+		networkListFunc: func(options types.NetworkListOptions) ([]types.NetworkResource, error) {
+			assert.Check(t, is.DeepEqual(options.Filters.Get("driver"), []string{"overlay"}))
+			return []types.NetworkResource{{ID: "net1", Name: "ingress"}, {ID: "net2", Name: "backend"}}, nil
+		},
+		serviceListFunc: func() ([]swarm.Service, error) {
+			return []swarm.Service{
+				{
+					ID: "s1",
+					Spec: swarm.ServiceSpec{
+						Annotations:  swarm.Annotations{Name: "web"},
+						TaskTemplate: swarm.TaskSpec{Networks: []swarm.NetworkAttachmentConfig{{Target: "net2"}}},
+					},
+				},
+				{
+					ID:   "s2",
+					Spec: swarm.ServiceSpec{Annotations: swarm.Annotations{Name: "idle"}},
+				},
+			}, nil
+		},
+		taskListFunc: func(options types.TaskListOptions) ([]swarm.Task, error) {
+			assert.Check(t, is.DeepEqual(options.Filters.Get("desired-state"), []string{"running"}))
+			starting := runningTask("s1", "w2")
+			starting.Status.State = swarm.TaskStateStarting
+			return []swarm.Task{runningTask("s1", "m1"), runningTask("s1", "m1"), runningTask("s1", "w2"), starting}, nil
+		},
+	}
+}
+
+func TestGraph(t *testing.T) {
+	for _, output := range []string{"dot", "mermaid", "json"} {
+		t.Run(output, func(t *testing.T) {
+			cli := test.NewFakeCli(graphClient(t))
+			cmd := newGraphCommand(cli)
+			cmd.SetArgs([]string{"-o", output})
+			assert.NilError(t, cmd.Execute())
+			test.AssertGolden(t, cli.OutBuffer().String(), fmt.Sprintf("graph-%s.golden", output))
+		})
+	}
+}
+
+func TestGraphInvalidOutput(t *testing.T) {
+	cmd := newGraphCommand(test.NewFakeCli(graphClient(t)))
+	cmd.SetArgs([]string{"-o", "svg"})
+	assert.Error(t, cmd.Execute(), `invalid output format "svg", must be one of dot, mermaid, json`)
+}
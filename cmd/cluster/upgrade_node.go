@@ -0,0 +1,212 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// labelUpgrade marks the node being upgraded, so that only one node
+	// is upgraded at a time.
+	labelUpgrade = "swarmctl.upgrade"
+	// labelUpgradeAvailability records the availability to restore once the
+	// upgrade is done, so that an interrupted upgrade can be resumed.
+	labelUpgradeAvailability = "swarmctl.upgrade.availability"
+	// labelUpgradeVersion records the engine version of the node before the
+	// upgrade, so that a resumed upgrade still waits for a new version.
+	labelUpgradeVersion = "swarmctl.upgrade.version"
+
+	upgradeInProgress = "in-progress"
+)
+
+// pollInterval is how often node and task state is checked while waiting.
+var pollInterval = 2 * time.Second
+
+type upgradeNodeOptions struct {
+	node          string
+	expectVersion string
+	drainTimeout  time.Duration
+	returnTimeout time.Duration
+	force         bool
+}
+
+func newUpgradeNodeCommand(dockerCli command.Cli) *cobra.Command {
+	opts := upgradeNodeOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "upgrade-node [OPTIONS] NODE",
+		Short: "Drain a node, wait for its engine to be upgraded, and reactivate it",
+		Args:  cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.node = args[0]
+			return runUpgradeNode(cmd.Context(), dockerCli, opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.expectVersion, "expect-version", "", "Engine version the node must report after the upgrade")
+	flags.DurationVar(&opts.drainTimeout, "drain-timeout", 10*time.Minute, "Maximum time to wait for tasks to leave the node")
+	flags.DurationVar(&opts.returnTimeout, "return-timeout", time.Hour, "Maximum time to wait for the node to come back upgraded")
+	flags.BoolVarP(&opts.force, "force", "f", false, "Upgrade a manager even if the swarm would lose quorum")
+	return cmd
+}
+
+func runUpgradeNode(ctx context.Context, dockerCli command.Cli, opts upgradeNodeOptions) error {
+	client := dockerCli.Client()
+	out := dockerCli.Out()
+
+	node, _, err := client.NodeInspectWithRaw(ctx, opts.node)
+	if err != nil {
+		return err
+	}
+	nodes, err := client.NodeList(ctx, types.NodeListOptions{})
+	if err != nil {
+		return err
+	}
+	if err := checkUpgradeAllowed(node, nodes, opts.force); err != nil {
+		return err
+	}
+
+	hostname := node.Description.Hostname
+	// Resuming an interrupted upgrade keeps the availability and the engine
+	// version recorded then: the engine may have been upgraded meanwhile.
+	if node.Spec.Labels[labelUpgrade] == "" {
+		version := node.Description.Engine.EngineVersion
+		if opts.expectVersion != "" && opts.expectVersion == version {
+			return errors.Errorf("node %s already runs engine %s", hostname, version)
+		}
+		if node.Spec.Labels == nil {
+			node.Spec.Labels = make(map[string]string)
+		}
+		node.Spec.Labels[labelUpgrade] = upgradeInProgress
+		node.Spec.Labels[labelUpgradeAvailability] = string(node.Spec.Availability)
+		node.Spec.Labels[labelUpgradeVersion] = version
+	}
+	previousVersion := node.Spec.Labels[labelUpgradeVersion]
+	if previousVersion == "" {
+		previousVersion = node.Description.Engine.EngineVersion
+	}
+	node.Spec.Availability = swarm.NodeAvailabilityDrain
+	if err := client.NodeUpdate(ctx, node.ID, node.Version, node.Spec); err != nil {
+		return errors.Wrapf(err, "failed to drain node %s", hostname)
+	}
+	fmt.Fprintf(out, "Draining node %s (engine %s).\n", hostname, previousVersion)
+
+	if err := waitForDrain(ctx, client, node.ID, opts.drainTimeout); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Node %s is drained. Upgrade its engine now; waiting for it to come back.\n", hostname)
+
+	node, err = waitForUpgrade(ctx, client, node.ID, previousVersion, opts.expectVersion, opts.returnTimeout)
+	if err != nil {
+		return errors.Wrapf(err, "node %s is left drained", hostname)
+	}
+
+	availability := swarm.NodeAvailability(node.Spec.Labels[labelUpgradeAvailability])
+	if availability == "" {
+		availability = swarm.NodeAvailabilityActive
+	}
+	node.Spec.Availability = availability
+	delete(node.Spec.Labels, labelUpgrade)
+	delete(node.Spec.Labels, labelUpgradeAvailability)
+	delete(node.Spec.Labels, labelUpgradeVersion)
+	if err := client.NodeUpdate(ctx, node.ID, node.Version, node.Spec); err != nil {
+		return errors.Wrapf(err, "failed to reactivate node %s", hostname)
+	}
+
+	fmt.Fprintf(out, "Node %s upgraded to engine %s and set back to %s.\n", hostname, node.Description.Engine.EngineVersion, availability)
+	return nil
+}
+
+// checkUpgradeAllowed makes sure a single node is upgraded at a time, and
+// that taking a manager down does not make the swarm lose quorum.
+func checkUpgradeAllowed(node swarm.Node, nodes []swarm.Node, force bool) error {
+	for _, n := range nodes {
+		if n.ID != node.ID && n.Spec.Labels[labelUpgrade] != "" {
+			return errors.Errorf("node %s is already being upgraded; finish that upgrade first", n.Description.Hostname)
+		}
+	}
+
+	if node.ManagerStatus == nil || force {
+		return nil
+	}
+	managers := managerNodes(nodes)
+	var reachable int
+	for _, m := range managers {
+		if m.ID != node.ID && m.ManagerStatus.Reachability == swarm.ReachabilityReachable {
+			reachable++
+		}
+	}
+	if quorum := len(managers)/2 + 1; reachable < quorum {
+		return errors.Errorf("upgrading manager %s would leave %d of %d managers reachable, below the quorum of %d; use --force to proceed anyway",
+			node.Description.Hostname, reachable, len(managers), quorum)
+	}
+	return nil
+}
+
+// waitForDrain waits until no task is running on the node anymore.
+func waitForDrain(ctx context.Context, apiClient client.APIClient, nodeID string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		tasks, err := apiClient.TaskList(ctx, types.TaskListOptions{
+			Filters: filters.NewArgs(filters.Arg("node", nodeID)),
+		})
+		if err != nil {
+			return err
+		}
+		var running int
+		for _, t := range tasks {
+			if t.Status.State == swarm.TaskStateRunning {
+				running++
+			}
+		}
+		if running == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.Errorf("timed out after %s waiting for %d task(s) to leave the node", timeout, running)
+		}
+		select {
+		case <-time.After(pollInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// waitForUpgrade waits for the node to be ready again with an engine version
+// different from previousVersion, or equal to expectVersion if set.
+func waitForUpgrade(ctx context.Context, apiClient client.APIClient, nodeID, previousVersion, expectVersion string, timeout time.Duration) (swarm.Node, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		node, _, err := apiClient.NodeInspectWithRaw(ctx, nodeID)
+		if err != nil {
+			return swarm.Node{}, err
+		}
+		version := node.Description.Engine.EngineVersion
+		if node.Status.State == swarm.NodeStateReady && version != previousVersion {
+			if expectVersion != "" && version != expectVersion {
+				return swarm.Node{}, errors.Errorf("node came back with engine %s, expected %s", version, expectVersion)
+			}
+			return node, nil
+		}
+		if time.Now().After(deadline) {
+			return swarm.Node{}, errors.Errorf("timed out after %s waiting for the node to come back with a new engine version", timeout)
+		}
+		select {
+		case <-time.After(pollInterval):
+		case <-ctx.Done():
+			return swarm.Node{}, ctx.Err()
+		}
+	}
+}
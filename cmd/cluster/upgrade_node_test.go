@@ -0,0 +1,207 @@
+package cluster
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestUpgradeNodeErrors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		args          []string
+		node          swarm.Node
+		nodes         []swarm.Node
+		expectedError string
+	}{
+		{
+			name:          "no-args",
+			expectedError: "requires exactly 1 argument",
+		},
+		{
+			name: "other-node-upgrading",
+			args: []string{"w1"},
+			node: testNode("w1", "worker1", "20.10.21", false, false),
+			nodes: func() []swarm.Node {
+				nodes := testCluster()
+				nodes[1].Spec.Labels = map[string]string{labelUpgrade: upgradeInProgress}
+				return nodes
+			}(),
+			expectedError: "node worker2 is already being upgraded; finish that upgrade first",
+		},
+		{
+			name: "quorum",
+			args: []string{"m1"},
+			node: testNode("m1", "manager1", "20.10.21", true, true),
+			nodes: func() []swarm.Node {
+				nodes := testCluster()
+				nodes[2].ManagerStatus.Reachability = swarm.ReachabilityUnreachable
+				return nodes
+			}(),
+			expectedError: "upgrading manager manager1 would leave 1 of 3 managers reachable, below the quorum of 2; use --force to proceed anyway",
+		},
+		{
+			name:          "already-upgraded",
+			args:          []string{"--expect-version=23.0.0", "w1"},
+			node:          testNode("w1", "worker1", "23.0.0", false, false),
+			nodes:         testCluster(),
+			expectedError: "node worker1 already runs engine 23.0.0",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := newUpgradeNodeCommand(test.NewFakeCli(&fakeClient{
+				nodeInspectFunc: func() (swarm.Node, []byte, error) {
+					return tc.node, nil, nil
+				},
+				nodeListFunc: func() ([]swarm.Node, error) {
+					return tc.nodes, nil
+				},
+			}))
+			cmd.SetArgs(tc.args)
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			assert.ErrorContains(t, cmd.Execute(), tc.expectedError)
+		})
+	}
+}
+
+func TestUpgradeNode(t *testing.T) {
+	defer func(interval time.Duration) { pollInterval = interval }(pollInterval)
+	pollInterval = 0
+
+	node := testNode("w2", "worker2", "20.10.21", false, false)
+	node.Spec.Availability = swarm.NodeAvailabilityPause
+
+	var (
+		updates   []swarm.NodeSpec
+		inspects  int
+		taskLists int
+	)
+	cli := test.NewFakeCli(&fakeClient{
+		nodeInspectFunc: func() (swarm.Node, []byte, error) {
+			inspects++
+			n := node
+			if len(updates) > 0 {
+				n.Spec = updates[len(updates)-1]
+				n.Spec.Labels = copyLabels(n.Spec.Labels)
+			}
+			switch inspects {
+			case 1:
+			case 2:
+				n.Status.State = swarm.NodeStateDown
+			default:
+				n.Description.Engine.EngineVersion = "23.0.0"
+			}
+			return n, nil, nil
+		},
+		nodeListFunc: func() ([]swarm.Node, error) {
+			return testCluster(), nil
+		},
+		nodeUpdateFunc: func(nodeID string, version swarm.Version, spec swarm.NodeSpec) error {
+			spec.Labels = copyLabels(spec.Labels)
+			updates = append(updates, spec)
+			return nil
+		},
+		taskListFunc: func(options types.TaskListOptions) ([]swarm.Task, error) {
+			taskLists++
+			assert.Check(t, options.Filters.ExactMatch("node", "w2"))
+			if taskLists == 1 {
+				return []swarm.Task{{Status: swarm.TaskStatus{State: swarm.TaskStateRunning}}}, nil
+			}
+			return []swarm.Task{{Status: swarm.TaskStatus{State: swarm.TaskStateShutdown}}}, nil
+		},
+	})
+	cmd := newUpgradeNodeCommand(cli)
+	cmd.SetArgs([]string{"--expect-version=23.0.0", "w2"})
+	assert.NilError(t, cmd.Execute())
+
+	assert.Assert(t, is.Len(updates, 2))
+	assert.Check(t, is.Equal(updates[0].Availability, swarm.NodeAvailabilityDrain))
+	assert.Check(t, is.DeepEqual(updates[0].Labels, map[string]string{
+		labelUpgrade:             upgradeInProgress,
+		labelUpgradeAvailability: "pause",
+		labelUpgradeVersion:      "20.10.21",
+	}))
+	assert.Check(t, is.Equal(updates[1].Availability, swarm.NodeAvailabilityPause))
+	assert.Check(t, is.Len(updates[1].Labels, 0))
+	assert.Check(t, is.Contains(cli.OutBuffer().String(), "Node worker2 upgraded to engine 23.0.0 and set back to pause."))
+}
+
+func TestUpgradeNodeResume(t *testing.T) {
+	defer func(interval time.Duration) { pollInterval = interval }(pollInterval)
+	pollInterval = 0
+
+	// The upgrade was interrupted after the engine was upgraded.
+	node := testNode("w2", "worker2", "23.0.0", false, false)
+	node.Spec.Availability = swarm.NodeAvailabilityDrain
+	node.Spec.Labels = map[string]string{
+		labelUpgrade:             upgradeInProgress,
+		labelUpgradeAvailability: "pause",
+		labelUpgradeVersion:      "20.10.21",
+	}
+
+	var updates []swarm.NodeSpec
+	cli := test.NewFakeCli(&fakeClient{
+		nodeInspectFunc: func() (swarm.Node, []byte, error) {
+			n := node
+			n.Spec.Labels = copyLabels(node.Spec.Labels)
+			return n, nil, nil
+		},
+		nodeListFunc: func() ([]swarm.Node, error) {
+			return testCluster(), nil
+		},
+		nodeUpdateFunc: func(nodeID string, version swarm.Version, spec swarm.NodeSpec) error {
+			spec.Labels = copyLabels(spec.Labels)
+			updates = append(updates, spec)
+			return nil
+		},
+	})
+	cmd := newUpgradeNodeCommand(cli)
+	cmd.SetArgs([]string{"--expect-version=23.0.0", "--return-timeout=0s", "w2"})
+	assert.NilError(t, cmd.Execute())
+
+	assert.Assert(t, is.Len(updates, 2))
+	assert.Check(t, is.Equal(updates[0].Labels[labelUpgradeVersion], "20.10.21"))
+	assert.Check(t, is.Equal(updates[1].Availability, swarm.NodeAvailabilityPause))
+	assert.Check(t, is.Len(updates[1].Labels, 0))
+	assert.Check(t, is.Contains(cli.OutBuffer().String(), "Node worker2 upgraded to engine 23.0.0 and set back to pause."))
+}
+
+func TestUpgradeNodeUnexpectedVersion(t *testing.T) {
+	defer func(interval time.Duration) { pollInterval = interval }(pollInterval)
+	pollInterval = 0
+
+	var inspects int
+	cmd := newUpgradeNodeCommand(test.NewFakeCli(&fakeClient{
+		nodeInspectFunc: func() (swarm.Node, []byte, error) {
+			inspects++
+			n := testNode("w2", "worker2", "20.10.21", false, false)
+			if inspects > 1 {
+				n.Description.Engine.EngineVersion = "22.06.0"
+			}
+			return n, nil, nil
+		},
+		nodeListFunc: func() ([]swarm.Node, error) {
+			return testCluster(), nil
+		},
+	}))
+	cmd.SetArgs([]string{"--expect-version=23.0.0", "w2"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "node worker2 is left drained: node came back with engine 22.06.0, expected 23.0.0")
+}
+
+func copyLabels(labels map[string]string) map[string]string {
+	c := make(map[string]string, len(labels))
+	for k, v := range labels {
+		c[k] = v
+	}
+	return c
+}
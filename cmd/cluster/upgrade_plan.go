@@ -0,0 +1,124 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/spf13/cobra"
+)
+
+func newUpgradePlanCommand(dockerCli command.Cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "upgrade-plan",
+		Short: "Show the order in which nodes should be upgraded",
+		Args:  cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUpgradePlan(cmd.Context(), dockerCli)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+	return cmd
+}
+
+func runUpgradePlan(ctx context.Context, dockerCli command.Cli) error {
+	client := dockerCli.Client()
+
+	nodes, err := client.NodeList(ctx, types.NodeListOptions{})
+	if err != nil {
+		return err
+	}
+
+	plan := upgradeOrder(nodes)
+	if err := printUpgradePlan(dockerCli.Out(), plan); err != nil {
+		return err
+	}
+
+	for _, warning := range planWarnings(plan) {
+		fmt.Fprintln(dockerCli.Err(), "WARNING: "+warning)
+	}
+	return nil
+}
+
+// upgradeOrder sorts nodes so that workers are upgraded first, then the
+// managers, with the leader last so that leadership only moves once.
+func upgradeOrder(nodes []swarm.Node) []swarm.Node {
+	rank := func(n swarm.Node) int {
+		switch {
+		case n.ManagerStatus == nil:
+			return 0
+		case !n.ManagerStatus.Leader:
+			return 1
+		default:
+			return 2
+		}
+	}
+	plan := append([]swarm.Node(nil), nodes...)
+	sort.SliceStable(plan, func(i, j int) bool {
+		if ri, rj := rank(plan[i]), rank(plan[j]); ri != rj {
+			return ri < rj
+		}
+		return plan[i].Description.Hostname < plan[j].Description.Hostname
+	})
+	return plan
+}
+
+func printUpgradePlan(out io.Writer, plan []swarm.Node) error {
+	w := tabwriter.NewWriter(out, 10, 1, 3, ' ', 0)
+	fmt.Fprintln(w, "STEP\tHOSTNAME\tROLE\tSTATUS\tAVAILABILITY\tENGINE VERSION")
+	for i, n := range plan {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n",
+			i+1,
+			n.Description.Hostname,
+			nodeRole(n),
+			n.Status.State,
+			n.Spec.Availability,
+			n.Description.Engine.EngineVersion,
+		)
+	}
+	return w.Flush()
+}
+
+func nodeRole(n swarm.Node) string {
+	switch {
+	case n.ManagerStatus == nil:
+		return "worker"
+	case n.ManagerStatus.Leader:
+		return "manager (leader)"
+	default:
+		return "manager"
+	}
+}
+
+func planWarnings(plan []swarm.Node) []string {
+	var warnings []string
+	for _, n := range plan {
+		if labels := n.Spec.Labels; labels[labelUpgrade] != "" {
+			warnings = append(warnings, fmt.Sprintf("node %s is marked as being upgraded", n.Description.Hostname))
+		}
+		if n.Status.State != swarm.NodeStateReady {
+			warnings = append(warnings, fmt.Sprintf("node %s is %s; bring it back before upgrading other nodes", n.Description.Hostname, n.Status.State))
+		}
+	}
+	if managers := len(managerNodes(plan)); managers > 0 && managers < 3 {
+		warnings = append(warnings, fmt.Sprintf("the swarm has %d manager(s); it cannot tolerate the loss of a manager, and will be unavailable while managers are upgraded", managers))
+	}
+	return warnings
+}
+
+func managerNodes(nodes []swarm.Node) []swarm.Node {
+	var managers []swarm.Node
+	for _, n := range nodes {
+		if n.ManagerStatus != nil {
+			managers = append(managers, n)
+		}
+	}
+	return managers
+}
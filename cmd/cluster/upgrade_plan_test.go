@@ -0,0 +1,83 @@
+package cluster
+
+import (
+	"io"
+	"testing"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/test"
+	"github.com/pkg/errors"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func testNode(id, hostname, version string, manager, leader bool) swarm.Node {
+	n := swarm.Node{
+		ID: id,
+		Spec: swarm.NodeSpec{
+			Availability: swarm.NodeAvailabilityActive,
+		},
+		Description: swarm.NodeDescription{
+			Hostname: hostname,
+			Engine:   swarm.EngineDescription{EngineVersion: version},
+		},
+		Status: swarm.NodeStatus{State: swarm.NodeStateReady},
+	}
+	if manager {
+		n.ManagerStatus = &swarm.ManagerStatus{
+			Leader:       leader,
+			Reachability: swarm.ReachabilityReachable,
+		}
+	}
+	return n
+}
+
+func testCluster() []swarm.Node {
+	return []swarm.Node{
+		testNode("m1", "manager1", "20.10.21", true, true),
+		testNode("w2", "worker2", "20.10.21", false, false),
+		testNode("m2", "manager2", "20.10.21", true, false),
+		testNode("w1", "worker1", "23.0.0", false, false),
+		testNode("m3", "manager3", "20.10.21", true, false),
+	}
+}
+
+func TestUpgradePlanError(t *testing.T) {
+	cmd := newUpgradePlanCommand(test.NewFakeCli(&fakeClient{
+		nodeListFunc: func() ([]swarm.Node, error) {
+			return nil, errors.Errorf("error listing nodes")
+		},
+	}))
+	cmd.SetArgs([]string{})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.ErrorContains(t, cmd.Execute(), "error listing nodes")
+}
+
+func TestUpgradePlan(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{
+		nodeListFunc: func() ([]swarm.Node, error) {
+			return testCluster(), nil
+		},
+	})
+	cmd := newUpgradePlanCommand(cli)
+	cmd.SetArgs([]string{})
+	assert.NilError(t, cmd.Execute())
+	test.AssertGolden(t, cli.OutBuffer().String(), "upgrade-plan.golden")
+	assert.Check(t, is.Equal(cli.ErrBuffer().String(), ""))
+}
+
+func TestUpgradePlanWarnings(t *testing.T) {
+	nodes := []swarm.Node{
+		testNode("m1", "manager1", "20.10.21", true, true),
+		testNode("w1", "worker1", "20.10.21", false, false),
+	}
+	nodes[1].Status.State = swarm.NodeStateDown
+	nodes[1].Spec.Labels = map[string]string{labelUpgrade: upgradeInProgress}
+
+	assert.Check(t, is.DeepEqual(planWarnings(upgradeOrder(nodes)), []string{
+		"node worker1 is marked as being upgraded",
+		"node worker1 is down; bring it back before upgrading other nodes",
+		"the swarm has 1 manager(s); it cannot tolerate the loss of a manager, and will be unavailable while managers are upgraded",
+	}))
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/moby/swarmctl/internal/settings"
+	"github.com/spf13/cobra"
+)
+
+// NewConfigCommand returns a cobra command for `config` subcommands. newRoot
+// builds the command tree the keys of the flag defaults are checked against.
+func NewConfigCommand(dockerCli command.Cli, newRoot func(command.Cli) *cobra.Command) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the default values of command flags",
+		Long: `Manage the default values of command flags.
+
+Defaults are stored in the docker CLI configuration file, and apply to the
+flags that are not set on the command line. They are keyed by the path of the
+command and the name of the flag, joined with dots: "swarm.ca.progress" is the
+default of the --progress flag of "swarmctl swarm ca".`,
+		Args: cli.NoArgs,
+		RunE: command.ShowHelp(dockerCli.Err()),
+	}
+	cmd.AddCommand(
+		newGetCommand(dockerCli),
+		newInitCommand(dockerCli, newRoot),
+		newListCommand(dockerCli),
+		newSetCommand(dockerCli, newRoot),
+		newUnsetCommand(dockerCli),
+	)
+	return cmd
+}
+
+// completeKeys completes the keys of the flag defaults that are set.
+func completeKeys(dockerCli command.Cli) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return settings.Keys(dockerCli.ConfigFile()), cobra.ShellCompDirectiveNoFileComp
+	}
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/moby/swarmctl/internal/settings"
+	"github.com/moby/swarmctl/internal/test"
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func newTestRoot(command.Cli) *cobra.Command {
+	root := &cobra.Command{Use: "swarmctl"}
+	ca := &cobra.Command{Use: "ca", RunE: func(*cobra.Command, []string) error { return nil }}
+	ca.Flags().String("progress", "auto", "")
+	ca.Flags().Bool("detach", false, "")
+	swarm := &cobra.Command{Use: "swarm"}
+	swarm.AddCommand(ca)
+	root.AddCommand(swarm)
+	return root
+}
+
+func newTestCli(t *testing.T) *test.FakeCli {
+	t.Helper()
+	cli := test.NewFakeCli(nil)
+	cli.ConfigFile().Filename = filepath.Join(t.TempDir(), "config.json")
+	return cli
+}
+
+func TestSetGetUnset(t *testing.T) {
+	cli := newTestCli(t)
+	cmd := newSetCommand(cli, newTestRoot)
+	cmd.SetArgs([]string{"swarm.ca.progress=plain", "swarm.ca.detach=true"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.DeepEqual(settings.Defaults(cli.ConfigFile()), map[string]string{
+		"swarm.ca.progress": "plain",
+		"swarm.ca.detach":   "true",
+	}))
+
+	cmd = newGetCommand(cli)
+	cmd.SetArgs([]string{"swarm.ca.progress"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "plain\n"))
+
+	cmd = newUnsetCommand(cli)
+	cmd.SetArgs([]string{"swarm.ca.progress"})
+	assert.NilError(t, cmd.Execute())
+
+	cmd = newGetCommand(cli)
+	cmd.SetArgs([]string{"swarm.ca.progress"})
+	cmd.SetOut(cli.OutBuffer())
+	cmd.SetErr(cli.ErrBuffer())
+	assert.Error(t, cmd.Execute(), "no default is set for swarm.ca.progress")
+}
+
+func TestSetErrors(t *testing.T) {
+	testCases := []struct {
+		arg           string
+		expectedError string
+	}{
+		{arg: "swarm.ca.progress", expectedError: `invalid argument "swarm.ca.progress", must be KEY=VALUE`},
+		{arg: "progress=plain", expectedError: `invalid key "progress", must be the path of a command and a flag, such as swarm.ca.progress`},
+		{arg: "swarm.init.progress=plain", expectedError: `invalid key "swarm.init.progress": unknown command "swarm init"`},
+		{arg: "swarm.ca.rotate=true", expectedError: `invalid key "swarm.ca.rotate": unknown flag --rotate for "swarmctl swarm ca"`},
+		{arg: "swarm.ca.detach=maybe", expectedError: `invalid value "maybe" for swarm.ca.detach: strconv.ParseBool: parsing "maybe": invalid syntax`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.arg, func(t *testing.T) {
+			cli := newTestCli(t)
+			cmd := newSetCommand(cli, newTestRoot)
+			cmd.SetArgs([]string{tc.arg})
+			cmd.SetOut(cli.OutBuffer())
+			cmd.SetErr(cli.ErrBuffer())
+			assert.Error(t, cmd.Execute(), tc.expectedError)
+			assert.Check(t, is.Len(settings.Keys(cli.ConfigFile()), 0))
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	cli := newTestCli(t)
+	settings.Set(cli.ConfigFile(), "swarm.ca.progress", "plain")
+	settings.Set(cli.ConfigFile(), "service.stats.format", "json")
+	cmd := newListCommand(cli)
+	cmd.SetArgs([]string{})
+	assert.NilError(t, cmd.Execute())
+	test.AssertGolden(t, cli.OutBuffer().String(), "config-list.golden")
+}
+
+func TestInit(t *testing.T) {
+	cli := newTestCli(t)
+	cli.SetInputScript("fancy", "plain")
+	cmd := newInitCommand(cli, newTestRoot)
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.DeepEqual(cli.Transcript(), []test.Exchange{
+		{Prompt: "Progress output of the commands waiting for services (auto, tty, plain, json) [auto]: ", Answer: "fancy"},
+		{Prompt: "Progress output of the commands waiting for services (auto, tty, plain, json) [auto]: ", Answer: "plain"},
+	}))
+	assert.Check(t, is.Contains(cli.ErrBuffer().String(), `invalid value "fancy", must be one of auto, tty, plain, json`))
+	assert.Check(t, is.DeepEqual(settings.Defaults(cli.ConfigFile()), map[string]string{"swarm.ca.progress": "plain"}))
+
+	cli.SetInputScript("")
+	cmd = newInitCommand(cli, newTestRoot)
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.Transcript()[0].Prompt, "Progress output of the commands waiting for services (auto, tty, plain, json) [plain]: "))
+	assert.Check(t, is.DeepEqual(settings.Defaults(cli.ConfigFile()), map[string]string{"swarm.ca.progress": "plain"}))
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/moby/swarmctl/internal/settings"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func newGetCommand(dockerCli command.Cli) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get KEY",
+		Short: "Display the default value of a command flag",
+		Args:  cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGet(dockerCli, args[0])
+		},
+		ValidArgsFunction: completeKeys(dockerCli),
+	}
+}
+
+func runGet(dockerCli command.Cli, key string) error {
+	value, ok := settings.Get(dockerCli.ConfigFile(), key)
+	if !ok {
+		return errors.Errorf("no default is set for %s", key)
+	}
+	fmt.Fprintln(dockerCli.Out(), value)
+	return nil
+}
@@ -0,0 +1,214 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/moby/swarmctl/internal/settings"
+	"github.com/spf13/cobra"
+)
+
+// question asks for the default of flags sharing the same values.
+type question struct {
+	prompt  string
+	choices []string
+	// keys are the flag defaults set by the answer. Those of commands
+	// missing from the tree are skipped.
+	keys []string
+	// flag, if set, adds the flags of this name of every command of the
+	// tree to keys.
+	flag string
+}
+
+var questions = []question{
+	{
+		prompt:  "Progress output of the commands waiting for services",
+		choices: []string{"auto", "tty", "plain", "json"},
+		flag:    "progress",
+	},
+	{
+		prompt:  "Output format of get",
+		choices: []string{"table", "json", "name"},
+		keys:    []string{"get.output"},
+	},
+	{
+		prompt:  "Output format of the stack and cluster graphs",
+		choices: []string{"dot", "mermaid", "json"},
+		keys:    []string{"stack.graph.output", "cluster.graph.output"},
+	},
+	{
+		prompt:  "Output format of cost report",
+		choices: []string{"table", "csv", "json"},
+		keys:    []string{"cost.report.format"},
+	},
+}
+
+func newInitCommand(dockerCli command.Cli, newRoot func(command.Cli) *cobra.Command) *cobra.Command {
+	return &cobra.Command{
+		Use:   "init",
+		Short: "Set the default docker context and output formats interactively",
+		Long: `Set the default docker context and output formats interactively.
+
+Each question shows the current value in brackets, an empty answer keeps it.
+Answers are checked before anything is saved, invalid ones are asked again.`,
+		Args: cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runInit(dockerCli, newRoot)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+}
+
+func runInit(dockerCli command.Cli, newRoot func(command.Cli) *cobra.Command) error {
+	cfg := dockerCli.ConfigFile()
+	in := bufio.NewReader(dockerCli.In())
+	out := dockerCli.Out()
+
+	contexts, err := contextNames(dockerCli)
+	if err != nil {
+		return err
+	}
+	if len(contexts) > 1 {
+		current := cfg.CurrentContext
+		if current == "" {
+			current = command.DefaultContextName
+		}
+		answer, err := ask(in, out, dockerCli.Err(), "Default docker context", contexts, current)
+		if err != nil {
+			return err
+		}
+		if answer == command.DefaultContextName {
+			answer = ""
+		}
+		cfg.CurrentContext = answer
+	}
+
+	root := newRoot(dockerCli)
+	for _, q := range questions {
+		keys := questionKeys(root, q)
+		if len(keys) == 0 {
+			continue
+		}
+		current := currentValue(root, cfg, keys)
+		answer, err := ask(in, out, dockerCli.Err(), q.prompt, q.choices, current)
+		if err != nil {
+			return err
+		}
+		if answer == current {
+			continue
+		}
+		for _, key := range keys {
+			settings.Set(cfg, key, answer)
+		}
+	}
+
+	if err := cfg.Save(); err != nil {
+		return err
+	}
+	fmt.Fprintf(dockerCli.Err(), "Configuration saved to %s\n", cfg.Filename)
+	return nil
+}
+
+// ask prompts for one of choices until a valid one is given, and returns
+// it. An empty answer, or the end of the input, returns current.
+func ask(in *bufio.Reader, out, errOut io.Writer, prompt string, choices []string, current string) (string, error) {
+	for {
+		fmt.Fprintf(out, "%s (%s) [%s]: ", prompt, strings.Join(choices, ", "), current)
+		line, err := in.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			if err == io.EOF {
+				fmt.Fprintln(out)
+			}
+			return current, nil
+		}
+		for _, choice := range choices {
+			if answer == choice {
+				return answer, nil
+			}
+		}
+		fmt.Fprintf(errOut, "invalid value %q, must be one of %s\n", answer, strings.Join(choices, ", "))
+		if err == io.EOF {
+			return current, nil
+		}
+	}
+}
+
+// contextNames returns the names of the docker contexts, the default one
+// first.
+func contextNames(dockerCli command.Cli) ([]string, error) {
+	names := []string{command.DefaultContextName}
+	s := dockerCli.ContextStore()
+	if s == nil {
+		return names, nil
+	}
+	contexts, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	var others []string
+	for _, c := range contexts {
+		if c.Name != command.DefaultContextName {
+			others = append(others, c.Name)
+		}
+	}
+	sort.Strings(others)
+	return append(names, others...), nil
+}
+
+// questionKeys returns the keys of the flag defaults set by the answer to q
+// that exist in the tree rooted at root.
+func questionKeys(root *cobra.Command, q question) []string {
+	var keys []string
+	for _, key := range q.keys {
+		if _, _, err := settings.Lookup(root, key); err == nil {
+			keys = append(keys, key)
+		}
+	}
+	if q.flag != "" {
+		var walk func(cmd *cobra.Command)
+		walk = func(cmd *cobra.Command) {
+			if flag := cmd.LocalFlags().Lookup(q.flag); flag != nil && !flag.Hidden && cmd.HasParent() {
+				keys = append(keys, settings.Key(cmd, q.flag))
+			}
+			for _, c := range cmd.Commands() {
+				walk(c)
+			}
+		}
+		walk(root)
+	}
+	return keys
+}
+
+// currentValue returns the value set for all keys, or the default of the
+// flag of the first key if they are not all set to the same value.
+func currentValue(root *cobra.Command, cfg *configfile.ConfigFile, keys []string) string {
+	first, ok := settings.Get(cfg, keys[0])
+	for _, key := range keys[1:] {
+		if value, _ := settings.Get(cfg, key); value != first {
+			ok = false
+		}
+	}
+	if ok {
+		return first
+	}
+	return defaultValue(root, keys[0])
+}
+
+func defaultValue(root *cobra.Command, key string) string {
+	_, flag, err := settings.Lookup(root, key)
+	if err != nil {
+		return ""
+	}
+	return flag.DefValue
+}
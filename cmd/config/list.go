@@ -0,0 +1,36 @@
+package config
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/moby/swarmctl/internal/settings"
+	"github.com/spf13/cobra"
+)
+
+func newListCommand(dockerCli command.Cli) *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the default values of command flags",
+		Args:    cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runList(dockerCli)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+}
+
+func runList(dockerCli command.Cli) error {
+	cfg := dockerCli.ConfigFile()
+	w := tabwriter.NewWriter(dockerCli.Out(), 10, 1, 3, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE")
+	for _, key := range settings.Keys(cfg) {
+		value, _ := settings.Get(cfg, key)
+		fmt.Fprintf(w, "%s\t%s\n", key, value)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/moby/swarmctl/internal/settings"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func newSetCommand(dockerCli command.Cli, newRoot func(command.Cli) *cobra.Command) *cobra.Command {
+	return &cobra.Command{
+		Use:   "set KEY=VALUE [KEY=VALUE...]",
+		Short: "Set the default value of command flags",
+		Example: `  $ swarmctl config set swarm.ca.progress=plain
+  $ swarmctl config set service.stats.no-stream=true service.stats.format=json`,
+		Args: cli.RequiresMinArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSet(dockerCli, newRoot, args)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+}
+
+func runSet(dockerCli command.Cli, newRoot func(command.Cli) *cobra.Command, args []string) error {
+	cfg := dockerCli.ConfigFile()
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return errors.Errorf("invalid argument %q, must be KEY=VALUE", arg)
+		}
+		// Commands are built again for every key, so that the values of
+		// previous keys are not appended to slice flags.
+		_, flag, err := settings.Lookup(newRoot(dockerCli), key)
+		if err != nil {
+			return err
+		}
+		if err := flag.Value.Set(value); err != nil {
+			return errors.Wrapf(err, "invalid value %q for %s", value, key)
+		}
+		settings.Set(cfg, key, value)
+	}
+	return cfg.Save()
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/moby/swarmctl/internal/settings"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func newUnsetCommand(dockerCli command.Cli) *cobra.Command {
+	return &cobra.Command{
+		Use:   "unset KEY [KEY...]",
+		Short: "Remove the default value of command flags",
+		Args:  cli.RequiresMinArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUnset(dockerCli, args)
+		},
+		ValidArgsFunction: completeKeys(dockerCli),
+	}
+}
+
+func runUnset(dockerCli command.Cli, keys []string) error {
+	cfg := dockerCli.ConfigFile()
+	for _, key := range keys {
+		if !settings.Unset(cfg, key) {
+			return errors.Errorf("no default is set for %s", key)
+		}
+	}
+	return cfg.Save()
+}
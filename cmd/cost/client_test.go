@@ -0,0 +1,50 @@
+package cost
+
+import (
+	"context"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+)
+
+type fakeClient struct {
+	client.Client
+	infoFunc        func() (types.Info, error)
+	statsFunc       func(containerID string, stream bool) (types.ContainerStats, error)
+	nodeListFunc    func(options types.NodeListOptions) ([]swarm.Node, error)
+	taskListFunc    func(options types.TaskListOptions) ([]swarm.Task, error)
+	serviceListFunc func(options types.ServiceListOptions) ([]swarm.Service, error)
+}
+
+func (cli *fakeClient) Info(context.Context) (types.Info, error) {
+	if cli.infoFunc != nil {
+		return cli.infoFunc()
+	}
+	return types.Info{}, nil
+}
+
+func (cli *fakeClient) ContainerStats(_ context.Context, containerID string, stream bool) (types.ContainerStats, error) {
+	return cli.statsFunc(containerID, stream)
+}
+
+func (cli *fakeClient) NodeList(_ context.Context, options types.NodeListOptions) ([]swarm.Node, error) {
+	if cli.nodeListFunc != nil {
+		return cli.nodeListFunc(options)
+	}
+	return nil, nil
+}
+
+func (cli *fakeClient) TaskList(_ context.Context, options types.TaskListOptions) ([]swarm.Task, error) {
+	if cli.taskListFunc != nil {
+		return cli.taskListFunc(options)
+	}
+	return nil, nil
+}
+
+func (cli *fakeClient) ServiceList(_ context.Context, options types.ServiceListOptions) ([]swarm.Service, error) {
+	if cli.serviceListFunc != nil {
+		return cli.serviceListFunc(options)
+	}
+	return nil, nil
+}
@@ -0,0 +1,25 @@
+package cost
+
+import (
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/spf13/cobra"
+)
+
+// NewCostCommand returns a cobra command for `cost` subcommands
+func NewCostCommand(dockerCli command.Cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cost",
+		Short: "Account for the resources used by services",
+		Args:  cli.NoArgs,
+		RunE:  command.ShowHelp(dockerCli.Err()),
+		Annotations: map[string]string{
+			"version": "1.24",
+			"swarm":   "manager",
+		},
+	}
+	cmd.AddCommand(
+		newReportCommand(dockerCli),
+	)
+	return cmd
+}
@@ -0,0 +1,264 @@
+package cost
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/engine"
+	"github.com/moby/swarmctl/internal/quota"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// Output formats of `cost report`.
+const (
+	formatTable = "table"
+	formatCSV   = "csv"
+	formatJSON  = "json"
+)
+
+const gib = 1 << 30
+
+type reportOptions struct {
+	cpuPrice    float64
+	memoryPrice float64
+	groupBy     string
+	usage       bool
+	format      string
+}
+
+func newReportCommand(dockerCli command.Cli) *cobra.Command {
+	opts := reportOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "report [OPTIONS]",
+		Short: "Report the cost of services, grouped by label",
+		Long: `Report the cost of services, grouped by label.
+
+The cost of a service is its CPUs and memory multiplied by their prices, for
+whatever period the prices are given for. Resources are the reservations of
+the tasks of the service, or their measured usage with --usage, which reads
+the statistics of every running task from the engine of its node.`,
+		Args: cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runReport(cmd.Context(), dockerCli, opts)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	flags := cmd.Flags()
+	flags.Float64Var(&opts.cpuPrice, "cpu-price", 0, "Price of a CPU")
+	flags.Float64Var(&opts.memoryPrice, "memory-price", 0, "Price of a GiB of memory")
+	flags.StringVar(&opts.groupBy, "group-by", swarmlabel.Namespace, "Group services by the value of this label")
+	flags.BoolVar(&opts.usage, "usage", false, "Use the measured usage of running tasks instead of their reservations")
+	flags.StringVar(&opts.format, "format", formatTable, `Output format ("table", "csv", "json")`)
+	return cmd
+}
+
+// costLine is the cost of a group of services.
+type costLine struct {
+	Group       string  `json:"group"`
+	Services    int     `json:"services"`
+	CPUs        float64 `json:"cpus"`
+	MemoryBytes int64   `json:"memoryBytes"`
+	CPUCost     float64 `json:"cpuCost"`
+	MemoryCost  float64 `json:"memoryCost"`
+	Cost        float64 `json:"cost"`
+}
+
+func runReport(ctx context.Context, dockerCli command.Cli, opts reportOptions) error {
+	switch opts.format {
+	case formatTable, formatCSV, formatJSON:
+	default:
+		return errors.Errorf("invalid format %q, must be one of table, csv, json", opts.format)
+	}
+	if opts.cpuPrice < 0 || opts.memoryPrice < 0 {
+		return errors.New("prices cannot be negative")
+	}
+
+	services, err := dockerCli.Client().ServiceList(ctx, types.ServiceListOptions{Status: true})
+	if err != nil {
+		return err
+	}
+	usage := make(map[string]quota.Usage, len(services))
+	if opts.usage {
+		if usage, err = measuredUsage(ctx, dockerCli); err != nil {
+			return err
+		}
+	} else {
+		for _, service := range services {
+			u := quota.Usage{}
+			u.Add(service)
+			usage[service.ID] = u
+		}
+	}
+
+	lines := costLines(services, usage, opts)
+	switch opts.format {
+	case formatJSON:
+		enc := json.NewEncoder(dockerCli.Out())
+		enc.SetIndent("", "    ")
+		return enc.Encode(lines)
+	case formatCSV:
+		return printCSV(dockerCli.Out(), lines)
+	default:
+		return printTable(dockerCli.Out(), lines)
+	}
+}
+
+// costLines returns the cost of each group of services, sorted by group,
+// followed by the total.
+func costLines(services []swarm.Service, usage map[string]quota.Usage, opts reportOptions) []costLine {
+	groups := make(map[string]*costLine)
+	total := costLine{Group: "TOTAL"}
+	for _, service := range services {
+		group := service.Spec.Labels[opts.groupBy]
+		if group == "" {
+			group = "-"
+		}
+		line, ok := groups[group]
+		if !ok {
+			line = &costLine{Group: group}
+			groups[group] = line
+		}
+		u := usage[service.ID]
+		for _, l := range []*costLine{line, &total} {
+			l.Services++
+			l.CPUs += float64(u.NanoCPUs) / 1e9
+			l.MemoryBytes += u.MemoryBytes
+		}
+	}
+
+	lines := make([]costLine, 0, len(groups)+1)
+	for _, line := range groups {
+		lines = append(lines, *line)
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i].Group < lines[j].Group })
+	lines = append(lines, total)
+	for i := range lines {
+		lines[i].CPUCost = lines[i].CPUs * opts.cpuPrice
+		lines[i].MemoryCost = float64(lines[i].MemoryBytes) / gib * opts.memoryPrice
+		lines[i].Cost = lines[i].CPUCost + lines[i].MemoryCost
+	}
+	return lines
+}
+
+// statsConcurrency is the number of task statistics read at the same time.
+const statsConcurrency = 8
+
+// measuredUsage returns the CPUs and memory used by the running tasks of
+// each service. Tasks whose statistics cannot be read are left out with a
+// warning.
+func measuredUsage(ctx context.Context, dockerCli command.Cli) (map[string]quota.Usage, error) {
+	client := dockerCli.Client()
+	tasks, err := client.TaskList(ctx, types.TaskListOptions{
+		Filters: filters.NewArgs(filters.Arg("desired-state", "running")),
+	})
+	if err != nil {
+		return nil, err
+	}
+	nodeList, err := client.NodeList(ctx, types.NodeListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	nodes := make(map[string]swarm.Node, len(nodeList))
+	for _, node := range nodeList {
+		nodes[node.ID] = node
+	}
+
+	resolver, err := engine.NewResolver(ctx, dockerCli)
+	if err != nil {
+		return nil, err
+	}
+	defer resolver.Close()
+
+	var (
+		mu    sync.Mutex
+		usage = make(map[string]quota.Usage)
+		wg    sync.WaitGroup
+		sem   = make(chan struct{}, statsConcurrency)
+	)
+	for _, task := range tasks {
+		if task.Status.State != swarm.TaskStateRunning || task.Status.ContainerStatus == nil || task.Status.ContainerStatus.ContainerID == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(task swarm.Task) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			stats, err := taskStats(ctx, resolver, nodes[task.NodeID], task.Status.ContainerStatus.ContainerID)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				fmt.Fprintf(dockerCli.Err(), "WARNING: no statistics for task %s: %s\n", task.ID, err)
+				return
+			}
+			u := usage[task.ServiceID]
+			u.Replicas++
+			u.NanoCPUs += int64(engine.CPUPercent(stats) / 100 * 1e9)
+			u.MemoryBytes += int64(engine.MemoryUsage(stats.MemoryStats))
+			usage[task.ServiceID] = u
+		}(task)
+	}
+	wg.Wait()
+	return usage, ctx.Err()
+}
+
+func taskStats(ctx context.Context, resolver *engine.Resolver, node swarm.Node, containerID string) (*types.StatsJSON, error) {
+	if node.ID == "" {
+		return nil, errors.New("node not found")
+	}
+	nodeClient, err := resolver.Client(node)
+	if err != nil {
+		return nil, err
+	}
+	return engine.Stats(ctx, nodeClient, containerID)
+}
+
+func printTable(out io.Writer, lines []costLine) error {
+	w := tabwriter.NewWriter(out, 10, 1, 3, ' ', 0)
+	fmt.Fprintln(w, "GROUP\tSERVICES\tCPUS\tMEMORY\tCPU COST\tMEMORY COST\tTOTAL COST")
+	for _, l := range lines {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%.2f\t%.2f\t%.2f\n",
+			l.Group, l.Services, strconv.FormatFloat(l.CPUs, 'f', 2, 64), quota.FormatMemory(l.MemoryBytes), l.CPUCost, l.MemoryCost, l.Cost)
+	}
+	return w.Flush()
+}
+
+func printCSV(out io.Writer, lines []costLine) error {
+	w := csv.NewWriter(out)
+	if err := w.Write([]string{"group", "services", "cpus", "memory_bytes", "cpu_cost", "memory_cost", "cost"}); err != nil {
+		return err
+	}
+	for _, l := range lines {
+		record := []string{
+			l.Group,
+			strconv.Itoa(l.Services),
+			strconv.FormatFloat(l.CPUs, 'f', -1, 64),
+			strconv.FormatInt(l.MemoryBytes, 10),
+			strconv.FormatFloat(l.CPUCost, 'f', 2, 64),
+			strconv.FormatFloat(l.MemoryCost, 'f', 2, 64),
+			strconv.FormatFloat(l.Cost, 'f', 2, 64),
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,147 @@
+package cost
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/test"
+	"github.com/pkg/errors"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func reservedService(id string, labels map[string]string, replicas uint64, nanoCPUs, memory int64) swarm.Service {
+	return swarm.Service{
+		ID: id,
+		Spec: swarm.ServiceSpec{
+			Annotations: swarm.Annotations{Name: id, Labels: labels},
+			Mode:        swarm.ServiceMode{Replicated: &swarm.ReplicatedService{Replicas: &replicas}},
+			TaskTemplate: swarm.TaskSpec{Resources: &swarm.ResourceRequirements{
+				Reservations: &swarm.Resources{NanoCPUs: nanoCPUs, MemoryBytes: memory},
+			}},
+		},
+	}
+}
+
+func reportClient() *fakeClient {
+	return &fakeClient{
+		serviceListFunc: func(types.ServiceListOptions) ([]swarm.Service, error) {
+			return []swarm.Service{
+				reservedService("web", map[string]string{"team": "shop"}, 2, 500000000, 512<<20),
+				reservedService("api", map[string]string{"team": "shop"}, 1, 1000000000, 1<<30),
+				reservedService("db", map[string]string{"team": "data"}, 1, 2000000000, 4<<30),
+				reservedService("tool", nil, 1, 0, 0),
+			}, nil
+		},
+	}
+}
+
+func TestReportReservations(t *testing.T) {
+	cli := test.NewFakeCli(reportClient())
+	cmd := newReportCommand(cli)
+	cmd.SetArgs([]string{"--group-by", "team", "--cpu-price", "20", "--memory-price", "2.5"})
+	assert.NilError(t, cmd.Execute())
+	test.AssertGolden(t, cli.OutBuffer().String(), "report-reservations.golden")
+}
+
+func TestReportCSV(t *testing.T) {
+	cli := test.NewFakeCli(reportClient())
+	cmd := newReportCommand(cli)
+	cmd.SetArgs([]string{"--group-by", "team", "--cpu-price", "20", "--memory-price", "2.5", "--format", "csv"})
+	assert.NilError(t, cmd.Execute())
+	test.AssertGolden(t, cli.OutBuffer().String(), "report-csv.golden")
+}
+
+func TestReportJSON(t *testing.T) {
+	cli := test.NewFakeCli(reportClient())
+	cmd := newReportCommand(cli)
+	cmd.SetArgs([]string{"--group-by", "team", "--cpu-price", "20", "--format", "json"})
+	assert.NilError(t, cmd.Execute())
+
+	var lines []costLine
+	assert.NilError(t, json.Unmarshal(cli.OutBuffer().Bytes(), &lines))
+	assert.Assert(t, is.Len(lines, 4))
+	assert.Check(t, is.DeepEqual(lines[2], costLine{Group: "shop", Services: 2, CPUs: 2, MemoryBytes: 2 << 30, CPUCost: 40, Cost: 40}))
+	assert.Check(t, is.Equal(lines[3].Group, "TOTAL"))
+	assert.Check(t, is.Equal(lines[3].Cost, 80.0))
+}
+
+func statsSample(t *testing.T, cpuDelta uint64, memory uint64) string {
+	t.Helper()
+	v := types.StatsJSON{
+		Stats: types.Stats{
+			PreCPUStats: types.CPUStats{CPUUsage: types.CPUUsage{TotalUsage: 1000}, SystemUsage: 10000},
+			CPUStats:    types.CPUStats{CPUUsage: types.CPUUsage{TotalUsage: 1000 + cpuDelta}, SystemUsage: 20000, OnlineCPUs: 4},
+			MemoryStats: types.MemoryStats{Usage: memory},
+		},
+	}
+	data, err := json.Marshal(v)
+	assert.NilError(t, err)
+	return string(data)
+}
+
+func runningTask(id, serviceID string) swarm.Task {
+	return swarm.Task{
+		ID:        id,
+		ServiceID: serviceID,
+		NodeID:    "id-local",
+		Status: swarm.TaskStatus{
+			State:           swarm.TaskStateRunning,
+			ContainerStatus: &swarm.ContainerStatus{ContainerID: "container-" + id},
+		},
+	}
+}
+
+func TestReportUsage(t *testing.T) {
+	apiClient := reportClient()
+	apiClient.infoFunc = func() (types.Info, error) {
+		return types.Info{Swarm: swarm.Info{NodeID: "id-local"}}, nil
+	}
+	apiClient.nodeListFunc = func(types.NodeListOptions) ([]swarm.Node, error) {
+		return []swarm.Node{{ID: "id-local"}}, nil
+	}
+	apiClient.taskListFunc = func(options types.TaskListOptions) ([]swarm.Task, error) {
+		assert.Check(t, is.DeepEqual(options.Filters.Get("desired-state"), []string{"running"}))
+		return []swarm.Task{runningTask("web1", "web"), runningTask("web2", "web"), runningTask("db1", "db")}, nil
+	}
+	apiClient.statsFunc = func(containerID string, stream bool) (types.ContainerStats, error) {
+		assert.Check(t, !stream)
+		switch containerID {
+		case "container-web1", "container-web2":
+			// a quarter of the system time on 4 CPUs is one CPU
+			return types.ContainerStats{Body: io.NopCloser(strings.NewReader(statsSample(t, 2500, 1<<30)))}, nil
+		default:
+			return types.ContainerStats{}, errors.New("no such container")
+		}
+	}
+
+	cli := test.NewFakeCli(apiClient)
+	cmd := newReportCommand(cli)
+	cmd.SetArgs([]string{"--usage", "--group-by", "team", "--cpu-price", "10", "--memory-price", "1", "--format", "json"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.ErrBuffer().String(), "WARNING: no statistics for task db1: no such container\n"))
+
+	var lines []costLine
+	assert.NilError(t, json.Unmarshal(cli.OutBuffer().Bytes(), &lines))
+	assert.Assert(t, is.Len(lines, 4))
+	assert.Check(t, is.DeepEqual(lines[2], costLine{Group: "shop", Services: 2, CPUs: 2, MemoryBytes: 2 << 30, CPUCost: 20, MemoryCost: 2, Cost: 22}))
+}
+
+func TestReportInvalid(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{args: []string{"--format", "xml"}, expected: `invalid format "xml", must be one of table, csv, json`},
+		{args: []string{"--cpu-price", "-1"}, expected: "prices cannot be negative"},
+	}
+	for _, tc := range testCases {
+		cmd := newReportCommand(test.NewFakeCli(reportClient()))
+		cmd.SetArgs(tc.args)
+		assert.Check(t, is.Error(cmd.Execute(), tc.expected))
+	}
+}
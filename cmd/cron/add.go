@@ -0,0 +1,109 @@
+package cron
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/cron"
+	"github.com/moby/swarmctl/internal/spec"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type addOptions struct {
+	schedule string
+	jobSpec  string
+	name     string
+}
+
+func newAddCommand(dockerCli command.Cli) *cobra.Command {
+	opts := addOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "add [OPTIONS] SCHEDULE",
+		Short: "Schedule a job",
+		Long: `Schedule a job.
+
+The job spec is a service spec in YAML, with the same fields as the output of
+"docker service inspect". Its mode defaults to a replicated job.`,
+		Example: `swarmctl cron add "0 3 * * *" --job-spec backup.yml`,
+		Args:    cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.schedule = args[0]
+			return runAdd(cmd.Context(), dockerCli, opts)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.jobSpec, "job-spec", "", "Path to the YAML spec of the job service")
+	flags.StringVar(&opts.name, "name", "", "Name of the scheduled job (defaults to the name in the job spec)")
+	_ = cmd.MarkFlagRequired("job-spec")
+	return cmd
+}
+
+func runAdd(ctx context.Context, dockerCli command.Cli, opts addOptions) error {
+	if _, err := cron.Parse(opts.schedule); err != nil {
+		return err
+	}
+	jobSpec, err := readJobSpec(opts.jobSpec)
+	if err != nil {
+		return err
+	}
+	name := opts.name
+	if name == "" {
+		name = jobSpec.Name
+	}
+	if name == "" {
+		return errors.New("the job spec has no name, use --name to name the scheduled job")
+	}
+
+	data, err := json.Marshal(jobSpec)
+	if err != nil {
+		return err
+	}
+	_, err = dockerCli.Client().ConfigCreate(ctx, swarm.ConfigSpec{
+		Annotations: swarm.Annotations{
+			Name: configPrefix + name,
+			Labels: map[string]string{
+				labelCron:     name,
+				labelSchedule: opts.schedule,
+			},
+		},
+		Data: data,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(dockerCli.Out(), name)
+	return nil
+}
+
+// readJobSpec reads the YAML spec of a job service.
+func readJobSpec(path string) (swarm.ServiceSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return swarm.ServiceSpec{}, err
+	}
+	doc, err := spec.YAMLToJSON(data)
+	if err != nil {
+		return swarm.ServiceSpec{}, errors.Wrapf(err, "invalid job spec %s", path)
+	}
+	var jobSpec swarm.ServiceSpec
+	if err := spec.Decode(doc, &jobSpec); err != nil {
+		return swarm.ServiceSpec{}, errors.Wrapf(err, "invalid job spec %s", path)
+	}
+	switch mode := jobSpec.Mode; {
+	case mode.Replicated != nil || mode.Global != nil:
+		return swarm.ServiceSpec{}, errors.Errorf("invalid job spec %s: the mode must be a replicated or global job", path)
+	case mode.ReplicatedJob == nil && mode.GlobalJob == nil:
+		jobSpec.Mode.ReplicatedJob = &swarm.ReplicatedJob{}
+	}
+	return jobSpec, nil
+}
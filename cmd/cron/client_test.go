@@ -0,0 +1,59 @@
+package cron
+
+import (
+	"context"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+)
+
+type fakeClient struct {
+	client.Client
+	configListFunc    func(options types.ConfigListOptions) ([]swarm.Config, error)
+	configCreateFunc  func(config swarm.ConfigSpec) (types.ConfigCreateResponse, error)
+	configInspectFunc func(id string) (swarm.Config, []byte, error)
+	configUpdateFunc  func(id string, version swarm.Version, config swarm.ConfigSpec) error
+	configRemoveFunc  func(id string) error
+	serviceCreateFunc func(service swarm.ServiceSpec) (types.ServiceCreateResponse, error)
+	serviceListFunc   func(options types.ServiceListOptions) ([]swarm.Service, error)
+	serviceRemoveFunc func(serviceID string) error
+}
+
+func (cli *fakeClient) ConfigList(_ context.Context, options types.ConfigListOptions) ([]swarm.Config, error) {
+	if cli.configListFunc != nil {
+		return cli.configListFunc(options)
+	}
+	return nil, nil
+}
+
+func (cli *fakeClient) ConfigCreate(_ context.Context, config swarm.ConfigSpec) (types.ConfigCreateResponse, error) {
+	return cli.configCreateFunc(config)
+}
+
+func (cli *fakeClient) ConfigInspectWithRaw(_ context.Context, id string) (swarm.Config, []byte, error) {
+	return cli.configInspectFunc(id)
+}
+
+func (cli *fakeClient) ConfigUpdate(_ context.Context, id string, version swarm.Version, config swarm.ConfigSpec) error {
+	return cli.configUpdateFunc(id, version, config)
+}
+
+func (cli *fakeClient) ConfigRemove(_ context.Context, id string) error {
+	return cli.configRemoveFunc(id)
+}
+
+func (cli *fakeClient) ServiceCreate(_ context.Context, service swarm.ServiceSpec, _ types.ServiceCreateOptions) (types.ServiceCreateResponse, error) {
+	return cli.serviceCreateFunc(service)
+}
+
+func (cli *fakeClient) ServiceList(_ context.Context, options types.ServiceListOptions) ([]swarm.Service, error) {
+	if cli.serviceListFunc != nil {
+		return cli.serviceListFunc(options)
+	}
+	return nil, nil
+}
+
+func (cli *fakeClient) ServiceRemove(_ context.Context, serviceID string) error {
+	return cli.serviceRemoveFunc(serviceID)
+}
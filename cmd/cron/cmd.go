@@ -0,0 +1,34 @@
+package cron
+
+import (
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/spf13/cobra"
+)
+
+// NewCronCommand returns a cobra command for `cron` subcommands
+func NewCronCommand(dockerCli command.Cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cron",
+		Short: "Manage scheduled jobs",
+		Long: `Manage scheduled jobs.
+
+A scheduled job is a job service spec and a cron schedule, stored in a swarm
+config. The "cron run" controller creates a replicated job from the spec
+every time the schedule is due. Schedules are evaluated in UTC.`,
+		Args: cli.NoArgs,
+		RunE: command.ShowHelp(dockerCli.Err()),
+		Annotations: map[string]string{
+			"version": "1.41",
+			"swarm":   "manager",
+		},
+	}
+	cmd.AddCommand(
+		newAddCommand(dockerCli),
+		newHistoryCommand(dockerCli),
+		newListCommand(dockerCli),
+		newRemoveCommand(dockerCli),
+		newRunCommand(dockerCli),
+	)
+	return cmd
+}
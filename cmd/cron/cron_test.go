@@ -0,0 +1,286 @@
+package cron
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"github.com/moby/swarmctl/internal/test"
+	"github.com/pkg/errors"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func setNow(t *testing.T, s string) {
+	t.Helper()
+	current, err := time.Parse(time.RFC3339, s)
+	assert.NilError(t, err)
+	now = func() time.Time { return current }
+	t.Cleanup(func() { now = time.Now })
+}
+
+func cronConfig(t *testing.T, name, schedule, lastRun string) swarm.Config {
+	t.Helper()
+	data, err := json.Marshal(swarm.ServiceSpec{
+		Annotations:  swarm.Annotations{Labels: map[string]string{"app": name}},
+		Mode:         swarm.ServiceMode{ReplicatedJob: &swarm.ReplicatedJob{}},
+		TaskTemplate: swarm.TaskSpec{ContainerSpec: &swarm.ContainerSpec{Image: name + ":latest"}},
+	})
+	assert.NilError(t, err)
+	labels := map[string]string{labelCron: name, labelSchedule: schedule}
+	if lastRun != "" {
+		labels[labelLastRun] = lastRun
+	}
+	return swarm.Config{
+		ID:   "id-" + name,
+		Meta: swarm.Meta{Version: swarm.Version{Index: 7}},
+		Spec: swarm.ConfigSpec{Annotations: swarm.Annotations{Name: configPrefix + name, Labels: labels}, Data: data},
+	}
+}
+
+func TestAdd(t *testing.T) {
+	jobSpec := filepath.Join(t.TempDir(), "backup.yml")
+	assert.NilError(t, os.WriteFile(jobSpec, []byte(`
+Name: backup
+TaskTemplate:
+  ContainerSpec:
+    Image: backup:1.0
+    Args: [--all]
+`), 0o644))
+
+	var created swarm.ConfigSpec
+	cli := test.NewFakeCli(&fakeClient{
+		configCreateFunc: func(config swarm.ConfigSpec) (types.ConfigCreateResponse, error) {
+			created = config
+			return types.ConfigCreateResponse{ID: "id-backup"}, nil
+		},
+	})
+	cmd := newAddCommand(cli)
+	cmd.SetArgs([]string{"0 3 * * *", "--job-spec", jobSpec})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "backup\n"))
+	assert.Check(t, is.Equal(created.Name, "swarmctl-cron-backup"))
+	assert.Check(t, is.DeepEqual(created.Labels, map[string]string{labelCron: "backup", labelSchedule: "0 3 * * *"}))
+
+	job, err := newCronJob(swarm.Config{Spec: created})
+	assert.NilError(t, err)
+	assert.Check(t, job.spec.Mode.ReplicatedJob != nil)
+	assert.Check(t, is.DeepEqual(job.spec.TaskTemplate.ContainerSpec.Args, []string{"--all"}))
+}
+
+func TestAddInvalid(t *testing.T) {
+	dir := t.TempDir()
+	unnamed := filepath.Join(dir, "unnamed.yml")
+	assert.NilError(t, os.WriteFile(unnamed, []byte("TaskTemplate: {}\n"), 0o644))
+	replicated := filepath.Join(dir, "replicated.yml")
+	assert.NilError(t, os.WriteFile(replicated, []byte("Name: web\nMode:\n  Replicated: {}\n"), 0o644))
+
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{
+			args:     []string{"0 3 * *", "--job-spec", unnamed},
+			expected: `invalid schedule "0 3 * *": expected 5 fields, got 4`,
+		},
+		{
+			args:     []string{"@daily", "--job-spec", unnamed},
+			expected: "the job spec has no name, use --name to name the scheduled job",
+		},
+		{
+			args:     []string{"@daily", "--job-spec", replicated},
+			expected: "invalid job spec " + replicated + ": the mode must be a replicated or global job",
+		},
+	}
+	for _, tc := range testCases {
+		cmd := newAddCommand(test.NewFakeCli(&fakeClient{}))
+		cmd.SetArgs(tc.args)
+		assert.Check(t, is.Error(cmd.Execute(), tc.expected))
+	}
+}
+
+func TestList(t *testing.T) {
+	setNow(t, "2024-01-31T02:30:00Z")
+	cli := test.NewFakeCli(&fakeClient{
+		configListFunc: func(options types.ConfigListOptions) ([]swarm.Config, error) {
+			assert.Check(t, is.DeepEqual(options.Filters.Get("label"), []string{labelCron}))
+			return []swarm.Config{
+				cronConfig(t, "report", "@monthly", ""),
+				cronConfig(t, "backup", "0 3 * * *", "2024-01-30T03:00:00Z"),
+			}, nil
+		},
+	})
+	cmd := newListCommand(cli)
+	cmd.SetArgs([]string{})
+	assert.NilError(t, cmd.Execute())
+	test.AssertGolden(t, cli.OutBuffer().String(), "cron-list.golden")
+}
+
+func TestListTime(t *testing.T) {
+	setNow(t, "2024-01-31T02:30:00Z")
+	cli := test.NewFakeCli(&fakeClient{
+		configListFunc: func(options types.ConfigListOptions) ([]swarm.Config, error) {
+			return []swarm.Config{cronConfig(t, "backup", "0 3 * * *", "2024-01-30T03:00:00Z")}, nil
+		},
+	})
+	cmd := newListCommand(cli)
+	cmd.SetArgs([]string{"--time", "relative"})
+	assert.NilError(t, cmd.Execute())
+	test.AssertGolden(t, cli.OutBuffer().String(), "cron-list-relative.golden")
+
+	cli.ResetOutputBuffers()
+	cmd = newListCommand(cli)
+	cmd.SetArgs([]string{"--time", "unix"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Contains(cli.OutBuffer().String(), "1706670000   1706583600"))
+}
+
+func TestRunOnce(t *testing.T) {
+	setNow(t, "2024-01-31T03:00:42Z")
+	var (
+		claimed []swarm.ConfigSpec
+		started []swarm.ServiceSpec
+		removed []string
+	)
+	cli := test.NewFakeCli(&fakeClient{
+		configListFunc: func(types.ConfigListOptions) ([]swarm.Config, error) {
+			return []swarm.Config{
+				cronConfig(t, "backup", "0 3 * * *", "2024-01-30T03:00:00Z"),
+				// claimed by another controller, but not listed as such yet
+				cronConfig(t, "cleanup", "0 * * * *", ""),
+				// not due
+				cronConfig(t, "report", "@daily", ""),
+				// already run this minute
+				cronConfig(t, "sync", "*/5 * * * *", "2024-01-31T03:00:00Z"),
+				// invalid, skipped
+				cronConfig(t, "broken", "0 3 * *", ""),
+			}, nil
+		},
+		configUpdateFunc: func(id string, version swarm.Version, config swarm.ConfigSpec) error {
+			assert.Check(t, is.Equal(version.Index, uint64(7)))
+			if id == "id-cleanup" {
+				return errors.New("Error response from daemon: rpc error: code = Unknown desc = update out of sequence")
+			}
+			claimed = append(claimed, config)
+			return nil
+		},
+		serviceCreateFunc: func(service swarm.ServiceSpec) (types.ServiceCreateResponse, error) {
+			started = append(started, service)
+			return types.ServiceCreateResponse{}, nil
+		},
+		serviceListFunc: func(options types.ServiceListOptions) ([]swarm.Service, error) {
+			return []swarm.Service{
+				{ID: "job3", Spec: swarm.ServiceSpec{Annotations: swarm.Annotations{Labels: map[string]string{labelScheduled: "2024-01-31T03:00:00Z"}}}},
+				{ID: "job1", Spec: swarm.ServiceSpec{Annotations: swarm.Annotations{Labels: map[string]string{labelScheduled: "2024-01-29T03:00:00Z"}}}},
+				{ID: "job2", Spec: swarm.ServiceSpec{Annotations: swarm.Annotations{Labels: map[string]string{labelScheduled: "2024-01-30T03:00:00Z"}}}},
+			}, nil
+		},
+		serviceRemoveFunc: func(serviceID string) error {
+			removed = append(removed, serviceID)
+			return nil
+		},
+	})
+	cmd := newRunCommand(cli)
+	cmd.SetArgs([]string{"--once", "--keep", "2"})
+	assert.NilError(t, cmd.Execute())
+
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "backup started backup-202401310300\n"))
+	assert.Check(t, is.Contains(cli.ErrBuffer().String(), "WARNING: skipping scheduled job broken: "))
+	assert.Assert(t, is.Len(claimed, 1))
+	assert.Check(t, is.Equal(claimed[0].Labels[labelLastRun], "2024-01-31T03:00:00Z"))
+	assert.Assert(t, is.Len(started, 1))
+	assert.Check(t, is.DeepEqual(started[0].Labels, map[string]string{
+		"app":          "backup",
+		labelCron:      "backup",
+		labelScheduled: "2024-01-31T03:00:00Z",
+	}))
+	assert.Check(t, is.Equal(started[0].TaskTemplate.ContainerSpec.Image, "backup:latest"))
+	assert.Check(t, is.DeepEqual(removed, []string{"job1"}))
+}
+
+func TestRunOnceCreateFailed(t *testing.T) {
+	setNow(t, "2024-01-31T03:00:42Z")
+	backup := cronConfig(t, "backup", "0 3 * * *", "2024-01-30T03:00:00Z")
+	var claimed []swarm.ConfigSpec
+	cli := test.NewFakeCli(&fakeClient{
+		configListFunc: func(types.ConfigListOptions) ([]swarm.Config, error) {
+			return []swarm.Config{backup}, nil
+		},
+		configInspectFunc: func(id string) (swarm.Config, []byte, error) {
+			config := backup
+			config.Version.Index = 8
+			return config, nil, nil
+		},
+		configUpdateFunc: func(id string, version swarm.Version, config swarm.ConfigSpec) error {
+			assert.Check(t, is.Equal(version.Index, uint64(7+len(claimed))))
+			claimed = append(claimed, config)
+			return nil
+		},
+		serviceCreateFunc: func(service swarm.ServiceSpec) (types.ServiceCreateResponse, error) {
+			return types.ServiceCreateResponse{}, errors.New("name conflicts with an existing object")
+		},
+	})
+	cmd := newRunCommand(cli)
+	cmd.SetArgs([]string{"--once"})
+	assert.NilError(t, cmd.Execute())
+
+	assert.Check(t, is.Equal(cli.ErrBuffer().String(), "WARNING: scheduled job backup: name conflicts with an existing object\n"))
+	assert.Assert(t, is.Len(claimed, 2))
+	assert.Check(t, is.Equal(claimed[0].Labels[labelLastRun], "2024-01-31T03:00:00Z"))
+	assert.Check(t, is.Equal(claimed[1].Labels[labelLastRun], "2024-01-30T03:00:00Z"))
+}
+
+func TestRunOnceQuotaExceeded(t *testing.T) {
+	quotaFile := filepath.Join(t.TempDir(), "quotas.yml")
+	assert.NilError(t, os.WriteFile(quotaFile, []byte("namespaces:\n  shop:\n    services: 1\n"), 0o644))
+	t.Setenv("SWARMCTL_QUOTA_FILE", quotaFile)
+	setNow(t, "2024-01-31T03:00:42Z")
+
+	backup := cronConfig(t, "backup", "0 3 * * *", "")
+	data, err := json.Marshal(swarm.ServiceSpec{
+		Annotations: swarm.Annotations{Labels: map[string]string{swarmlabel.Namespace: "shop"}},
+		Mode:        swarm.ServiceMode{ReplicatedJob: &swarm.ReplicatedJob{}},
+	})
+	assert.NilError(t, err)
+	backup.Spec.Data = data
+	cli := test.NewFakeCli(&fakeClient{
+		configListFunc: func(types.ConfigListOptions) ([]swarm.Config, error) {
+			return []swarm.Config{backup}, nil
+		},
+		serviceListFunc: func(options types.ServiceListOptions) ([]swarm.Service, error) {
+			return []swarm.Service{{ID: "web", Spec: swarm.ServiceSpec{Annotations: swarm.Annotations{Labels: map[string]string{swarmlabel.Namespace: "shop"}}}}}, nil
+		},
+	})
+	cmd := newRunCommand(cli)
+	cmd.SetArgs([]string{"--once"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.ErrBuffer().String(), "WARNING: scheduled job backup: quota exceeded for namespace shop: services 2/1\n"))
+}
+
+func TestHistory(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{
+		serviceListFunc: func(options types.ServiceListOptions) ([]swarm.Service, error) {
+			assert.Check(t, is.DeepEqual(options.Filters.Get("label"), []string{labelCron + "=backup"}))
+			assert.Check(t, options.Status)
+			return []swarm.Service{
+				{
+					Spec:          swarm.ServiceSpec{Annotations: swarm.Annotations{Name: "backup-202401310300", Labels: map[string]string{labelScheduled: "2024-01-31T03:00:00Z"}}},
+					ServiceStatus: &swarm.ServiceStatus{RunningTasks: 1},
+				},
+				{
+					Spec:          swarm.ServiceSpec{Annotations: swarm.Annotations{Name: "backup-202401300300", Labels: map[string]string{labelScheduled: "2024-01-30T03:00:00Z"}}},
+					ServiceStatus: &swarm.ServiceStatus{CompletedTasks: 1},
+				},
+			}, nil
+		},
+	})
+	cmd := newHistoryCommand(cli)
+	cmd.SetArgs([]string{"backup"})
+	assert.NilError(t, cmd.Execute())
+	test.AssertGolden(t, cli.OutBuffer().String(), "cron-history.golden")
+}
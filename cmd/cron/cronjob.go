@@ -0,0 +1,109 @@
+package cron
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+	"github.com/moby/swarmctl/internal/cron"
+	"github.com/pkg/errors"
+)
+
+const (
+	// labelCron is set on the configs of scheduled jobs and on the services
+	// they create, to the name of the scheduled job.
+	labelCron = "swarmctl.cron"
+	// labelSchedule is the cron schedule of a scheduled job.
+	labelSchedule = "swarmctl.cron.schedule"
+	// labelLastRun is the last time a scheduled job was due, in RFC 3339.
+	labelLastRun = "swarmctl.cron.last-run"
+	// labelScheduled is the time a job service was due, in RFC 3339.
+	labelScheduled = "swarmctl.cron.scheduled"
+
+	// configPrefix prefixes the names of the configs of scheduled jobs.
+	configPrefix = "swarmctl-cron-"
+)
+
+// now returns the current time; tests replace it.
+var now = time.Now
+
+// cronJob is a scheduled job, stored in a swarm config.
+type cronJob struct {
+	name     string
+	schedule *cron.Schedule
+	lastRun  time.Time
+	spec     swarm.ServiceSpec
+	config   swarm.Config
+}
+
+func newCronJob(config swarm.Config) (cronJob, error) {
+	job := cronJob{name: config.Spec.Labels[labelCron], config: config}
+	schedule, err := cron.Parse(config.Spec.Labels[labelSchedule])
+	if err != nil {
+		return cronJob{}, errors.Wrapf(err, "scheduled job %s", job.name)
+	}
+	job.schedule = schedule
+	if lastRun := config.Spec.Labels[labelLastRun]; lastRun != "" {
+		if job.lastRun, err = time.Parse(time.RFC3339, lastRun); err != nil {
+			return cronJob{}, errors.Wrapf(err, "scheduled job %s: invalid last run", job.name)
+		}
+	}
+	if err := json.Unmarshal(config.Spec.Data, &job.spec); err != nil {
+		return cronJob{}, errors.Wrapf(err, "scheduled job %s: invalid job spec", job.name)
+	}
+	return job, nil
+}
+
+// listCronJobs returns the scheduled jobs, sorted by name. The jobs whose
+// config is invalid are reported on errOut and skipped, so that they do not
+// prevent the others from running.
+func listCronJobs(ctx context.Context, apiClient client.APIClient, errOut io.Writer) ([]cronJob, error) {
+	configs, err := apiClient.ConfigList(ctx, types.ConfigListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", labelCron)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]cronJob, 0, len(configs))
+	for _, config := range configs {
+		if !strings.HasPrefix(config.Spec.Name, configPrefix) {
+			continue
+		}
+		job, err := newCronJob(config)
+		if err != nil {
+			fmt.Fprintf(errOut, "WARNING: skipping %s\n", err)
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].name < jobs[j].name })
+	return jobs, nil
+}
+
+// jobServices returns the job services created for a scheduled job, oldest
+// first.
+func jobServices(ctx context.Context, apiClient client.APIClient, name string) ([]swarm.Service, error) {
+	services, err := apiClient.ServiceList(ctx, types.ServiceListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", labelCron+"="+name)),
+		Status:  true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Spec.Labels[labelScheduled] < services[j].Spec.Labels[labelScheduled]
+	})
+	return services, nil
+}
+
+// timeLayout is the layout of the absolute times of scheduled jobs, which
+// run at most once a minute.
+const timeLayout = "2006-01-02 15:04"
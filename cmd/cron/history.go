@@ -0,0 +1,56 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/moby/swarmctl/internal/timefmt"
+	"github.com/spf13/cobra"
+)
+
+type historyOptions struct {
+	name string
+	time timefmt.Mode
+}
+
+func newHistoryCommand(dockerCli command.Cli) *cobra.Command {
+	opts := historyOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "history [OPTIONS] NAME",
+		Short: "Display the jobs run by a scheduled job",
+		Args:  cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.name = args[0]
+			return runHistory(cmd.Context(), dockerCli, opts)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	timefmt.AddFlag(cmd.Flags(), &opts.time)
+	return cmd
+}
+
+func runHistory(ctx context.Context, dockerCli command.Cli, opts historyOptions) error {
+	services, err := jobServices(ctx, dockerCli.Client(), opts.name)
+	if err != nil {
+		return err
+	}
+	current := now()
+	w := tabwriter.NewWriter(dockerCli.Out(), 10, 1, 3, ' ', 0)
+	fmt.Fprintln(w, "JOB\tSCHEDULED\tRUNNING\tCOMPLETED")
+	for _, service := range services {
+		scheduled, _ := time.Parse(time.RFC3339, service.Spec.Labels[labelScheduled])
+		var running, completed uint64
+		if status := service.ServiceStatus; status != nil {
+			running, completed = status.RunningTasks, status.CompletedTasks
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\n", service.Spec.Name, opts.time.Format(scheduled, current, timeLayout), running, completed)
+	}
+	return w.Flush()
+}
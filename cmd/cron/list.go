@@ -0,0 +1,54 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/moby/swarmctl/internal/timefmt"
+	"github.com/spf13/cobra"
+)
+
+type listOptions struct {
+	time timefmt.Mode
+}
+
+func newListCommand(dockerCli command.Cli) *cobra.Command {
+	opts := listOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Short:   "List scheduled jobs",
+		Args:    cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runList(cmd.Context(), dockerCli, opts)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	timefmt.AddFlag(cmd.Flags(), &opts.time)
+	return cmd
+}
+
+func runList(ctx context.Context, dockerCli command.Cli, opts listOptions) error {
+	jobs, err := listCronJobs(ctx, dockerCli.Client(), dockerCli.Err())
+	if err != nil {
+		return err
+	}
+	current := now()
+	w := tabwriter.NewWriter(dockerCli.Out(), 10, 1, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSCHEDULE\tNEXT RUN\tLAST RUN")
+	for _, job := range jobs {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			job.name,
+			job.config.Spec.Labels[labelSchedule],
+			opts.time.Format(job.schedule.Next(current.UTC()), current, timeLayout),
+			opts.time.Format(job.lastRun, current, timeLayout),
+		)
+	}
+	return w.Flush()
+}
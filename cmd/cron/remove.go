@@ -0,0 +1,44 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func newRemoveCommand(dockerCli command.Cli) *cobra.Command {
+	return &cobra.Command{
+		Use:     "rm NAME [NAME...]",
+		Aliases: []string{"remove"},
+		Short:   "Remove scheduled jobs",
+		Long: `Remove scheduled jobs.
+
+The job services already created are kept, along with their history.`,
+		Args: cli.RequiresMinArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRemove(cmd.Context(), dockerCli, args)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+}
+
+func runRemove(ctx context.Context, dockerCli command.Cli, names []string) error {
+	var errs []string
+	for _, name := range names {
+		if err := dockerCli.Client().ConfigRemove(ctx, configPrefix+name); err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		fmt.Fprintln(dockerCli.Out(), name)
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+	return nil
+}
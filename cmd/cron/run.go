@@ -0,0 +1,175 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/moby/swarmctl/internal/quota"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type runOptions struct {
+	once bool
+	keep int
+}
+
+func newRunCommand(dockerCli command.Cli) *cobra.Command {
+	opts := runOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "run [OPTIONS]",
+		Short: "Run the scheduled jobs when they are due",
+		Long: `Run the scheduled jobs when they are due.
+
+Every minute, a job service is created for each scheduled job that is due.
+Several controllers can run at the same time: each due job is claimed by
+updating the labels of its config, which only one of them can do. Jobs that
+were due while no controller was running are not caught up, and jobs that
+would exceed the quotas of their namespace are not started.`,
+		Args: cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRun(cmd.Context(), dockerCli, opts)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVar(&opts.once, "once", false, "Run the jobs due in the current minute, and exit")
+	flags.IntVar(&opts.keep, "keep", 10, "Number of finished job services to keep for each scheduled job")
+	return cmd
+}
+
+func runRun(ctx context.Context, dockerCli command.Cli, opts runOptions) error {
+	for {
+		minute := now().UTC().Truncate(time.Minute)
+		if err := runDue(ctx, dockerCli, minute, opts); err != nil {
+			if opts.once {
+				return err
+			}
+			fmt.Fprintf(dockerCli.Err(), "WARNING: %s\n", err)
+		}
+		if opts.once {
+			return nil
+		}
+
+		select {
+		case <-time.After(minute.Add(time.Minute).Sub(now())):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// runDue starts the scheduled jobs due at minute. Failing jobs are reported
+// as warnings, so that they do not prevent the others from running.
+func runDue(ctx context.Context, dockerCli command.Cli, minute time.Time, opts runOptions) error {
+	jobs, err := listCronJobs(ctx, dockerCli.Client(), dockerCli.Err())
+	if err != nil {
+		return err
+	}
+	quotas, err := quota.Load(quota.File())
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if !job.schedule.Matches(minute) || !job.lastRun.Before(minute) {
+			continue
+		}
+		started, err := startJob(ctx, dockerCli, quotas, job, minute)
+		if err != nil {
+			fmt.Fprintf(dockerCli.Err(), "WARNING: scheduled job %s: %s\n", job.name, err)
+			continue
+		}
+		if !started {
+			continue
+		}
+		if err := pruneJobs(ctx, dockerCli, job.name, opts.keep); err != nil {
+			fmt.Fprintf(dockerCli.Err(), "WARNING: scheduled job %s: %s\n", job.name, err)
+		}
+	}
+	return nil
+}
+
+// startJob claims a due job by recording its last run on its config, and
+// creates its job service. It returns false if another controller claimed
+// the job first. The claim is released if the job service is not created.
+func startJob(ctx context.Context, dockerCli command.Cli, quotas *quota.Quotas, job cronJob, minute time.Time) (bool, error) {
+	client := dockerCli.Client()
+
+	serviceSpec := job.spec
+	serviceSpec.Name = fmt.Sprintf("%s-%s", job.name, minute.Format("200601021504"))
+	serviceLabels := make(map[string]string, len(serviceSpec.Labels)+2)
+	for k, v := range serviceSpec.Labels {
+		serviceLabels[k] = v
+	}
+	serviceLabels[labelCron] = job.name
+	serviceLabels[labelScheduled] = minute.Format(time.RFC3339)
+	serviceSpec.Labels = serviceLabels
+	if err := quotas.CheckServiceCreate(ctx, client, serviceSpec); err != nil {
+		return false, err
+	}
+
+	configSpec := job.config.Spec
+	labels := make(map[string]string, len(configSpec.Labels)+1)
+	for k, v := range configSpec.Labels {
+		labels[k] = v
+	}
+	labels[labelLastRun] = minute.Format(time.RFC3339)
+	configSpec.Labels = labels
+	if err := client.ConfigUpdate(ctx, job.config.ID, job.config.Version, configSpec); err != nil {
+		if strings.Contains(err.Error(), "update out of sequence") {
+			return false, nil
+		}
+		return false, err
+	}
+
+	response, err := client.ServiceCreate(ctx, serviceSpec, types.ServiceCreateOptions{})
+	if err != nil {
+		if releaseErr := releaseJob(ctx, client, job); releaseErr != nil {
+			return false, errors.Errorf("%s, and the last run could not be restored: %s", err, releaseErr)
+		}
+		return false, err
+	}
+	for _, warning := range response.Warnings {
+		fmt.Fprintln(dockerCli.Err(), warning)
+	}
+	fmt.Fprintf(dockerCli.Out(), "%s started %s\n", job.name, serviceSpec.Name)
+	return true, nil
+}
+
+// releaseJob restores the last run recorded on the config of a job claimed
+// by startJob.
+func releaseJob(ctx context.Context, apiClient client.APIClient, job cronJob) error {
+	config, _, err := apiClient.ConfigInspectWithRaw(ctx, job.config.ID)
+	if err != nil {
+		return err
+	}
+	return apiClient.ConfigUpdate(ctx, config.ID, config.Version, job.config.Spec)
+}
+
+// pruneJobs removes the oldest finished job services of a scheduled job,
+// keeping the last keep ones.
+func pruneJobs(ctx context.Context, dockerCli command.Cli, name string, keep int) error {
+	services, err := jobServices(ctx, dockerCli.Client(), name)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(services)-keep; i++ {
+		service := services[i]
+		if status := service.ServiceStatus; status != nil && status.RunningTasks > 0 {
+			continue
+		}
+		if err := dockerCli.Client().ServiceRemove(ctx, service.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,12 +1,43 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
+	cliconfig "github.com/docker/cli/cli/config"
+	cliflags "github.com/docker/cli/cli/flags"
+	dopts "github.com/docker/cli/opts"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/moby/swarmctl/cmd/cluster"
+	"github.com/moby/swarmctl/cmd/config"
+	"github.com/moby/swarmctl/cmd/cost"
+	"github.com/moby/swarmctl/cmd/cron"
+	"github.com/moby/swarmctl/cmd/node"
+	"github.com/moby/swarmctl/cmd/quota"
+	"github.com/moby/swarmctl/cmd/service"
+	"github.com/moby/swarmctl/cmd/stack"
+	"github.com/moby/swarmctl/cmd/stats"
 	"github.com/moby/swarmctl/cmd/swarm"
+	"github.com/moby/swarmctl/cmd/system"
+	"github.com/moby/swarmctl/internal/apiclient"
+	"github.com/moby/swarmctl/internal/capability"
+	"github.com/moby/swarmctl/internal/errinfo"
+	"github.com/moby/swarmctl/internal/profile"
+	"github.com/moby/swarmctl/internal/query"
+	"github.com/moby/swarmctl/internal/recording"
+	"github.com/moby/swarmctl/internal/settings"
+	"github.com/moby/swarmctl/internal/usage"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
 
@@ -18,45 +49,266 @@ func main() {
 		os.Exit(1)
 	}
 
-	cmd := RootCommand(dockerCli)
+	apiCli := apiclient.NewCli(dockerCli)
+	cmd := RootCommand(apiCli)
 	opts, flags := cli.SetupPluginRootCommand(cmd)
+	var (
+		recordDir    string
+		timeout      time.Duration
+		retryTimeout time.Duration
+		errorFormat  string
+		queryExpr    string
+		profileURL   string
+	)
+	flags.StringVar(&recordDir, "record", "", "Record the API requests and responses of the command to a directory, see \"swarmctl replay\"")
+	flags.DurationVar(&timeout, "timeout", 0, "Abort the command if it does not complete within this duration (0 for no timeout)")
+	flags.DurationVar(&retryTimeout, "retry-timeout", 30*time.Second, "Retry the requests failing while the swarm elects a leader for up to this duration (0 to disable)")
+	flags.StringVar(&errorFormat, "errors", "text", `Format of the error of a failed command ("text"|"json")`)
+	flags.StringVar(&profileURL, "profile-url", os.Getenv(profile.EnvURL), "Apply the signed organization profile at this URL (env "+profile.EnvURL+")")
+	flags.StringVar(&queryExpr, "query", "", "Evaluate a jq expression over the JSON output of the command, such as \"get -o json\"")
+	flags.Lookup("host").Usage += ", or a comma-separated list of managers to fail over between"
 	tcmd := cli.NewTopLevelCommand(cmd, dockerCli, opts, flags)
-	tcmd.Initialize()
 
 	cmd, args, err := tcmd.HandleGlobalFlags()
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
+	if errorFormat != "text" && errorFormat != "json" {
+		fmt.Fprintf(os.Stderr, "invalid --errors format %q, must be text or json\n", errorFormat)
+		os.Exit(1)
+	}
+	managers, err := splitManagers(opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	// Initialize once the global flags are parsed, so that the client
+	// connects to the host or context they select.
+	if err := tcmd.Initialize(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := setupClient(apiCli, recordDir, retryTimeout, managers, args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if profileURL != "" {
+		if orgProfile, err = loadProfile(profileURL, dockerCli.Err()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	var finishQuery func() error
+	if queryExpr != "" {
+		if finishQuery, err = setupQuery(dockerCli, queryExpr); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
 	// We've parsed global args already, so reset args to those
 	// which remain.
 	cmd.SetArgs(args)
-	err = cmd.Execute()
-	if sterr, ok := err.(cli.StatusError); ok {
-		if sterr.Status != "" {
-			fmt.Fprintln(dockerCli.Err(), sterr.Status)
-		}
-		// StatusError should only be used for errors, and all errors should
-		// have a non-zero exit status, so never exit with 0
-		if sterr.StatusCode == 0 {
-			os.Exit(1)
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	start := time.Now()
+	executed, err := cmd.ExecuteContextC(ctx)
+	if executed != nil && executed.HasParent() {
+		// The usage log is best effort, it must not fail the command.
+		_ = usage.Default().Record(usage.Entry{Time: start, Command: executed.CommandPath(), Duration: time.Since(start), Failed: err != nil})
+	}
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		err = errors.Wrapf(errdefs.Deadline(err), "command timed out after %s", timeout)
+	}
+	cancel()
+	if finishQuery != nil {
+		// Report why the query failed rather than the write error the
+		// command may have got from it.
+		if queryErr := finishQuery(); queryErr != nil {
+			err = queryErr
 		}
+	}
+	if err == nil {
+		return
+	}
+
+	if errorFormat == "json" {
+		_ = json.NewEncoder(dockerCli.Err()).Encode(errinfo.Describe(err))
+	} else if sterr, ok := err.(cli.StatusError); !ok {
+		fmt.Fprintln(dockerCli.Err(), err)
+	} else if sterr.Status != "" {
+		fmt.Fprintln(dockerCli.Err(), sterr.Status)
+	}
+	// StatusError should only be used for errors, and all errors should
+	// have a non-zero exit status, so never exit with 0
+	if sterr, ok := err.(cli.StatusError); ok && sterr.StatusCode != 0 {
 		os.Exit(sterr.StatusCode)
 	}
+	os.Exit(1)
+}
+
+// orgProfile is the organization profile set with --profile-url, applied by
+// the root command.
+var orgProfile *profile.Profile
+
+// loadProfile loads the organization profile at url, cached in the docker
+// configuration directory.
+func loadProfile(url string, errOut io.Writer) (*profile.Profile, error) {
+	key, err := profile.ParseKey(os.Getenv(profile.EnvKey))
 	if err != nil {
-		fmt.Fprintln(dockerCli.Err(), err)
-		os.Exit(1)
+		return nil, err
+	}
+	loader := &profile.Loader{
+		URL:      url,
+		Key:      key,
+		CacheDir: filepath.Join(cliconfig.Dir(), "swarmctl", "profiles"),
+		TTL:      profile.DefaultTTL,
+		Client:   &http.Client{Timeout: 10 * time.Second},
+		Err:      errOut,
+	}
+	return loader.Load(context.Background())
+}
+
+// splitManagers splits the comma-separated list of managers that --host or
+// DOCKER_HOST may be set to, leaving the first one for the CLI to connect
+// to. It returns the addresses of the managers, if there are several.
+func splitManagers(opts *cliflags.ClientOptions) ([]string, error) {
+	var hosts string
+	switch len(opts.Common.Hosts) {
+	case 0:
+		hosts = os.Getenv(client.EnvOverrideHost)
+	case 1:
+		hosts = opts.Common.Hosts[0]
+	}
+	if !strings.Contains(hosts, ",") {
+		return nil, nil
+	}
+
+	list := strings.Split(hosts, ",")
+	addrs := make([]string, 0, len(list))
+	for i, host := range list {
+		host, err := dopts.ParseHost(opts.Common.TLS || opts.Common.TLSVerify, strings.TrimSpace(host))
+		if err != nil {
+			return nil, err
+		}
+		addr := strings.TrimPrefix(host, "tcp://")
+		if addr == host {
+			return nil, errors.Errorf("invalid manager %q, failing over between managers requires tcp:// hosts", host)
+		}
+		list[i] = host
+		addrs = append(addrs, addr)
+	}
+	if len(opts.Common.Hosts) == 0 {
+		os.Setenv(client.EnvOverrideHost, list[0])
+	} else {
+		opts.Common.Hosts = list[:1]
+	}
+	return addrs, nil
+}
+
+// setupClient replaces the API client of apiCli with one that fails over
+// between managers if there are several, retries the requests failing on
+// leader changes for up to retryTimeout, and records them to recordDir if
+// set.
+func setupClient(apiCli *apiclient.Cli, recordDir string, retryTimeout time.Duration, managers []string, args []string) error {
+	var middlewares []apiclient.Middleware
+	if retryTimeout > 0 {
+		middlewares = append(middlewares, apiclient.RetryLeaderChanges(retryTimeout))
+	}
+	if recordDir != "" {
+		recorder, err := recording.NewRecorder(recordDir, args)
+		if err != nil {
+			return err
+		}
+		// Record every attempt, so that the retries are replayed too.
+		middlewares = append(middlewares, recorder.Middleware)
+	}
+	if len(managers) > 1 {
+		middlewares = append(middlewares, apiclient.Failover(managers))
+	}
+	if len(middlewares) == 0 {
+		return nil
+	}
+	apiClient, err := apiclient.NewClient(apiCli, middlewares...)
+	if err != nil {
+		return err
+	}
+	apiCli.SetClient(apiClient)
+	return nil
+}
+
+// setupQuery pipes the output of dockerCli to the evaluation of the jq
+// expression expr, so that streamed output is queried as it is written. The
+// returned function ends the output and waits for the evaluation.
+func setupQuery(dockerCli *command.DockerCli, expr string) (func() error, error) {
+	q, err := query.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	out := dockerCli.Out()
+	r, w := io.Pipe()
+	if err := dockerCli.Apply(command.WithOutputStream(w)); err != nil {
+		return nil, err
 	}
+	done := make(chan error, 1)
+	go func() {
+		err := q.Run(out, r)
+		r.CloseWithError(err)
+		done <- err
+	}()
+	return func() error {
+		w.Close()
+		return <-done
+	}, nil
 }
 
 func RootCommand(cli command.Cli) *cobra.Command {
 	cmd := &cobra.Command{
 		Short:            "Swarm Control",
 		Use:              "swarmctl COMMAND",
+		SilenceUsage:     true,
+		SilenceErrors:    true,
 		TraverseChildren: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := settings.Apply(cmd, cli.ConfigFile()); err != nil {
+				return err
+			}
+			if orgProfile != nil {
+				if err := orgProfile.Apply(cmd); err != nil {
+					return err
+				}
+			}
+			return capability.CheckCommand(cmd.Context(), cli.Client(), cmd)
+		},
 	}
 
 	cmd.AddCommand(
-		swarm.NewSwarmCommand(cli))
+		system.NewAPICommand(cli),
+		cluster.NewClusterCommand(cli),
+		config.NewConfigCommand(cli, RootCommand),
+		cost.NewCostCommand(cli),
+		cron.NewCronCommand(cli),
+		node.NewNodeCommand(cli),
+		quota.NewQuotaCommand(cli),
+		service.NewRolloutCommand(cli),
+		service.NewServiceCommand(cli),
+		stack.NewStackCommand(cli),
+		stats.NewStatsCommand(cli),
+		swarm.NewSwarmCommand(cli),
+		system.NewEditCommand(cli),
+		system.NewEventsCommand(cli),
+		system.NewGetCommand(cli),
+		system.NewLogsCommand(cli),
+		system.NewPatchCommand(cli),
+		system.NewReplayCommand(cli, RootCommand),
+		system.NewSupportBundleCommand(cli),
+		system.NewVersionCommand(cli))
 	return cmd
 }
@@ -0,0 +1,210 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/cli/opts"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/go-units"
+	"github.com/moby/swarmctl/internal/placement"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type capacityOptions struct {
+	cpus        opts.NanoCPUs
+	memory      opts.MemBytes
+	constraints opts.ListOpts
+}
+
+func newCapacityCommand(dockerCli command.Cli) *cobra.Command {
+	options := capacityOptions{constraints: opts.NewListOpts(nil)}
+
+	cmd := &cobra.Command{
+		Use:   "capacity [OPTIONS]",
+		Short: "Display the reserved and free resources of the nodes",
+		Long: `Display the reserved and free resources of the nodes.
+
+The resources reserved by the running tasks are subtracted from the resources
+of each node. With --cpus or --memory, the number of additional tasks with
+these reservations that fit on each node is computed. Only the ready and
+active nodes matching the --constraint flags can run them.`,
+		Args: cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCapacity(cmd.Context(), dockerCli, options)
+		},
+		ValidArgsFunction: completion.NoComplete,
+		Annotations: map[string]string{
+			"version": "1.24",
+			"swarm":   "manager",
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.Var(&options.cpus, "cpus", "CPUs reserved by the additional task")
+	flags.Var(&options.memory, "memory", "Memory reserved by the additional task")
+	flags.Var(&options.constraints, "constraint", "Placement constraint of the additional task")
+	return cmd
+}
+
+// nodeCapacity holds the resources of a node.
+type nodeCapacity struct {
+	hostname       string
+	status         string
+	totalCPUs      int64
+	totalMemory    int64
+	reservedCPUs   int64
+	reservedMemory int64
+	// schedulable is set if the node can run the additional task.
+	schedulable bool
+}
+
+func (c nodeCapacity) freeCPUs() int64 {
+	return c.totalCPUs - c.reservedCPUs
+}
+
+func (c nodeCapacity) freeMemory() int64 {
+	return c.totalMemory - c.reservedMemory
+}
+
+// fits returns how many tasks reserving cpus and memory fit on the node.
+func (c nodeCapacity) fits(cpus, memory int64) int64 {
+	if !c.schedulable || c.freeCPUs() < cpus || c.freeMemory() < memory {
+		return 0
+	}
+	n := int64(-1)
+	if cpus > 0 {
+		n = c.freeCPUs() / cpus
+	}
+	if memory > 0 && (n < 0 || c.freeMemory()/memory < n) {
+		n = c.freeMemory() / memory
+	}
+	return n
+}
+
+func runCapacity(ctx context.Context, dockerCli command.Cli, options capacityOptions) error {
+	constraints, err := placement.Parse(options.constraints.GetAll())
+	if err != nil {
+		return err
+	}
+	client := dockerCli.Client()
+	nodes, err := client.NodeList(ctx, types.NodeListOptions{})
+	if err != nil {
+		return err
+	}
+	tasks, err := client.TaskList(ctx, types.TaskListOptions{
+		Filters: filters.NewArgs(filters.Arg("desired-state", "running")),
+	})
+	if err != nil {
+		return err
+	}
+
+	capacities := nodeCapacities(nodes, tasks, constraints)
+	cpus, memory := options.cpus.Value(), options.memory.Value()
+	sized := cpus > 0 || memory > 0
+	if err := printCapacities(dockerCli.Out(), capacities, sized, cpus, memory); err != nil {
+		return err
+	}
+	if !sized {
+		return nil
+	}
+
+	size := taskSize(cpus, memory)
+	var replicas, onNodes int64
+	for _, c := range capacities {
+		if n := c.fits(cpus, memory); n > 0 {
+			replicas += n
+			onNodes++
+		}
+	}
+	if replicas == 0 {
+		return errors.Errorf("no node can fit a task reserving %s", size)
+	}
+	fmt.Fprintf(dockerCli.Out(), "\n%d more task(s) reserving %s fit on %d node(s).\n", replicas, size, onNodes)
+	return nil
+}
+
+// nodeCapacities returns the capacity of each node, sorted by hostname.
+func nodeCapacities(nodes []swarm.Node, tasks []swarm.Task, constraints []placement.Constraint) []nodeCapacity {
+	capacities := make([]nodeCapacity, 0, len(nodes))
+	index := make(map[string]int, len(nodes))
+	for _, node := range nodes {
+		status := string(node.Spec.Availability)
+		if node.Status.State != swarm.NodeStateReady {
+			status = string(node.Status.State)
+		}
+		index[node.ID] = len(capacities)
+		capacities = append(capacities, nodeCapacity{
+			hostname:    node.Description.Hostname,
+			status:      status,
+			totalCPUs:   node.Description.Resources.NanoCPUs,
+			totalMemory: node.Description.Resources.MemoryBytes,
+			schedulable: status == string(swarm.NodeAvailabilityActive) && placement.Match(node, constraints),
+		})
+	}
+	for _, task := range tasks {
+		i, ok := index[task.NodeID]
+		if !ok || task.Spec.Resources == nil || task.Spec.Resources.Reservations == nil {
+			continue
+		}
+		capacities[i].reservedCPUs += task.Spec.Resources.Reservations.NanoCPUs
+		capacities[i].reservedMemory += task.Spec.Resources.Reservations.MemoryBytes
+	}
+	sort.Slice(capacities, func(i, j int) bool { return capacities[i].hostname < capacities[j].hostname })
+	return capacities
+}
+
+func printCapacities(out io.Writer, capacities []nodeCapacity, sized bool, cpus, memory int64) error {
+	w := tabwriter.NewWriter(out, 10, 1, 3, ' ', 0)
+	header := "HOSTNAME\tSTATUS\tCPUS RESERVED\tMEMORY RESERVED\tCPUS FREE\tMEMORY FREE"
+	if sized {
+		header += "\tFITS"
+	}
+	fmt.Fprintln(w, header)
+	for _, c := range capacities {
+		fmt.Fprintf(w, "%s\t%s\t%s / %s\t%s / %s\t%s\t%s",
+			c.hostname, c.status,
+			formatCPUs(c.reservedCPUs), formatCPUs(c.totalCPUs),
+			units.BytesSize(float64(c.reservedMemory)), units.BytesSize(float64(c.totalMemory)),
+			formatCPUs(c.freeCPUs()), units.BytesSize(float64(c.freeMemory())))
+		if sized {
+			if c.schedulable {
+				fmt.Fprintf(w, "\t%d", c.fits(cpus, memory))
+			} else {
+				fmt.Fprint(w, "\t-")
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	return w.Flush()
+}
+
+func formatCPUs(nanoCPUs int64) string {
+	return strconv.FormatFloat(float64(nanoCPUs)/1e9, 'f', -1, 64)
+}
+
+func taskSize(cpus, memory int64) string {
+	var size []string
+	if cpus > 0 {
+		unit := " CPUs"
+		if cpus == 1e9 {
+			unit = " CPU"
+		}
+		size = append(size, formatCPUs(cpus)+unit)
+	}
+	if memory > 0 {
+		size = append(size, units.BytesSize(float64(memory)))
+	}
+	return strings.Join(size, " and ")
+}
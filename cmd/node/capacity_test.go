@@ -0,0 +1,80 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+const gib = 1024 * 1024 * 1024
+
+func capacityNode(id, hostname string, role swarm.NodeRole, availability swarm.NodeAvailability, state swarm.NodeState, cpus, memory int64) swarm.Node {
+	return swarm.Node{
+		ID:          id,
+		Spec:        swarm.NodeSpec{Role: role, Availability: availability},
+		Description: swarm.NodeDescription{Hostname: hostname, Resources: swarm.Resources{NanoCPUs: cpus * 1e9, MemoryBytes: memory * gib}},
+		Status:      swarm.NodeStatus{State: state},
+	}
+}
+
+func reservingTask(nodeID string, nanoCPUs, memory int64) swarm.Task {
+	return swarm.Task{
+		NodeID:       nodeID,
+		DesiredState: swarm.TaskStateRunning,
+		Spec: swarm.TaskSpec{
+			Resources: &swarm.ResourceRequirements{Reservations: &swarm.Resources{NanoCPUs: nanoCPUs, MemoryBytes: memory}},
+		},
+	}
+}
+
+func capacityClient(t *testing.T) *fakeClient {
+	return &fakeClient{
+		nodeListFunc: func() ([]swarm.Node, error) {
+			return []swarm.Node{
+				capacityNode("id1", "manager1", swarm.NodeRoleManager, swarm.NodeAvailabilityActive, swarm.NodeStateReady, 4, 8),
+				capacityNode("id2", "worker1", swarm.NodeRoleWorker, swarm.NodeAvailabilityActive, swarm.NodeStateReady, 8, 16),
+				capacityNode("id3", "worker2", swarm.NodeRoleWorker, swarm.NodeAvailabilityDrain, swarm.NodeStateReady, 8, 16),
+				capacityNode("id4", "worker3", swarm.NodeRoleWorker, swarm.NodeAvailabilityActive, swarm.NodeStateDown, 8, 16),
+			}, nil
+		},
+		taskListFunc: func(options types.TaskListOptions) ([]swarm.Task, error) {
+			assert.Check(t, is.DeepEqual(options.Filters.Get("desired-state"), []string{"running"}))
+			return []swarm.Task{
+				reservingTask("id1", 1500000000, 2*gib),
+				reservingTask("id2", 2000000000, 4*gib),
+				reservingTask("id2", 2000000000, 8*gib),
+				{NodeID: "id2", DesiredState: swarm.TaskStateRunning},
+			}, nil
+		},
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	cli := test.NewFakeCli(capacityClient(t))
+	cmd := newCapacityCommand(cli)
+	cmd.SetArgs([]string{})
+	assert.NilError(t, cmd.Execute())
+	test.AssertGolden(t, cli.OutBuffer().String(), "node-capacity.golden")
+}
+
+func TestCapacityFits(t *testing.T) {
+	cli := test.NewFakeCli(capacityClient(t))
+	cmd := newCapacityCommand(cli)
+	cmd.SetArgs([]string{"--cpus", "1", "--memory", "2GiB"})
+	assert.NilError(t, cmd.Execute())
+	test.AssertGolden(t, cli.OutBuffer().String(), "node-capacity-fits.golden")
+
+	cli = test.NewFakeCli(capacityClient(t))
+	cmd = newCapacityCommand(cli)
+	cmd.SetArgs([]string{"--cpus", "2", "--memory", "4GiB", "--constraint", "node.role==worker"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Contains(cli.OutBuffer().String(), "1 more task(s) reserving 2 CPUs and 4GiB fit on 1 node(s)."))
+
+	cmd = newCapacityCommand(test.NewFakeCli(capacityClient(t)))
+	cmd.SetArgs([]string{"--cpus", "6"})
+	assert.Error(t, cmd.Execute(), "no node can fit a task reserving 6 CPUs")
+}
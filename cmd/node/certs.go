@@ -0,0 +1,82 @@
+package node
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/go-units"
+	"github.com/pkg/errors"
+)
+
+// now is replaced in tests.
+var now = time.Now
+
+// earliestExpiry returns the earliest expiry of the certificates of a PEM
+// bundle, such as a trust root, which holds two certificates during a root
+// rotation.
+func earliestExpiry(bundle string) (time.Time, error) {
+	var (
+		earliest time.Time
+		rest     = []byte(bundle)
+	)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if earliest.IsZero() || cert.NotAfter.Before(earliest) {
+			earliest = cert.NotAfter
+		}
+	}
+	if earliest.IsZero() {
+		return time.Time{}, errors.New("no certificate found")
+	}
+	return earliest, nil
+}
+
+// checkCerts warns about the nodes trusting a root CA certificate expiring
+// within window, and returns an error if there is any. The API does not
+// expose the certificates of the nodes themselves, which are renewed by the
+// swarm before they expire.
+func checkCerts(errOut io.Writer, nodes []swarm.Node, window time.Duration) error {
+	var expiring int
+	for _, node := range nodes {
+		trustRoot := node.Description.TLSInfo.TrustRoot
+		if trustRoot == "" {
+			continue
+		}
+		expiry, err := earliestExpiry(trustRoot)
+		if err != nil {
+			fmt.Fprintf(errOut, "WARNING: unable to decode the root CA certificate trusted by node %s: %s\n", node.Description.Hostname, err)
+			continue
+		}
+		left := expiry.Sub(now())
+		switch {
+		case left <= 0:
+			fmt.Fprintf(errOut, "WARNING: the root CA certificate trusted by node %s expired %s ago (%s)\n",
+				node.Description.Hostname, units.HumanDuration(-left), expiry.UTC().Format(time.RFC3339))
+		case left <= window:
+			fmt.Fprintf(errOut, "WARNING: the root CA certificate trusted by node %s expires in %s (%s)\n",
+				node.Description.Hostname, units.HumanDuration(left), expiry.UTC().Format(time.RFC3339))
+		default:
+			continue
+		}
+		expiring++
+	}
+	if expiring > 0 {
+		return errors.Errorf("%d node(s) trust a root CA certificate expiring within %s, rotate it with \"swarmctl swarm ca --rotate\"", expiring, units.HumanDuration(window))
+	}
+	return nil
+}
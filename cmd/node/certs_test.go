@@ -0,0 +1,77 @@
+package node
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/swarm"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+var testNow = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func rootCA(t *testing.T, notAfter time.Time) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NilError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "swarm-ca"},
+		NotBefore:             notAfter.Add(-20 * 365 * 24 * time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NilError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func trustingNode(hostname, trustRoot string) swarm.Node {
+	return swarm.Node{Description: swarm.NodeDescription{
+		Hostname: hostname,
+		TLSInfo:  swarm.TLSInfo{TrustRoot: trustRoot},
+	}}
+}
+
+func TestEarliestExpiry(t *testing.T) {
+	first := testNow.Add(48 * time.Hour)
+	bundle := rootCA(t, testNow.Add(72*time.Hour)) + rootCA(t, first)
+	expiry, err := earliestExpiry(bundle)
+	assert.NilError(t, err)
+	assert.Check(t, expiry.Equal(first))
+
+	_, err = earliestExpiry("not a certificate")
+	assert.Error(t, err, "no certificate found")
+}
+
+func TestCheckCerts(t *testing.T) {
+	now = func() time.Time { return testNow }
+	defer func() { now = time.Now }()
+
+	nodes := []swarm.Node{
+		trustingNode("manager1", rootCA(t, testNow.Add(10*365*24*time.Hour))),
+		trustingNode("manager2", rootCA(t, testNow.Add(10*24*time.Hour))),
+		trustingNode("worker1", rootCA(t, testNow.Add(-3*24*time.Hour))),
+		trustingNode("worker2", ""),
+	}
+	errOut := new(bytes.Buffer)
+	err := checkCerts(errOut, nodes, 30*24*time.Hour)
+	assert.Error(t, err, `2 node(s) trust a root CA certificate expiring within 4 weeks, rotate it with "swarmctl swarm ca --rotate"`)
+	assert.Check(t, is.Equal(errOut.String(),
+		"WARNING: the root CA certificate trusted by node manager2 expires in 10 days (2023-01-11T00:00:00Z)\n"+
+			"WARNING: the root CA certificate trusted by node worker1 expired 3 days ago (2022-12-29T00:00:00Z)\n"))
+
+	errOut.Reset()
+	assert.NilError(t, checkCerts(errOut, nodes[:1], 30*24*time.Hour))
+	assert.Check(t, is.Equal(errOut.String(), ""))
+}
@@ -0,0 +1,30 @@
+package node
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/moby/swarmctl/cmd/service"
+)
+
+// NewNodeCommand returns a cobra command for `node` subcommands
+func NewNodeCommand(dockerCli command.Cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "node",
+		Short: "Manage Swarm nodes",
+		Args:  cli.NoArgs,
+		RunE:  command.ShowHelp(dockerCli.Err()),
+		Annotations: map[string]string{
+			"version": "1.24",
+			"swarm":   "manager",
+		},
+	}
+	cmd.AddCommand(
+		newCapacityCommand(dockerCli),
+		service.NewEvacuateCommand(dockerCli),
+		newInfoCommand(dockerCli),
+		newSSHCommand(dockerCli),
+	)
+	return cmd
+}
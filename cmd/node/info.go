@@ -0,0 +1,238 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/api/types/versions"
+	"github.com/moby/swarmctl/internal/engine"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type infoOptions struct {
+	nodes       []string
+	all         bool
+	checkCerts  bool
+	certsWindow time.Duration
+}
+
+// engineInfo is what a node's engine reports about itself. Fields are left
+// empty when the engine could not be reached.
+type engineInfo struct {
+	hostname      string
+	engineVersion string
+	apiVersion    string
+	storageDriver string
+	cgroup        string
+	plugins       []string
+	err           error
+}
+
+func newInfoCommand(dockerCli command.Cli) *cobra.Command {
+	opts := infoOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "info [OPTIONS] [NODE...]",
+		Short: "Display engine information of one or more nodes",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if opts.all && len(args) > 0 {
+				return errors.New("--all and a list of nodes are mutually exclusive")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.nodes = args
+			return runInfo(cmd.Context(), dockerCli, opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVarP(&opts.all, "all", "a", false, "Collect information from every node of the swarm")
+	flags.BoolVar(&opts.checkCerts, "check-certs", false, "Fail if a node trusts a root CA certificate expiring soon")
+	flags.DurationVar(&opts.certsWindow, "cert-expiry-window", 30*24*time.Hour, "Flag the certificates expiring within this duration, with --check-certs")
+	return cmd
+}
+
+func runInfo(ctx context.Context, dockerCli command.Cli, opts infoOptions) error {
+	client := dockerCli.Client()
+
+	var nodes []swarm.Node
+	if opts.all {
+		var err error
+		nodes, err = client.NodeList(ctx, types.NodeListOptions{})
+		if err != nil {
+			return err
+		}
+	} else {
+		if len(opts.nodes) == 0 {
+			opts.nodes = []string{"self"}
+		}
+		for _, ref := range opts.nodes {
+			node, _, err := client.NodeInspectWithRaw(ctx, ref)
+			if err != nil {
+				return err
+			}
+			nodes = append(nodes, node)
+		}
+	}
+
+	resolver, err := engine.NewResolver(ctx, dockerCli)
+	if err != nil {
+		return err
+	}
+	defer resolver.Close()
+
+	infos := collectEngineInfo(ctx, resolver, nodes)
+	for _, info := range infos {
+		if info.err != nil {
+			fmt.Fprintf(dockerCli.Err(), "WARNING: %s; showing the engine version reported to the swarm\n", info.err)
+		}
+	}
+	if err := printEngineInfo(dockerCli.Out(), dockerCli.Err(), infos); err != nil {
+		return err
+	}
+	if opts.checkCerts {
+		return checkCerts(dockerCli.Err(), nodes, opts.certsWindow)
+	}
+	return nil
+}
+
+// collectEngineInfo queries the engines of all nodes concurrently.
+func collectEngineInfo(ctx context.Context, resolver *engine.Resolver, nodes []swarm.Node) []engineInfo {
+	infos := make([]engineInfo, len(nodes))
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, node swarm.Node) {
+			defer wg.Done()
+			infos[i] = nodeEngineInfo(ctx, resolver, node)
+		}(i, node)
+	}
+	wg.Wait()
+
+	sort.SliceStable(infos, func(i, j int) bool {
+		return infos[i].hostname < infos[j].hostname
+	})
+	return infos
+}
+
+func nodeEngineInfo(ctx context.Context, resolver *engine.Resolver, node swarm.Node) engineInfo {
+	info := engineInfo{
+		hostname:      node.Description.Hostname,
+		engineVersion: node.Description.Engine.EngineVersion,
+	}
+	for _, p := range node.Description.Engine.Plugins {
+		info.plugins = append(info.plugins, p.Name)
+	}
+
+	c, err := resolver.Client(node)
+	if err != nil {
+		info.err = err
+		return info
+	}
+	sysInfo, err := c.Info(ctx)
+	if err != nil {
+		info.err = errors.Wrapf(err, "unable to get info of node %s", info.hostname)
+		return info
+	}
+	version, err := c.ServerVersion(ctx)
+	if err != nil {
+		info.err = errors.Wrapf(err, "unable to get version of node %s", info.hostname)
+		return info
+	}
+
+	info.engineVersion = version.Version
+	info.apiVersion = version.APIVersion
+	info.storageDriver = sysInfo.Driver
+	if sysInfo.CgroupVersion != "" {
+		info.cgroup = fmt.Sprintf("v%s (%s)", sysInfo.CgroupVersion, sysInfo.CgroupDriver)
+	}
+	info.plugins = nil
+	for _, names := range [][]string{sysInfo.Plugins.Volume, sysInfo.Plugins.Network, sysInfo.Plugins.Authorization, sysInfo.Plugins.Log} {
+		info.plugins = append(info.plugins, names...)
+	}
+	return info
+}
+
+// majorityVersion returns the most common engine version, preferring the
+// highest version on ties so that lagging nodes are the ones flagged.
+func majorityVersion(infos []engineInfo) string {
+	counts := make(map[string]int)
+	for _, info := range infos {
+		counts[info.engineVersion]++
+	}
+	var majority string
+	for version, count := range counts {
+		if count > counts[majority] || (count == counts[majority] && versions.GreaterThan(version, majority)) {
+			majority = version
+		}
+	}
+	return majority
+}
+
+func printEngineInfo(out, errOut io.Writer, infos []engineInfo) error {
+	majority := majorityVersion(infos)
+
+	w := tabwriter.NewWriter(out, 10, 1, 3, ' ', 0)
+	fmt.Fprintln(w, "HOSTNAME\tENGINE VERSION\tAPI VERSION\tSTORAGE DRIVER\tCGROUP\tPLUGINS")
+	for _, info := range infos {
+		version := info.engineVersion
+		if version != majority {
+			version += " *"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			info.hostname,
+			version,
+			orDash(info.apiVersion),
+			orDash(info.storageDriver),
+			orDash(info.cgroup),
+			orDash(strings.Join(info.plugins, ", ")),
+		)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if entries := skew(infos); len(entries) > 1 {
+		fmt.Fprintf(errOut, "WARNING: engine version skew across the swarm: %s\n", strings.Join(entries, ", "))
+	}
+	return nil
+}
+
+// skew returns a "version (N nodes)" entry per distinct engine version.
+func skew(infos []engineInfo) []string {
+	counts := make(map[string]int)
+	for _, info := range infos {
+		counts[info.engineVersion]++
+	}
+	entries := make([]string, 0, len(counts))
+	for version := range counts {
+		entries = append(entries, version)
+	}
+	sort.Strings(entries)
+	for i, version := range entries {
+		unit := "nodes"
+		if counts[version] == 1 {
+			unit = "node"
+		}
+		entries[i] = fmt.Sprintf("%s (%d %s)", version, counts[version], unit)
+	}
+	return entries
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
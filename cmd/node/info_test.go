@@ -0,0 +1,106 @@
+package node
+
+import (
+	"io"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/test"
+	"github.com/pkg/errors"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestNodeInfoErrors(t *testing.T) {
+	testCases := []struct {
+		args          []string
+		nodeListFunc  func() ([]swarm.Node, error)
+		infoFunc      func() (types.Info, error)
+		expectedError string
+	}{
+		{
+			args:          []string{"--all", "node1"},
+			expectedError: "--all and a list of nodes are mutually exclusive",
+		},
+		{
+			args: []string{"--all"},
+			nodeListFunc: func() ([]swarm.Node, error) {
+				return nil, errors.Errorf("error listing nodes")
+			},
+			expectedError: "error listing nodes",
+		},
+		{
+			args: []string{"--all"},
+			infoFunc: func() (types.Info, error) {
+				return types.Info{}, errors.Errorf("error getting info")
+			},
+			expectedError: "error getting info",
+		},
+	}
+	for _, tc := range testCases {
+		cmd := newInfoCommand(
+			test.NewFakeCli(&fakeClient{
+				nodeListFunc: tc.nodeListFunc,
+				infoFunc:     tc.infoFunc,
+			}))
+		cmd.SetArgs(tc.args)
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		assert.ErrorContains(t, cmd.Execute(), tc.expectedError)
+	}
+}
+
+func TestNodeInfoAll(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{
+		nodeListFunc: func() ([]swarm.Node, error) {
+			return []swarm.Node{
+				{
+					ID: "id2",
+					Description: swarm.NodeDescription{
+						Hostname: "worker1",
+						Engine: swarm.EngineDescription{
+							EngineVersion: "20.10.21",
+							Plugins:       []swarm.PluginDescription{{Type: "Volume", Name: "local"}},
+						},
+					},
+				},
+				{
+					ID:          "id1",
+					Description: swarm.NodeDescription{Hostname: "manager1"},
+				},
+			}, nil
+		},
+		infoFunc: func() (types.Info, error) {
+			return types.Info{
+				Swarm:         swarm.Info{NodeID: "id1"},
+				Driver:        "overlay2",
+				CgroupVersion: "2",
+				CgroupDriver:  "systemd",
+				Plugins: types.PluginsInfo{
+					Volume:  []string{"local"},
+					Network: []string{"overlay"},
+				},
+			}, nil
+		},
+		serverVersionFunc: func() (types.Version, error) {
+			return types.Version{Version: "23.0.0", APIVersion: "1.42"}, nil
+		},
+	})
+	cmd := newInfoCommand(cli)
+	cmd.SetArgs([]string{"--all"})
+	assert.NilError(t, cmd.Execute())
+	test.AssertGolden(t, cli.OutBuffer().String(), "node-info-all.golden")
+	assert.Check(t, is.Contains(cli.ErrBuffer().String(), "WARNING: no context store available to reach node worker1"))
+	assert.Check(t, is.Contains(cli.ErrBuffer().String(), "WARNING: engine version skew across the swarm: 20.10.21 (1 node), 23.0.0 (1 node)"))
+}
+
+func TestMajorityVersion(t *testing.T) {
+	infos := []engineInfo{
+		{engineVersion: "20.10.21"},
+		{engineVersion: "23.0.0"},
+		{engineVersion: "20.10.21"},
+	}
+	assert.Check(t, is.Equal(majorityVersion(infos), "20.10.21"))
+	assert.Check(t, is.Equal(majorityVersion(infos[:2]), "23.0.0"))
+}
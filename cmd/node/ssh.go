@@ -0,0 +1,123 @@
+package node
+
+import (
+	"context"
+	"net"
+	"os/exec"
+	"strings"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// labelSSHHost overrides the address used to reach a node over ssh, for
+// nodes whose swarm address is not reachable from the operator's machine.
+const labelSSHHost = "swarmctl.ssh.host"
+
+type sshOptions struct {
+	node    string
+	user    string
+	command []string
+}
+
+func newSSHCommand(dockerCli command.Cli) *cobra.Command {
+	opts := sshOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "ssh [OPTIONS] NODE [--] [COMMAND] [ARG...]",
+		Short: "Open an ssh session to a node",
+		Args:  cli.RequiresMinArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.node, opts.command = splitSSHArgs(args)
+			return runSSH(cmd.Context(), dockerCli, opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.SetInterspersed(false)
+	flags.StringVarP(&opts.user, "user", "l", "", "User to log in as on the node")
+	return cmd
+}
+
+func runSSH(ctx context.Context, dockerCli command.Cli, opts sshOptions) error {
+	client := dockerCli.Client()
+
+	node, _, err := client.NodeInspectWithRaw(ctx, opts.node)
+	if err != nil {
+		return err
+	}
+
+	host, err := sshHost(node)
+	if err != nil {
+		return err
+	}
+
+	sshPath, err := exec.LookPath("ssh")
+	if err != nil {
+		return errors.Wrap(err, "ssh client not found")
+	}
+
+	args, err := sshArgs(host, opts)
+	if err != nil {
+		return err
+	}
+	ssh := exec.CommandContext(ctx, sshPath, args...)
+	ssh.Stdin = dockerCli.In()
+	ssh.Stdout = dockerCli.Out()
+	ssh.Stderr = dockerCli.Err()
+	if err := ssh.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return cli.StatusError{StatusCode: exitErr.ExitCode()}
+		}
+		return err
+	}
+	return nil
+}
+
+// splitSSHArgs returns the node and the command to run on it. Flags are not
+// parsed after the node, so the "--" before the command is left in args.
+func splitSSHArgs(args []string) (string, []string) {
+	command := args[1:]
+	if len(command) > 0 && command[0] == "--" {
+		command = command[1:]
+	}
+	return args[0], command
+}
+
+// sshHost returns the address to connect to for the given node. The
+// swarmctl.ssh.host label takes precedence over the address reported by the
+// node, which in turn takes precedence over the manager's raft address.
+func sshHost(node swarm.Node) (string, error) {
+	if host := node.Spec.Labels[labelSSHHost]; host != "" {
+		return host, nil
+	}
+	if addr := node.Status.Addr; addr != "" && addr != "0.0.0.0" {
+		return addr, nil
+	}
+	if node.ManagerStatus != nil && node.ManagerStatus.Addr != "" {
+		host, _, err := net.SplitHostPort(node.ManagerStatus.Addr)
+		if err == nil {
+			return host, nil
+		}
+	}
+	return "", errors.Errorf("no address found for node %s, set the %s label on the node", node.Description.Hostname, labelSSHHost)
+}
+
+// sshArgs returns the arguments of ssh. The host comes from a node label
+// or address, it must not be parsed as an option of ssh.
+func sshArgs(host string, opts sshOptions) ([]string, error) {
+	if strings.HasPrefix(host, "-") {
+		return nil, errors.Errorf("invalid ssh host %q", host)
+	}
+	target := host
+	if opts.user != "" {
+		if strings.HasPrefix(opts.user, "-") {
+			return nil, errors.Errorf("invalid ssh user %q", opts.user)
+		}
+		target = opts.user + "@" + host
+	}
+	return append([]string{"--", target}, opts.command...), nil
+}
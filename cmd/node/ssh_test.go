@@ -0,0 +1,107 @@
+package node
+
+import (
+	"io"
+	"testing"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/test"
+	"github.com/pkg/errors"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestNodeSSHErrors(t *testing.T) {
+	testCases := []struct {
+		args            []string
+		nodeInspectFunc func() (swarm.Node, []byte, error)
+		expectedError   string
+	}{
+		{
+			expectedError: "requires at least 1 argument",
+		},
+		{
+			args: []string{"nodeID"},
+			nodeInspectFunc: func() (swarm.Node, []byte, error) {
+				return swarm.Node{}, []byte{}, errors.Errorf("error inspecting the node")
+			},
+			expectedError: "error inspecting the node",
+		},
+		{
+			args: []string{"nodeID"},
+			nodeInspectFunc: func() (swarm.Node, []byte, error) {
+				return swarm.Node{Description: swarm.NodeDescription{Hostname: "node1"}}, []byte{}, nil
+			},
+			expectedError: "no address found for node node1, set the swarmctl.ssh.host label on the node",
+		},
+	}
+	for _, tc := range testCases {
+		cmd := newSSHCommand(
+			test.NewFakeCli(&fakeClient{
+				nodeInspectFunc: tc.nodeInspectFunc,
+			}))
+		cmd.SetArgs(tc.args)
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		assert.ErrorContains(t, cmd.Execute(), tc.expectedError)
+	}
+}
+
+func TestSSHHost(t *testing.T) {
+	testCases := []struct {
+		name     string
+		node     swarm.Node
+		expected string
+	}{
+		{
+			name: "label",
+			node: swarm.Node{
+				Spec:   swarm.NodeSpec{Annotations: swarm.Annotations{Labels: map[string]string{labelSSHHost: "bastion.example.com"}}},
+				Status: swarm.NodeStatus{Addr: "10.0.0.1"},
+			},
+			expected: "bastion.example.com",
+		},
+		{
+			name:     "status-addr",
+			node:     swarm.Node{Status: swarm.NodeStatus{Addr: "10.0.0.1"}},
+			expected: "10.0.0.1",
+		},
+		{
+			name: "manager-addr",
+			node: swarm.Node{
+				Status:        swarm.NodeStatus{Addr: "0.0.0.0"},
+				ManagerStatus: &swarm.ManagerStatus{Addr: "10.0.0.2:2377"},
+			},
+			expected: "10.0.0.2",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			host, err := sshHost(tc.node)
+			assert.NilError(t, err)
+			assert.Check(t, is.Equal(host, tc.expected))
+		})
+	}
+}
+
+func TestSSHArgs(t *testing.T) {
+	args, err := sshArgs("10.0.0.1", sshOptions{})
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(args, []string{"--", "10.0.0.1"}))
+
+	args, err = sshArgs("10.0.0.1", sshOptions{user: "ops", command: []string{"uptime", "-p"}})
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(args, []string{"--", "ops@10.0.0.1", "uptime", "-p"}))
+
+	node, command := splitSSHArgs([]string{"node1", "--", "ls", "-l"})
+	assert.Check(t, is.Equal(node, "node1"))
+	args, err = sshArgs("10.0.0.1", sshOptions{command: command})
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(args, []string{"--", "10.0.0.1", "ls", "-l"}))
+
+	_, err = sshArgs("-oProxyCommand=touch /tmp/pwned", sshOptions{})
+	assert.Check(t, is.Error(err, `invalid ssh host "-oProxyCommand=touch /tmp/pwned"`))
+
+	_, err = sshArgs("10.0.0.1", sshOptions{user: "-oProxyCommand=id"})
+	assert.Check(t, is.Error(err, `invalid ssh user "-oProxyCommand=id"`))
+}
@@ -0,0 +1,31 @@
+package quota
+
+import (
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/spf13/cobra"
+)
+
+// NewQuotaCommand returns a cobra command for `quota` subcommands
+func NewQuotaCommand(dockerCli command.Cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "quota",
+		Short: "Manage the resource quotas of stack namespaces",
+		Long: `Manage the resource quotas of stack namespaces.
+
+Quotas are read from the file set by SWARMCTL_QUOTA_FILE, or from
+swarmctl/quotas.yml in the docker configuration directory. They are
+checked by swarmctl before updating a service, and are not enforced by
+the swarm itself.`,
+		Args: cli.NoArgs,
+		RunE: command.ShowHelp(dockerCli.Err()),
+		Annotations: map[string]string{
+			"version": "1.24",
+			"swarm":   "manager",
+		},
+	}
+	cmd.AddCommand(
+		newStatusCommand(dockerCli),
+	)
+	return cmd
+}
@@ -0,0 +1,74 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/docker/api/types"
+	"github.com/moby/swarmctl/internal/quota"
+	"github.com/spf13/cobra"
+)
+
+func newStatusCommand(dockerCli command.Cli) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Display the consumption of the namespaces with quotas",
+		Args:  cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runStatus(cmd.Context(), dockerCli)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+}
+
+func runStatus(ctx context.Context, dockerCli command.Cli) error {
+	path := quota.File()
+	quotas, err := quota.Load(path)
+	if err != nil {
+		return err
+	}
+	if len(quotas.Namespaces) == 0 {
+		fmt.Fprintf(dockerCli.Err(), "WARNING: no quotas are defined in %s\n", path)
+		return nil
+	}
+
+	services, err := dockerCli.Client().ServiceList(ctx, types.ServiceListOptions{Status: true})
+	if err != nil {
+		return err
+	}
+	return printStatus(dockerCli.Out(), quotas, quota.Consumption(services))
+}
+
+func printStatus(out io.Writer, quotas *quota.Quotas, usage map[string]quota.Usage) error {
+	w := tabwriter.NewWriter(out, 10, 1, 3, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tSERVICES\tREPLICAS\tCPUS\tMEMORY")
+	for _, namespace := range quotas.SortedNamespaces() {
+		u := usage[namespace]
+		limits := quotas.Limits(namespace)
+		if limits == nil {
+			limits = &quota.Limits{}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			namespace,
+			withLimit(strconv.Itoa(u.Services), limits.Services != 0, strconv.Itoa(limits.Services)),
+			withLimit(strconv.FormatUint(u.Replicas, 10), limits.Replicas != 0, strconv.FormatUint(limits.Replicas, 10)),
+			withLimit(quota.FormatCPUs(u.NanoCPUs), limits.CPUs != "", limits.CPUs),
+			withLimit(quota.FormatMemory(u.MemoryBytes), limits.Memory != "", limits.Memory),
+		)
+	}
+	return w.Flush()
+}
+
+// withLimit formats a consumption as "used/limit", or "used" if unlimited.
+func withLimit(used string, limited bool, limit string) string {
+	if !limited {
+		return used
+	}
+	return used + "/" + limit
+}
@@ -0,0 +1,74 @@
+package quota
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"github.com/moby/swarmctl/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func setQuotas(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "quotas.yml")
+	assert.NilError(t, os.WriteFile(path, []byte(content), 0o644))
+	t.Setenv("SWARMCTL_QUOTA_FILE", path)
+	return path
+}
+
+func namespaceService(namespace string, replicas uint64, memory int64) swarm.Service {
+	return swarm.Service{
+		Spec: swarm.ServiceSpec{
+			Annotations: swarm.Annotations{Labels: map[string]string{swarmlabel.Namespace: namespace}},
+			Mode:        swarm.ServiceMode{Replicated: &swarm.ReplicatedService{Replicas: &replicas}},
+			TaskTemplate: swarm.TaskSpec{Resources: &swarm.ResourceRequirements{
+				Reservations: &swarm.Resources{NanoCPUs: 250000000, MemoryBytes: memory},
+			}},
+		},
+	}
+}
+
+func TestStatus(t *testing.T) {
+	setQuotas(t, `
+namespaces:
+  web:
+    services: 5
+    replicas: 10
+    cpus: 2
+    memory: 4GiB
+  db:
+    memory: 16GiB
+  idle:
+    services: 1
+`)
+	cli := test.NewFakeCli(&fakeClient{
+		serviceListFunc: func(_ context.Context, options types.ServiceListOptions) ([]swarm.Service, error) {
+			assert.Check(t, options.Status)
+			return []swarm.Service{
+				namespaceService("web", 3, 256<<20),
+				namespaceService("web", 2, 512<<20),
+				namespaceService("db", 1, 8<<30),
+				namespaceService("other", 7, 0),
+			}, nil
+		},
+	})
+	cmd := newStatusCommand(cli)
+	cmd.SetArgs([]string{})
+	assert.NilError(t, cmd.Execute())
+	test.AssertGolden(t, cli.OutBuffer().String(), "quota-status.golden")
+}
+
+func TestStatusNoQuotas(t *testing.T) {
+	path := setQuotas(t, "namespaces: {}\n")
+	cli := test.NewFakeCli(&fakeClient{})
+	cmd := newStatusCommand(cli)
+	cmd.SetArgs([]string{})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.ErrBuffer().String(), "WARNING: no quotas are defined in "+path+"\n"))
+}
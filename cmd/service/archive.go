@@ -0,0 +1,185 @@
+package service
+
+import (
+	"archive/tar"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// The copy API exchanges tar archives whose root entry is named after the
+// copied file or directory. These helpers implement the `docker cp` rules for
+// regular files, directories and symbolic links: copying to an existing
+// directory creates the resource inside it, copying to any other path
+// creates or replaces the resource at that path.
+
+// extractArchive extracts an archive returned by the copy API to dstPath.
+// The archive comes from a container, its entries are not trusted: none is
+// written outside of dstPath, through a symbolic link, or links outside of
+// it.
+func extractArchive(r io.Reader, dstPath string) error {
+	root, rename := dstPath, ""
+	if fi, err := os.Stat(dstPath); err != nil || !fi.IsDir() {
+		root, rename = filepath.Dir(dstPath), filepath.Base(dstPath)
+	}
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := path.Clean(strings.TrimPrefix(hdr.Name, "/"))
+		if rename != "" {
+			_, rest, _ := strings.Cut(name, "/")
+			name = path.Join(rename, rest)
+		}
+		target := filepath.Join(root, filepath.FromSlash(name))
+		if !withinRoot(root, target) || (target == root && hdr.Typeflag != tar.TypeDir) {
+			return errors.Errorf("invalid archive entry %q", hdr.Name)
+		}
+		if err := checkParents(root, target); err != nil {
+			return errors.Wrapf(err, "invalid archive entry %q", hdr.Name)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := removeSymlink(target); err != nil {
+				return err
+			}
+			if err := os.MkdirAll(target, hdr.FileInfo().Mode().Perm()); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := removeSymlink(target); err != nil {
+				return err
+			}
+			if err := writeFile(target, tr, hdr.FileInfo().Mode().Perm()); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			link := filepath.FromSlash(hdr.Linkname)
+			if filepath.IsAbs(link) || path.IsAbs(hdr.Linkname) || !withinRoot(root, filepath.Join(filepath.Dir(target), link)) {
+				return errors.Errorf("invalid archive entry %q: link to %q is outside of the destination", hdr.Name, hdr.Linkname)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			if err := os.Symlink(hdr.Linkname, target); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// withinRoot reports whether target is root or a path inside it.
+func withinRoot(root, target string) bool {
+	rel, err := filepath.Rel(root, target)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// checkParents returns an error if one of the directories between root and
+// target is a symbolic link, which writing target would follow.
+func checkParents(root, target string) error {
+	for dir := filepath.Dir(target); dir != root && withinRoot(root, dir); dir = filepath.Dir(dir) {
+		fi, err := os.Lstat(dir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			return errors.Errorf("%s is a symbolic link", dir)
+		}
+	}
+	return nil
+}
+
+// removeSymlink removes target if it is a symbolic link, so that it is
+// replaced instead of followed.
+func removeSymlink(target string) error {
+	fi, err := os.Lstat(target)
+	if err != nil || fi.Mode()&os.ModeSymlink == 0 {
+		return nil
+	}
+	return os.Remove(target)
+}
+
+func writeFile(target string, r io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// createArchive returns an archive of srcPath with its root entry named name.
+func createArchive(srcPath, name string) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(pw)
+		err := filepath.Walk(srcPath, func(p string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(srcPath, p)
+			if err != nil {
+				return err
+			}
+			var link string
+			if fi.Mode()&os.ModeSymlink != 0 {
+				if link, err = os.Readlink(p); err != nil {
+					return err
+				}
+			}
+			hdr, err := tar.FileInfoHeader(fi, link)
+			if err != nil {
+				return err
+			}
+			hdr.Name = path.Join(name, filepath.ToSlash(rel))
+			if fi.IsDir() {
+				hdr.Name += "/"
+			}
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+			if !fi.Mode().IsRegular() {
+				return nil
+			}
+			f, err := os.Open(p)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = io.Copy(tw, f)
+			return err
+		})
+		if err == nil {
+			err = tw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr
+}
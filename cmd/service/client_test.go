@@ -0,0 +1,191 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	specs "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+type fakeClient struct {
+	client.Client
+	infoFunc             func() (types.Info, error)
+	copyFromFunc         func(containerID, srcPath string) (io.ReadCloser, types.ContainerPathStat, error)
+	copyToFunc           func(containerID, dstPath string, content io.Reader) error
+	statPathFunc         func(containerID, path string) (types.ContainerPathStat, error)
+	statsFunc            func(containerID string, stream bool) (types.ContainerStats, error)
+	containerInspectFunc func(containerID string) (types.ContainerJSON, error)
+	containerCreateFunc  func(config *container.Config, hostConfig *container.HostConfig) (container.CreateResponse, error)
+	removedContainers    []string
+	execFunc             func(containerID string, config types.ExecConfig) (output string, exitCode int)
+	execs                []fakeExec
+	updateOptions        []types.ServiceUpdateOptions
+	nodeInspectFunc      func(nodeID string) (swarm.Node, []byte, error)
+	nodeListFunc         func(options types.NodeListOptions) ([]swarm.Node, error)
+	nodeUpdateFunc       func(nodeID string, version swarm.Version, node swarm.NodeSpec) error
+	taskListFunc         func(options types.TaskListOptions) ([]swarm.Task, error)
+	networkInspectFunc   func(networkID string) (types.NetworkResource, error)
+	serviceInspectFunc   func(serviceID string) (swarm.Service, []byte, error)
+	serviceListFunc      func(options types.ServiceListOptions) ([]swarm.Service, error)
+	serviceUpdateFunc    func(serviceID string, version swarm.Version, service swarm.ServiceSpec) (types.ServiceUpdateResponse, error)
+}
+
+func (cli *fakeClient) NetworkInspect(ctx context.Context, networkID string, options types.NetworkInspectOptions) (types.NetworkResource, error) {
+	if cli.networkInspectFunc != nil {
+		return cli.networkInspectFunc(networkID)
+	}
+	return types.NetworkResource{}, nil
+}
+
+func (cli *fakeClient) NodeInspectWithRaw(ctx context.Context, nodeID string) (swarm.Node, []byte, error) {
+	if cli.nodeInspectFunc != nil {
+		return cli.nodeInspectFunc(nodeID)
+	}
+	return swarm.Node{}, []byte{}, nil
+}
+
+func (cli *fakeClient) NodeList(ctx context.Context, options types.NodeListOptions) ([]swarm.Node, error) {
+	if cli.nodeListFunc != nil {
+		return cli.nodeListFunc(options)
+	}
+	return []swarm.Node{}, nil
+}
+
+func (cli *fakeClient) NodeUpdate(ctx context.Context, nodeID string, version swarm.Version, node swarm.NodeSpec) error {
+	if cli.nodeUpdateFunc != nil {
+		return cli.nodeUpdateFunc(nodeID, version, node)
+	}
+	return nil
+}
+
+func (cli *fakeClient) TaskList(ctx context.Context, options types.TaskListOptions) ([]swarm.Task, error) {
+	if cli.taskListFunc != nil {
+		return cli.taskListFunc(options)
+	}
+	return []swarm.Task{}, nil
+}
+
+func (cli *fakeClient) ServiceInspectWithRaw(ctx context.Context, serviceID string, options types.ServiceInspectOptions) (swarm.Service, []byte, error) {
+	if cli.serviceInspectFunc != nil {
+		return cli.serviceInspectFunc(serviceID)
+	}
+	return swarm.Service{}, []byte{}, nil
+}
+
+func (cli *fakeClient) ServiceList(ctx context.Context, options types.ServiceListOptions) ([]swarm.Service, error) {
+	if cli.serviceListFunc != nil {
+		return cli.serviceListFunc(options)
+	}
+	return []swarm.Service{}, nil
+}
+
+func (cli *fakeClient) ServiceUpdate(ctx context.Context, serviceID string, version swarm.Version, service swarm.ServiceSpec, options types.ServiceUpdateOptions) (types.ServiceUpdateResponse, error) {
+	cli.updateOptions = append(cli.updateOptions, options)
+	if err := ctx.Err(); err != nil {
+		return types.ServiceUpdateResponse{}, err
+	}
+	if cli.serviceUpdateFunc != nil {
+		return cli.serviceUpdateFunc(serviceID, version, service)
+	}
+	return types.ServiceUpdateResponse{}, nil
+}
+
+func (cli *fakeClient) Info(ctx context.Context) (types.Info, error) {
+	if cli.infoFunc != nil {
+		return cli.infoFunc()
+	}
+	return types.Info{}, nil
+}
+
+func (cli *fakeClient) CopyFromContainer(ctx context.Context, containerID, srcPath string) (io.ReadCloser, types.ContainerPathStat, error) {
+	if cli.copyFromFunc != nil {
+		return cli.copyFromFunc(containerID, srcPath)
+	}
+	return nil, types.ContainerPathStat{}, nil
+}
+
+func (cli *fakeClient) CopyToContainer(ctx context.Context, containerID, dstPath string, content io.Reader, options types.CopyToContainerOptions) error {
+	if cli.copyToFunc != nil {
+		return cli.copyToFunc(containerID, dstPath, content)
+	}
+	return nil
+}
+
+func (cli *fakeClient) ContainerStatPath(ctx context.Context, containerID, path string) (types.ContainerPathStat, error) {
+	if cli.statPathFunc != nil {
+		return cli.statPathFunc(containerID, path)
+	}
+	return types.ContainerPathStat{}, nil
+}
+
+func (cli *fakeClient) ContainerStats(ctx context.Context, containerID string, stream bool) (types.ContainerStats, error) {
+	if cli.statsFunc != nil {
+		return cli.statsFunc(containerID, stream)
+	}
+	return types.ContainerStats{Body: io.NopCloser(strings.NewReader(""))}, nil
+}
+
+func (cli *fakeClient) ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error) {
+	if cli.containerInspectFunc != nil {
+		return cli.containerInspectFunc(containerID)
+	}
+	return types.ContainerJSON{ContainerJSONBase: &types.ContainerJSONBase{State: &types.ContainerState{}}}, nil
+}
+
+func (cli *fakeClient) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *specs.Platform, containerName string) (container.CreateResponse, error) {
+	if cli.containerCreateFunc != nil {
+		return cli.containerCreateFunc(config, hostConfig)
+	}
+	return container.CreateResponse{ID: "container-" + strconv.Itoa(len(cli.removedContainers))}, nil
+}
+
+func (cli *fakeClient) ContainerRemove(ctx context.Context, containerID string, options types.ContainerRemoveOptions) error {
+	cli.removedContainers = append(cli.removedContainers, containerID)
+	return nil
+}
+
+// fakeExec is an exec created on the fake client. Its ID is its index in
+// fakeClient.execs.
+type fakeExec struct {
+	containerID string
+	config      types.ExecConfig
+}
+
+func (cli *fakeClient) ContainerExecCreate(ctx context.Context, containerID string, config types.ExecConfig) (types.IDResponse, error) {
+	cli.execs = append(cli.execs, fakeExec{containerID: containerID, config: config})
+	return types.IDResponse{ID: strconv.Itoa(len(cli.execs) - 1)}, nil
+}
+
+func (cli *fakeClient) ContainerExecAttach(ctx context.Context, execID string, config types.ExecStartCheck) (types.HijackedResponse, error) {
+	output, _ := cli.runExec(execID)
+	buf := new(bytes.Buffer)
+	if _, err := stdcopy.NewStdWriter(buf, stdcopy.Stdout).Write([]byte(output)); err != nil {
+		return types.HijackedResponse{}, err
+	}
+	conn, _ := net.Pipe()
+	return types.HijackedResponse{Conn: conn, Reader: bufio.NewReader(buf)}, nil
+}
+
+func (cli *fakeClient) ContainerExecInspect(ctx context.Context, execID string) (types.ContainerExecInspect, error) {
+	_, exitCode := cli.runExec(execID)
+	return types.ContainerExecInspect{ExecID: execID, ExitCode: exitCode}, nil
+}
+
+func (cli *fakeClient) runExec(execID string) (string, int) {
+	i, _ := strconv.Atoi(execID)
+	if cli.execFunc == nil || i >= len(cli.execs) {
+		return "", 0
+	}
+	return cli.execFunc(cli.execs[i].containerID, cli.execs[i].config)
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+)
+
+// NewServiceCommand returns a cobra command for `service` subcommands
+func NewServiceCommand(dockerCli command.Cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "service",
+		Short: "Inspect and make focused changes to services",
+		Args:  cli.NoArgs,
+		RunE:  command.ShowHelp(dockerCli.Err()),
+		Annotations: map[string]string{
+			"version": "1.24",
+			"swarm":   "manager",
+		},
+	}
+	cmd.AddCommand(
+		newPublishCommand(dockerCli),
+		newUnpublishCommand(dockerCli),
+		newCpCommand(dockerCli),
+		newMountCommand(dockerCli),
+		newNetworkCommand(dockerCli),
+		newSpreadCommand(dockerCli),
+		newStatsCommand(dockerCli),
+		newSuspendCommand(dockerCli),
+		newResumeCommand(dockerCli),
+		newHistoryCommand(dockerCli),
+	)
+	return cmd
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-units"
+	"github.com/moby/swarmctl/internal/engine"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type cpOptions struct {
+	source      string
+	destination string
+	selector    taskSelector
+	quiet       bool
+}
+
+func newCpCommand(dockerCli command.Cli) *cobra.Command {
+	opts := cpOptions{}
+
+	cmd := &cobra.Command{
+		Use: `cp [OPTIONS] SERVICE:SRC_PATH DEST_PATH|-
+	swarmctl service cp [OPTIONS] SRC_PATH|- SERVICE:DEST_PATH`,
+		Short: "Copy files between a task of a service and the local filesystem",
+		Long: `Copy files between a task of a service and the local filesystem.
+
+The files are streamed through the engine of the node running the task, which
+is reached through the docker context named after the node (see "node info").
+Use "-" as the local path to read or write a tar archive on stdin or stdout.`,
+		Args: cli.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.source, opts.destination = args[0], args[1]
+			return runCp(cmd.Context(), dockerCli, opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.IntVar(&opts.selector.slot, "slot", 0, "Copy from or to the task in this slot")
+	flags.StringVar(&opts.selector.task, "task", "", "Copy from or to the task with this ID or ID prefix")
+	flags.BoolVarP(&opts.quiet, "quiet", "q", false, "Suppress the summary written to stderr")
+	return cmd
+}
+
+func runCp(ctx context.Context, dockerCli command.Cli, opts cpOptions) error {
+	srcService, srcPath := splitCpArg(opts.source)
+	dstService, dstPath := splitCpArg(opts.destination)
+	switch {
+	case srcService != "" && dstService != "":
+		return errors.New("copying between services is not supported")
+	case srcService == "" && dstService == "":
+		return errors.New("must specify at least one service source")
+	}
+
+	serviceRef := srcService
+	if serviceRef == "" {
+		serviceRef = dstService
+	}
+	service, tasks, err := runningTasks(ctx, dockerCli.Client(), serviceRef)
+	if err != nil {
+		return err
+	}
+	task, err := opts.selector.selectTask(service, tasks)
+	if err != nil {
+		return err
+	}
+	node, _, err := dockerCli.Client().NodeInspectWithRaw(ctx, task.NodeID)
+	if err != nil {
+		return err
+	}
+
+	resolver, err := engine.NewResolver(ctx, dockerCli)
+	if err != nil {
+		return err
+	}
+	defer resolver.Close()
+	nodeClient, err := resolver.Client(node)
+	if err != nil {
+		return err
+	}
+
+	c := taskCopy{
+		dockerCli:   dockerCli,
+		client:      nodeClient,
+		containerID: task.Status.ContainerStatus.ContainerID,
+		name:        taskName(service, task),
+		quiet:       opts.quiet,
+	}
+	if srcService != "" {
+		return c.copyFrom(ctx, srcPath, dstPath)
+	}
+	return c.copyTo(ctx, srcPath, dstPath)
+}
+
+// taskCopy copies files from and to the container of a task.
+type taskCopy struct {
+	dockerCli   command.Cli
+	client      client.APIClient
+	containerID string
+	name        string
+	quiet       bool
+}
+
+func (c taskCopy) copyFrom(ctx context.Context, srcPath, dstPath string) error {
+	if dstPath != "-" {
+		var err error
+		if dstPath, err = filepath.Abs(dstPath); err != nil {
+			return err
+		}
+		if err := command.ValidateOutputPath(dstPath); err != nil {
+			return err
+		}
+	}
+
+	content, stat, err := c.client.CopyFromContainer(ctx, c.containerID, srcPath)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	if dstPath == "-" {
+		_, err = io.Copy(c.dockerCli.Out(), content)
+		return err
+	}
+
+	if stat.Mode.IsDir() {
+		if fi, err := os.Stat(dstPath); err == nil && !fi.IsDir() {
+			return errors.Errorf("cannot copy directory %s:%s to file %s", c.name, srcPath, dstPath)
+		}
+	}
+	counter := &countingReader{Reader: content}
+	if err := extractArchive(counter, dstPath); err != nil {
+		return err
+	}
+	c.summary(counter.n, dstPath)
+	return nil
+}
+
+func (c taskCopy) copyTo(ctx context.Context, srcPath, dstPath string) error {
+	dstStat, err := c.client.ContainerStatPath(ctx, c.containerID, dstPath)
+	dstIsDir := err == nil && dstStat.Mode.IsDir()
+	if err == nil && !dstIsDir && !dstStat.Mode.IsRegular() {
+		return errors.Errorf(`destination "%s:%s" must be a directory or a regular file`, c.name, dstPath)
+	}
+
+	var (
+		content   io.Reader
+		extractTo string
+	)
+	if srcPath == "-" {
+		if !dstIsDir {
+			return errors.Errorf(`destination "%s:%s" must be a directory`, c.name, dstPath)
+		}
+		content, extractTo = c.dockerCli.In(), dstPath
+	} else {
+		if srcPath, err = filepath.Abs(srcPath); err != nil {
+			return err
+		}
+		fi, err := os.Lstat(srcPath)
+		if err != nil {
+			return err
+		}
+		name := filepath.Base(srcPath)
+		extractTo = dstPath
+		if !dstIsDir {
+			if dstStat.Mode.IsRegular() && fi.IsDir() {
+				return errors.Errorf("cannot copy directory %s to file %s:%s", srcPath, c.name, dstPath)
+			}
+			name, extractTo = path.Base(dstPath), path.Dir(dstPath)
+		}
+		srcArchive := createArchive(srcPath, name)
+		defer srcArchive.Close()
+		content = srcArchive
+	}
+
+	counter := &countingReader{Reader: content}
+	if err := c.client.CopyToContainer(ctx, c.containerID, extractTo, counter, types.CopyToContainerOptions{}); err != nil {
+		return err
+	}
+	if srcPath != "-" {
+		c.summary(counter.n, c.name+":"+dstPath)
+	}
+	return nil
+}
+
+func (c taskCopy) summary(size int64, destination string) {
+	if !c.quiet {
+		fmt.Fprintf(c.dockerCli.Err(), "Successfully copied %s to %s\n", units.HumanSize(float64(size)), destination)
+	}
+}
+
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// splitCpArg splits SERVICE:PATH. Absolute paths and paths starting with "."
+// are local, so that local file names containing a colon can be copied.
+func splitCpArg(arg string) (service, path string) {
+	if filepath.IsAbs(arg) {
+		return "", arg
+	}
+	service, path, ok := strings.Cut(arg, ":")
+	if !ok || strings.HasPrefix(service, ".") {
+		return "", arg
+	}
+	return service, path
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/errdefs"
+	"github.com/moby/swarmctl/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/fs"
+)
+
+func runningTask(id string, slot int, nodeID string) swarm.Task {
+	return swarm.Task{
+		ID:     id,
+		Slot:   slot,
+		NodeID: nodeID,
+		Status: swarm.TaskStatus{
+			State:           swarm.TaskStateRunning,
+			ContainerStatus: &swarm.ContainerStatus{ContainerID: "container-" + id},
+		},
+	}
+}
+
+// cpClient returns a client for a service with two tasks on the local node.
+func cpClient() *fakeClient {
+	cli := publishClient(testService("id-web", "web"), nil, &update{})
+	cli.infoFunc = func() (types.Info, error) {
+		return types.Info{Swarm: swarm.Info{NodeID: "id-local"}}, nil
+	}
+	cli.nodeInspectFunc = func(nodeID string) (swarm.Node, []byte, error) {
+		return swarm.Node{ID: nodeID}, nil, nil
+	}
+	cli.taskListFunc = func(types.TaskListOptions) ([]swarm.Task, error) {
+		stopped := runningTask("task3", 3, "id-local")
+		stopped.Status.State = swarm.TaskStateShutdown
+		return []swarm.Task{runningTask("task2", 2, "id-local"), stopped, runningTask("task1", 1, "id-local")}, nil
+	}
+	return cli
+}
+
+func testArchive(t *testing.T, entries ...*tar.Header) []byte {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	for _, hdr := range entries {
+		content := []byte(hdr.Linkname)
+		if hdr.Typeflag == tar.TypeReg {
+			content = []byte("content of " + hdr.Name)
+			hdr.Size = int64(len(content))
+		} else {
+			content = nil
+		}
+		assert.NilError(t, tw.WriteHeader(hdr))
+		_, err := tw.Write(content)
+		assert.NilError(t, err)
+	}
+	assert.NilError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func TestCpFromTask(t *testing.T) {
+	archive := testArchive(t,
+		&tar.Header{Name: "logs/", Typeflag: tar.TypeDir, Mode: 0o755},
+		&tar.Header{Name: "logs/app.log", Typeflag: tar.TypeReg, Mode: 0o644},
+		&tar.Header{Name: "logs/current", Typeflag: tar.TypeSymlink, Linkname: "app.log"},
+	)
+	cli := cpClient()
+	cli.copyFromFunc = func(containerID, srcPath string) (io.ReadCloser, types.ContainerPathStat, error) {
+		assert.Check(t, is.Equal(containerID, "container-task2"))
+		assert.Check(t, is.Equal(srcPath, "/var/log/logs"))
+		return io.NopCloser(bytes.NewReader(archive)), types.ContainerPathStat{Name: "logs", Mode: os.ModeDir | 0o755}, nil
+	}
+
+	dir := fs.NewDir(t, "service-cp")
+	dockerCli := test.NewFakeCli(cli)
+	cmd := newCpCommand(dockerCli)
+	cmd.SetArgs([]string{"--slot", "2", "web:/var/log/logs", dir.Join("collected")})
+	assert.NilError(t, cmd.Execute())
+
+	content, err := os.ReadFile(dir.Join("collected", "app.log"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(content), "content of logs/app.log"))
+	link, err := os.Readlink(dir.Join("collected", "current"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(link, "app.log"))
+	assert.Check(t, is.Contains(dockerCli.ErrBuffer().String(), "Successfully copied"))
+
+	// copying again to the now existing directory creates the resource in it
+	cmd = newCpCommand(test.NewFakeCli(cli))
+	cmd.SetArgs([]string{"--task", "task2", "web:/var/log/logs", dir.Join("collected")})
+	assert.NilError(t, cmd.Execute())
+	_, err = os.Stat(dir.Join("collected", "logs", "app.log"))
+	assert.Check(t, err)
+}
+
+func TestCpFromTaskToStdout(t *testing.T) {
+	archive := testArchive(t, &tar.Header{Name: "app.log", Typeflag: tar.TypeReg, Mode: 0o644})
+	cli := cpClient()
+	cli.copyFromFunc = func(containerID, srcPath string) (io.ReadCloser, types.ContainerPathStat, error) {
+		return io.NopCloser(bytes.NewReader(archive)), types.ContainerPathStat{Name: "app.log", Mode: 0o644}, nil
+	}
+	dockerCli := test.NewFakeCli(cli)
+	cmd := newCpCommand(dockerCli)
+	cmd.SetArgs([]string{"--slot", "1", "web:/app.log", "-"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.DeepEqual(dockerCli.OutBuffer().Bytes(), archive))
+}
+
+func TestCpToTask(t *testing.T) {
+	dir := fs.NewDir(t, "service-cp", fs.WithFile("app.conf", "debug = true\n"))
+	tests := []struct {
+		doc               string
+		dst               string
+		stat              func(path string) (types.ContainerPathStat, error)
+		expectedExtractTo string
+		expectedName      string
+	}{
+		{
+			doc: "to directory",
+			dst: "/etc/app",
+			stat: func(string) (types.ContainerPathStat, error) {
+				return types.ContainerPathStat{Mode: os.ModeDir | 0o755}, nil
+			},
+			expectedExtractTo: "/etc/app",
+			expectedName:      "app.conf",
+		},
+		{
+			doc: "to new file",
+			dst: "/etc/app/override.conf",
+			stat: func(string) (types.ContainerPathStat, error) {
+				return types.ContainerPathStat{}, errdefs.NotFound(os.ErrNotExist)
+			},
+			expectedExtractTo: "/etc/app",
+			expectedName:      "override.conf",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.doc, func(t *testing.T) {
+			cli := cpClient()
+			cli.statPathFunc = func(_, path string) (types.ContainerPathStat, error) {
+				return tc.stat(path)
+			}
+			var names []string
+			cli.copyToFunc = func(containerID, dstPath string, content io.Reader) error {
+				assert.Check(t, is.Equal(containerID, "container-task1"))
+				assert.Check(t, is.Equal(dstPath, tc.expectedExtractTo))
+				tr := tar.NewReader(content)
+				for {
+					hdr, err := tr.Next()
+					if err == io.EOF {
+						return nil
+					}
+					assert.NilError(t, err)
+					names = append(names, hdr.Name)
+				}
+			}
+			cmd := newCpCommand(test.NewFakeCli(cli))
+			cmd.SetArgs([]string{"--slot", "1", filepath.Join(dir.Path(), "app.conf"), "web:" + tc.dst})
+			assert.NilError(t, cmd.Execute())
+			assert.Check(t, is.DeepEqual(names, []string{tc.expectedName}))
+		})
+	}
+}
+
+func TestCpErrors(t *testing.T) {
+	tests := []struct {
+		args          []string
+		expectedError string
+	}{
+		{
+			args:          []string{"web:/a", "db:/b"},
+			expectedError: "copying between services is not supported",
+		},
+		{
+			args:          []string{"./web:/a", "/b"},
+			expectedError: "must specify at least one service source",
+		},
+		{
+			args:          []string{"web:/a", "/b"},
+			expectedError: "service web has 2 running tasks, select one with --slot or --task",
+		},
+		{
+			args:          []string{"--slot", "3", "web:/a", "/b"},
+			expectedError: "no running task of service web matches the --slot and --task flags",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.expectedError, func(t *testing.T) {
+			cmd := newCpCommand(test.NewFakeCli(cpClient()))
+			cmd.SetArgs(tc.args)
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			assert.Check(t, is.Error(cmd.Execute(), tc.expectedError))
+		})
+	}
+}
+
+func TestExtractArchiveRejectsTraversal(t *testing.T) {
+	archive := testArchive(t, &tar.Header{Name: "logs/../../escape", Typeflag: tar.TypeReg, Mode: 0o644})
+	dir := fs.NewDir(t, "service-cp")
+	err := extractArchive(bytes.NewReader(archive), dir.Path())
+	assert.Check(t, is.ErrorContains(err, "invalid archive entry"))
+}
+
+func TestExtractArchiveRejectsSymlinkBreakout(t *testing.T) {
+	outside := fs.NewDir(t, "service-cp-outside")
+	tests := []struct {
+		name    string
+		entries []*tar.Header
+		setup   func(dir string)
+	}{
+		{
+			name: "absolute link",
+			entries: []*tar.Header{
+				{Name: "app/a", Typeflag: tar.TypeSymlink, Linkname: outside.Path()},
+				{Name: "app/a/passwd", Typeflag: tar.TypeReg, Mode: 0o644},
+			},
+		},
+		{
+			name: "relative link outside",
+			entries: []*tar.Header{
+				{Name: "app/a", Typeflag: tar.TypeSymlink, Linkname: "../../" + filepath.Base(outside.Path())},
+				{Name: "app/a/passwd", Typeflag: tar.TypeReg, Mode: 0o644},
+			},
+		},
+		{
+			name: "entry through a link",
+			entries: []*tar.Header{
+				{Name: "app/b/", Typeflag: tar.TypeDir, Mode: 0o755},
+				{Name: "app/a", Typeflag: tar.TypeSymlink, Linkname: "b"},
+				{Name: "app/a/passwd", Typeflag: tar.TypeReg, Mode: 0o644},
+			},
+		},
+		{
+			name:    "entry through an existing link",
+			entries: []*tar.Header{{Name: "app/passwd", Typeflag: tar.TypeReg, Mode: 0o644}},
+			setup: func(dir string) {
+				assert.NilError(t, os.Symlink(outside.Path(), filepath.Join(dir, "app")))
+			},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			dir := fs.NewDir(t, "service-cp")
+			if tc.setup != nil {
+				tc.setup(dir.Path())
+			}
+			err := extractArchive(bytes.NewReader(testArchive(t, tc.entries...)), dir.Path())
+			assert.Check(t, is.ErrorContains(err, "invalid archive entry"))
+			_, err = os.Stat(filepath.Join(outside.Path(), "passwd"))
+			assert.Check(t, os.IsNotExist(err))
+		})
+	}
+}
+
+func TestExtractArchiveRelativeDestination(t *testing.T) {
+	dir := fs.NewDir(t, "service-cp")
+	wd, err := os.Getwd()
+	assert.NilError(t, err)
+	assert.NilError(t, os.Chdir(dir.Path()))
+	defer func() { assert.Check(t, os.Chdir(wd)) }()
+
+	file := testArchive(t, &tar.Header{Name: "nginx.conf", Typeflag: tar.TypeReg, Mode: 0o644})
+	assert.NilError(t, extractArchive(bytes.NewReader(file), "copied.conf"))
+	content, err := os.ReadFile(filepath.Join(dir.Path(), "copied.conf"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(content), "content of nginx.conf"))
+
+	tree := testArchive(t,
+		&tar.Header{Name: "app/", Typeflag: tar.TypeDir, Mode: 0o755},
+		&tar.Header{Name: "app/config.json", Typeflag: tar.TypeReg, Mode: 0o644},
+		&tar.Header{Name: "app/current", Typeflag: tar.TypeSymlink, Linkname: "config.json"},
+	)
+	assert.NilError(t, extractArchive(bytes.NewReader(tree), "."))
+	content, err = os.ReadFile(filepath.Join(dir.Path(), "app", "current"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(content), "content of app/config.json"))
+}
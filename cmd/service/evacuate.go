@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/freeze"
+	"github.com/moby/swarmctl/internal/placement"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type evacuateOptions struct {
+	node           string
+	to             []string
+	overrideFreeze bool
+	wait           waitOptions
+}
+
+// NewEvacuateCommand returns a cobra command for `node evacuate`. It is in
+// this package to share the convergence waiter of the services.
+func NewEvacuateCommand(dockerCli command.Cli) *cobra.Command {
+	opts := evacuateOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "evacuate [OPTIONS] NODE --to CONSTRAINT [--to CONSTRAINT...]",
+		Short: "Move the tasks of a node to the nodes matching constraints, then drain it",
+		Long: `Move the tasks of a node to the nodes matching constraints, then drain it.
+
+The replicated services with tasks on the node get the --to constraints, and
+one excluding the node, added to their placement. Their tasks not matching
+them, on the node or elsewhere, are moved. Once the services converged, the
+node is drained and the added constraints are removed, which does not move
+the tasks again. Global services are left to the drain, their task on the
+node is stopped.`,
+		Example: `  $ swarmctl node evacuate worker3 --to node.labels.zone==eu-west-1a`,
+		Args:    cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.node = args[0]
+			return runEvacuate(cmd.Context(), dockerCli, opts)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	flags := cmd.Flags()
+	flags.StringArrayVar(&opts.to, "to", nil, `Placement constraint of the nodes to move the tasks to, such as "node.labels.zone==eu-west-1a"`)
+	_ = cmd.MarkFlagRequired("to")
+	freeze.AddFlag(flags, &opts.overrideFreeze)
+	addWaitFlags(flags, &opts.wait)
+	_ = flags.MarkHidden("detach")
+	return cmd
+}
+
+func runEvacuate(ctx context.Context, dockerCli command.Cli, opts evacuateOptions) error {
+	apiClient := dockerCli.Client()
+
+	target, err := placement.Parse(opts.to)
+	if err != nil {
+		return err
+	}
+	node, _, err := apiClient.NodeInspectWithRaw(ctx, opts.node)
+	if err != nil {
+		return err
+	}
+	nodes, err := apiClient.NodeList(ctx, types.NodeListOptions{})
+	if err != nil {
+		return err
+	}
+	if !hasEvacuationTarget(nodes, node.ID, target) {
+		return errors.Errorf("no ready and active node other than %s matches %s", node.Description.Hostname, strings.Join(opts.to, " and "))
+	}
+
+	services, err := nodeServices(ctx, dockerCli, node.ID)
+	if err != nil {
+		return err
+	}
+	for _, service := range services {
+		if service.Spec.Mode.Replicated == nil {
+			continue
+		}
+		if err := freeze.Check("service", service.Spec.Name, service.Spec.Labels, opts.overrideFreeze); err != nil {
+			return err
+		}
+	}
+	constraints := append(append([]string{}, opts.to...), "node.id!="+node.ID)
+	var (
+		moved []swarm.Service
+		added = make(map[string][]string)
+	)
+	for _, service := range services {
+		if service.Spec.Mode.Replicated == nil {
+			fmt.Fprintf(dockerCli.Err(), "WARNING: %s is not a replicated service, its task on %s is stopped by the drain\n", service.Spec.Name, node.Description.Hostname)
+			continue
+		}
+		spec := service.Spec
+		spec.TaskTemplate.Placement, added[service.ID] = addConstraints(spec.TaskTemplate.Placement, constraints)
+		if err := updateService(ctx, dockerCli, service, spec); err != nil {
+			return restoreConstraints(dockerCli, moved, added, errors.Wrapf(err, "unable to constrain service %s", service.Spec.Name))
+		}
+		moved = append(moved, service)
+	}
+
+	err = waitForServices(ctx, dockerCli, moved, opts.wait)
+	if err == nil {
+		err = drainNode(ctx, dockerCli, node.ID)
+	}
+	return restoreConstraints(dockerCli, moved, added, err)
+}
+
+// evacuateCleanupTimeout bounds the removal of the constraints added by
+// evacuate, which runs after the command was cancelled or timed out.
+var evacuateCleanupTimeout = 30 * time.Second
+
+// restoreConstraints removes the constraints added to the services, and
+// returns err along with the errors of the removals. They are removed even
+// if the evacuation failed, relaxing them does not move the tasks that were
+// already moved, so they use their own context.
+func restoreConstraints(dockerCli command.Cli, services []swarm.Service, added map[string][]string, err error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), evacuateCleanupTimeout)
+	defer cancel()
+
+	var errs []string
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	for _, service := range services {
+		if err := removeConstraints(ctx, dockerCli, service.ID, added[service.ID]); err != nil {
+			errs = append(errs, fmt.Sprintf("unable to remove the constraints added to service %s: %s", service.Spec.Name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// hasEvacuationTarget returns whether a ready and active node, other than
+// the one evacuated, matches the target constraints.
+func hasEvacuationTarget(nodes []swarm.Node, nodeID string, target []placement.Constraint) bool {
+	for _, n := range nodes {
+		if n.ID != nodeID && n.Status.State == swarm.NodeStateReady && n.Spec.Availability == swarm.NodeAvailabilityActive && placement.Match(n, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeServices returns the services with tasks meant to run on the node,
+// ordered by name.
+func nodeServices(ctx context.Context, dockerCli command.Cli, nodeID string) ([]swarm.Service, error) {
+	apiClient := dockerCli.Client()
+	tasks, err := apiClient.TaskList(ctx, types.TaskListOptions{
+		Filters: filters.NewArgs(filters.Arg("node", nodeID), filters.Arg("desired-state", "running")),
+	})
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var services []swarm.Service
+	for _, task := range tasks {
+		if seen[task.ServiceID] {
+			continue
+		}
+		seen[task.ServiceID] = true
+		service, _, err := apiClient.ServiceInspectWithRaw(ctx, task.ServiceID, types.ServiceInspectOptions{})
+		if err != nil {
+			return nil, err
+		}
+		services = append(services, service)
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Spec.Name < services[j].Spec.Name })
+	return services, nil
+}
+
+// addConstraints returns a copy of p with the constraints it does not have
+// yet, and these constraints.
+func addConstraints(p *swarm.Placement, constraints []string) (*swarm.Placement, []string) {
+	result := &swarm.Placement{}
+	if p != nil {
+		*result = *p
+	}
+	result.Constraints = append([]string{}, result.Constraints...)
+	var added []string
+	for _, c := range constraints {
+		if !containsString(result.Constraints, c) {
+			result.Constraints = append(result.Constraints, c)
+			added = append(added, c)
+		}
+	}
+	return result, added
+}
+
+// removeConstraints removes constraints from the placement of the current
+// spec of the service.
+func removeConstraints(ctx context.Context, dockerCli command.Cli, serviceID string, constraints []string) error {
+	service, _, err := dockerCli.Client().ServiceInspectWithRaw(ctx, serviceID, types.ServiceInspectOptions{})
+	if err != nil {
+		return err
+	}
+	spec := service.Spec
+	if spec.TaskTemplate.Placement == nil {
+		return nil
+	}
+	p := *spec.TaskTemplate.Placement
+	p.Constraints = nil
+	for _, c := range spec.TaskTemplate.Placement.Constraints {
+		if !containsString(constraints, c) {
+			p.Constraints = append(p.Constraints, c)
+		}
+	}
+	spec.TaskTemplate.Placement = &p
+	return updateService(ctx, dockerCli, service, spec)
+}
+
+func drainNode(ctx context.Context, dockerCli command.Cli, nodeID string) error {
+	node, _, err := dockerCli.Client().NodeInspectWithRaw(ctx, nodeID)
+	if err != nil {
+		return err
+	}
+	spec := node.Spec
+	spec.Availability = swarm.NodeAvailabilityDrain
+	if err := dockerCli.Client().NodeUpdate(ctx, node.ID, node.Version, spec); err != nil {
+		return errors.Wrapf(err, "unable to drain node %s", node.Description.Hostname)
+	}
+	fmt.Fprintf(dockerCli.Err(), "Node %s drained\n", node.Description.Hostname)
+	return nil
+}
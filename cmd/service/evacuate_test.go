@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/test"
+	"github.com/pkg/errors"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func zoneNode(id, hostname, zone string) swarm.Node {
+	return swarm.Node{
+		ID:          id,
+		Description: swarm.NodeDescription{Hostname: hostname},
+		Spec:        swarm.NodeSpec{Availability: swarm.NodeAvailabilityActive, Annotations: swarm.Annotations{Labels: map[string]string{"zone": zone}}},
+		Status:      swarm.NodeStatus{State: swarm.NodeStateReady},
+	}
+}
+
+// evacuateClient returns a client for a swarm of two nodes in zones a and b,
+// with replicated api and web services and a global agent service with a
+// task on worker2. The specs the services are updated to are recorded in
+// specs, the availability of the nodes in availability.
+func evacuateClient(specs map[string][]swarm.ServiceSpec, availability map[string]swarm.NodeAvailability) *fakeClient {
+	replicas := uint64(1)
+	web := testService("id-web", "web")
+	web.Spec.Mode = swarm.ServiceMode{Replicated: &swarm.ReplicatedService{Replicas: &replicas}}
+	web.Spec.TaskTemplate.Placement = &swarm.Placement{Constraints: []string{"node.role==worker"}}
+	web.Spec.UpdateConfig = &swarm.UpdateConfig{Monitor: time.Millisecond}
+	api := testService("id-api", "api")
+	api.Spec.Mode = swarm.ServiceMode{Replicated: &swarm.ReplicatedService{Replicas: &replicas}}
+	api.Spec.UpdateConfig = &swarm.UpdateConfig{Monitor: time.Millisecond}
+	agent := testService("id-agent", "agent")
+	agent.Spec.Mode = swarm.ServiceMode{Global: &swarm.GlobalService{}}
+	services := map[string]swarm.Service{"id-web": web, "id-api": api, "id-agent": agent}
+	nodes := map[string]swarm.Node{
+		"worker1":  zoneNode("id-node1", "worker1", "a"),
+		"id-node2": zoneNode("id-node2", "worker2", "b"),
+		"worker2":  zoneNode("id-node2", "worker2", "b"),
+	}
+
+	return &fakeClient{
+		nodeInspectFunc: func(nodeID string) (swarm.Node, []byte, error) {
+			return nodes[nodeID], nil, nil
+		},
+		nodeListFunc: func(types.NodeListOptions) ([]swarm.Node, error) {
+			return []swarm.Node{nodes["worker1"], nodes["worker2"]}, nil
+		},
+		nodeUpdateFunc: func(nodeID string, version swarm.Version, spec swarm.NodeSpec) error {
+			availability[nodeID] = spec.Availability
+			return nil
+		},
+		taskListFunc: func(options types.TaskListOptions) ([]swarm.Task, error) {
+			if options.Filters.Contains("node") {
+				webTask := runningTask("task1", 1, "id-node2")
+				webTask.ServiceID = "id-web"
+				agentTask := runningTask("task2", 0, "id-node2")
+				agentTask.ServiceID = "id-agent"
+				apiTask := runningTask("task4", 1, "id-node2")
+				apiTask.ServiceID = "id-api"
+				return []swarm.Task{agentTask, webTask, apiTask}, nil
+			}
+			moved := runningTask("task3", 1, "id-node1")
+			moved.ServiceID = options.Filters.Get("service")[0]
+			moved.DesiredState = swarm.TaskStateRunning
+			return []swarm.Task{moved}, nil
+		},
+		serviceInspectFunc: func(serviceID string) (swarm.Service, []byte, error) {
+			return services[serviceID], nil, nil
+		},
+		serviceUpdateFunc: func(serviceID string, version swarm.Version, spec swarm.ServiceSpec) (types.ServiceUpdateResponse, error) {
+			specs[serviceID] = append(specs[serviceID], spec)
+			s := services[serviceID]
+			s.Spec = spec
+			services[serviceID] = s
+			return types.ServiceUpdateResponse{}, nil
+		},
+	}
+}
+
+func TestEvacuate(t *testing.T) {
+	specs := make(map[string][]swarm.ServiceSpec)
+	availability := make(map[string]swarm.NodeAvailability)
+	cli := test.NewFakeCli(evacuateClient(specs, availability))
+	cmd := NewEvacuateCommand(cli)
+	cmd.SetArgs([]string{"worker2", "--to", "node.labels.zone==a", "--quiet"})
+	assert.NilError(t, cmd.Execute())
+
+	assert.Check(t, is.Len(specs["id-agent"], 0))
+	assert.Assert(t, is.Len(specs["id-api"], 2))
+	assert.Check(t, is.DeepEqual(specs["id-api"][0].TaskTemplate.Placement.Constraints, []string{"node.labels.zone==a", "node.id!=id-node2"}))
+	assert.Check(t, is.Len(specs["id-api"][1].TaskTemplate.Placement.Constraints, 0))
+	assert.Assert(t, is.Len(specs["id-web"], 2))
+	assert.Check(t, is.DeepEqual(specs["id-web"][0].TaskTemplate.Placement.Constraints, []string{"node.role==worker", "node.labels.zone==a", "node.id!=id-node2"}))
+	assert.Check(t, is.DeepEqual(specs["id-web"][1].TaskTemplate.Placement.Constraints, []string{"node.role==worker"}))
+	assert.Check(t, is.DeepEqual(availability, map[string]swarm.NodeAvailability{"id-node2": swarm.NodeAvailabilityDrain}))
+	assert.Check(t, is.Equal(cli.ErrBuffer().String(), "WARNING: agent is not a replicated service, its task on worker2 is stopped by the drain\nNode worker2 drained\n"))
+}
+
+func TestEvacuateRestoresConstraintsOnError(t *testing.T) {
+	specs := make(map[string][]swarm.ServiceSpec)
+	availability := make(map[string]swarm.NodeAvailability)
+	apiClient := evacuateClient(specs, availability)
+	update := apiClient.serviceUpdateFunc
+	apiClient.serviceUpdateFunc = func(serviceID string, version swarm.Version, spec swarm.ServiceSpec) (types.ServiceUpdateResponse, error) {
+		if serviceID == "id-web" {
+			return types.ServiceUpdateResponse{}, errors.New("update out of sequence")
+		}
+		return update(serviceID, version, spec)
+	}
+	cmd := NewEvacuateCommand(test.NewFakeCli(apiClient))
+	cmd.SetArgs([]string{"worker2", "--to", "node.labels.zone==a", "--quiet"})
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "unable to constrain service web: update out of sequence")
+
+	assert.Assert(t, is.Len(specs["id-api"], 2))
+	assert.Check(t, is.Len(specs["id-api"][1].TaskTemplate.Placement.Constraints, 0))
+	assert.Check(t, is.Len(availability, 0))
+}
+
+func TestEvacuateRestoresConstraintsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	specs := make(map[string][]swarm.ServiceSpec)
+	apiClient := evacuateClient(specs, make(map[string]swarm.NodeAvailability))
+	apiClient.nodeUpdateFunc = func(string, swarm.Version, swarm.NodeSpec) error {
+		cancel()
+		return ctx.Err()
+	}
+	cmd := NewEvacuateCommand(test.NewFakeCli(apiClient))
+	cmd.SetArgs([]string{"worker2", "--to", "node.labels.zone==a", "--quiet"})
+	assert.Error(t, cmd.ExecuteContext(ctx), "unable to drain node worker2: context canceled")
+
+	assert.Assert(t, is.Len(specs["id-api"], 2))
+	assert.Check(t, is.Len(specs["id-api"][1].TaskTemplate.Placement.Constraints, 0))
+	assert.Assert(t, is.Len(specs["id-web"], 2))
+	assert.Check(t, is.DeepEqual(specs["id-web"][1].TaskTemplate.Placement.Constraints, []string{"node.role==worker"}))
+}
+
+func TestEvacuateErrors(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{args: []string{"worker2"}, expected: `required flag(s) "to" not set`},
+		{args: []string{"worker2", "--to", "zone=a"}, expected: `invalid constraint "zone=a", must be KEY==VALUE or KEY!=VALUE`},
+		{args: []string{"worker2", "--to", "node.labels.zone==b"}, expected: "no ready and active node other than worker2 matches node.labels.zone==b"},
+	}
+	for _, tc := range testCases {
+		specs := make(map[string][]swarm.ServiceSpec)
+		cmd := NewEvacuateCommand(test.NewFakeCli(evacuateClient(specs, make(map[string]swarm.NodeAvailability))))
+		cmd.SetArgs(tc.args)
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		assert.Error(t, cmd.Execute(), tc.expected)
+		assert.Check(t, is.Len(specs, 0))
+	}
+}
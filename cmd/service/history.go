@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/go-units"
+	"github.com/moby/swarmctl/internal/quota"
+	"github.com/spf13/cobra"
+)
+
+// now returns the current time; tests replace it.
+var now = time.Now
+
+type historyOptions struct {
+	service string
+}
+
+func newHistoryCommand(dockerCli command.Cli) *cobra.Command {
+	opts := historyOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "history SERVICE",
+		Short: "Show the changes of the image, environment and resources of a service",
+		Long: `Show the changes of the image, environment and resources of a service.
+
+The timeline is rebuilt from the specs of the tasks the swarm still keeps (see
+the task history limit of "swarm update"), from the spec that preceded the last
+update of the service, and from its current spec. Changes older than the
+oldest task are not shown. The engine does not record who updated a service.`,
+		Args: cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.service = args[0]
+			return runHistory(cmd.Context(), dockerCli, opts)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+	return cmd
+}
+
+// revision is a task spec of a service, and when the service started to
+// use it. at is zero when it is not known.
+type revision struct {
+	spec swarm.TaskSpec
+	at   time.Time
+}
+
+func runHistory(ctx context.Context, dockerCli command.Cli, opts historyOptions) error {
+	apiClient := dockerCli.Client()
+
+	service, _, err := apiClient.ServiceInspectWithRaw(ctx, opts.service, types.ServiceInspectOptions{})
+	if err != nil {
+		return err
+	}
+	tasks, err := apiClient.TaskList(ctx, types.TaskListOptions{
+		Filters: filters.NewArgs(filters.Arg("service", service.ID)),
+	})
+	if err != nil {
+		return err
+	}
+
+	revisions := serviceRevisions(service, tasks)
+	w := tabwriter.NewWriter(dockerCli.Out(), 10, 1, 3, ' ', 0)
+	fmt.Fprintln(w, "REVISION\tCREATED\tCHANGES")
+	for i, r := range revisions {
+		name := strconv.Itoa(i + 1)
+		if i == len(revisions)-1 {
+			name += " (current)"
+		}
+		created := "-"
+		if !r.at.IsZero() {
+			created = units.HumanDuration(now().Sub(r.at)) + " ago"
+		}
+		var changes []string
+		if i == 0 {
+			changes = specChanges(swarm.TaskSpec{}, r.spec)
+		} else {
+			changes = specChanges(revisions[i-1].spec, r.spec)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", name, created, strings.Join(changes, ", "))
+	}
+	return w.Flush()
+}
+
+// serviceRevisions returns the successive task specs of the service, oldest
+// first, from the specs of its tasks, its previous spec and its current
+// spec. Only the image, environment and resources tell revisions apart.
+func serviceRevisions(service swarm.Service, tasks []swarm.Task) []revision {
+	sort.SliceStable(tasks, func(i, j int) bool { return tasks[i].CreatedAt.Before(tasks[j].CreatedAt) })
+
+	var revisions []revision
+	add := func(r revision) {
+		if n := len(revisions); n == 0 || len(specChanges(revisions[n-1].spec, r.spec)) > 0 {
+			revisions = append(revisions, r)
+		}
+	}
+	for _, task := range tasks {
+		add(revision{spec: task.Spec, at: task.CreatedAt})
+	}
+
+	// The tasks of the current spec may not be created yet, and the tasks of
+	// the previous one may have been removed from the history.
+	current := revision{spec: service.Spec.TaskTemplate, at: service.UpdatedAt}
+	if service.UpdateStatus != nil && service.UpdateStatus.StartedAt != nil {
+		current.at = *service.UpdateStatus.StartedAt
+	}
+	if n := len(revisions); n > 0 && len(specChanges(revisions[n-1].spec, current.spec)) == 0 {
+		current = revisions[n-1]
+		revisions = revisions[:n-1]
+	}
+	if service.PreviousSpec != nil && len(specChanges(service.PreviousSpec.TaskTemplate, current.spec)) > 0 {
+		add(revision{spec: service.PreviousSpec.TaskTemplate})
+	}
+	add(current)
+	return revisions
+}
+
+// specChanges describes the changes of the image, environment and resources
+// between two task specs.
+func specChanges(from, to swarm.TaskSpec) []string {
+	var fromContainer, toContainer swarm.ContainerSpec
+	if from.ContainerSpec != nil {
+		fromContainer = *from.ContainerSpec
+	}
+	if to.ContainerSpec != nil {
+		toContainer = *to.ContainerSpec
+	}
+
+	var changes []string
+	changes = appendChange(changes, "image", fromContainer.Image, toContainer.Image)
+	changes = append(changes, envChanges(fromContainer.Env, toContainer.Env)...)
+
+	var fromLimits, toLimits swarm.Limit
+	var fromReservations, toReservations swarm.Resources
+	if from.Resources != nil && from.Resources.Limits != nil {
+		fromLimits = *from.Resources.Limits
+	}
+	if to.Resources != nil && to.Resources.Limits != nil {
+		toLimits = *to.Resources.Limits
+	}
+	if from.Resources != nil && from.Resources.Reservations != nil {
+		fromReservations = *from.Resources.Reservations
+	}
+	if to.Resources != nil && to.Resources.Reservations != nil {
+		toReservations = *to.Resources.Reservations
+	}
+	changes = appendChange(changes, "cpu limit", formatCPUs(fromLimits.NanoCPUs), formatCPUs(toLimits.NanoCPUs))
+	changes = appendChange(changes, "memory limit", formatMemory(fromLimits.MemoryBytes), formatMemory(toLimits.MemoryBytes))
+	changes = appendChange(changes, "cpu reservation", formatCPUs(fromReservations.NanoCPUs), formatCPUs(toReservations.NanoCPUs))
+	changes = appendChange(changes, "memory reservation", formatMemory(fromReservations.MemoryBytes), formatMemory(toReservations.MemoryBytes))
+	return changes
+}
+
+// appendChange appends the change of a setting to changes, if it changed.
+// The old value is left out when the setting was not set.
+func appendChange(changes []string, name, from, to string) []string {
+	switch {
+	case from == to:
+		return changes
+	case from == "":
+		return append(changes, name+" "+to)
+	case to == "":
+		return append(changes, name+" "+from+"→none")
+	default:
+		return append(changes, name+" "+from+"→"+to)
+	}
+}
+
+// envChanges describes the variables added, changed and removed between
+// two environments, ordered by name.
+func envChanges(from, to []string) []string {
+	fromVars, toVars := envMap(from), envMap(to)
+	names := make([]string, 0, len(fromVars)+len(toVars))
+	for name := range fromVars {
+		names = append(names, name)
+	}
+	for name := range toVars {
+		if _, ok := fromVars[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var changes []string
+	for _, name := range names {
+		oldValue, hadVar := fromVars[name]
+		newValue, hasVar := toVars[name]
+		switch {
+		case !hadVar:
+			changes = append(changes, "env +"+name+"="+newValue)
+		case !hasVar:
+			changes = append(changes, "env -"+name)
+		case oldValue != newValue:
+			changes = append(changes, "env "+name+"="+oldValue+"→"+newValue)
+		}
+	}
+	return changes
+}
+
+func envMap(env []string) map[string]string {
+	vars := make(map[string]string, len(env))
+	for _, v := range env {
+		name, value, _ := strings.Cut(v, "=")
+		vars[name] = value
+	}
+	return vars
+}
+
+// formatCPUs formats nano CPUs, or returns an empty string if they are not
+// set.
+func formatCPUs(nanoCPUs int64) string {
+	if nanoCPUs == 0 {
+		return ""
+	}
+	return quota.FormatCPUs(nanoCPUs)
+}
+
+// formatMemory formats bytes, or returns an empty string if they are not
+// set.
+func formatMemory(bytes int64) string {
+	if bytes == 0 {
+		return ""
+	}
+	return quota.FormatMemory(bytes)
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func historyTask(id string, created time.Time, spec swarm.TaskSpec) swarm.Task {
+	return swarm.Task{
+		ID:     id,
+		Meta:   swarm.Meta{CreatedAt: created},
+		Spec:   spec,
+		Status: swarm.TaskStatus{State: swarm.TaskStateRunning},
+	}
+}
+
+func historyService(spec swarm.TaskSpec) swarm.Service {
+	return swarm.Service{
+		ID:   "id-web",
+		Spec: swarm.ServiceSpec{Annotations: swarm.Annotations{Name: "web"}, TaskTemplate: spec},
+	}
+}
+
+func TestHistory(t *testing.T) {
+	defer func() { now = time.Now }()
+	start := time.Date(2023, 1, 2, 15, 0, 0, 0, time.UTC)
+	now = func() time.Time { return start.Add(time.Hour) }
+
+	v1 := swarm.TaskSpec{ContainerSpec: &swarm.ContainerSpec{Image: "nginx:1.24", Env: []string{"MODE=prod", "DEBUG=0"}}}
+	v2 := swarm.TaskSpec{
+		ContainerSpec: &swarm.ContainerSpec{Image: "nginx:1.25", Env: []string{"MODE=prod", "DEBUG=1"}},
+		Resources:     &swarm.ResourceRequirements{Limits: &swarm.Limit{NanoCPUs: 500000000, MemoryBytes: 256 * 1024 * 1024}},
+	}
+	v3 := swarm.TaskSpec{
+		ContainerSpec: &swarm.ContainerSpec{Image: "nginx:1.25", Env: []string{"MODE=prod", "WORKERS=4"}},
+		Resources:     &swarm.ResourceRequirements{Limits: &swarm.Limit{NanoCPUs: 1000000000}},
+	}
+	// v4 only changes the placement, which is not shown.
+	v4 := v3
+	v4.Placement = &swarm.Placement{Constraints: []string{"node.role==worker"}}
+
+	web := historyService(v4)
+	web.PreviousSpec = &swarm.ServiceSpec{TaskTemplate: v3}
+	web.UpdatedAt = start.Add(40 * time.Minute)
+	tasks := []swarm.Task{
+		historyTask("task3", start.Add(30*time.Minute), v3),
+		historyTask("task1", start, v1),
+		historyTask("task2", start.Add(10*time.Minute), v2),
+		historyTask("task2b", start.Add(11*time.Minute), v2),
+	}
+	client := &fakeClient{
+		serviceInspectFunc: func(string) (swarm.Service, []byte, error) {
+			return web, nil, nil
+		},
+		taskListFunc: func(options types.TaskListOptions) ([]swarm.Task, error) {
+			assert.Check(t, is.DeepEqual(options.Filters.Get("service"), []string{"id-web"}))
+			return tasks, nil
+		},
+	}
+	cli := test.NewFakeCli(client)
+	cmd := NewServiceCommand(cli)
+	cmd.SetArgs([]string{"history", "web"})
+	assert.NilError(t, cmd.Execute())
+	test.AssertGolden(t, cli.OutBuffer().String(), "history.golden")
+}
+
+func TestServiceRevisions(t *testing.T) {
+	created := time.Date(2023, 1, 2, 15, 0, 0, 0, time.UTC)
+	v1 := swarm.TaskSpec{ContainerSpec: &swarm.ContainerSpec{Image: "redis:6"}}
+	v2 := swarm.TaskSpec{ContainerSpec: &swarm.ContainerSpec{Image: "redis:7"}}
+
+	// The previous spec has no task left and the current one has no task yet.
+	db := historyService(v2)
+	db.PreviousSpec = &swarm.ServiceSpec{TaskTemplate: v1}
+	db.UpdatedAt = created
+	revisions := serviceRevisions(db, nil)
+	assert.Check(t, is.DeepEqual(revisionImages(revisions), []string{"redis:6 -", "redis:7 15:00"}))
+
+	// A rollback is a new revision.
+	db.Spec.TaskTemplate = v1
+	db.PreviousSpec = &swarm.ServiceSpec{TaskTemplate: v2}
+	revisions = serviceRevisions(db, []swarm.Task{
+		historyTask("task1", created, v1),
+		historyTask("task2", created.Add(time.Minute), v2),
+		historyTask("task3", created.Add(2*time.Minute), v1),
+	})
+	assert.Check(t, is.DeepEqual(revisionImages(revisions), []string{"redis:6 15:00", "redis:7 15:01", "redis:6 15:02"}))
+}
+
+// revisionImages returns the image of each revision, and the time it was
+// created at.
+func revisionImages(revisions []revision) []string {
+	images := make([]string, 0, len(revisions))
+	for _, r := range revisions {
+		at := "-"
+		if !r.at.IsZero() {
+			at = r.at.Format("15:04")
+		}
+		images = append(images, r.spec.ContainerSpec.Image+" "+at)
+	}
+	return images
+}
@@ -0,0 +1,462 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/cli/opts"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	mounttypes "github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/go-units"
+	"github.com/moby/swarmctl/internal/engine"
+	"github.com/moby/swarmctl/internal/freeze"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func newMountCommand(dockerCli command.Cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mount",
+		Short: "Manage the mounts of a service",
+		Args:  cli.NoArgs,
+		RunE:  command.ShowHelp(dockerCli.Err()),
+	}
+	cmd.AddCommand(
+		newMountAddCommand(dockerCli),
+		newMountListCommand(dockerCli),
+		newMountRemoveCommand(dockerCli),
+	)
+	return cmd
+}
+
+type mountAddOptions struct {
+	service         string
+	mountType       string
+	source          string
+	target          string
+	readOnly        bool
+	volumeDriver    string
+	volumeOpts      opts.ListOpts
+	volumeLabels    opts.ListOpts
+	volumeNoCopy    bool
+	bindPropagation string
+	tmpfsSize       opts.MemBytes
+	tmpfsMode       string
+	force           bool
+	overrideFreeze  bool
+	wait            waitOptions
+}
+
+func newMountAddCommand(dockerCli command.Cli) *cobra.Command {
+	options := mountAddOptions{
+		volumeOpts:   opts.NewListOpts(opts.ValidateEnv),
+		volumeLabels: opts.NewListOpts(opts.ValidateLabel),
+	}
+
+	cmd := &cobra.Command{
+		Use:   "add [OPTIONS] SERVICE",
+		Short: "Add a mount to a service",
+		Long: `Add a mount to a service.
+
+The source of a bind mount must exist on the nodes running the service. It is
+checked on the nodes running its tasks, reached through the docker context
+named after each node (see "node info"), and the mount is not added if it is
+missing on one of them, unless --force is set. The nodes the tasks are later
+moved to are not checked.`,
+		Args: cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.service = args[0]
+			return runMountAdd(cmd.Context(), dockerCli, options)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&options.mountType, "type", string(mounttypes.TypeVolume), `Type of the mount ("volume", "bind", "tmpfs")`)
+	flags.StringVar(&options.source, "source", "", "Volume name or absolute host path of the mount")
+	flags.StringVar(&options.target, "target", "", "Absolute path of the mount in the container")
+	flags.BoolVar(&options.readOnly, "readonly", false, "Mount read-only")
+	flags.StringVar(&options.volumeDriver, "volume-driver", "", "Driver of the volume")
+	flags.Var(&options.volumeOpts, "volume-opt", "Option of the volume driver")
+	flags.Var(&options.volumeLabels, "volume-label", "Label of the volume")
+	flags.BoolVar(&options.volumeNoCopy, "volume-nocopy", false, "Do not populate the volume with the data at the target of the image")
+	flags.StringVar(&options.bindPropagation, "bind-propagation", "", `Propagation of the bind mount ("rprivate", "private", "rshared", "shared", "rslave", "slave")`)
+	flags.Var(&options.tmpfsSize, "tmpfs-size", "Size of the tmpfs mount")
+	flags.StringVar(&options.tmpfsMode, "tmpfs-mode", "", "File mode of the tmpfs mount, in octal (for example 1770)")
+	flags.BoolVarP(&options.force, "force", "f", false, "Add a bind mount even if its source does not exist on the nodes running the service")
+	freeze.AddFlag(flags, &options.overrideFreeze)
+	addWaitFlags(flags, &options.wait)
+	return cmd
+}
+
+func runMountAdd(ctx context.Context, dockerCli command.Cli, options mountAddOptions) error {
+	client := dockerCli.Client()
+
+	mount, err := options.mount()
+	if err != nil {
+		return err
+	}
+
+	service, _, err := client.ServiceInspectWithRaw(ctx, options.service, types.ServiceInspectOptions{})
+	if err != nil {
+		return err
+	}
+	if err := freeze.Check("service", service.Spec.Name, service.Spec.Labels, options.overrideFreeze); err != nil {
+		return err
+	}
+	spec := service.Spec
+	if spec.TaskTemplate.ContainerSpec == nil {
+		return errors.Errorf("service %s does not run containers", spec.Name)
+	}
+	containerSpec := *spec.TaskTemplate.ContainerSpec
+	for _, m := range containerSpec.Mounts {
+		if path.Clean(m.Target) == mount.Target {
+			return errors.Errorf("service %s already has a mount at %s", spec.Name, mount.Target)
+		}
+	}
+
+	switch {
+	case mount.Type == mounttypes.TypeVolume && mount.Source == "":
+		fmt.Fprintf(dockerCli.Err(), "WARNING: %s is an anonymous volume: every task gets a new volume, which is not shared with other tasks nor kept when the task is rescheduled\n", mount.Target)
+	case mount.Type == mounttypes.TypeBind:
+		missing, errs, err := checkBindSource(ctx, dockerCli, service, mount.Source)
+		if err != nil {
+			return err
+		}
+		for _, err := range errs {
+			fmt.Fprintf(dockerCli.Err(), "WARNING: unable to check bind source %s on %s\n", mount.Source, err)
+		}
+		if len(missing) > 0 {
+			if !options.force {
+				return errors.Errorf("bind source %s does not exist on %s, create it or use --force", mount.Source, strings.Join(missing, ", "))
+			}
+			fmt.Fprintf(dockerCli.Err(), "WARNING: bind source %s does not exist on %s\n", mount.Source, strings.Join(missing, ", "))
+		}
+	}
+
+	containerSpec.Mounts = append(containerSpec.Mounts[:len(containerSpec.Mounts):len(containerSpec.Mounts)], mount)
+	spec.TaskTemplate.ContainerSpec = &containerSpec
+	if err := updateService(ctx, dockerCli, service, spec); err != nil {
+		return err
+	}
+	return waitForService(ctx, dockerCli, service.ID, options.wait)
+}
+
+// mount validates the options and returns the mount to add.
+func (o mountAddOptions) mount() (mounttypes.Mount, error) {
+	if o.target == "" {
+		return mounttypes.Mount{}, errors.New("--target is required")
+	}
+	if !path.IsAbs(o.target) {
+		return mounttypes.Mount{}, errors.Errorf("invalid target %q, must be an absolute path", o.target)
+	}
+	m := mounttypes.Mount{
+		Type:     mounttypes.Type(o.mountType),
+		Source:   o.source,
+		Target:   path.Clean(o.target),
+		ReadOnly: o.readOnly,
+	}
+
+	volumeOptions := o.volumeDriver != "" || o.volumeOpts.Len() > 0 || o.volumeLabels.Len() > 0 || o.volumeNoCopy
+	tmpfsOptions := o.tmpfsSize.Value() != 0 || o.tmpfsMode != ""
+
+	switch m.Type {
+	case mounttypes.TypeVolume:
+		if o.bindPropagation != "" || tmpfsOptions {
+			return m, errors.New("bind and tmpfs options cannot be used with volume mounts")
+		}
+		if volumeOptions {
+			m.VolumeOptions = &mounttypes.VolumeOptions{
+				NoCopy: o.volumeNoCopy,
+				Labels: opts.ConvertKVStringsToMap(o.volumeLabels.GetAll()),
+			}
+			if o.volumeDriver != "" || o.volumeOpts.Len() > 0 {
+				m.VolumeOptions.DriverConfig = &mounttypes.Driver{
+					Name:    o.volumeDriver,
+					Options: opts.ConvertKVStringsToMap(o.volumeOpts.GetAll()),
+				}
+			}
+		}
+	case mounttypes.TypeBind:
+		if volumeOptions || tmpfsOptions {
+			return m, errors.New("volume and tmpfs options cannot be used with bind mounts")
+		}
+		if !path.IsAbs(o.source) {
+			return m, errors.Errorf("invalid bind source %q, must be an absolute path", o.source)
+		}
+		if o.bindPropagation != "" {
+			m.BindOptions = &mounttypes.BindOptions{Propagation: mounttypes.Propagation(o.bindPropagation)}
+			if !isPropagation(m.BindOptions.Propagation) {
+				return m, errors.Errorf("invalid bind propagation %q", o.bindPropagation)
+			}
+		}
+	case mounttypes.TypeTmpfs:
+		if volumeOptions || o.bindPropagation != "" {
+			return m, errors.New("volume and bind options cannot be used with tmpfs mounts")
+		}
+		if o.source != "" {
+			return m, errors.New("tmpfs mounts do not have a source")
+		}
+		if tmpfsOptions {
+			m.TmpfsOptions = &mounttypes.TmpfsOptions{SizeBytes: o.tmpfsSize.Value()}
+		}
+		if o.tmpfsMode != "" {
+			mode, err := strconv.ParseUint(o.tmpfsMode, 8, 32)
+			if err != nil {
+				return m, errors.Errorf("invalid tmpfs mode %q, must be an octal file mode", o.tmpfsMode)
+			}
+			m.TmpfsOptions.Mode = os.FileMode(mode)
+		}
+	default:
+		return m, errors.Errorf("invalid mount type %q, must be one of volume, bind, tmpfs", o.mountType)
+	}
+	return m, nil
+}
+
+func isPropagation(p mounttypes.Propagation) bool {
+	for _, valid := range mounttypes.Propagations {
+		if p == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// bindCheckTarget is where the bind source is mounted in the containers
+// checking that it exists.
+const bindCheckTarget = "/swarmctl-bind-check"
+
+// checkBindSource checks that source exists on the nodes running tasks of
+// the service. On each node, a container binding source is created with the
+// image of the task and removed right away, without being started: engines
+// refuse to create it if source does not exist. It returns the hostnames of
+// the nodes where source does not exist, sorted, and the errors of the
+// nodes where it could not be checked.
+func checkBindSource(ctx context.Context, dockerCli command.Cli, service swarm.Service, source string) ([]string, []error, error) {
+	apiClient := dockerCli.Client()
+	tasks, err := apiClient.TaskList(ctx, types.TaskListOptions{
+		Filters: filters.NewArgs(filters.Arg("service", service.ID), filters.Arg("desired-state", "running")),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tasks) == 0 {
+		return nil, nil, nil
+	}
+	resolver, err := engine.NewResolver(ctx, dockerCli)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resolver.Close()
+
+	seen := make(map[string]bool)
+	var (
+		missing []string
+		errs    []error
+	)
+	for _, task := range tasks {
+		if task.NodeID == "" || seen[task.NodeID] {
+			continue
+		}
+		seen[task.NodeID] = true
+		node, _, err := apiClient.NodeInspectWithRaw(ctx, task.NodeID)
+		if err != nil {
+			return nil, nil, err
+		}
+		image := service.Spec.TaskTemplate.ContainerSpec.Image
+		if task.Spec.ContainerSpec != nil && task.Spec.ContainerSpec.Image != "" {
+			image = task.Spec.ContainerSpec.Image
+		}
+		exists, err := bindSourceExists(ctx, resolver, node, image, source)
+		switch {
+		case err != nil:
+			errs = append(errs, errors.Wrapf(err, "node %s", node.Description.Hostname))
+		case !exists:
+			missing = append(missing, node.Description.Hostname)
+		}
+	}
+	sort.Strings(missing)
+	return missing, errs, nil
+}
+
+// bindSourceExists returns whether source exists on node.
+func bindSourceExists(ctx context.Context, resolver *engine.Resolver, node swarm.Node, image, source string) (bool, error) {
+	nodeClient, err := resolver.Client(node)
+	if err != nil {
+		return false, err
+	}
+	created, err := nodeClient.ContainerCreate(ctx, &container.Config{Image: image}, &container.HostConfig{
+		Mounts: []mounttypes.Mount{{Type: mounttypes.TypeBind, Source: source, Target: bindCheckTarget, ReadOnly: true}},
+	}, nil, nil, "")
+	if err != nil {
+		if errdefs.IsInvalidParameter(err) && strings.Contains(err.Error(), "bind source path does not exist") {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nodeClient.ContainerRemove(ctx, created.ID, types.ContainerRemoveOptions{Force: true})
+}
+
+type mountRemoveOptions struct {
+	service        string
+	targets        []string
+	overrideFreeze bool
+	wait           waitOptions
+}
+
+func newMountRemoveCommand(dockerCli command.Cli) *cobra.Command {
+	options := mountRemoveOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "rm [OPTIONS] SERVICE TARGET [TARGET...]",
+		Aliases: []string{"remove"},
+		Short:   "Remove mounts from a service",
+		Args:    cli.RequiresMinArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.service = args[0]
+			options.targets = args[1:]
+			return runMountRemove(cmd.Context(), dockerCli, options)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	flags := cmd.Flags()
+	freeze.AddFlag(flags, &options.overrideFreeze)
+	addWaitFlags(flags, &options.wait)
+	return cmd
+}
+
+func runMountRemove(ctx context.Context, dockerCli command.Cli, options mountRemoveOptions) error {
+	service, _, err := dockerCli.Client().ServiceInspectWithRaw(ctx, options.service, types.ServiceInspectOptions{})
+	if err != nil {
+		return err
+	}
+	if err := freeze.Check("service", service.Spec.Name, service.Spec.Labels, options.overrideFreeze); err != nil {
+		return err
+	}
+	spec := service.Spec
+	if spec.TaskTemplate.ContainerSpec == nil {
+		return errors.Errorf("service %s does not run containers", spec.Name)
+	}
+
+	containerSpec := *spec.TaskTemplate.ContainerSpec
+	mounts := containerSpec.Mounts
+	for _, target := range options.targets {
+		target = path.Clean(target)
+		var kept []mounttypes.Mount
+		for _, m := range mounts {
+			if path.Clean(m.Target) != target {
+				kept = append(kept, m)
+			}
+		}
+		if len(kept) == len(mounts) {
+			return errors.Errorf("service %s has no mount at %s", spec.Name, target)
+		}
+		mounts = kept
+	}
+	containerSpec.Mounts = mounts
+	spec.TaskTemplate.ContainerSpec = &containerSpec
+
+	if err := updateService(ctx, dockerCli, service, spec); err != nil {
+		return err
+	}
+	return waitForService(ctx, dockerCli, service.ID, options.wait)
+}
+
+func newMountListCommand(dockerCli command.Cli) *cobra.Command {
+	return &cobra.Command{
+		Use:     "ls SERVICE",
+		Aliases: []string{"list"},
+		Short:   "List the mounts of a service",
+		Args:    cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runMountList(cmd.Context(), dockerCli, args[0])
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+}
+
+func runMountList(ctx context.Context, dockerCli command.Cli, serviceRef string) error {
+	service, _, err := dockerCli.Client().ServiceInspectWithRaw(ctx, serviceRef, types.ServiceInspectOptions{})
+	if err != nil {
+		return err
+	}
+	var mounts []mounttypes.Mount
+	if cs := service.Spec.TaskTemplate.ContainerSpec; cs != nil {
+		mounts = cs.Mounts
+	}
+	return printMounts(dockerCli.Out(), mounts)
+}
+
+func printMounts(out io.Writer, mounts []mounttypes.Mount) error {
+	w := tabwriter.NewWriter(out, 10, 1, 3, ' ', 0)
+	fmt.Fprintln(w, "TYPE\tSOURCE\tTARGET\tMODE\tOPTIONS")
+	for _, m := range mounts {
+		mode := "rw"
+		if m.ReadOnly {
+			mode = "ro"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", m.Type, orDash(m.Source), m.Target, mode, orDash(strings.Join(mountOptions(m), ",")))
+	}
+	return w.Flush()
+}
+
+// mountOptions returns the type-specific options of m, in the syntax of the
+// --mount flag of `docker service create`.
+func mountOptions(m mounttypes.Mount) []string {
+	var options []string
+	if v := m.VolumeOptions; v != nil {
+		if v.NoCopy {
+			options = append(options, "volume-nocopy")
+		}
+		if v.DriverConfig != nil && v.DriverConfig.Name != "" {
+			options = append(options, "volume-driver="+v.DriverConfig.Name)
+		}
+		if v.DriverConfig != nil {
+			options = append(options, sortedKeyValues("volume-opt", v.DriverConfig.Options)...)
+		}
+		options = append(options, sortedKeyValues("volume-label", v.Labels)...)
+	}
+	if b := m.BindOptions; b != nil && b.Propagation != "" {
+		options = append(options, "bind-propagation="+string(b.Propagation))
+	}
+	if t := m.TmpfsOptions; t != nil {
+		if t.SizeBytes != 0 {
+			options = append(options, "tmpfs-size="+units.BytesSize(float64(t.SizeBytes)))
+		}
+		if t.Mode != 0 {
+			options = append(options, fmt.Sprintf("tmpfs-mode=%o", t.Mode))
+		}
+	}
+	return options
+}
+
+func sortedKeyValues(prefix string, values map[string]string) []string {
+	var kvs []string
+	for k, v := range values {
+		kvs = append(kvs, fmt.Sprintf("%s=%s=%s", prefix, k, v))
+	}
+	sort.Strings(kvs)
+	return kvs
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
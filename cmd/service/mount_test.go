@@ -0,0 +1,231 @@
+package service
+
+import (
+	"io"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	mounttypes "github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/errdefs"
+	"github.com/moby/swarmctl/internal/test"
+	"github.com/pkg/errors"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func mountService(mounts ...mounttypes.Mount) swarm.Service {
+	service := testService("id-db", "db")
+	service.Spec.TaskTemplate.ContainerSpec = &swarm.ContainerSpec{Image: "postgres", Mounts: mounts}
+	return service
+}
+
+// mountClient returns a client for a service with tasks on node-1, the
+// local node, and node-2, which cannot be reached.
+func mountClient(service swarm.Service, updated *update) *fakeClient {
+	cli := publishClient(service, nil, updated)
+	cli.infoFunc = func() (types.Info, error) {
+		return types.Info{Swarm: swarm.Info{NodeID: "id-1"}}, nil
+	}
+	cli.taskListFunc = func(types.TaskListOptions) ([]swarm.Task, error) {
+		return []swarm.Task{{NodeID: "id-2"}, {NodeID: "id-1"}, {NodeID: "id-2"}}, nil
+	}
+	cli.nodeInspectFunc = func(nodeID string) (swarm.Node, []byte, error) {
+		return swarm.Node{ID: nodeID, Description: swarm.NodeDescription{Hostname: "node-" + nodeID[3:]}}, nil, nil
+	}
+	return cli
+}
+
+func TestMountAdd(t *testing.T) {
+	tests := []struct {
+		doc             string
+		args            []string
+		expected        mounttypes.Mount
+		expectedWarning string
+	}{
+		{
+			doc:  "named volume",
+			args: []string{"--source", "pgdata", "--target", "/var/lib/postgresql/data/", "--volume-driver", "local", "--volume-opt", "type=nfs", "--volume-label", "backup=daily"},
+			expected: mounttypes.Mount{
+				Type:   mounttypes.TypeVolume,
+				Source: "pgdata",
+				Target: "/var/lib/postgresql/data",
+				VolumeOptions: &mounttypes.VolumeOptions{
+					Labels:       map[string]string{"backup": "daily"},
+					DriverConfig: &mounttypes.Driver{Name: "local", Options: map[string]string{"type": "nfs"}},
+				},
+			},
+		},
+		{
+			doc:             "anonymous volume",
+			args:            []string{"--target", "/cache"},
+			expected:        mounttypes.Mount{Type: mounttypes.TypeVolume, Target: "/cache"},
+			expectedWarning: "WARNING: /cache is an anonymous volume: every task gets a new volume, which is not shared with other tasks nor kept when the task is rescheduled\n",
+		},
+		{
+			doc:  "bind",
+			args: []string{"--type", "bind", "--source", "/srv/certs", "--target", "/certs", "--readonly", "--bind-propagation", "rslave"},
+			expected: mounttypes.Mount{
+				Type:        mounttypes.TypeBind,
+				Source:      "/srv/certs",
+				Target:      "/certs",
+				ReadOnly:    true,
+				BindOptions: &mounttypes.BindOptions{Propagation: mounttypes.PropagationRSlave},
+			},
+			expectedWarning: "WARNING: unable to check bind source /srv/certs on node node-2: no context store available to reach node node-2\n",
+		},
+		{
+			doc:  "tmpfs",
+			args: []string{"--type", "tmpfs", "--target", "/run", "--tmpfs-size", "64m", "--tmpfs-mode", "1770"},
+			expected: mounttypes.Mount{
+				Type:         mounttypes.TypeTmpfs,
+				Target:       "/run",
+				TmpfsOptions: &mounttypes.TmpfsOptions{SizeBytes: 64 << 20, Mode: 01770},
+			},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.doc, func(t *testing.T) {
+			existing := mounttypes.Mount{Type: mounttypes.TypeVolume, Source: "logs", Target: "/logs"}
+			var updated update
+			cli := test.NewFakeCli(mountClient(mountService(existing), &updated))
+			cmd := newMountAddCommand(cli)
+			cmd.SetArgs(append([]string{"db", "--detach"}, tc.args...))
+			assert.NilError(t, cmd.Execute())
+
+			assert.Assert(t, updated.spec != nil)
+			assert.Check(t, is.DeepEqual(updated.spec.TaskTemplate.ContainerSpec.Mounts, []mounttypes.Mount{existing, tc.expected}))
+			assert.Check(t, is.Equal(cli.ErrBuffer().String(), tc.expectedWarning))
+		})
+	}
+}
+
+func TestMountAddMissingBindSource(t *testing.T) {
+	var updated update
+	client := mountClient(mountService(), &updated)
+	client.containerCreateFunc = func(config *container.Config, hostConfig *container.HostConfig) (container.CreateResponse, error) {
+		assert.Check(t, is.Equal(config.Image, "postgres"))
+		assert.Check(t, is.DeepEqual(hostConfig.Mounts, []mounttypes.Mount{{Type: mounttypes.TypeBind, Source: "/srv/certs", Target: bindCheckTarget, ReadOnly: true}}))
+		return container.CreateResponse{}, errdefs.InvalidParameter(errors.New(`invalid mount config for type "bind": bind source path does not exist: /srv/certs`))
+	}
+	cmd := newMountAddCommand(test.NewFakeCli(client))
+	cmd.SetArgs([]string{"db", "--detach", "--type", "bind", "--source", "/srv/certs", "--target", "/certs"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Check(t, is.Error(cmd.Execute(), "bind source /srv/certs does not exist on node-1, create it or use --force"))
+	assert.Check(t, updated.spec == nil)
+
+	cli := test.NewFakeCli(client)
+	cmd = newMountAddCommand(cli)
+	cmd.SetArgs([]string{"db", "--detach", "--type", "bind", "--source", "/srv/certs", "--target", "/certs", "--force"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, updated.spec != nil)
+	assert.Check(t, is.Equal(cli.ErrBuffer().String(), "WARNING: unable to check bind source /srv/certs on node node-2: no context store available to reach node node-2\n"+
+		"WARNING: bind source /srv/certs does not exist on node-1\n"))
+	assert.Check(t, is.Len(client.removedContainers, 0))
+}
+
+func TestMountAddErrors(t *testing.T) {
+	tests := []struct {
+		args          []string
+		expectedError string
+	}{
+		{
+			args:          []string{"--source", "data"},
+			expectedError: "--target is required",
+		},
+		{
+			args:          []string{"--target", "data"},
+			expectedError: `invalid target "data", must be an absolute path`,
+		},
+		{
+			args:          []string{"--target", "/logs/"},
+			expectedError: "service db already has a mount at /logs",
+		},
+		{
+			args:          []string{"--type", "npipe", "--target", "/pipe"},
+			expectedError: `invalid mount type "npipe", must be one of volume, bind, tmpfs`,
+		},
+		{
+			args:          []string{"--type", "bind", "--source", "certs", "--target", "/certs"},
+			expectedError: `invalid bind source "certs", must be an absolute path`,
+		},
+		{
+			args:          []string{"--type", "bind", "--source", "/certs", "--target", "/certs", "--bind-propagation", "up"},
+			expectedError: `invalid bind propagation "up"`,
+		},
+		{
+			args:          []string{"--type", "bind", "--source", "/certs", "--target", "/certs", "--volume-nocopy"},
+			expectedError: "volume and tmpfs options cannot be used with bind mounts",
+		},
+		{
+			args:          []string{"--target", "/data", "--tmpfs-size", "1g"},
+			expectedError: "bind and tmpfs options cannot be used with volume mounts",
+		},
+		{
+			args:          []string{"--type", "tmpfs", "--source", "x", "--target", "/run"},
+			expectedError: "tmpfs mounts do not have a source",
+		},
+		{
+			args:          []string{"--type", "tmpfs", "--target", "/run", "--tmpfs-mode", "999"},
+			expectedError: `invalid tmpfs mode "999", must be an octal file mode`,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.expectedError, func(t *testing.T) {
+			var updated update
+			cmd := newMountAddCommand(test.NewFakeCli(mountClient(mountService(mounttypes.Mount{Type: mounttypes.TypeVolume, Target: "/logs"}), &updated)))
+			cmd.SetArgs(append([]string{"db", "--detach"}, tc.args...))
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			assert.Check(t, is.Error(cmd.Execute(), tc.expectedError))
+			assert.Check(t, updated.spec == nil)
+		})
+	}
+}
+
+func TestMountRemove(t *testing.T) {
+	mounts := []mounttypes.Mount{
+		{Type: mounttypes.TypeVolume, Source: "pgdata", Target: "/var/lib/postgresql/data"},
+		{Type: mounttypes.TypeTmpfs, Target: "/run"},
+		{Type: mounttypes.TypeBind, Source: "/srv/certs", Target: "/certs"},
+	}
+	var updated update
+	cmd := newMountRemoveCommand(test.NewFakeCli(mountClient(mountService(mounts...), &updated)))
+	cmd.SetArgs([]string{"db", "/run/", "/certs", "--detach"})
+	assert.NilError(t, cmd.Execute())
+
+	assert.Assert(t, updated.spec != nil)
+	assert.Check(t, is.DeepEqual(updated.spec.TaskTemplate.ContainerSpec.Mounts, mounts[:1]))
+
+	cmd = newMountRemoveCommand(test.NewFakeCli(mountClient(mountService(mounts...), &updated)))
+	cmd.SetArgs([]string{"db", "/tmp", "--detach"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Check(t, is.Error(cmd.Execute(), "service db has no mount at /tmp"))
+}
+
+func TestMountList(t *testing.T) {
+	cli := test.NewFakeCli(mountClient(mountService(
+		mounttypes.Mount{
+			Type:   mounttypes.TypeVolume,
+			Source: "pgdata",
+			Target: "/var/lib/postgresql/data",
+			VolumeOptions: &mounttypes.VolumeOptions{
+				NoCopy:       true,
+				Labels:       map[string]string{"backup": "daily"},
+				DriverConfig: &mounttypes.Driver{Name: "local", Options: map[string]string{"type": "nfs", "device": ":/export"}},
+			},
+		},
+		mounttypes.Mount{Type: mounttypes.TypeBind, Source: "/srv/certs", Target: "/certs", ReadOnly: true, BindOptions: &mounttypes.BindOptions{Propagation: mounttypes.PropagationRSlave}},
+		mounttypes.Mount{Type: mounttypes.TypeTmpfs, Target: "/run", TmpfsOptions: &mounttypes.TmpfsOptions{SizeBytes: 64 << 20, Mode: 01770}},
+		mounttypes.Mount{Type: mounttypes.TypeVolume, Target: "/cache"},
+	), nil))
+	cmd := newMountListCommand(cli)
+	cmd.SetArgs([]string{"db"})
+	assert.NilError(t, cmd.Execute())
+	test.AssertGolden(t, cli.OutBuffer().String(), "mount-list.golden")
+}
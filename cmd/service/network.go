@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/freeze"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func newNetworkCommand(dockerCli command.Cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "network",
+		Short: "Manage the networks of a service",
+		Args:  cli.NoArgs,
+		RunE:  command.ShowHelp(dockerCli.Err()),
+	}
+	cmd.AddCommand(
+		newNetworkConnectCommand(dockerCli),
+		newNetworkDisconnectCommand(dockerCli),
+		newNetworkResolveCommand(dockerCli),
+	)
+	return cmd
+}
+
+type networkConnectOptions struct {
+	service        string
+	network        string
+	aliases        []string
+	overrideFreeze bool
+	wait           waitOptions
+}
+
+func newNetworkConnectCommand(dockerCli command.Cli) *cobra.Command {
+	opts := networkConnectOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "connect [OPTIONS] SERVICE NETWORK",
+		Short: "Connect a service to a network",
+		Args:  cli.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.service, opts.network = args[0], args[1]
+			return runNetworkConnect(cmd.Context(), dockerCli, opts)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	flags := cmd.Flags()
+	flags.StringSliceVar(&opts.aliases, "alias", nil, "Add network-scoped alias for the service")
+	freeze.AddFlag(flags, &opts.overrideFreeze)
+	addWaitFlags(flags, &opts.wait)
+	return cmd
+}
+
+func runNetworkConnect(ctx context.Context, dockerCli command.Cli, opts networkConnectOptions) error {
+	client := dockerCli.Client()
+
+	network, err := client.NetworkInspect(ctx, opts.network, types.NetworkInspectOptions{Scope: "swarm"})
+	if err != nil {
+		return err
+	}
+	if err := validateServiceNetwork(network); err != nil {
+		return err
+	}
+
+	service, _, err := client.ServiceInspectWithRaw(ctx, opts.service, types.ServiceInspectOptions{})
+	if err != nil {
+		return err
+	}
+	if err := freeze.Check("service", service.Spec.Name, service.Spec.Labels, opts.overrideFreeze); err != nil {
+		return err
+	}
+
+	spec := service.Spec
+	networks := serviceNetworks(spec)
+	if findNetwork(networks, network) >= 0 {
+		return errors.Errorf("service %s is already connected to network %s", spec.Name, network.Name)
+	}
+	spec.TaskTemplate.Networks = append(networks, swarm.NetworkAttachmentConfig{
+		Target:  network.ID,
+		Aliases: opts.aliases,
+	})
+	spec.Networks = nil
+
+	if err := updateService(ctx, dockerCli, service, spec); err != nil {
+		return err
+	}
+	return waitForService(ctx, dockerCli, service.ID, opts.wait)
+}
+
+type networkDisconnectOptions struct {
+	service        string
+	network        string
+	overrideFreeze bool
+	wait           waitOptions
+}
+
+func newNetworkDisconnectCommand(dockerCli command.Cli) *cobra.Command {
+	opts := networkDisconnectOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "disconnect [OPTIONS] SERVICE NETWORK",
+		Short: "Disconnect a service from a network",
+		Args:  cli.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.service, opts.network = args[0], args[1]
+			return runNetworkDisconnect(cmd.Context(), dockerCli, opts)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	flags := cmd.Flags()
+	freeze.AddFlag(flags, &opts.overrideFreeze)
+	addWaitFlags(flags, &opts.wait)
+	return cmd
+}
+
+func runNetworkDisconnect(ctx context.Context, dockerCli command.Cli, opts networkDisconnectOptions) error {
+	client := dockerCli.Client()
+
+	network, err := client.NetworkInspect(ctx, opts.network, types.NetworkInspectOptions{Scope: "swarm"})
+	if err != nil {
+		return err
+	}
+	service, _, err := client.ServiceInspectWithRaw(ctx, opts.service, types.ServiceInspectOptions{})
+	if err != nil {
+		return err
+	}
+	if err := freeze.Check("service", service.Spec.Name, service.Spec.Labels, opts.overrideFreeze); err != nil {
+		return err
+	}
+
+	spec := service.Spec
+	networks := serviceNetworks(spec)
+	i := findNetwork(networks, network)
+	if i < 0 {
+		return errors.Errorf("service %s is not connected to network %s", spec.Name, network.Name)
+	}
+	spec.TaskTemplate.Networks = append(networks[:i:i], networks[i+1:]...)
+	spec.Networks = nil
+
+	if err := updateService(ctx, dockerCli, service, spec); err != nil {
+		return err
+	}
+	return waitForService(ctx, dockerCli, service.ID, opts.wait)
+}
+
+// validateServiceNetwork returns an error if services cannot be attached to
+// the network.
+func validateServiceNetwork(network types.NetworkResource) error {
+	if network.Ingress {
+		return errors.Errorf("network %s is the ingress network, use \"service publish\" to expose ports", network.Name)
+	}
+	if network.Scope != "swarm" {
+		return errors.Errorf("network %s has %s scope, services can only be connected to swarm-scoped networks such as overlay networks", network.Name, network.Scope)
+	}
+	return nil
+}
+
+// serviceNetworks returns the networks of the service. Networks set in the
+// deprecated ServiceSpec.Networks field are migrated to the task template,
+// as done by `docker service update`.
+func serviceNetworks(spec swarm.ServiceSpec) []swarm.NetworkAttachmentConfig {
+	if len(spec.TaskTemplate.Networks) > 0 {
+		return spec.TaskTemplate.Networks
+	}
+	return spec.Networks
+}
+
+// findNetwork returns the index of the attachment to network, which may
+// reference it by ID or by name, or -1.
+func findNetwork(networks []swarm.NetworkAttachmentConfig, network types.NetworkResource) int {
+	for i, n := range networks {
+		if n.Target == network.ID || n.Target == network.Name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"io"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+var testNetworks = map[string]types.NetworkResource{
+	"backend": {ID: "id-backend", Name: "backend", Driver: "overlay", Scope: "swarm"},
+	"ingress": {ID: "id-ingress", Name: "ingress", Driver: "overlay", Scope: "swarm", Ingress: true},
+	"bridge":  {ID: "id-bridge", Name: "bridge", Driver: "bridge", Scope: "local"},
+}
+
+func networkClient(service swarm.Service, updated *update) *fakeClient {
+	cli := publishClient(service, nil, updated)
+	cli.networkInspectFunc = func(networkID string) (types.NetworkResource, error) {
+		return testNetworks[networkID], nil
+	}
+	return cli
+}
+
+func TestNetworkConnect(t *testing.T) {
+	service := testService("id-web", "web")
+	service.Spec.Networks = []swarm.NetworkAttachmentConfig{{Target: "frontend"}}
+	var updated update
+	cli := test.NewFakeCli(networkClient(service, &updated))
+	cmd := newNetworkConnectCommand(cli)
+	cmd.SetArgs([]string{"web", "backend", "--alias", "api", "--detach"})
+	assert.NilError(t, cmd.Execute())
+
+	assert.Assert(t, updated.spec != nil)
+	assert.Check(t, is.Len(updated.spec.Networks, 0))
+	assert.Check(t, is.DeepEqual(updated.spec.TaskTemplate.Networks, []swarm.NetworkAttachmentConfig{
+		{Target: "frontend"},
+		{Target: "id-backend", Aliases: []string{"api"}},
+	}))
+}
+
+func TestNetworkConnectErrors(t *testing.T) {
+	tests := []struct {
+		doc           string
+		network       string
+		expectedError string
+	}{
+		{
+			doc:           "already connected by name",
+			network:       "backend",
+			expectedError: "service web is already connected to network backend",
+		},
+		{
+			doc:           "ingress network",
+			network:       "ingress",
+			expectedError: `network ingress is the ingress network, use "service publish" to expose ports`,
+		},
+		{
+			doc:           "local network",
+			network:       "bridge",
+			expectedError: "network bridge has local scope, services can only be connected to swarm-scoped networks such as overlay networks",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.doc, func(t *testing.T) {
+			service := testService("id-web", "web")
+			service.Spec.TaskTemplate.Networks = []swarm.NetworkAttachmentConfig{{Target: "backend"}}
+			var updated update
+			cmd := newNetworkConnectCommand(test.NewFakeCli(networkClient(service, &updated)))
+			cmd.SetArgs([]string{"web", tc.network, "--detach"})
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			assert.Check(t, is.Error(cmd.Execute(), tc.expectedError))
+			assert.Check(t, updated.spec == nil)
+		})
+	}
+}
+
+func TestNetworkDisconnect(t *testing.T) {
+	service := testService("id-web", "web")
+	service.Spec.TaskTemplate.Networks = []swarm.NetworkAttachmentConfig{{Target: "frontend"}, {Target: "id-backend"}, {Target: "admin"}}
+	var updated update
+	cli := test.NewFakeCli(networkClient(service, &updated))
+	cmd := newNetworkDisconnectCommand(cli)
+	cmd.SetArgs([]string{"web", "backend", "--detach"})
+	assert.NilError(t, cmd.Execute())
+
+	assert.Assert(t, updated.spec != nil)
+	assert.Check(t, is.DeepEqual(updated.spec.TaskTemplate.Networks, []swarm.NetworkAttachmentConfig{{Target: "frontend"}, {Target: "admin"}}))
+	assert.Check(t, is.Len(service.Spec.TaskTemplate.Networks, 3), "inspected spec must not be modified")
+}
+
+func TestNetworkDisconnectNotConnected(t *testing.T) {
+	var updated update
+	cmd := newNetworkDisconnectCommand(test.NewFakeCli(networkClient(testService("id-web", "web"), &updated)))
+	cmd.SetArgs([]string{"web", "backend", "--detach"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Check(t, is.Error(cmd.Execute(), "service web is not connected to network backend"))
+}
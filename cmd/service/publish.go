@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/cli/opts"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/freeze"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type publishOptions struct {
+	service        string
+	ports          opts.PortOpt
+	mode           string
+	overrideFreeze bool
+}
+
+func newPublishCommand(dockerCli command.Cli) *cobra.Command {
+	options := publishOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "publish [OPTIONS] SERVICE PORT [PORT...]",
+		Short: "Publish ports of a service",
+		Long: `Publish ports of a service.
+
+PORT uses the same syntax as "docker service create --publish", for example
+8080:80/tcp or published=8080,target=80,mode=host. Ingress ports are checked
+against the ports published by the other services of the swarm.`,
+		Args: cli.RequiresMinArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.service = args[0]
+			for _, port := range args[1:] {
+				if err := options.ports.Set(port); err != nil {
+					return err
+				}
+			}
+			return runPublish(cmd.Context(), dockerCli, options)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&options.mode, "mode", "", `Publish mode of the ports ("ingress", "host"), overriding the mode given in PORT`)
+	freeze.AddFlag(flags, &options.overrideFreeze)
+	return cmd
+}
+
+func runPublish(ctx context.Context, dockerCli command.Cli, options publishOptions) error {
+	client := dockerCli.Client()
+
+	ports := options.ports.Value()
+	if options.mode != "" {
+		mode := swarm.PortConfigPublishMode(options.mode)
+		if mode != swarm.PortConfigPublishModeIngress && mode != swarm.PortConfigPublishModeHost {
+			return errors.Errorf("invalid publish mode %q, must be one of ingress, host", options.mode)
+		}
+		for i := range ports {
+			ports[i].PublishMode = mode
+		}
+	}
+
+	service, _, err := client.ServiceInspectWithRaw(ctx, options.service, types.ServiceInspectOptions{})
+	if err != nil {
+		return err
+	}
+	if err := freeze.Check("service", service.Spec.Name, service.Spec.Labels, options.overrideFreeze); err != nil {
+		return err
+	}
+	services, err := client.ServiceList(ctx, types.ServiceListOptions{})
+	if err != nil {
+		return err
+	}
+
+	spec := service.Spec
+	if spec.EndpointSpec == nil {
+		spec.EndpointSpec = &swarm.EndpointSpec{}
+	}
+	added := 0
+	for _, port := range ports {
+		if err := checkPortConflict(service, services, port); err != nil {
+			return err
+		}
+		if hasPort(spec.EndpointSpec.Ports, port) {
+			fmt.Fprintf(dockerCli.Err(), "WARNING: %s is already published by service %s\n", formatPort(port), service.Spec.Name)
+			continue
+		}
+		spec.EndpointSpec.Ports = append(spec.EndpointSpec.Ports, port)
+		added++
+	}
+	if added == 0 {
+		return nil
+	}
+	return updateService(ctx, dockerCli, service, spec)
+}
+
+func updateService(ctx context.Context, dockerCli command.Cli, service swarm.Service, spec swarm.ServiceSpec) error {
+	response, err := dockerCli.Client().ServiceUpdate(ctx, service.ID, service.Version, spec, types.ServiceUpdateOptions{})
+	if err != nil {
+		return err
+	}
+	for _, warning := range response.Warnings {
+		fmt.Fprintln(dockerCli.Err(), warning)
+	}
+	fmt.Fprintln(dockerCli.Out(), service.Spec.Name)
+	return nil
+}
+
+// checkPortConflict returns an error if port is an ingress port already
+// published by another service, or published by the service to another
+// target. Host mode ports are only published on the nodes running the
+// service's tasks, so they are left for the scheduler to place.
+func checkPortConflict(service swarm.Service, services []swarm.Service, port swarm.PortConfig) error {
+	if port.PublishedPort == 0 {
+		return nil
+	}
+	for _, s := range services {
+		for _, p := range publishedPorts(s) {
+			if p.PublishedPort != port.PublishedPort || p.Protocol != port.Protocol {
+				continue
+			}
+			if s.ID == service.ID {
+				if p.TargetPort != port.TargetPort || p.PublishMode != port.PublishMode {
+					return errors.Errorf("%s is already published by service %s as %s, unpublish it first", formatPort(port), s.Spec.Name, formatPort(p))
+				}
+				continue
+			}
+			if p.PublishMode == swarm.PortConfigPublishModeIngress || port.PublishMode == swarm.PortConfigPublishModeIngress {
+				return errors.Errorf("%s is already published by service %s", formatPort(port), s.Spec.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// publishedPorts returns the ports published by s, including the ports the
+// swarm assigned automatically.
+func publishedPorts(s swarm.Service) []swarm.PortConfig {
+	if len(s.Endpoint.Ports) > 0 {
+		return s.Endpoint.Ports
+	}
+	if s.Spec.EndpointSpec != nil {
+		return s.Spec.EndpointSpec.Ports
+	}
+	return nil
+}
+
+func hasPort(ports []swarm.PortConfig, port swarm.PortConfig) bool {
+	for _, p := range ports {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
+
+func formatPort(p swarm.PortConfig) string {
+	published := "*"
+	if p.PublishedPort != 0 {
+		published = strconv.FormatUint(uint64(p.PublishedPort), 10)
+	}
+	return fmt.Sprintf("port %s:%d/%s (%s)", published, p.TargetPort, p.Protocol, p.PublishMode)
+}
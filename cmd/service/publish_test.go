@@ -0,0 +1,125 @@
+package service
+
+import (
+	"io"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func testService(id, name string, ports ...swarm.PortConfig) swarm.Service {
+	return swarm.Service{
+		ID:       id,
+		Meta:     swarm.Meta{Version: swarm.Version{Index: 10}},
+		Spec:     swarm.ServiceSpec{Annotations: swarm.Annotations{Name: name}, EndpointSpec: &swarm.EndpointSpec{Ports: ports}},
+		Endpoint: swarm.Endpoint{Ports: ports},
+	}
+}
+
+func ingressPort(published, target uint32) swarm.PortConfig {
+	return swarm.PortConfig{
+		Protocol:      swarm.PortConfigProtocolTCP,
+		PublishMode:   swarm.PortConfigPublishModeIngress,
+		PublishedPort: published,
+		TargetPort:    target,
+	}
+}
+
+// update records the last ServiceUpdate call of the fake client.
+type update struct {
+	version swarm.Version
+	spec    *swarm.ServiceSpec
+}
+
+func publishClient(service swarm.Service, others []swarm.Service, updated *update) *fakeClient {
+	return &fakeClient{
+		serviceInspectFunc: func(string) (swarm.Service, []byte, error) {
+			return service, nil, nil
+		},
+		serviceListFunc: func(types.ServiceListOptions) ([]swarm.Service, error) {
+			return append([]swarm.Service{service}, others...), nil
+		},
+		serviceUpdateFunc: func(serviceID string, version swarm.Version, spec swarm.ServiceSpec) (types.ServiceUpdateResponse, error) {
+			updated.version = version
+			updated.spec = &spec
+			return types.ServiceUpdateResponse{}, nil
+		},
+	}
+}
+
+func TestPublish(t *testing.T) {
+	var updated update
+	cli := test.NewFakeCli(publishClient(testService("id-web", "web", ingressPort(80, 80)), nil, &updated))
+	cmd := newPublishCommand(cli)
+	cmd.SetArgs([]string{"web", "8443:443/tcp", "9000:9000/udp", "--mode", "host"})
+	assert.NilError(t, cmd.Execute())
+
+	assert.Assert(t, updated.spec != nil)
+	assert.Check(t, is.Equal(updated.version.Index, uint64(10)))
+	assert.Check(t, is.DeepEqual(updated.spec.EndpointSpec.Ports, []swarm.PortConfig{
+		ingressPort(80, 80),
+		{Protocol: swarm.PortConfigProtocolTCP, PublishMode: swarm.PortConfigPublishModeHost, PublishedPort: 8443, TargetPort: 443},
+		{Protocol: swarm.PortConfigProtocolUDP, PublishMode: swarm.PortConfigPublishModeHost, PublishedPort: 9000, TargetPort: 9000},
+	}))
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "web\n"))
+}
+
+func TestPublishAlreadyPublished(t *testing.T) {
+	var updated update
+	cli := test.NewFakeCli(publishClient(testService("id-web", "web", ingressPort(80, 80)), nil, &updated))
+	cmd := newPublishCommand(cli)
+	cmd.SetArgs([]string{"web", "80:80"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, updated.spec == nil, "service must not be updated")
+	assert.Check(t, is.Equal(cli.ErrBuffer().String(), "WARNING: port 80:80/tcp (ingress) is already published by service web\n"))
+}
+
+func TestPublishErrors(t *testing.T) {
+	others := []swarm.Service{testService("id-api", "api", ingressPort(8080, 80))}
+	tests := []struct {
+		doc           string
+		args          []string
+		expectedError string
+	}{
+		{
+			doc:           "ingress port used by another service",
+			args:          []string{"web", "8080:8080"},
+			expectedError: "port 8080:8080/tcp (ingress) is already published by service api",
+		},
+		{
+			doc:           "host port used by another service in ingress mode",
+			args:          []string{"web", "8080:8080", "--mode", "host"},
+			expectedError: "port 8080:8080/tcp (host) is already published by service api",
+		},
+		{
+			doc:           "port published by the service to another target",
+			args:          []string{"web", "80:8080"},
+			expectedError: "port 80:8080/tcp (ingress) is already published by service web as port 80:80/tcp (ingress), unpublish it first",
+		},
+		{
+			doc:           "invalid mode",
+			args:          []string{"web", "81:80", "--mode", "bridge"},
+			expectedError: `invalid publish mode "bridge", must be one of ingress, host`,
+		},
+		{
+			doc:           "invalid port",
+			args:          []string{"web", "http"},
+			expectedError: "Invalid containerPort: http",
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.doc, func(t *testing.T) {
+			var updated update
+			cmd := newPublishCommand(test.NewFakeCli(publishClient(testService("id-web", "web", ingressPort(80, 80)), others, &updated)))
+			cmd.SetArgs(tc.args)
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			assert.Check(t, is.ErrorContains(cmd.Execute(), tc.expectedError))
+		})
+	}
+}
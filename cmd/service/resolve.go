@@ -0,0 +1,309 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/moby/swarmctl/internal/engine"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// embeddedDNS is the address of the DNS server of the engine in the
+// containers of tasks, left out of lookup outputs.
+const embeddedDNS = "127.0.0.11"
+
+// lookupScript resolves $1 to its IPv4 addresses with whichever of getent or
+// nslookup the image provides, exiting with 127 if it has neither.
+const lookupScript = `if command -v getent >/dev/null 2>&1; then exec getent ahostsv4 "$1"; fi
+if command -v nslookup >/dev/null 2>&1; then exec nslookup "$1"; fi
+exit 127`
+
+type networkResolveOptions struct {
+	service  string
+	from     string
+	selector taskSelector
+}
+
+func newNetworkResolveCommand(dockerCli command.Cli) *cobra.Command {
+	opts := networkResolveOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "resolve [OPTIONS] SERVICE",
+		Short: "Check the name resolution of a service from the task of another",
+		Long: `Check the name resolution of a service from the task of another.
+
+The name of the service and its "tasks." name are looked up from inside a task
+of the --from service, with getent or nslookup, and compared with the virtual
+IP and the task addresses the swarm assigned on the networks both services are
+connected to. The task is reached through the docker context named after its
+node (see "node info"). Without --from, the lookup runs in a task of the
+service itself.`,
+		Args: cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.service = args[0]
+			return runNetworkResolve(cmd.Context(), dockerCli, opts)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.from, "from", "", "Run the lookup from a task of this service")
+	flags.IntVar(&opts.selector.slot, "slot", 0, "Run the lookup from the task of the --from service in this slot")
+	flags.StringVar(&opts.selector.task, "task", "", "Run the lookup from the task of the --from service with this ID or ID prefix")
+	return cmd
+}
+
+// resolution is the outcome of the lookup of a name.
+type resolution struct {
+	name     string
+	expected []string
+	resolved []string
+}
+
+// status describes how the resolved addresses differ from the expected
+// ones.
+func (r resolution) status() string {
+	if len(r.resolved) == 0 {
+		return "not resolved"
+	}
+	missing := difference(r.expected, r.resolved)
+	unexpected := difference(r.resolved, r.expected)
+	var problems []string
+	if len(missing) > 0 {
+		problems = append(problems, "missing "+strings.Join(missing, ", "))
+	}
+	if len(unexpected) > 0 {
+		problems = append(problems, "unexpected "+strings.Join(unexpected, ", "))
+	}
+	if len(problems) == 0 {
+		return "ok"
+	}
+	return strings.Join(problems, "; ")
+}
+
+func runNetworkResolve(ctx context.Context, dockerCli command.Cli, opts networkResolveOptions) error {
+	apiClient := dockerCli.Client()
+
+	target, targetTasks, err := runningTasks(ctx, apiClient, opts.service)
+	if err != nil {
+		return err
+	}
+	from, fromTasks := target, targetTasks
+	if opts.from != "" {
+		if from, fromTasks, err = runningTasks(ctx, apiClient, opts.from); err != nil {
+			return err
+		}
+	}
+	task, err := opts.selector.selectLookupTask(from, fromTasks)
+	if err != nil {
+		return err
+	}
+
+	shared := sharedNetworks(target, task)
+	if len(shared) == 0 {
+		return errors.Errorf("service %s is not connected to any network of task %s, it cannot be resolved from there", target.Spec.Name, taskName(from, task))
+	}
+	expected := expectedAddresses(target, targetTasks, shared)
+
+	node, _, err := apiClient.NodeInspectWithRaw(ctx, task.NodeID)
+	if err != nil {
+		return err
+	}
+	resolver, err := engine.NewResolver(ctx, dockerCli)
+	if err != nil {
+		return err
+	}
+	defer resolver.Close()
+	nodeClient, err := resolver.Client(node)
+	if err != nil {
+		return err
+	}
+
+	var resolutions []resolution
+	for _, name := range []string{target.Spec.Name, "tasks." + target.Spec.Name} {
+		resolved, err := lookup(ctx, nodeClient, task.Status.ContainerStatus.ContainerID, name)
+		if err != nil {
+			return errors.Wrapf(err, "unable to resolve %s from task %s", name, taskName(from, task))
+		}
+		resolutions = append(resolutions, resolution{name: name, expected: expected[name], resolved: resolved})
+	}
+
+	names := make([]string, 0, len(shared))
+	for _, name := range shared {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(dockerCli.Out(), "From task %s on node %s, over %s\n\n", taskName(from, task), node.Description.Hostname, strings.Join(names, ", "))
+	if err := printResolutions(dockerCli.Out(), resolutions); err != nil {
+		return err
+	}
+	for _, r := range resolutions {
+		if r.status() != "ok" {
+			return errors.Errorf("the name resolution of service %s from task %s does not match the swarm state", target.Spec.Name, taskName(from, task))
+		}
+	}
+	return nil
+}
+
+// selectLookupTask returns the task matching the selector, or the first
+// running task of the service if the selector is zero.
+func (s taskSelector) selectLookupTask(service swarm.Service, tasks []swarm.Task) (swarm.Task, error) {
+	if s.slot == 0 && s.task == "" && len(tasks) > 0 {
+		return tasks[0], nil
+	}
+	return s.selectTask(service, tasks)
+}
+
+// sharedNetworks returns the names of the networks of task the service is
+// connected to, by ID.
+func sharedNetworks(service swarm.Service, task swarm.Task) map[string]string {
+	networks := serviceNetworks(service.Spec)
+	shared := make(map[string]string)
+	for _, attachment := range task.NetworksAttachments {
+		network := types.NetworkResource{ID: attachment.Network.ID, Name: attachment.Network.Spec.Name}
+		if findNetwork(networks, network) >= 0 {
+			shared[network.ID] = network.Name
+		}
+	}
+	return shared
+}
+
+// expectedAddresses returns the addresses the name and the "tasks." name of
+// the service should resolve to on the shared networks. Services in dnsrr
+// endpoint mode have no virtual IP, their name resolves to their tasks.
+func expectedAddresses(service swarm.Service, tasks []swarm.Task, shared map[string]string) map[string][]string {
+	var taskIPs []string
+	for _, task := range tasks {
+		for _, attachment := range task.NetworksAttachments {
+			if _, ok := shared[attachment.Network.ID]; !ok {
+				continue
+			}
+			for _, addr := range attachment.Addresses {
+				taskIPs = append(taskIPs, stripPrefix(addr))
+			}
+		}
+	}
+
+	serviceIPs := taskIPs
+	if spec := service.Spec.EndpointSpec; spec == nil || spec.Mode != swarm.ResolutionModeDNSRR {
+		serviceIPs = nil
+		for _, vip := range service.Endpoint.VirtualIPs {
+			if _, ok := shared[vip.NetworkID]; ok {
+				serviceIPs = append(serviceIPs, stripPrefix(vip.Addr))
+			}
+		}
+	}
+	return map[string][]string{
+		service.Spec.Name:            sortedUnique(serviceIPs),
+		"tasks." + service.Spec.Name: sortedUnique(taskIPs),
+	}
+}
+
+// lookup resolves name from inside a container, and returns the IPv4
+// addresses found.
+func lookup(ctx context.Context, apiClient client.APIClient, containerID, name string) ([]string, error) {
+	exec, err := apiClient.ContainerExecCreate(ctx, containerID, types.ExecConfig{
+		Cmd:          []string{"sh", "-c", lookupScript, "-", name},
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := apiClient.ContainerExecAttach(ctx, exec.ID, types.ExecStartCheck{})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Close()
+	var stdout bytes.Buffer
+	if _, err := stdcopy.StdCopy(&stdout, io.Discard, resp.Reader); err != nil {
+		return nil, err
+	}
+	inspect, err := apiClient.ContainerExecInspect(ctx, exec.ID)
+	if err != nil {
+		return nil, err
+	}
+	if inspect.ExitCode == 126 || inspect.ExitCode == 127 {
+		return nil, errors.New("the image of the task needs a shell with getent or nslookup")
+	}
+	return parseLookup(stdout.String()), nil
+}
+
+// parseLookup returns the IPv4 addresses in the output of getent or
+// nslookup, but the address of the DNS server.
+func parseLookup(output string) []string {
+	var addrs []string
+	for _, field := range strings.Fields(output) {
+		field, _, _ = strings.Cut(field, "#")
+		if ip := net.ParseIP(field); ip != nil && ip.To4() != nil && field != embeddedDNS {
+			addrs = append(addrs, field)
+		}
+	}
+	return sortedUnique(addrs)
+}
+
+func printResolutions(out io.Writer, resolutions []resolution) error {
+	w := tabwriter.NewWriter(out, 10, 1, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tEXPECTED\tRESOLVED\tSTATUS")
+	for _, r := range resolutions {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.name, joinOrDash(r.expected), joinOrDash(r.resolved), r.status())
+	}
+	return w.Flush()
+}
+
+func stripPrefix(addr string) string {
+	ip, _, _ := strings.Cut(addr, "/")
+	return ip
+}
+
+func sortedUnique(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	var unique []string
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	sort.Strings(unique)
+	return unique
+}
+
+// difference returns the values of a missing from b.
+func difference(a, b []string) []string {
+	var diff []string
+	for _, v := range a {
+		found := false
+		for _, w := range b {
+			if v == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			diff = append(diff, v)
+		}
+	}
+	return diff
+}
+
+func joinOrDash(values []string) string {
+	if len(values) == 0 {
+		return "-"
+	}
+	return strings.Join(values, ", ")
+}
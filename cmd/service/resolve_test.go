@@ -0,0 +1,145 @@
+package service
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func attachedTask(id string, slot int, serviceID string, addrs ...string) swarm.Task {
+	task := runningTask(id, slot, "id-local")
+	task.ServiceID = serviceID
+	task.NetworksAttachments = []swarm.NetworkAttachment{{
+		Network:   swarm.Network{ID: "id-backend", Spec: swarm.NetworkSpec{Annotations: swarm.Annotations{Name: "backend"}}},
+		Addresses: addrs,
+	}}
+	return task
+}
+
+// resolveClient returns a client for a web service with two tasks, and an
+// api service with one task, connected to the backend network. The lookups
+// from the api task answer with lookups.
+func resolveClient(t *testing.T, lookups map[string]string) *fakeClient {
+	web := testService("id-web", "web")
+	web.Spec.TaskTemplate.Networks = []swarm.NetworkAttachmentConfig{{Target: "id-backend"}}
+	web.Endpoint.VirtualIPs = []swarm.EndpointVirtualIP{{NetworkID: "id-backend", Addr: "10.0.1.2/24"}}
+	api := testService("id-api", "api")
+	api.Spec.TaskTemplate.Networks = []swarm.NetworkAttachmentConfig{{Target: "backend"}}
+	services := map[string]swarm.Service{"web": web, "api": api}
+
+	return &fakeClient{
+		infoFunc: func() (types.Info, error) {
+			return types.Info{Swarm: swarm.Info{NodeID: "id-local"}}, nil
+		},
+		nodeInspectFunc: func(nodeID string) (swarm.Node, []byte, error) {
+			return swarm.Node{ID: nodeID, Description: swarm.NodeDescription{Hostname: "worker1"}}, nil, nil
+		},
+		serviceInspectFunc: func(name string) (swarm.Service, []byte, error) {
+			return services[name], nil, nil
+		},
+		taskListFunc: func(options types.TaskListOptions) ([]swarm.Task, error) {
+			if options.Filters.Get("service")[0] == "id-api" {
+				return []swarm.Task{attachedTask("task3", 1, "id-api", "10.0.1.9/24")}, nil
+			}
+			return []swarm.Task{
+				attachedTask("task1", 1, "id-web", "10.0.1.5/24"),
+				attachedTask("task2", 2, "id-web", "10.0.1.6/24"),
+			}, nil
+		},
+		execFunc: func(containerID string, config types.ExecConfig) (string, int) {
+			assert.Check(t, is.Equal(containerID, "container-task3"))
+			name := config.Cmd[len(config.Cmd)-1]
+			output, ok := lookups[name]
+			if !ok {
+				return "", 2
+			}
+			return output, 0
+		},
+	}
+}
+
+func TestNetworkResolve(t *testing.T) {
+	cli := test.NewFakeCli(resolveClient(t, map[string]string{
+		"web":       "10.0.1.2        STREAM web\n10.0.1.2        DGRAM  \n",
+		"tasks.web": "Server:    127.0.0.11\nAddress:   127.0.0.11#53\n\nName:   tasks.web\nAddress: 10.0.1.6\nName:   tasks.web\nAddress: 10.0.1.5\n",
+	}))
+	cmd := newNetworkResolveCommand(cli)
+	cmd.SetArgs([]string{"web", "--from", "api"})
+	assert.NilError(t, cmd.Execute())
+	test.AssertGolden(t, cli.OutBuffer().String(), "network-resolve.golden")
+}
+
+func TestNetworkResolveMismatch(t *testing.T) {
+	cli := test.NewFakeCli(resolveClient(t, map[string]string{
+		"tasks.web": "10.0.1.5        STREAM tasks.web\n10.0.1.7        STREAM\n",
+	}))
+	cmd := newNetworkResolveCommand(cli)
+	cmd.SetArgs([]string{"web", "--from", "api"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "the name resolution of service web from task api.1 does not match the swarm state")
+	out := cli.OutBuffer().String()
+	assert.Check(t, is.Contains(out, "not resolved"))
+	assert.Check(t, is.Contains(out, "missing 10.0.1.6; unexpected 10.0.1.7"))
+}
+
+func TestNetworkResolveErrors(t *testing.T) {
+	noNetwork := resolveClient(t, nil)
+	inspect := noNetwork.serviceInspectFunc
+	noNetwork.serviceInspectFunc = func(name string) (swarm.Service, []byte, error) {
+		service, raw, err := inspect(name)
+		service.Spec.TaskTemplate.Networks = nil
+		return service, raw, err
+	}
+	noTools := resolveClient(t, nil)
+	noTools.execFunc = func(string, types.ExecConfig) (string, int) {
+		return "", 127
+	}
+
+	testCases := []struct {
+		name     string
+		client   *fakeClient
+		expected string
+	}{
+		{
+			name:     "no-shared-network",
+			client:   noNetwork,
+			expected: "service web is not connected to any network of task api.1, it cannot be resolved from there",
+		},
+		{
+			name:     "no-lookup-tool",
+			client:   noTools,
+			expected: "unable to resolve web from task api.1: the image of the task needs a shell with getent or nslookup",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			cli := test.NewFakeCli(tc.client)
+			cmd := newNetworkResolveCommand(cli)
+			cmd.SetArgs([]string{"web", "--from", "api"})
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			assert.Error(t, cmd.Execute(), tc.expected)
+		})
+	}
+}
+
+func TestParseLookup(t *testing.T) {
+	output := strings.Join([]string{
+		"Server:\t\t127.0.0.11",
+		"Address:\t127.0.0.11#53",
+		"",
+		"Non-authoritative answer:",
+		"Name:\ttasks.web",
+		"Address: 10.0.1.6",
+		"Address 1: 10.0.1.5 web.1.abcdef.backend",
+	}, "\n")
+	assert.Check(t, is.DeepEqual(parseLookup(output), []string{"10.0.1.5", "10.0.1.6"}))
+}
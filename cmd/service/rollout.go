@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-units"
+	"github.com/moby/swarmctl/internal/freeze"
+	"github.com/moby/swarmctl/internal/quota"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewRolloutCommand returns a cobra command for `rollout` subcommands
+func NewRolloutCommand(dockerCli command.Cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rollout",
+		Short: "Follow and manage the updates of services",
+		Long: `Follow and manage the updates of services.
+
+The subcommands act on a service, or on every service of a stack with
+--stack. The engine has no way to pause an update on demand: updates pause
+on their own when tasks fail, according to the update config of the
+service.`,
+		Args: cli.NoArgs,
+		RunE: command.ShowHelp(dockerCli.Err()),
+		Annotations: map[string]string{
+			"version": "1.31",
+			"swarm":   "manager",
+		},
+	}
+	cmd.AddCommand(
+		newRolloutStatusCommand(dockerCli),
+		newRolloutResumeCommand(dockerCli),
+		newRolloutUndoCommand(dockerCli),
+	)
+	return cmd
+}
+
+type rolloutOptions struct {
+	service        string
+	stack          string
+	watch          bool
+	overrideFreeze bool
+	wait           waitOptions
+}
+
+func rolloutArgs(opts *rolloutOptions) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if err := cli.RequiresMaxArgs(1)(cmd, args); err != nil {
+			return err
+		}
+		switch {
+		case len(args) == 1 && opts.stack != "":
+			return errors.New("a service and --stack cannot be used together")
+		case len(args) == 0 && opts.stack == "":
+			return errors.New("a service or --stack is required")
+		case len(args) == 1:
+			opts.service = args[0]
+		}
+		return nil
+	}
+}
+
+func newRolloutStatusCommand(dockerCli command.Cli) *cobra.Command {
+	opts := rolloutOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "status [OPTIONS] SERVICE|--stack STACK",
+		Short: "Show the state of the last update of services",
+		Long: `Show the state of the last update of services.
+
+With --watch, wait for the services to converge, one after the other.`,
+		Args: rolloutArgs(&opts),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRolloutStatus(cmd.Context(), dockerCli, opts)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.stack, "stack", "", "Show the services of this stack")
+	flags.BoolVarP(&opts.watch, "watch", "w", false, "Wait for the services to converge")
+	addWaitFlags(flags, &opts.wait)
+	_ = flags.MarkHidden("detach")
+	return cmd
+}
+
+func newRolloutResumeCommand(dockerCli command.Cli) *cobra.Command {
+	opts := rolloutOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "resume [OPTIONS] SERVICE|--stack STACK",
+		Short: "Resume the paused updates of services",
+		Long: `Resume the paused updates of services.
+
+A paused update or rollback is resumed by submitting the spec of the service
+again. Services whose update is not paused are left alone.`,
+		Args: rolloutArgs(&opts),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRolloutResume(cmd.Context(), dockerCli, opts)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.stack, "stack", "", "Resume the services of this stack")
+	freeze.AddFlag(flags, &opts.overrideFreeze)
+	addWaitFlags(flags, &opts.wait)
+	return cmd
+}
+
+func newRolloutUndoCommand(dockerCli command.Cli) *cobra.Command {
+	opts := rolloutOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "undo [OPTIONS] SERVICE|--stack STACK",
+		Short: "Roll services back to their previous spec",
+		Long: `Roll services back to their previous spec.
+
+The engine only keeps the spec that preceded the last update, so undoing twice
+returns to the spec that was undone.`,
+		Args: rolloutArgs(&opts),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRolloutUndo(cmd.Context(), dockerCli, opts)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.stack, "stack", "", "Roll back the services of this stack")
+	freeze.AddFlag(flags, &opts.overrideFreeze)
+	addWaitFlags(flags, &opts.wait)
+	return cmd
+}
+
+// rolloutServices returns the service or the services of the stack of
+// opts, ordered by name.
+func rolloutServices(ctx context.Context, apiClient client.APIClient, opts rolloutOptions) ([]swarm.Service, error) {
+	if opts.service != "" {
+		service, _, err := apiClient.ServiceInspectWithRaw(ctx, opts.service, types.ServiceInspectOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return []swarm.Service{service}, nil
+	}
+	services, err := apiClient.ServiceList(ctx, types.ServiceListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", swarmlabel.Namespace+"="+opts.stack)),
+		Status:  true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(services) == 0 {
+		return nil, errors.Errorf("nothing found in stack: %s", opts.stack)
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Spec.Name < services[j].Spec.Name })
+	return services, nil
+}
+
+func runRolloutStatus(ctx context.Context, dockerCli command.Cli, opts rolloutOptions) error {
+	services, err := rolloutServices(ctx, dockerCli.Client(), opts)
+	if err != nil {
+		return err
+	}
+	if opts.watch {
+		return waitForServices(ctx, dockerCli, services, opts.wait)
+	}
+
+	w := tabwriter.NewWriter(dockerCli.Out(), 10, 1, 3, ' ', 0)
+	fmt.Fprintln(w, "SERVICE\tSTATE\tSTARTED\tMESSAGE")
+	for _, service := range services {
+		state, started, message := "-", "-", ""
+		if status := service.UpdateStatus; status != nil {
+			state = strings.ReplaceAll(string(status.State), "_", " ")
+			if status.StartedAt != nil {
+				started = units.HumanDuration(now().Sub(*status.StartedAt)) + " ago"
+			}
+			message = status.Message
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", service.Spec.Name, state, started, message)
+	}
+	return w.Flush()
+}
+
+func runRolloutResume(ctx context.Context, dockerCli command.Cli, opts rolloutOptions) error {
+	services, err := rolloutServices(ctx, dockerCli.Client(), opts)
+	if err != nil {
+		return err
+	}
+	var resumed []swarm.Service
+	for _, service := range services {
+		if service.UpdateStatus == nil || (service.UpdateStatus.State != swarm.UpdateStatePaused && service.UpdateStatus.State != swarm.UpdateStateRollbackPaused) {
+			if opts.service != "" {
+				return errors.Errorf("the update of service %s is not paused", service.Spec.Name)
+			}
+			continue
+		}
+		if err := freeze.Check("service", service.Spec.Name, service.Spec.Labels, opts.overrideFreeze); err != nil {
+			return err
+		}
+		if err := updateService(ctx, dockerCli, service, service.Spec); err != nil {
+			return err
+		}
+		resumed = append(resumed, service)
+	}
+	return waitForServices(ctx, dockerCli, resumed, opts.wait)
+}
+
+func runRolloutUndo(ctx context.Context, dockerCli command.Cli, opts rolloutOptions) error {
+	services, err := rolloutServices(ctx, dockerCli.Client(), opts)
+	if err != nil {
+		return err
+	}
+	quotas, err := quota.Load(quota.File())
+	if err != nil {
+		return err
+	}
+	var undone []swarm.Service
+	for _, service := range services {
+		if service.PreviousSpec == nil {
+			if opts.service != "" {
+				return errors.Errorf("service %s has no previous spec to roll back to", service.Spec.Name)
+			}
+			continue
+		}
+		if err := freeze.Check("service", service.Spec.Name, service.Spec.Labels, opts.overrideFreeze); err != nil {
+			return err
+		}
+		if err := quotas.CheckServiceUpdate(ctx, dockerCli.Client(), service, *service.PreviousSpec); err != nil {
+			return err
+		}
+		response, err := dockerCli.Client().ServiceUpdate(ctx, service.ID, service.Version, service.Spec, types.ServiceUpdateOptions{Rollback: "previous"})
+		if err != nil {
+			return err
+		}
+		for _, warning := range response.Warnings {
+			fmt.Fprintln(dockerCli.Err(), warning)
+		}
+		fmt.Fprintln(dockerCli.Out(), service.Spec.Name)
+		undone = append(undone, service)
+	}
+	return waitForServices(ctx, dockerCli, undone, opts.wait)
+}
+
+// waitForServices waits for the services to converge, one after the other.
+func waitForServices(ctx context.Context, dockerCli command.Cli, services []swarm.Service, opts waitOptions) error {
+	for _, service := range services {
+		if err := waitForService(ctx, dockerCli, service.ID, opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/freeze"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"github.com/moby/swarmctl/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+// rolloutClient returns a client for a stack of an updating service, a
+// paused one and one that was never updated.
+func rolloutClient(updated *update) *fakeClient {
+	started := time.Date(2023, 1, 2, 15, 0, 0, 0, time.UTC)
+	web := testService("id-web", "shop_web")
+	web.UpdateStatus = &swarm.UpdateStatus{State: swarm.UpdateStateUpdating, StartedAt: &started, Message: "update in progress"}
+	web.PreviousSpec = &swarm.ServiceSpec{Annotations: swarm.Annotations{Name: "shop_web"}}
+	api := testService("id-api", "shop_api")
+	api.UpdateStatus = &swarm.UpdateStatus{State: swarm.UpdateStatePaused, StartedAt: &started, Message: "update paused due to failure or early termination of task abc"}
+	db := testService("id-db", "shop_db")
+
+	cli := publishClient(web, []swarm.Service{api, db}, updated)
+	cli.serviceInspectFunc = func(ref string) (swarm.Service, []byte, error) {
+		for _, s := range []swarm.Service{web, api, db} {
+			if s.Spec.Name == ref {
+				return s, nil, nil
+			}
+		}
+		return swarm.Service{}, nil, nil
+	}
+	return cli
+}
+
+func TestRolloutStatus(t *testing.T) {
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Date(2023, 1, 2, 15, 5, 0, 0, time.UTC) }
+
+	cli := test.NewFakeCli(rolloutClient(&update{}))
+	cmd := NewRolloutCommand(cli)
+	cmd.SetArgs([]string{"status", "--stack", "shop"})
+	assert.NilError(t, cmd.Execute())
+	test.AssertGolden(t, cli.OutBuffer().String(), "rollout-status.golden")
+}
+
+func TestRolloutResume(t *testing.T) {
+	var updated update
+	cli := test.NewFakeCli(rolloutClient(&updated))
+	cmd := NewRolloutCommand(cli)
+	cmd.SetArgs([]string{"resume", "--stack", "shop", "--detach"})
+	assert.NilError(t, cmd.Execute())
+	assert.Assert(t, updated.spec != nil)
+	assert.Check(t, is.Equal(updated.spec.Name, "shop_api"))
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "shop_api\n"))
+}
+
+func TestRolloutUndo(t *testing.T) {
+	var updated update
+	client := rolloutClient(&updated)
+	cli := test.NewFakeCli(client)
+	cmd := NewRolloutCommand(cli)
+	cmd.SetArgs([]string{"undo", "shop_web", "--detach"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.DeepEqual(client.updateOptions, []types.ServiceUpdateOptions{{Rollback: "previous"}}))
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "shop_web\n"))
+}
+
+func TestRolloutUndoFrozen(t *testing.T) {
+	var updated update
+	client := rolloutClient(&updated)
+	inspect := client.serviceInspectFunc
+	client.serviceInspectFunc = func(ref string) (swarm.Service, []byte, error) {
+		service, raw, err := inspect(ref)
+		service.Spec.Labels = map[string]string{swarmlabel.Namespace: "shop", freeze.Label: ""}
+		return service, raw, err
+	}
+	cmd := NewRolloutCommand(test.NewFakeCli(client))
+	cmd.SetArgs([]string{"undo", "shop_web", "--detach"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "service shop_web belongs to frozen stack shop, use --override-freeze to change it anyway")
+	assert.Check(t, is.Len(client.updateOptions, 0))
+}
+
+func TestRolloutUndoQuotaExceeded(t *testing.T) {
+	quotaFile := filepath.Join(t.TempDir(), "quotas.yml")
+	assert.NilError(t, os.WriteFile(quotaFile, []byte("namespaces:\n  shop:\n    replicas: 4\n"), 0o644))
+	t.Setenv("SWARMCTL_QUOTA_FILE", quotaFile)
+
+	replicas := func(n uint64) swarm.ServiceMode {
+		return swarm.ServiceMode{Replicated: &swarm.ReplicatedService{Replicas: &n}}
+	}
+	web := testService("id-web", "shop_web")
+	web.Spec.Labels = map[string]string{swarmlabel.Namespace: "shop"}
+	web.Spec.Mode = replicas(2)
+	web.PreviousSpec = &swarm.ServiceSpec{Annotations: web.Spec.Annotations, Mode: replicas(5)}
+	var updated update
+	client := publishClient(web, nil, &updated)
+	cmd := NewRolloutCommand(test.NewFakeCli(client))
+	cmd.SetArgs([]string{"undo", "shop_web", "--detach"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "quota exceeded for namespace shop: replicas 5/4")
+	assert.Check(t, is.Len(client.updateOptions, 0))
+}
+
+func TestRolloutErrors(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{args: []string{"status"}, expected: "a service or --stack is required"},
+		{args: []string{"status", "shop_web", "--stack", "shop"}, expected: "a service and --stack cannot be used together"},
+		{args: []string{"resume", "shop_web"}, expected: "the update of service shop_web is not paused"},
+		{args: []string{"undo", "shop_db"}, expected: "service shop_db has no previous spec to roll back to"},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.expected, func(t *testing.T) {
+			var updated update
+			cli := test.NewFakeCli(rolloutClient(&updated))
+			cmd := NewRolloutCommand(cli)
+			cmd.SetArgs(tc.args)
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			assert.Error(t, cmd.Execute(), tc.expected)
+			assert.Check(t, updated.spec == nil, "service must not be updated")
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/placement"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const nodeLabelPrefix = "node.labels."
+
+type spreadOptions struct {
+	service string
+	label   string
+}
+
+func newSpreadCommand(dockerCli command.Cli) *cobra.Command {
+	opts := spreadOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "spread [OPTIONS] SERVICE",
+		Short: "Display how the running tasks of a service are spread across failure domains",
+		Long: `Display how the running tasks of a service are spread across failure domains.
+
+A failure domain is a node, or with --label the nodes sharing a value of a
+node label, such as an availability zone. Only the ready and active nodes
+matching the placement constraints and platforms of the service count as
+domains the tasks can spread to. A warning is printed for each domain holding
+more tasks than an even spread would put there, and when nothing restricts
+the service to the operating system of its image while it can be scheduled
+on nodes of several, such as Linux and Windows workers.`,
+		Args: cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.service = args[0]
+			return runSpread(cmd.Context(), dockerCli, opts)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.label, "label", "", `Node label defining the failure domains, such as "zone" (default: every node is a domain)`)
+	return cmd
+}
+
+// failureDomain counts the nodes and tasks of a failure domain.
+type failureDomain struct {
+	name  string
+	nodes int
+	tasks int
+}
+
+func runSpread(ctx context.Context, dockerCli command.Cli, opts spreadOptions) error {
+	opts.label = strings.TrimPrefix(opts.label, nodeLabelPrefix)
+	client := dockerCli.Client()
+
+	service, tasks, err := runningTasks(ctx, client, opts.service)
+	if err != nil {
+		return err
+	}
+	var (
+		constraints []placement.Constraint
+		platforms   []swarm.Platform
+	)
+	if p := service.Spec.TaskTemplate.Placement; p != nil {
+		if constraints, err = placement.Parse(p.Constraints); err != nil {
+			return errors.Wrapf(err, "service %s", service.Spec.Name)
+		}
+		platforms = p.Platforms
+	}
+	nodes, err := client.NodeList(ctx, types.NodeListOptions{})
+	if err != nil {
+		return err
+	}
+
+	domains := spreadDomains(nodes, tasks, constraints, platforms, opts.label)
+	if err := printSpread(dockerCli.Out(), domains, opts.label); err != nil {
+		return err
+	}
+	for _, warning := range spreadWarnings(service, domains, len(tasks), opts.label) {
+		fmt.Fprintln(dockerCli.Err(), "WARNING: "+warning)
+	}
+	if warning := platformWarning(service, nodes, tasks, constraints); warning != "" {
+		fmt.Fprintln(dockerCli.Err(), "WARNING: "+warning)
+	}
+	return nil
+}
+
+// spreadDomains returns the failure domains of the nodes, sorted by name.
+// A node without the label belongs to the domain with an empty name.
+func spreadDomains(nodes []swarm.Node, tasks []swarm.Task, constraints []placement.Constraint, platforms []swarm.Platform, label string) []failureDomain {
+	domainOf := make(map[string]string, len(nodes))
+	byName := make(map[string]*failureDomain)
+	var domains []*failureDomain
+	domain := func(name string) *failureDomain {
+		d, ok := byName[name]
+		if !ok {
+			d = &failureDomain{name: name}
+			byName[name] = d
+			domains = append(domains, d)
+		}
+		return d
+	}
+	for _, node := range nodes {
+		name := node.Description.Hostname
+		if label != "" {
+			name = node.Spec.Labels[label]
+		}
+		domainOf[node.ID] = name
+		if node.Status.State == swarm.NodeStateReady && node.Spec.Availability == swarm.NodeAvailabilityActive && placement.Match(node, constraints) && placement.MatchPlatforms(node, platforms) {
+			domain(name).nodes++
+		}
+	}
+	for _, task := range tasks {
+		if name, ok := domainOf[task.NodeID]; ok {
+			domain(name).tasks++
+		}
+	}
+
+	sorted := make([]failureDomain, 0, len(domains))
+	for _, d := range domains {
+		sorted = append(sorted, *d)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].name < sorted[j].name })
+	return sorted
+}
+
+func printSpread(out io.Writer, domains []failureDomain, label string) error {
+	header := "NODE"
+	if label != "" {
+		header = strings.ToUpper(label)
+	}
+	w := tabwriter.NewWriter(out, 10, 1, 3, ' ', 0)
+	fmt.Fprintf(w, "%s\tNODES\tTASKS\n", header)
+	for _, d := range domains {
+		fmt.Fprintf(w, "%s\t%d\t%d\n", orDash(d.name), d.nodes, d.tasks)
+	}
+	return w.Flush()
+}
+
+// spreadWarnings returns a warning for each domain holding more tasks than
+// an even spread of the tasks over the domains with eligible nodes would,
+// with the command to fix it.
+func spreadWarnings(service swarm.Service, domains []failureDomain, tasks int, label string) []string {
+	var eligible int
+	for _, d := range domains {
+		if d.nodes > 0 {
+			eligible++
+		}
+	}
+	if eligible == 0 || tasks == 0 {
+		return nil
+	}
+	even := (tasks + eligible - 1) / eligible
+
+	var warnings []string
+	for _, d := range domains {
+		if d.tasks <= even {
+			continue
+		}
+		switch {
+		case label == "":
+			warnings = append(warnings, fmt.Sprintf("%d tasks of %s run on node %s, an even spread puts at most %d there", d.tasks, service.Spec.Name, d.name, even))
+		case d.name == "":
+			warnings = append(warnings, fmt.Sprintf("%d tasks of %s run on nodes without a %s label, an even spread puts at most %d there", d.tasks, service.Spec.Name, label, even))
+		default:
+			warnings = append(warnings, fmt.Sprintf("%d tasks of %s share %s=%s, an even spread puts at most %d there", d.tasks, service.Spec.Name, label, d.name, even))
+		}
+	}
+	if len(warnings) == 0 {
+		return nil
+	}
+
+	switch {
+	case label != "" && !hasSpreadPreference(service, nodeLabelPrefix+label):
+		warnings = append(warnings, fmt.Sprintf("spread the tasks with \"docker service update --placement-pref-add spread=%s%s %s\"", nodeLabelPrefix, label, service.Spec.Name))
+	case label == "" && service.Spec.Mode.Replicated != nil && (service.Spec.TaskTemplate.Placement == nil || service.Spec.TaskTemplate.Placement.MaxReplicas == 0):
+		warnings = append(warnings, fmt.Sprintf("limit the tasks per node with \"docker service update --replicas-max-per-node %d %s\"", even, service.Spec.Name))
+	default:
+		warnings = append(warnings, fmt.Sprintf("rebalance the tasks with \"docker service update --force %s\"", service.Spec.Name))
+	}
+	return warnings
+}
+
+// platformWarning returns a warning when nothing restricts the operating
+// system of service while the nodes matching its constraints run several,
+// with the constraint pinning it to the one its tasks run on.
+func platformWarning(service swarm.Service, nodes []swarm.Node, tasks []swarm.Task, constraints []placement.Constraint) string {
+	if placement.ConstrainsOS(service.Spec.TaskTemplate.Placement) {
+		return ""
+	}
+	osOf := make(map[string]string, len(nodes))
+	seen := make(map[string]bool)
+	var systems []string
+	for _, node := range nodes {
+		os := strings.ToLower(node.Description.Platform.OS)
+		osOf[node.ID] = os
+		if os != "" && !seen[os] && placement.Match(node, constraints) {
+			seen[os] = true
+			systems = append(systems, os)
+		}
+	}
+	if len(systems) < 2 {
+		return ""
+	}
+	sort.Strings(systems)
+	warning := fmt.Sprintf("%s has no platform constraint and can be scheduled on %s nodes", service.Spec.Name, strings.Join(systems, " and "))
+
+	taskSystems := make(map[string]bool)
+	for _, task := range tasks {
+		if os := osOf[task.NodeID]; os != "" {
+			taskSystems[os] = true
+		}
+	}
+	if len(taskSystems) == 1 {
+		for os := range taskSystems {
+			warning += fmt.Sprintf(", pin it with \"docker service update --constraint-add node.platform.os==%s %s\"", os, service.Spec.Name)
+		}
+	}
+	return warning
+}
+
+func hasSpreadPreference(service swarm.Service, descriptor string) bool {
+	p := service.Spec.TaskTemplate.Placement
+	if p == nil {
+		return false
+	}
+	for _, pref := range p.Preferences {
+		if pref.Spread != nil && pref.Spread.SpreadDescriptor == descriptor {
+			return true
+		}
+	}
+	return false
+}
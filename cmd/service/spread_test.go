@@ -0,0 +1,115 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func spreadNode(id, hostname, zone string) swarm.Node {
+	node := swarm.Node{
+		ID:          id,
+		Spec:        swarm.NodeSpec{Role: swarm.NodeRoleWorker, Availability: swarm.NodeAvailabilityActive},
+		Description: swarm.NodeDescription{Hostname: hostname},
+		Status:      swarm.NodeStatus{State: swarm.NodeStateReady},
+	}
+	if zone != "" {
+		node.Spec.Labels = map[string]string{"zone": zone}
+	}
+	return node
+}
+
+// spreadClient returns a client for a service with three tasks in zone a,
+// on two nodes, and one in zone b.
+func spreadClient() *fakeClient {
+	replicas := uint64(4)
+	service := testService("id-web", "web")
+	service.Spec.Mode = swarm.ServiceMode{Replicated: &swarm.ReplicatedService{Replicas: &replicas}}
+	service.Spec.TaskTemplate.Placement = &swarm.Placement{Constraints: []string{"node.role==worker"}}
+	cli := publishClient(service, nil, &update{})
+	cli.taskListFunc = func(types.TaskListOptions) ([]swarm.Task, error) {
+		return []swarm.Task{
+			runningTask("task1", 1, "id1"),
+			runningTask("task2", 2, "id1"),
+			runningTask("task3", 3, "id2"),
+			runningTask("task4", 4, "id3"),
+		}, nil
+	}
+	cli.nodeListFunc = func(types.NodeListOptions) ([]swarm.Node, error) {
+		manager := spreadNode("id5", "manager1", "c")
+		manager.Spec.Role = swarm.NodeRoleManager
+		return []swarm.Node{
+			spreadNode("id1", "worker1", "a"),
+			spreadNode("id2", "worker2", "a"),
+			spreadNode("id3", "worker3", "b"),
+			spreadNode("id4", "worker4", ""),
+			manager,
+		}, nil
+	}
+	return cli
+}
+
+func TestSpreadLabel(t *testing.T) {
+	cli := test.NewFakeCli(spreadClient())
+	cmd := newSpreadCommand(cli)
+	cmd.SetArgs([]string{"web", "--label", "node.labels.zone"})
+	assert.NilError(t, cmd.Execute())
+	test.AssertGolden(t, cli.OutBuffer().String(), "spread-label.golden")
+	assert.Check(t, is.Equal(cli.ErrBuffer().String(),
+		"WARNING: 3 tasks of web share zone=a, an even spread puts at most 2 there\n"+
+			"WARNING: spread the tasks with \"docker service update --placement-pref-add spread=node.labels.zone web\"\n"))
+}
+
+func TestSpreadNodes(t *testing.T) {
+	cli := test.NewFakeCli(spreadClient())
+	cmd := newSpreadCommand(cli)
+	cmd.SetArgs([]string{"web"})
+	assert.NilError(t, cmd.Execute())
+	test.AssertGolden(t, cli.OutBuffer().String(), "spread-nodes.golden")
+	assert.Check(t, is.Equal(cli.ErrBuffer().String(),
+		"WARNING: 2 tasks of web run on node worker1, an even spread puts at most 1 there\n"+
+			"WARNING: limit the tasks per node with \"docker service update --replicas-max-per-node 1 web\"\n"))
+}
+
+func TestSpreadEven(t *testing.T) {
+	cli := spreadClient()
+	cli.taskListFunc = func(types.TaskListOptions) ([]swarm.Task, error) {
+		return []swarm.Task{runningTask("task1", 1, "id1"), runningTask("task2", 2, "id3"), runningTask("task3", 3, "id4")}, nil
+	}
+	fakeCli := test.NewFakeCli(cli)
+	cmd := newSpreadCommand(fakeCli)
+	cmd.SetArgs([]string{"web", "--label", "zone"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(fakeCli.ErrBuffer().String(), ""))
+}
+
+func TestSpreadPlatforms(t *testing.T) {
+	cli := spreadClient()
+	listNodes := cli.nodeListFunc
+	cli.nodeListFunc = func(options types.NodeListOptions) ([]swarm.Node, error) {
+		nodes, err := listNodes(options)
+		for i := range nodes {
+			nodes[i].Description.Platform = swarm.Platform{OS: "linux", Architecture: "x86_64"}
+		}
+		windows := spreadNode("id6", "winworker1", "b")
+		windows.Description.Platform = swarm.Platform{OS: "windows", Architecture: "x86_64"}
+		return append(nodes, windows), err
+	}
+	fakeCli := test.NewFakeCli(cli)
+	cmd := newSpreadCommand(fakeCli)
+	cmd.SetArgs([]string{"web", "--label", "zone"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Contains(fakeCli.ErrBuffer().String(),
+		"WARNING: web has no platform constraint and can be scheduled on linux and windows nodes, pin it with \"docker service update --constraint-add node.platform.os==linux web\"\n"))
+
+	// The platforms of the image manifest exclude the windows node.
+	nodes, err := cli.nodeListFunc(types.NodeListOptions{})
+	assert.NilError(t, err)
+	domains := spreadDomains(nodes, nil, nil, []swarm.Platform{{OS: "linux", Architecture: "amd64"}}, "zone")
+	assert.Check(t, is.Equal(domains[2].name, "b"))
+	assert.Check(t, is.Equal(domains[2].nodes, 1))
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/go-units"
+	"github.com/moby/swarmctl/internal/engine"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// statsRefreshInterval is how often streamed stats are redrawn.
+var statsRefreshInterval = time.Second
+
+type statsOptions struct {
+	service  string
+	noStream bool
+	format   string
+}
+
+func newStatsCommand(dockerCli command.Cli) *cobra.Command {
+	opts := statsOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "stats [OPTIONS] SERVICE",
+		Short: "Display resource usage statistics of the tasks of a service",
+		Long: `Display resource usage statistics of the tasks of a service.
+
+Statistics are read from the engines of the nodes running the tasks, which are
+reached through the docker context named after each node (see "node info").`,
+		Args: cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.service = args[0]
+			return runStats(cmd.Context(), dockerCli, opts)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVar(&opts.noStream, "no-stream", false, "Print the statistics once instead of streaming them")
+	flags.StringVar(&opts.format, "format", "", `Print the statistics as a table (default), or "json" to print one JSON object per refresh`)
+	return cmd
+}
+
+// taskStats holds the last statistics received for a task.
+type taskStats struct {
+	Task        string  `json:"task"`
+	Node        string  `json:"node"`
+	CPUPercent  float64 `json:"cpuPercent"`
+	MemoryUsage uint64  `json:"memoryUsage"`
+	MemoryLimit uint64  `json:"memoryLimit"`
+	NetworkRx   uint64  `json:"networkRx"`
+	NetworkTx   uint64  `json:"networkTx"`
+	Error       string  `json:"error,omitempty"`
+	received    bool
+}
+
+type statsSnapshot struct {
+	Tasks []taskStats `json:"tasks"`
+	Total taskStats   `json:"total"`
+}
+
+func runStats(ctx context.Context, dockerCli command.Cli, opts statsOptions) error {
+	if opts.format != "" && opts.format != "json" {
+		return errors.Errorf(`invalid format %q, must be "json" or empty`, opts.format)
+	}
+	client := dockerCli.Client()
+
+	service, tasks, err := runningTasks(ctx, client, opts.service)
+	if err != nil {
+		return err
+	}
+	if len(tasks) == 0 {
+		return errors.Errorf("service %s has no running task", service.Spec.Name)
+	}
+	resolver, err := engine.NewResolver(ctx, dockerCli)
+	if err != nil {
+		return err
+	}
+	defer resolver.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu    sync.Mutex
+		stats = make([]taskStats, len(tasks))
+		wg    sync.WaitGroup
+	)
+	nodes := make(map[string]swarm.Node)
+	for i, task := range tasks {
+		node, ok := nodes[task.NodeID]
+		if !ok {
+			if node, _, err = client.NodeInspectWithRaw(ctx, task.NodeID); err != nil {
+				return err
+			}
+			nodes[task.NodeID] = node
+		}
+		stats[i] = taskStats{Task: taskName(service, task), Node: node.Description.Hostname}
+
+		wg.Add(1)
+		go func(i int, node swarm.Node, containerID string) {
+			defer wg.Done()
+			err := collectStats(ctx, resolver, node, containerID, !opts.noStream, func(s taskStats) {
+				mu.Lock()
+				s.Task, s.Node = stats[i].Task, stats[i].Node
+				stats[i] = s
+				mu.Unlock()
+			})
+			if err != nil && ctx.Err() == nil {
+				mu.Lock()
+				stats[i].Error = err.Error()
+				mu.Unlock()
+				fmt.Fprintf(dockerCli.Err(), "WARNING: no statistics for task %s on node %s: %s\n", stats[i].Task, stats[i].Node, err)
+			}
+		}(i, node, task.Status.ContainerStatus.ContainerID)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	render := func() error {
+		mu.Lock()
+		snapshot := newStatsSnapshot(stats)
+		mu.Unlock()
+		if opts.format == "json" {
+			return json.NewEncoder(dockerCli.Out()).Encode(snapshot)
+		}
+		if !opts.noStream {
+			// clear the screen and move the cursor to the top left corner
+			fmt.Fprint(dockerCli.Out(), "\033[2J\033[H")
+		}
+		return printStats(dockerCli.Out(), snapshot)
+	}
+
+	if opts.noStream {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		return render()
+	}
+
+	ticker := time.NewTicker(statsRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := render(); err != nil {
+				return err
+			}
+		case <-done:
+			return render()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// collectStats reads the statistics of a container from the engine of node,
+// calling update for every sample.
+func collectStats(ctx context.Context, resolver *engine.Resolver, node swarm.Node, containerID string, stream bool, update func(taskStats)) error {
+	nodeClient, err := resolver.Client(node)
+	if err != nil {
+		return err
+	}
+	response, err := nodeClient.ContainerStats(ctx, containerID, stream)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	dec := json.NewDecoder(response.Body)
+	for {
+		var v types.StatsJSON
+		if err := dec.Decode(&v); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		update(newTaskStats(&v))
+	}
+}
+
+func newTaskStats(v *types.StatsJSON) taskStats {
+	s := taskStats{
+		CPUPercent:  engine.CPUPercent(v),
+		MemoryUsage: engine.MemoryUsage(v.MemoryStats),
+		MemoryLimit: v.MemoryStats.Limit,
+		received:    true,
+	}
+	for _, network := range v.Networks {
+		s.NetworkRx += network.RxBytes
+		s.NetworkTx += network.TxBytes
+	}
+	return s
+}
+
+func newStatsSnapshot(stats []taskStats) statsSnapshot {
+	snapshot := statsSnapshot{Total: taskStats{Task: "TOTAL"}}
+	for _, s := range stats {
+		snapshot.Tasks = append(snapshot.Tasks, s)
+		if !s.received {
+			continue
+		}
+		snapshot.Total.received = true
+		snapshot.Total.CPUPercent += s.CPUPercent
+		snapshot.Total.MemoryUsage += s.MemoryUsage
+		snapshot.Total.MemoryLimit += s.MemoryLimit
+		snapshot.Total.NetworkRx += s.NetworkRx
+		snapshot.Total.NetworkTx += s.NetworkTx
+	}
+	return snapshot
+}
+
+func printStats(out io.Writer, snapshot statsSnapshot) error {
+	w := tabwriter.NewWriter(out, 10, 1, 3, ' ', 0)
+	fmt.Fprintln(w, "TASK\tNODE\tCPU %\tMEM USAGE / LIMIT\tNET I/O")
+	row := func(s taskStats) {
+		if !s.received {
+			fmt.Fprintf(w, "%s\t%s\t-\t-\t-\n", s.Task, orDash(s.Node))
+			return
+		}
+		fmt.Fprintf(w, "%s\t%s\t%.2f%%\t%s / %s\t%s / %s\n",
+			s.Task, orDash(s.Node), s.CPUPercent,
+			units.BytesSize(float64(s.MemoryUsage)), units.BytesSize(float64(s.MemoryLimit)),
+			units.HumanSizeWithPrecision(float64(s.NetworkRx), 3), units.HumanSizeWithPrecision(float64(s.NetworkTx), 3))
+	}
+	for _, s := range snapshot.Tasks {
+		row(s)
+	}
+	row(snapshot.Total)
+	return w.Flush()
+}
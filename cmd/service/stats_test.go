@@ -0,0 +1,93 @@
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/test"
+	"github.com/pkg/errors"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func statsSample(t *testing.T, cpuDelta uint64, memory uint64) string {
+	t.Helper()
+	v := types.StatsJSON{
+		Stats: types.Stats{
+			PreCPUStats: types.CPUStats{CPUUsage: types.CPUUsage{TotalUsage: 1000}, SystemUsage: 10000},
+			CPUStats:    types.CPUStats{CPUUsage: types.CPUUsage{TotalUsage: 1000 + cpuDelta}, SystemUsage: 20000, OnlineCPUs: 2},
+			MemoryStats: types.MemoryStats{Usage: memory + 1<<20, Limit: 512 << 20, Stats: map[string]uint64{"inactive_file": 1 << 20}},
+		},
+		Networks: map[string]types.NetworkStats{
+			"eth0": {RxBytes: 1000, TxBytes: 2000},
+			"eth1": {RxBytes: 500, TxBytes: 0},
+		},
+	}
+	data, err := json.Marshal(v)
+	assert.NilError(t, err)
+	return string(data)
+}
+
+// statsClient returns a client for a service with three tasks on the local
+// node. The container of task3 has no statistics.
+func statsClient(t *testing.T) *fakeClient {
+	cli := cpClient()
+	cli.taskListFunc = func(types.TaskListOptions) ([]swarm.Task, error) {
+		return []swarm.Task{runningTask("task1", 1, "id-local"), runningTask("task2", 2, "id-local"), runningTask("task3", 3, "id-local")}, nil
+	}
+	cli.nodeInspectFunc = func(nodeID string) (swarm.Node, []byte, error) {
+		return swarm.Node{ID: nodeID, Description: swarm.NodeDescription{Hostname: "node-1"}}, nil, nil
+	}
+	cli.statsFunc = func(containerID string, stream bool) (types.ContainerStats, error) {
+		assert.Check(t, !stream)
+		switch containerID {
+		case "container-task1":
+			return types.ContainerStats{Body: io.NopCloser(strings.NewReader(statsSample(t, 500, 64<<20)))}, nil
+		case "container-task2":
+			return types.ContainerStats{Body: io.NopCloser(strings.NewReader(statsSample(t, 1000, 128<<20)))}, nil
+		default:
+			return types.ContainerStats{}, errors.New("no such container")
+		}
+	}
+	return cli
+}
+
+func TestStatsNoStream(t *testing.T) {
+	cli := test.NewFakeCli(statsClient(t))
+	cmd := newStatsCommand(cli)
+	cmd.SetArgs([]string{"web", "--no-stream"})
+	assert.NilError(t, cmd.Execute())
+	test.AssertGolden(t, cli.OutBuffer().String(), "stats-no-stream.golden")
+	assert.Check(t, is.Equal(cli.ErrBuffer().String(), "WARNING: no statistics for task web.3 on node node-1: no such container\n"))
+}
+
+func TestStatsJSON(t *testing.T) {
+	cli := test.NewFakeCli(statsClient(t))
+	cmd := newStatsCommand(cli)
+	cmd.SetArgs([]string{"web", "--no-stream", "--format", "json"})
+	assert.NilError(t, cmd.Execute())
+
+	var snapshot statsSnapshot
+	assert.NilError(t, json.Unmarshal(cli.OutBuffer().Bytes(), &snapshot))
+	assert.Check(t, is.Len(snapshot.Tasks, 3))
+	assert.Check(t, is.Equal(snapshot.Tasks[2].Error, "no such container"))
+	total := snapshot.Total
+	assert.Check(t, is.Equal(total.Task, "TOTAL"))
+	assert.Check(t, is.Equal(total.CPUPercent, 30.0))
+	assert.Check(t, is.Equal(total.MemoryUsage, uint64(192<<20)))
+	assert.Check(t, is.Equal(total.MemoryLimit, uint64(1<<30)))
+	assert.Check(t, is.Equal(total.NetworkRx, uint64(3000)))
+	assert.Check(t, is.Equal(total.NetworkTx, uint64(4000)))
+}
+
+func TestStatsInvalidFormat(t *testing.T) {
+	cmd := newStatsCommand(test.NewFakeCli(statsClient(t)))
+	cmd.SetArgs([]string{"web", "--format", "{{.CPU}}"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Check(t, is.Error(cmd.Execute(), `invalid format "{{.CPU}}", must be "json" or empty`))
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/freeze"
+	"github.com/moby/swarmctl/internal/quota"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func newSuspendCommand(dockerCli command.Cli) *cobra.Command {
+	var overrideFreeze bool
+
+	cmd := &cobra.Command{
+		Use:   "suspend SERVICE",
+		Short: "Scale a replicated service to 0, keeping its replica count for resume",
+		Long: `Scale a replicated service to 0, keeping its replica count for resume.
+
+The replica count is recorded in the "` + swarmlabel.SuspendedReplicas + `" label of the
+service, and restored by "service resume".`,
+		Args: cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSuspend(cmd.Context(), dockerCli, args[0], overrideFreeze)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	freeze.AddFlag(cmd.Flags(), &overrideFreeze)
+	return cmd
+}
+
+func newResumeCommand(dockerCli command.Cli) *cobra.Command {
+	var overrideFreeze bool
+
+	cmd := &cobra.Command{
+		Use:   "resume SERVICE",
+		Short: "Restore the replica count of a suspended service",
+		Args:  cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runResume(cmd.Context(), dockerCli, args[0], overrideFreeze)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	freeze.AddFlag(cmd.Flags(), &overrideFreeze)
+	return cmd
+}
+
+func runSuspend(ctx context.Context, dockerCli command.Cli, name string, overrideFreeze bool) error {
+	service, _, err := dockerCli.Client().ServiceInspectWithRaw(ctx, name, types.ServiceInspectOptions{})
+	if err != nil {
+		return err
+	}
+	if err := freeze.Check("service", service.Spec.Name, service.Spec.Labels, overrideFreeze); err != nil {
+		return err
+	}
+
+	spec := service.Spec
+	if spec.Mode.Replicated == nil || spec.Mode.Replicated.Replicas == nil {
+		return errors.Errorf("service %s is not a replicated service, only replicated services can be suspended", spec.Name)
+	}
+	if _, ok := spec.Labels[swarmlabel.SuspendedReplicas]; ok {
+		return errors.Errorf("service %s is already suspended", spec.Name)
+	}
+
+	labels := make(map[string]string, len(spec.Labels)+1)
+	for k, v := range spec.Labels {
+		labels[k] = v
+	}
+	labels[swarmlabel.SuspendedReplicas] = strconv.FormatUint(*spec.Mode.Replicated.Replicas, 10)
+	spec.Labels = labels
+	replicas := uint64(0)
+	spec.Mode.Replicated = &swarm.ReplicatedService{Replicas: &replicas}
+	return updateService(ctx, dockerCli, service, spec)
+}
+
+func runResume(ctx context.Context, dockerCli command.Cli, name string, overrideFreeze bool) error {
+	service, _, err := dockerCli.Client().ServiceInspectWithRaw(ctx, name, types.ServiceInspectOptions{})
+	if err != nil {
+		return err
+	}
+	if err := freeze.Check("service", service.Spec.Name, service.Spec.Labels, overrideFreeze); err != nil {
+		return err
+	}
+
+	spec := service.Spec
+	value, ok := spec.Labels[swarmlabel.SuspendedReplicas]
+	if !ok {
+		return errors.Errorf("service %s is not suspended", spec.Name)
+	}
+	if spec.Mode.Replicated == nil {
+		return errors.Errorf("service %s is not a replicated service", spec.Name)
+	}
+	replicas, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return errors.Errorf("invalid replica count %q in label %s of service %s", value, swarmlabel.SuspendedReplicas, spec.Name)
+	}
+
+	labels := make(map[string]string, len(spec.Labels))
+	for k, v := range spec.Labels {
+		if k != swarmlabel.SuspendedReplicas {
+			labels[k] = v
+		}
+	}
+	spec.Labels = labels
+	spec.Mode.Replicated = &swarm.ReplicatedService{Replicas: &replicas}
+
+	quotas, err := quota.Load(quota.File())
+	if err != nil {
+		return err
+	}
+	if err := quotas.CheckServiceUpdate(ctx, dockerCli.Client(), service, spec); err != nil {
+		return err
+	}
+	return updateService(ctx, dockerCli, service, spec)
+}
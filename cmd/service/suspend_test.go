@@ -0,0 +1,122 @@
+package service
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/freeze"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"github.com/moby/swarmctl/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func replicatedService(replicas uint64, labels map[string]string) swarm.Service {
+	service := testService("id-web", "web")
+	service.Spec.Labels = labels
+	service.Spec.Mode = swarm.ServiceMode{Replicated: &swarm.ReplicatedService{Replicas: &replicas}}
+	return service
+}
+
+func TestSuspendResume(t *testing.T) {
+	var updated update
+	cli := test.NewFakeCli(publishClient(replicatedService(3, map[string]string{"team": "front"}), nil, &updated))
+	cmd := newSuspendCommand(cli)
+	cmd.SetArgs([]string{"web"})
+	assert.NilError(t, cmd.Execute())
+
+	assert.Assert(t, updated.spec != nil)
+	assert.Check(t, is.Equal(*updated.spec.Mode.Replicated.Replicas, uint64(0)))
+	assert.Check(t, is.DeepEqual(updated.spec.Labels, map[string]string{"team": "front", swarmlabel.SuspendedReplicas: "3"}))
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "web\n"))
+
+	suspended := replicatedService(0, updated.spec.Labels)
+	updated = update{}
+	cli = test.NewFakeCli(publishClient(suspended, nil, &updated))
+	cmd = newResumeCommand(cli)
+	cmd.SetArgs([]string{"web"})
+	assert.NilError(t, cmd.Execute())
+
+	assert.Assert(t, updated.spec != nil)
+	assert.Check(t, is.Equal(*updated.spec.Mode.Replicated.Replicas, uint64(3)))
+	assert.Check(t, is.DeepEqual(updated.spec.Labels, map[string]string{"team": "front"}))
+}
+
+func TestSuspendFrozen(t *testing.T) {
+	frozen := replicatedService(3, map[string]string{swarmlabel.Namespace: "shop", freeze.Label: "release"})
+	var updated update
+	cmd := newSuspendCommand(test.NewFakeCli(publishClient(frozen, nil, &updated)))
+	cmd.SetArgs([]string{"web"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "service web belongs to frozen stack shop (release), use --override-freeze to change it anyway")
+	assert.Check(t, updated.spec == nil)
+
+	cmd = newSuspendCommand(test.NewFakeCli(publishClient(frozen, nil, &updated)))
+	cmd.SetArgs([]string{"web", "--override-freeze"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, updated.spec != nil)
+}
+
+func TestResumeQuotaExceeded(t *testing.T) {
+	quotaFile := filepath.Join(t.TempDir(), "quotas.yml")
+	assert.NilError(t, os.WriteFile(quotaFile, []byte("namespaces:\n  shop:\n    replicas: 4\n"), 0o644))
+	t.Setenv("SWARMCTL_QUOTA_FILE", quotaFile)
+
+	suspended := replicatedService(0, map[string]string{swarmlabel.Namespace: "shop", swarmlabel.SuspendedReplicas: "5"})
+	var updated update
+	cmd := newResumeCommand(test.NewFakeCli(publishClient(suspended, nil, &updated)))
+	cmd.SetArgs([]string{"web"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "quota exceeded for namespace shop: replicas 5/4")
+	assert.Check(t, updated.spec == nil)
+}
+
+func TestSuspendErrors(t *testing.T) {
+	global := testService("id-agent", "agent")
+	global.Spec.Mode = swarm.ServiceMode{Global: &swarm.GlobalService{}}
+
+	testCases := []struct {
+		name     string
+		service  swarm.Service
+		resume   bool
+		expected string
+	}{
+		{
+			name:     "global",
+			service:  global,
+			expected: "service agent is not a replicated service, only replicated services can be suspended",
+		},
+		{
+			name:     "already-suspended",
+			service:  replicatedService(0, map[string]string{swarmlabel.SuspendedReplicas: "3"}),
+			expected: "service web is already suspended",
+		},
+		{
+			name:     "not-suspended",
+			service:  replicatedService(3, nil),
+			resume:   true,
+			expected: "service web is not suspended",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var updated update
+			cli := test.NewFakeCli(publishClient(tc.service, nil, &updated))
+			cmd := newSuspendCommand(cli)
+			if tc.resume {
+				cmd = newResumeCommand(cli)
+			}
+			cmd.SetArgs([]string{tc.service.Spec.Name})
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			assert.Error(t, cmd.Execute(), tc.expected)
+			assert.Check(t, updated.spec == nil, "service must not be updated")
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+	"github.com/pkg/errors"
+)
+
+// taskSelector selects among the running tasks of a service.
+type taskSelector struct {
+	slot int
+	task string
+}
+
+// runningTasks returns the service and its tasks that run a container,
+// ordered by slot.
+func runningTasks(ctx context.Context, apiClient client.APIClient, serviceRef string) (swarm.Service, []swarm.Task, error) {
+	service, _, err := apiClient.ServiceInspectWithRaw(ctx, serviceRef, types.ServiceInspectOptions{})
+	if err != nil {
+		return swarm.Service{}, nil, err
+	}
+	tasks, err := apiClient.TaskList(ctx, types.TaskListOptions{
+		Filters: filters.NewArgs(filters.Arg("service", service.ID), filters.Arg("desired-state", "running")),
+	})
+	if err != nil {
+		return swarm.Service{}, nil, err
+	}
+	var running []swarm.Task
+	for _, task := range tasks {
+		if task.Status.State == swarm.TaskStateRunning && task.Status.ContainerStatus != nil && task.Status.ContainerStatus.ContainerID != "" {
+			running = append(running, task)
+		}
+	}
+	sortTasks(running)
+	return service, running, nil
+}
+
+// filter returns the tasks matching the selector. A zero selector matches
+// every task.
+func (s taskSelector) filter(tasks []swarm.Task) []swarm.Task {
+	var matching []swarm.Task
+	for _, task := range tasks {
+		if s.task != "" && !strings.HasPrefix(task.ID, s.task) {
+			continue
+		}
+		if s.slot != 0 && task.Slot != s.slot {
+			continue
+		}
+		matching = append(matching, task)
+	}
+	return matching
+}
+
+// selectTask returns the single running task of the service matching the
+// selector.
+func (s taskSelector) selectTask(service swarm.Service, tasks []swarm.Task) (swarm.Task, error) {
+	matching := s.filter(tasks)
+	switch {
+	case len(matching) == 1:
+		return matching[0], nil
+	case len(matching) == 0 && len(tasks) == 0:
+		return swarm.Task{}, errors.Errorf("service %s has no running task", service.Spec.Name)
+	case len(matching) == 0:
+		return swarm.Task{}, errors.Errorf("no running task of service %s matches the --slot and --task flags", service.Spec.Name)
+	default:
+		return swarm.Task{}, errors.Errorf("service %s has %d running tasks, select one with --slot or --task", service.Spec.Name, len(matching))
+	}
+}
+
+// taskName returns the name `docker service ps` shows for the task.
+func taskName(service swarm.Service, task swarm.Task) string {
+	if task.Slot != 0 {
+		return fmt.Sprintf("%s.%d", service.Spec.Name, task.Slot)
+	}
+	return fmt.Sprintf("%s.%s", service.Spec.Name, task.NodeID)
+}
+
+func sortTasks(tasks []swarm.Task) {
+	sort.Slice(tasks, func(i, j int) bool {
+		if tasks[i].Slot != tasks[j].Slot {
+			return tasks[i].Slot < tasks[j].Slot
+		}
+		return tasks[i].NodeID < tasks[j].NodeID
+	})
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/freeze"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type unpublishOptions struct {
+	service        string
+	ports          []string
+	overrideFreeze bool
+}
+
+func newUnpublishCommand(dockerCli command.Cli) *cobra.Command {
+	options := unpublishOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "unpublish SERVICE PUBLISHED-PORT[/PROTOCOL] [PUBLISHED-PORT[/PROTOCOL]...]",
+		Short: "Stop publishing ports of a service",
+		Args:  cli.RequiresMinArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.service = args[0]
+			options.ports = args[1:]
+			return runUnpublish(cmd.Context(), dockerCli, options)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	freeze.AddFlag(cmd.Flags(), &options.overrideFreeze)
+	return cmd
+}
+
+func runUnpublish(ctx context.Context, dockerCli command.Cli, options unpublishOptions) error {
+	client := dockerCli.Client()
+
+	service, _, err := client.ServiceInspectWithRaw(ctx, options.service, types.ServiceInspectOptions{})
+	if err != nil {
+		return err
+	}
+	if err := freeze.Check("service", service.Spec.Name, service.Spec.Labels, options.overrideFreeze); err != nil {
+		return err
+	}
+
+	spec := service.Spec
+	var current []swarm.PortConfig
+	if spec.EndpointSpec != nil {
+		current = spec.EndpointSpec.Ports
+	}
+	for _, value := range options.ports {
+		published, protocol, err := parsePublishedPort(value)
+		if err != nil {
+			return err
+		}
+		var kept []swarm.PortConfig
+		for _, port := range current {
+			if port.PublishedPort != published || port.Protocol != protocol {
+				kept = append(kept, port)
+			}
+		}
+		if len(kept) == len(current) {
+			return errors.Errorf("service %s does not publish port %d/%s", service.Spec.Name, published, protocol)
+		}
+		current = kept
+	}
+
+	endpointSpec := *spec.EndpointSpec
+	endpointSpec.Ports = current
+	spec.EndpointSpec = &endpointSpec
+	return updateService(ctx, dockerCli, service, spec)
+}
+
+// parsePublishedPort parses PUBLISHED-PORT[/PROTOCOL], defaulting to tcp.
+func parsePublishedPort(value string) (uint32, swarm.PortConfigProtocol, error) {
+	port, protocol, _ := strings.Cut(value, "/")
+	if protocol == "" {
+		protocol = string(swarm.PortConfigProtocolTCP)
+	}
+	switch swarm.PortConfigProtocol(protocol) {
+	case swarm.PortConfigProtocolTCP, swarm.PortConfigProtocolUDP, swarm.PortConfigProtocolSCTP:
+	default:
+		return 0, "", errors.Errorf("invalid protocol %q in %q, must be one of tcp, udp, sctp", protocol, value)
+	}
+	published, err := strconv.ParseUint(port, 10, 16)
+	if err != nil || published == 0 {
+		return 0, "", errors.Errorf("invalid published port %q", value)
+	}
+	return uint32(published), swarm.PortConfigProtocol(protocol), nil
+}
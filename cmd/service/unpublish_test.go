@@ -0,0 +1,61 @@
+package service
+
+import (
+	"io"
+	"testing"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestUnpublish(t *testing.T) {
+	udp := ingressPort(53, 53)
+	udp.Protocol = swarm.PortConfigProtocolUDP
+	var updated update
+	cli := test.NewFakeCli(publishClient(testService("id-dns", "dns", ingressPort(53, 53), udp, ingressPort(8080, 80)), nil, &updated))
+	cmd := newUnpublishCommand(cli)
+	cmd.SetArgs([]string{"dns", "53/udp", "8080"})
+	assert.NilError(t, cmd.Execute())
+
+	assert.Assert(t, updated.spec != nil)
+	assert.Check(t, is.DeepEqual(updated.spec.EndpointSpec.Ports, []swarm.PortConfig{ingressPort(53, 53)}))
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "dns\n"))
+}
+
+func TestUnpublishErrors(t *testing.T) {
+	tests := []struct {
+		doc           string
+		args          []string
+		expectedError string
+	}{
+		{
+			doc:           "port not published",
+			args:          []string{"web", "8080/udp"},
+			expectedError: "service web does not publish port 8080/udp",
+		},
+		{
+			doc:           "invalid protocol",
+			args:          []string{"web", "80/http"},
+			expectedError: `invalid protocol "http" in "80/http", must be one of tcp, udp, sctp`,
+		},
+		{
+			doc:           "invalid port",
+			args:          []string{"web", "0"},
+			expectedError: `invalid published port "0"`,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.doc, func(t *testing.T) {
+			var updated update
+			cmd := newUnpublishCommand(test.NewFakeCli(publishClient(testService("id-web", "web", ingressPort(8080, 80)), nil, &updated)))
+			cmd.SetArgs(tc.args)
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			assert.Check(t, is.Error(cmd.Execute(), tc.expectedError))
+			assert.Check(t, updated.spec == nil)
+		})
+	}
+}
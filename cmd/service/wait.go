@@ -0,0 +1,327 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	serviceprogress "github.com/docker/cli/cli/command/service/progress"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/moby/swarmctl/cmd/swarm/progress"
+	"github.com/moby/swarmctl/internal/engine"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+// forensicsTasks is the number of failed tasks reported when an update
+// pauses or rolls back.
+const forensicsTasks = 5
+
+// Readiness of the tasks of a service, set by --ready-when.
+const (
+	readyRunning = "running"
+	readyHealthy = "healthy"
+)
+
+// healthPollInterval is how often the health of tasks is checked with
+// --ready-when healthy.
+var healthPollInterval = time.Second
+
+// exitProgressDeadline is the exit status of the commands that stopped
+// waiting for a service to converge after --progress-deadline.
+const exitProgressDeadline = 3
+
+// waitOptions holds the flags of commands waiting for a service to converge
+// after updating it.
+type waitOptions struct {
+	detach   bool
+	quiet    bool
+	progress string
+	deadline time.Duration
+	// forensics is "on" to report the failed tasks of an update that
+	// paused or rolled back.
+	forensics string
+	readyWhen string
+}
+
+func addWaitFlags(flags *pflag.FlagSet, opts *waitOptions) {
+	flags.BoolVarP(&opts.detach, "detach", "d", false, "Exit immediately instead of waiting for the service to converge")
+	flags.BoolVarP(&opts.quiet, "quiet", "q", false, "Suppress progress output")
+	flags.StringVar(&opts.progress, "progress", progress.ModeAuto, `Set type of progress output ("auto", "tty", "plain", "json")`)
+	flags.DurationVar(&opts.deadline, "progress-deadline", 0, fmt.Sprintf("Stop waiting for the service to converge after this duration, and exit with status %d (0 to wait indefinitely)", exitProgressDeadline))
+	flags.StringVar(&opts.readyWhen, "ready-when", readyRunning, `Consider tasks ready once "running", or once "healthy" if they have a healthcheck`)
+	flags.StringVar(&opts.forensics, "forensics", "on", `Report the last failed tasks when an update pauses or rolls back ("on", "off")`)
+}
+
+// waitForService waits for the tasks of the service to converge, rendering
+// their progress according to opts.
+func waitForService(ctx context.Context, dockerCli command.Cli, serviceID string, opts waitOptions) error {
+	if opts.detach {
+		return nil
+	}
+	if opts.forensics != "on" && opts.forensics != "off" {
+		return errors.Errorf(`invalid --forensics value %q, must be "on" or "off"`, opts.forensics)
+	}
+	if opts.readyWhen != readyRunning && opts.readyWhen != readyHealthy {
+		return errors.Errorf(`invalid --ready-when value %q, must be "running" or "healthy"`, opts.readyWhen)
+	}
+	renderer, err := progress.NewRenderer(opts.progress, dockerCli.Out())
+	if err != nil {
+		return err
+	}
+
+	waitCtx := ctx
+	if opts.deadline > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, opts.deadline)
+		defer cancel()
+	}
+
+	errChan := make(chan error, 1)
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		errChan <- serviceprogress.ServiceProgress(waitCtx, dockerCli.Client(), serviceID, pipeWriter)
+	}()
+	// Stop rendering once the deadline passes, even if the progress is
+	// blocked on a request.
+	go func() {
+		<-waitCtx.Done()
+		pipeReader.CloseWithError(waitCtx.Err())
+	}()
+
+	if opts.quiet {
+		go io.Copy(io.Discard, pipeReader)
+		select {
+		case err = <-errChan:
+		case <-waitCtx.Done():
+		}
+	} else {
+		err = renderer.Render(pipeReader)
+		if err == nil {
+			err = <-errChan
+		}
+	}
+	if err == nil && opts.readyWhen == readyHealthy {
+		err = waitHealthy(waitCtx, dockerCli, serviceID, opts.quiet)
+	}
+	if waitCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		return deadlineError(ctx, dockerCli, serviceID, opts.deadline)
+	}
+	if opts.forensics == "on" && ctx.Err() == nil {
+		if forensicsErr := printForensics(ctx, dockerCli, serviceID); forensicsErr != nil {
+			fmt.Fprintf(dockerCli.Err(), "WARNING: unable to report the failed tasks: %s\n", forensicsErr)
+		}
+	}
+	return err
+}
+
+// printForensics reports the last failed tasks of a service whose update
+// paused or rolled back, with their nodes and whether their image could not
+// be pulled.
+func printForensics(ctx context.Context, dockerCli command.Cli, serviceID string) error {
+	client := dockerCli.Client()
+	service, _, err := client.ServiceInspectWithRaw(ctx, serviceID, types.ServiceInspectOptions{})
+	if err != nil {
+		return err
+	}
+	if service.UpdateStatus == nil {
+		return nil
+	}
+	switch service.UpdateStatus.State {
+	case swarm.UpdateStatePaused, swarm.UpdateStateRollbackStarted, swarm.UpdateStateRollbackPaused, swarm.UpdateStateRollbackCompleted:
+	default:
+		return nil
+	}
+
+	tasks, err := client.TaskList(ctx, types.TaskListOptions{
+		Filters: filters.NewArgs(filters.Arg("service", service.ID)),
+	})
+	if err != nil {
+		return err
+	}
+	var failed []swarm.Task
+	for _, task := range tasks {
+		if task.Status.State == swarm.TaskStateFailed || task.Status.State == swarm.TaskStateRejected {
+			failed = append(failed, task)
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	sort.SliceStable(failed, func(i, j int) bool {
+		return failed[i].Status.Timestamp.After(failed[j].Status.Timestamp)
+	})
+	if len(failed) > forensicsTasks {
+		failed = failed[:forensicsTasks]
+	}
+	nodes, err := client.NodeList(ctx, types.NodeListOptions{})
+	if err != nil {
+		return err
+	}
+	hostnames := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		hostnames[node.ID] = node.Description.Hostname
+	}
+
+	out := dockerCli.Err()
+	fmt.Fprintf(out, "\nThe update of service %s is %s, last failed tasks:\n", service.Spec.Name, strings.ReplaceAll(string(service.UpdateStatus.State), "_", " "))
+	w := tabwriter.NewWriter(out, 10, 1, 3, ' ', 0)
+	fmt.Fprintln(w, "ID\tTASK\tNODE\tSTATE\tIMAGE\tERROR")
+	pullFailures := make(map[string][]string)
+	for _, task := range failed {
+		node := hostnames[task.NodeID]
+		if node == "" {
+			node = "-"
+		}
+		image := "-"
+		if task.Spec.ContainerSpec != nil {
+			image, _, _ = strings.Cut(task.Spec.ContainerSpec.Image, "@")
+		}
+		message := task.Status.Err
+		if message == "" {
+			message = task.Status.Message
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", stringid.TruncateID(task.ID), taskName(service, task), node, task.Status.State, image, message)
+		if isPullFailure(message) && !containsString(pullFailures[image], node) {
+			pullFailures[image] = append(pullFailures[image], node)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	images := make([]string, 0, len(pullFailures))
+	for image := range pullFailures {
+		images = append(images, image)
+	}
+	sort.Strings(images)
+	for _, image := range images {
+		fmt.Fprintf(out, "Image %s could not be pulled on %s\n", image, strings.Join(pullFailures[image], ", "))
+	}
+	return nil
+}
+
+// isPullFailure reports whether the error of a task is about pulling its
+// image.
+func isPullFailure(message string) bool {
+	message = strings.ToLower(message)
+	for _, s := range []string{"pull access denied", "no such image", "manifest unknown", "not found: manifest", "error pulling image"} {
+		if strings.Contains(message, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// waitHealthy waits for the running tasks of the service whose container
+// has a healthcheck to be healthy, as reported by the engines of their
+// nodes. The tasks are only running once their healthcheck first passed,
+// this catches the ones that turned unhealthy since.
+func waitHealthy(ctx context.Context, dockerCli command.Cli, serviceID string, quiet bool) error {
+	resolver, err := engine.NewResolver(ctx, dockerCli)
+	if err != nil {
+		return err
+	}
+	defer resolver.Close()
+
+	nodes := make(map[string]swarm.Node)
+	var reported string
+	for {
+		service, tasks, err := runningTasks(ctx, dockerCli.Client(), serviceID)
+		if err != nil {
+			return err
+		}
+		var waiting []string
+		for _, task := range tasks {
+			node, ok := nodes[task.NodeID]
+			if !ok {
+				if node, _, err = dockerCli.Client().NodeInspectWithRaw(ctx, task.NodeID); err != nil {
+					return err
+				}
+				nodes[task.NodeID] = node
+			}
+			c, err := resolver.Client(node)
+			if err != nil {
+				return err
+			}
+			container, err := c.ContainerInspect(ctx, task.Status.ContainerStatus.ContainerID)
+			if err != nil {
+				return err
+			}
+			if health := container.State.Health; health != nil && health.Status != types.Healthy {
+				waiting = append(waiting, fmt.Sprintf("%s (%s)", taskName(service, task), health.Status))
+			}
+		}
+		if len(waiting) == 0 {
+			return nil
+		}
+		if message := strings.Join(waiting, ", "); !quiet && message != reported {
+			fmt.Fprintf(dockerCli.Err(), "Waiting for tasks to be healthy: %s\n", message)
+			reported = message
+		}
+		select {
+		case <-time.After(healthPollInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// deadlineError returns the error of a service that did not converge
+// before the deadline, summarizing the state of its tasks.
+func deadlineError(ctx context.Context, dockerCli command.Cli, serviceID string, deadline time.Duration) error {
+	client := dockerCli.Client()
+	service, _, err := client.ServiceInspectWithRaw(ctx, serviceID, types.ServiceInspectOptions{})
+	if err != nil {
+		return err
+	}
+	tasks, err := client.TaskList(ctx, types.TaskListOptions{
+		Filters: filters.NewArgs(filters.Arg("service", service.ID), filters.Arg("desired-state", "running")),
+	})
+	if err != nil {
+		return err
+	}
+	sortTasks(tasks)
+
+	running := 0
+	var pending []string
+	for _, task := range tasks {
+		if task.Status.State == swarm.TaskStateRunning {
+			running++
+			continue
+		}
+		line := fmt.Sprintf("  %s: %s", taskName(service, task), task.Status.State)
+		if task.Status.Err != "" {
+			line += ": " + task.Status.Err
+		} else if task.Status.Message != "" {
+			line += ": " + task.Status.Message
+		}
+		pending = append(pending, line)
+	}
+
+	summary := fmt.Sprintf("service %s did not converge within %s, %d of %d tasks running", service.Spec.Name, deadline, running, len(tasks))
+	if service.UpdateStatus != nil {
+		summary += fmt.Sprintf(", update %s", service.UpdateStatus.State)
+	}
+	if len(pending) > 0 {
+		summary += ":\n" + strings.Join(pending, "\n")
+	}
+	return cli.StatusError{StatusCode: exitProgressDeadline, Status: summary}
+}
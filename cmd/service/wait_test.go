@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestWaitProgressDeadline(t *testing.T) {
+	replicas := uint64(2)
+	service := testService("id-web", "web")
+	service.Spec.Mode = swarm.ServiceMode{Replicated: &swarm.ReplicatedService{Replicas: &replicas}}
+	service.UpdateStatus = &swarm.UpdateStatus{State: swarm.UpdateStateUpdating}
+	client := networkClient(service, &update{})
+	client.taskListFunc = func(types.TaskListOptions) ([]swarm.Task, error) {
+		pending := runningTask("task2", 2, "id-node")
+		pending.Status = swarm.TaskStatus{State: swarm.TaskStatePreparing, Err: "pull access denied for web:2"}
+		return []swarm.Task{pending, runningTask("task1", 1, "id-node")}, nil
+	}
+
+	for _, mode := range []string{"--quiet", "--progress=plain"} {
+		mode := mode
+		t.Run(mode, func(t *testing.T) {
+			fakeCli := test.NewFakeCli(client)
+			cmd := newNetworkConnectCommand(fakeCli)
+			cmd.SetArgs([]string{"web", "backend", mode, "--progress-deadline", "100ms"})
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			err := cmd.Execute()
+			assert.Check(t, is.DeepEqual(err, cli.StatusError{
+				StatusCode: exitProgressDeadline,
+				Status:     "service web did not converge within 100ms, 1 of 2 tasks running, update updating:\n  web.2: preparing: pull access denied for web:2",
+			}))
+		})
+	}
+}
+
+func TestWaitForensics(t *testing.T) {
+	replicas := uint64(2)
+	service := testService("id-web", "web")
+	service.Spec.Mode = swarm.ServiceMode{Replicated: &swarm.ReplicatedService{Replicas: &replicas}}
+	service.UpdateStatus = &swarm.UpdateStatus{State: swarm.UpdateStatePaused, Message: "update paused due to failure or early termination of task task3"}
+	client := networkClient(service, &update{})
+	failedTask := func(id string, slot int, minute int, err string) swarm.Task {
+		task := runningTask(id, slot, "id-node")
+		task.Spec.ContainerSpec = &swarm.ContainerSpec{Image: "web:2@sha256:abcdef"}
+		task.Status = swarm.TaskStatus{
+			Timestamp: time.Date(2023, 1, 2, 15, minute, 0, 0, time.UTC),
+			State:     swarm.TaskStateRejected,
+			Err:       err,
+		}
+		return task
+	}
+	client.taskListFunc = func(types.TaskListOptions) ([]swarm.Task, error) {
+		return []swarm.Task{
+			runningTask("task1", 1, "id-node"),
+			failedTask("task2", 2, 1, "No such image: web:2"),
+			failedTask("task3", 2, 2, "No such image: web:2"),
+		}, nil
+	}
+	client.nodeListFunc = func(types.NodeListOptions) ([]swarm.Node, error) {
+		return []swarm.Node{{ID: "id-node", Description: swarm.NodeDescription{Hostname: "worker1"}}}, nil
+	}
+
+	cli := test.NewFakeCli(client)
+	cmd := newNetworkConnectCommand(cli)
+	cmd.SetArgs([]string{"web", "backend", "--quiet"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "service update paused: update paused due to failure or early termination of task task3")
+	test.AssertGolden(t, cli.ErrBuffer().String(), "wait-forensics.golden")
+
+	cli = test.NewFakeCli(client)
+	cmd = newNetworkConnectCommand(cli)
+	cmd.SetArgs([]string{"web", "backend", "--quiet", "--forensics=off"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Check(t, cmd.Execute() != nil)
+	assert.Check(t, is.Equal(cli.ErrBuffer().String(), ""))
+}
+
+func TestWaitHealthy(t *testing.T) {
+	defer func(interval time.Duration) { healthPollInterval = interval }(healthPollInterval)
+	healthPollInterval = time.Millisecond
+
+	client := cpClient()
+	inspects := 0
+	client.containerInspectFunc = func(containerID string) (types.ContainerJSON, error) {
+		inspects++
+		status := types.Healthy
+		if containerID == "container-task2" && inspects < 5 {
+			status = types.Unhealthy
+		}
+		return types.ContainerJSON{ContainerJSONBase: &types.ContainerJSONBase{
+			State: &types.ContainerState{Health: &types.Health{Status: status}},
+		}}, nil
+	}
+
+	fakeCli := test.NewFakeCli(client)
+	assert.NilError(t, waitHealthy(context.Background(), fakeCli, "web", false))
+	assert.Check(t, is.Equal(fakeCli.ErrBuffer().String(), "Waiting for tasks to be healthy: web.2 (unhealthy)\n"))
+}
+
+func TestWaitHealthyDeadline(t *testing.T) {
+	client := cpClient()
+	client.containerInspectFunc = func(containerID string) (types.ContainerJSON, error) {
+		return types.ContainerJSON{ContainerJSONBase: &types.ContainerJSONBase{
+			State: &types.ContainerState{Health: &types.Health{Status: types.Starting}},
+		}}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := waitHealthy(ctx, test.NewFakeCli(client), "web", true)
+	assert.Check(t, is.ErrorIs(err, context.DeadlineExceeded))
+}
+
+func TestWaitReadyWhenInvalid(t *testing.T) {
+	cmd := newNetworkConnectCommand(test.NewFakeCli(networkClient(testService("id-web", "web"), &update{})))
+	cmd.SetArgs([]string{"web", "backend", "--ready-when", "started"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), `invalid --ready-when value "started", must be "running" or "healthy"`)
+}
@@ -0,0 +1,78 @@
+package stack
+
+import (
+	"context"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+)
+
+type fakeClient struct {
+	client.Client
+	serviceListFunc   func(options types.ServiceListOptions) ([]swarm.Service, error)
+	networkListFunc   func(options types.NetworkListOptions) ([]types.NetworkResource, error)
+	configListFunc    func(options types.ConfigListOptions) ([]swarm.Config, error)
+	serviceUpdateFunc func(serviceID string, version swarm.Version, service swarm.ServiceSpec) (types.ServiceUpdateResponse, error)
+	configUpdateFunc  func(id string, version swarm.Version, config swarm.ConfigSpec) error
+	secretListFunc    func(options types.SecretListOptions) ([]swarm.Secret, error)
+	// removed records the objects removed, as "type id".
+	removed []string
+}
+
+func (cli *fakeClient) ServiceList(ctx context.Context, options types.ServiceListOptions) ([]swarm.Service, error) {
+	if cli.serviceListFunc != nil {
+		return cli.serviceListFunc(options)
+	}
+	return nil, nil
+}
+
+func (cli *fakeClient) NetworkList(ctx context.Context, options types.NetworkListOptions) ([]types.NetworkResource, error) {
+	if cli.networkListFunc != nil {
+		return cli.networkListFunc(options)
+	}
+	return nil, nil
+}
+
+func (cli *fakeClient) ConfigList(ctx context.Context, options types.ConfigListOptions) ([]swarm.Config, error) {
+	if cli.configListFunc != nil {
+		return cli.configListFunc(options)
+	}
+	return nil, nil
+}
+
+func (cli *fakeClient) ServiceUpdate(ctx context.Context, serviceID string, version swarm.Version, service swarm.ServiceSpec, options types.ServiceUpdateOptions) (types.ServiceUpdateResponse, error) {
+	if cli.serviceUpdateFunc != nil {
+		return cli.serviceUpdateFunc(serviceID, version, service)
+	}
+	return types.ServiceUpdateResponse{}, nil
+}
+
+func (cli *fakeClient) ConfigUpdate(ctx context.Context, id string, version swarm.Version, config swarm.ConfigSpec) error {
+	if cli.configUpdateFunc != nil {
+		return cli.configUpdateFunc(id, version, config)
+	}
+	return nil
+}
+
+func (cli *fakeClient) SecretList(ctx context.Context, options types.SecretListOptions) ([]swarm.Secret, error) {
+	if cli.secretListFunc != nil {
+		return cli.secretListFunc(options)
+	}
+	return nil, nil
+}
+
+func (cli *fakeClient) NetworkRemove(ctx context.Context, networkID string) error {
+	cli.removed = append(cli.removed, "network "+networkID)
+	return nil
+}
+
+func (cli *fakeClient) ConfigRemove(ctx context.Context, id string) error {
+	cli.removed = append(cli.removed, "config "+id)
+	return nil
+}
+
+func (cli *fakeClient) SecretRemove(ctx context.Context, id string) error {
+	cli.removed = append(cli.removed, "secret "+id)
+	return nil
+}
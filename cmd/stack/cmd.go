@@ -0,0 +1,32 @@
+package stack
+
+import (
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/moby/swarmctl/cmd/system"
+	"github.com/spf13/cobra"
+)
+
+// NewStackCommand returns a cobra command for `stack` subcommands
+func NewStackCommand(dockerCli command.Cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stack",
+		Short: "Inspect and manage deployed stacks",
+		Args:  cli.NoArgs,
+		RunE:  command.ShowHelp(dockerCli.Err()),
+		Annotations: map[string]string{
+			"version": "1.25",
+			"swarm":   "manager",
+		},
+	}
+	cmd.AddCommand(
+		newFreezeCommand(dockerCli),
+		newGraphCommand(dockerCli),
+		newLabelCommand(dockerCli),
+		newListCommand(dockerCli),
+		newPruneCommand(dockerCli),
+		system.NewStackServicesCommand(dockerCli),
+		newUnfreezeCommand(dockerCli),
+	)
+	return cmd
+}
@@ -0,0 +1,56 @@
+package stack
+
+import (
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/moby/swarmctl/internal/freeze"
+	"github.com/spf13/cobra"
+)
+
+func newFreezeCommand(dockerCli command.Cli) *cobra.Command {
+	var reason string
+
+	cmd := &cobra.Command{
+		Use:   "freeze [OPTIONS] STACK",
+		Short: "Block the changes to a stack made with swarmctl",
+		Long: `Block the changes to a stack made with swarmctl.
+
+The "` + freeze.Label + `" label is set on the services, configs and secrets of the
+stack. While it is set, the swarmctl commands changing them fail unless
+--override-freeze is given. Changes made with the docker CLI, including
+"docker stack deploy", are not blocked.`,
+		Args: cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runLabel(cmd.Context(), dockerCli, labelOptions{
+				stack:          args[0],
+				add:            map[string]string{freeze.Label: reason},
+				configs:        true,
+				secrets:        true,
+				overrideFreeze: true,
+			})
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	cmd.Flags().StringVar(&reason, "reason", "", "Reason of the freeze, shown by the commands it blocks")
+	return cmd
+}
+
+func newUnfreezeCommand(dockerCli command.Cli) *cobra.Command {
+	return &cobra.Command{
+		Use:   "unfreeze STACK",
+		Short: "Allow the changes to a frozen stack again",
+		Args:  cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runLabel(cmd.Context(), dockerCli, labelOptions{
+				stack:          args[0],
+				remove:         []string{freeze.Label},
+				configs:        true,
+				secrets:        true,
+				overrideFreeze: true,
+			})
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+}
@@ -0,0 +1,98 @@
+package stack
+
+import (
+	"io"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/freeze"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"github.com/moby/swarmctl/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+// frozenClient returns labelClient with the freeze label set on the
+// services of the stack.
+func frozenClient(t *testing.T, updated map[string]map[string]string) *fakeClient {
+	client := labelClient(t, updated)
+	list := client.serviceListFunc
+	client.serviceListFunc = func(options types.ServiceListOptions) ([]swarm.Service, error) {
+		services, err := list(options)
+		for _, s := range services {
+			s.Spec.Labels[freeze.Label] = "black friday"
+		}
+		return services, err
+	}
+	return client
+}
+
+func TestFreeze(t *testing.T) {
+	updated := map[string]map[string]string{}
+	cli := test.NewFakeCli(labelClient(t, updated))
+	cmd := NewStackCommand(cli)
+	cmd.SetArgs([]string{"freeze", "shop", "--reason", "black friday"})
+	assert.NilError(t, cmd.Execute())
+
+	assert.Check(t, is.DeepEqual(updated, map[string]map[string]string{
+		"id-web":  {swarmlabel.Namespace: "shop", "team": "front", freeze.Label: "black friday"},
+		"id-api":  {swarmlabel.Namespace: "shop", "team": "back", "owner": "payments", freeze.Label: "black friday"},
+		"id-conf": {swarmlabel.Namespace: "shop", freeze.Label: "black friday"},
+	}))
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "service shop_api\nservice shop_web\nconfig shop_conf\n"))
+}
+
+func TestUnfreeze(t *testing.T) {
+	updated := map[string]map[string]string{}
+	cli := test.NewFakeCli(frozenClient(t, updated))
+	cmd := NewStackCommand(cli)
+	cmd.SetArgs([]string{"unfreeze", "shop"})
+	assert.NilError(t, cmd.Execute())
+
+	assert.Check(t, is.DeepEqual(updated, map[string]map[string]string{
+		"id-web": {swarmlabel.Namespace: "shop", "team": "front"},
+		"id-api": {swarmlabel.Namespace: "shop", "team": "back", "owner": "payments"},
+	}))
+}
+
+func TestLabelFrozen(t *testing.T) {
+	updated := map[string]map[string]string{}
+	cmd := newLabelAddCommand(test.NewFakeCli(frozenClient(t, updated)))
+	cmd.SetArgs([]string{"shop", "owner=payments"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "service shop_api belongs to frozen stack shop (black friday), use --override-freeze to change it anyway")
+	assert.Check(t, is.Len(updated, 0))
+
+	cmd = newLabelAddCommand(test.NewFakeCli(frozenClient(t, updated)))
+	cmd.SetArgs([]string{"shop", "owner=payments", "--override-freeze"})
+	cmd.SetOut(io.Discard)
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Len(updated, 1))
+}
+
+func TestPruneFrozen(t *testing.T) {
+	client := pruneClient()
+	list := client.secretListFunc
+	client.secretListFunc = func(options types.SecretListOptions) ([]swarm.Secret, error) {
+		secrets, err := list(options)
+		for _, s := range secrets {
+			s.Spec.Labels[freeze.Label] = ""
+		}
+		return secrets, err
+	}
+	cmd := newPruneCommand(test.NewFakeCli(client))
+	cmd.SetArgs([]string{"blog", "--force"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "secret blog_db_password belongs to frozen stack blog, use --override-freeze to change it anyway")
+	assert.Check(t, is.Len(client.removed, 0))
+
+	cmd = newPruneCommand(test.NewFakeCli(client))
+	cmd.SetArgs([]string{"blog", "--force", "--override-freeze"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.DeepEqual(client.removed, []string{"network id-blog-net", "secret id-blog-secret"}))
+}
@@ -0,0 +1,102 @@
+package stack
+
+import (
+	"context"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/graph"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type graphOptions struct {
+	stack  string
+	output string
+}
+
+func newGraphCommand(dockerCli command.Cli) *cobra.Command {
+	opts := graphOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "graph [OPTIONS] STACK",
+		Short: "Render the services of a stack and the objects they use as a graph",
+		Long: `Render the services of a stack and the objects they use as a graph.
+
+The graph links each service to its networks, configs and secrets, as
+deployed. It is printed in the Graphviz dot language, as a Mermaid flowchart
+with -o mermaid, or as JSON with -o json.`,
+		Args: cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.stack = args[0]
+			return runGraph(cmd.Context(), dockerCli, opts)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&opts.output, "output", "o", graph.FormatDot, `Output format ("dot", "mermaid", "json")`)
+	return cmd
+}
+
+func runGraph(ctx context.Context, dockerCli command.Cli, opts graphOptions) error {
+	switch opts.output {
+	case graph.FormatDot, graph.FormatMermaid, graph.FormatJSON:
+	default:
+		return errors.Errorf("invalid output format %q, must be one of dot, mermaid, json", opts.output)
+	}
+
+	client := dockerCli.Client()
+	services, err := client.ServiceList(ctx, types.ServiceListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", swarmlabel.Namespace+"="+opts.stack)),
+	})
+	if err != nil {
+		return err
+	}
+	if len(services) == 0 {
+		return errors.Errorf("nothing found in stack: %s", opts.stack)
+	}
+	networks, err := client.NetworkList(ctx, types.NetworkListOptions{})
+	if err != nil {
+		return err
+	}
+	networkNames := make(map[string]string, len(networks))
+	for _, n := range networks {
+		networkNames[n.ID] = n.Name
+	}
+
+	return graph.Write(dockerCli.Out(), buildGraph(opts.stack, services, networkNames), opts.output)
+}
+
+// buildGraph returns the graph of the services of a stack. Networks are
+// named after networkNames, and by ID if missing.
+func buildGraph(name string, services []swarm.Service, networkNames map[string]string) *graph.Graph {
+	g := &graph.Graph{Name: name}
+	for _, service := range services {
+		from := g.AddNode(graph.KindService, service.Spec.Name, service.Spec.Name)
+		for _, attachment := range service.Spec.TaskTemplate.Networks {
+			networkName, ok := networkNames[attachment.Target]
+			if !ok {
+				networkName = attachment.Target
+			}
+			g.AddEdge(from, g.AddNode(graph.KindNetwork, networkName, networkName), "", false)
+		}
+		containerSpec := service.Spec.TaskTemplate.ContainerSpec
+		if containerSpec == nil {
+			continue
+		}
+		for _, config := range containerSpec.Configs {
+			g.AddEdge(from, g.AddNode(graph.KindConfig, config.ConfigName, config.ConfigName), "", true)
+		}
+		for _, secret := range containerSpec.Secrets {
+			g.AddEdge(from, g.AddNode(graph.KindSecret, secret.SecretName, secret.SecretName), "", true)
+		}
+	}
+	g.Sort()
+	return g
+}
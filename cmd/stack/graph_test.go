@@ -0,0 +1,70 @@
+package stack
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"github.com/moby/swarmctl/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func graphClient(t *testing.T) *fakeClient {
+	return &fakeClient{
+		serviceListFunc: func(options types.ServiceListOptions) ([]swarm.Service, error) {
+			assert.Check(t, is.DeepEqual(options.Filters.Get("label"), []string{swarmlabel.Namespace + "=shop"}))
+			return []swarm.Service{
+				{
+					Spec: swarm.ServiceSpec{
+						Annotations: swarm.Annotations{Name: "shop_web"},
+						TaskTemplate: swarm.TaskSpec{
+							ContainerSpec: &swarm.ContainerSpec{
+								Configs: []*swarm.ConfigReference{{ConfigName: "shop_nginx.conf"}},
+							},
+							Networks: []swarm.NetworkAttachmentConfig{{Target: "net1"}, {Target: "net2"}},
+						},
+					},
+				},
+				{
+					Spec: swarm.ServiceSpec{
+						Annotations: swarm.Annotations{Name: "shop_db"},
+						TaskTemplate: swarm.TaskSpec{
+							ContainerSpec: &swarm.ContainerSpec{
+								Secrets: []*swarm.SecretReference{{SecretName: "shop_db-password"}},
+							},
+							Networks: []swarm.NetworkAttachmentConfig{{Target: "net2"}},
+						},
+					},
+				},
+			}, nil
+		},
+		networkListFunc: func(types.NetworkListOptions) ([]types.NetworkResource, error) {
+			return []types.NetworkResource{{ID: "net1", Name: "shop_front"}, {ID: "net2", Name: "shop_back"}}, nil
+		},
+	}
+}
+
+func TestGraph(t *testing.T) {
+	for _, output := range []string{"dot", "mermaid"} {
+		t.Run(output, func(t *testing.T) {
+			cli := test.NewFakeCli(graphClient(t))
+			cmd := newGraphCommand(cli)
+			cmd.SetArgs([]string{"shop", "-o", output})
+			assert.NilError(t, cmd.Execute())
+			test.AssertGolden(t, cli.OutBuffer().String(), fmt.Sprintf("graph-%s.golden", output))
+		})
+	}
+}
+
+func TestGraphErrors(t *testing.T) {
+	cmd := newGraphCommand(test.NewFakeCli(graphClient(t)))
+	cmd.SetArgs([]string{"shop", "-o", "svg"})
+	assert.Error(t, cmd.Execute(), `invalid output format "svg", must be one of dot, mermaid, json`)
+
+	cmd = newGraphCommand(test.NewFakeCli(&fakeClient{}))
+	cmd.SetArgs([]string{"shop"})
+	assert.Error(t, cmd.Execute(), "nothing found in stack: shop")
+}
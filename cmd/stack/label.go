@@ -0,0 +1,258 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	"github.com/moby/swarmctl/internal/freeze"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func newLabelCommand(dockerCli command.Cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "label",
+		Short: "Manage the labels of the services of a stack",
+		Args:  cli.NoArgs,
+		RunE:  command.ShowHelp(dockerCli.Err()),
+	}
+	cmd.AddCommand(
+		newLabelAddCommand(dockerCli),
+		newLabelRemoveCommand(dockerCli),
+	)
+	return cmd
+}
+
+type labelOptions struct {
+	stack          string
+	add            map[string]string
+	remove         []string
+	configs        bool
+	secrets        bool
+	overrideFreeze bool
+}
+
+func addLabelFlags(cmd *cobra.Command, opts *labelOptions) {
+	flags := cmd.Flags()
+	flags.BoolVar(&opts.configs, "configs", false, "Also change the labels of the configs of the stack")
+	flags.BoolVar(&opts.secrets, "secrets", false, "Also change the labels of the secrets of the stack")
+	freeze.AddFlag(flags, &opts.overrideFreeze)
+}
+
+func newLabelAddCommand(dockerCli command.Cli) *cobra.Command {
+	opts := labelOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "add [OPTIONS] STACK KEY[=VALUE] [KEY[=VALUE]...]",
+		Short: "Add or update labels on every service of a stack",
+		Long: `Add or update labels on every service of a stack.
+
+The services are updated concurrently, and only if their labels change. The
+labels of the networks of a stack cannot be changed once they are created.`,
+		Args: cli.RequiresMinArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.stack = args[0]
+			opts.add = make(map[string]string, len(args)-1)
+			for _, arg := range args[1:] {
+				key, value, _ := strings.Cut(arg, "=")
+				if key == "" {
+					return errors.Errorf("invalid label %q, must be KEY[=VALUE]", arg)
+				}
+				opts.add[key] = value
+			}
+			return runLabel(cmd.Context(), dockerCli, opts)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+	addLabelFlags(cmd, &opts)
+	return cmd
+}
+
+func newLabelRemoveCommand(dockerCli command.Cli) *cobra.Command {
+	opts := labelOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "rm [OPTIONS] STACK KEY [KEY...]",
+		Aliases: []string{"remove"},
+		Short:   "Remove labels from every service of a stack",
+		Args:    cli.RequiresMinArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.stack = args[0]
+			for _, key := range args[1:] {
+				if key == swarmlabel.Namespace {
+					return errors.Errorf("label %s cannot be removed, it ties the objects to their stack", key)
+				}
+			}
+			opts.remove = args[1:]
+			return runLabel(cmd.Context(), dockerCli, opts)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+	addLabelFlags(cmd, &opts)
+	return cmd
+}
+
+// labelTarget is an object of a stack whose labels can be changed.
+type labelTarget struct {
+	kind   string
+	name   string
+	labels map[string]string
+	// update sets the labels of the object, returning the warnings of the
+	// engine.
+	update func(ctx context.Context, labels map[string]string) ([]string, error)
+}
+
+func runLabel(ctx context.Context, dockerCli command.Cli, opts labelOptions) error {
+	targets, err := labelTargets(ctx, dockerCli.Client(), opts)
+	if err != nil {
+		return err
+	}
+	for _, target := range targets {
+		if err := freeze.Check(target.kind, target.name, target.labels, opts.overrideFreeze); err != nil {
+			return err
+		}
+	}
+
+	errs := make([]error, len(targets))
+	warnings := make([][]string, len(targets))
+	updated := make([]bool, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		labels, changed := changeLabels(target.labels, opts.add, opts.remove)
+		if !changed {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, target labelTarget) {
+			defer wg.Done()
+			var err error
+			if warnings[i], err = target.update(ctx, labels); err != nil {
+				errs[i] = errors.Wrapf(err, "unable to update %s %s", target.kind, target.name)
+				return
+			}
+			updated[i] = true
+		}(i, target)
+	}
+	wg.Wait()
+
+	var messages []string
+	for i, target := range targets {
+		for _, warning := range warnings[i] {
+			fmt.Fprintln(dockerCli.Err(), warning)
+		}
+		if updated[i] {
+			fmt.Fprintf(dockerCli.Out(), "%s %s\n", target.kind, target.name)
+		}
+		if errs[i] != nil {
+			messages = append(messages, errs[i].Error())
+		}
+	}
+	if len(messages) > 0 {
+		return errors.New(strings.Join(messages, "\n"))
+	}
+	return nil
+}
+
+// labelTargets returns the services of the stack, then its configs and
+// secrets if selected, each ordered by name.
+func labelTargets(ctx context.Context, apiClient client.APIClient, opts labelOptions) ([]labelTarget, error) {
+	f := filters.NewArgs(filters.Arg("label", swarmlabel.Namespace+"="+opts.stack))
+	services, err := apiClient.ServiceList(ctx, types.ServiceListOptions{Filters: f})
+	if err != nil {
+		return nil, err
+	}
+	if len(services) == 0 {
+		return nil, errors.Errorf("nothing found in stack: %s", opts.stack)
+	}
+
+	var targets []labelTarget
+	sort.Slice(services, func(i, j int) bool { return services[i].Spec.Name < services[j].Spec.Name })
+	for _, service := range services {
+		service := service
+		targets = append(targets, labelTarget{
+			kind:   "service",
+			name:   service.Spec.Name,
+			labels: service.Spec.Labels,
+			update: func(ctx context.Context, labels map[string]string) ([]string, error) {
+				spec := service.Spec
+				spec.Labels = labels
+				response, err := apiClient.ServiceUpdate(ctx, service.ID, service.Version, spec, types.ServiceUpdateOptions{})
+				return response.Warnings, err
+			},
+		})
+	}
+	if opts.configs {
+		configs, err := apiClient.ConfigList(ctx, types.ConfigListOptions{Filters: f})
+		if err != nil {
+			return nil, err
+		}
+		sort.Slice(configs, func(i, j int) bool { return configs[i].Spec.Name < configs[j].Spec.Name })
+		for _, config := range configs {
+			config := config
+			targets = append(targets, labelTarget{
+				kind:   "config",
+				name:   config.Spec.Name,
+				labels: config.Spec.Labels,
+				update: func(ctx context.Context, labels map[string]string) ([]string, error) {
+					spec := config.Spec
+					spec.Labels = labels
+					return nil, apiClient.ConfigUpdate(ctx, config.ID, config.Version, spec)
+				},
+			})
+		}
+	}
+	if opts.secrets {
+		secrets, err := apiClient.SecretList(ctx, types.SecretListOptions{Filters: f})
+		if err != nil {
+			return nil, err
+		}
+		sort.Slice(secrets, func(i, j int) bool { return secrets[i].Spec.Name < secrets[j].Spec.Name })
+		for _, secret := range secrets {
+			secret := secret
+			targets = append(targets, labelTarget{
+				kind:   "secret",
+				name:   secret.Spec.Name,
+				labels: secret.Spec.Labels,
+				update: func(ctx context.Context, labels map[string]string) ([]string, error) {
+					spec := secret.Spec
+					spec.Labels = labels
+					return nil, apiClient.SecretUpdate(ctx, secret.ID, secret.Version, spec)
+				},
+			})
+		}
+	}
+	return targets, nil
+}
+
+// changeLabels returns a copy of labels with the labels of add set and the
+// keys of remove removed, and whether they changed.
+func changeLabels(labels map[string]string, add map[string]string, remove []string) (map[string]string, bool) {
+	changed := false
+	result := make(map[string]string, len(labels)+len(add))
+	for k, v := range labels {
+		result[k] = v
+	}
+	for k, v := range add {
+		if current, ok := result[k]; !ok || current != v {
+			result[k] = v
+			changed = true
+		}
+	}
+	for _, k := range remove {
+		if _, ok := result[k]; ok {
+			delete(result, k)
+			changed = true
+		}
+	}
+	return result, changed
+}
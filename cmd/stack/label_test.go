@@ -0,0 +1,129 @@
+package stack
+
+import (
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"github.com/moby/swarmctl/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+// labelClient returns a client for a stack of two services and a config,
+// recording the labels they are updated with.
+func labelClient(t *testing.T, updated map[string]map[string]string) *fakeClient {
+	var mu sync.Mutex
+	service := func(id, name string, labels map[string]string) swarm.Service {
+		labels[swarmlabel.Namespace] = "shop"
+		return swarm.Service{
+			ID:   id,
+			Meta: swarm.Meta{Version: swarm.Version{Index: 7}},
+			Spec: swarm.ServiceSpec{Annotations: swarm.Annotations{Name: name, Labels: labels}},
+		}
+	}
+	return &fakeClient{
+		serviceListFunc: func(options types.ServiceListOptions) ([]swarm.Service, error) {
+			if options.Filters.Get("label")[0] != swarmlabel.Namespace+"=shop" {
+				return nil, nil
+			}
+			return []swarm.Service{
+				service("id-web", "shop_web", map[string]string{"team": "front"}),
+				service("id-api", "shop_api", map[string]string{"team": "back", "owner": "payments"}),
+			}, nil
+		},
+		configListFunc: func(types.ConfigListOptions) ([]swarm.Config, error) {
+			return []swarm.Config{{
+				ID:   "id-conf",
+				Spec: swarm.ConfigSpec{Annotations: swarm.Annotations{Name: "shop_conf", Labels: map[string]string{swarmlabel.Namespace: "shop"}}},
+			}}, nil
+		},
+		serviceUpdateFunc: func(serviceID string, version swarm.Version, spec swarm.ServiceSpec) (types.ServiceUpdateResponse, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Check(t, is.Equal(version.Index, uint64(7)))
+			updated[serviceID] = spec.Labels
+			return types.ServiceUpdateResponse{}, nil
+		},
+		configUpdateFunc: func(id string, version swarm.Version, spec swarm.ConfigSpec) error {
+			mu.Lock()
+			defer mu.Unlock()
+			updated[id] = spec.Labels
+			return nil
+		},
+	}
+}
+
+func TestLabelAdd(t *testing.T) {
+	updated := map[string]map[string]string{}
+	cli := test.NewFakeCli(labelClient(t, updated))
+	cmd := newLabelAddCommand(cli)
+	cmd.SetArgs([]string{"shop", "owner=payments", "cost-center=42", "--configs"})
+	assert.NilError(t, cmd.Execute())
+
+	assert.Check(t, is.DeepEqual(updated, map[string]map[string]string{
+		"id-web":  {swarmlabel.Namespace: "shop", "team": "front", "owner": "payments", "cost-center": "42"},
+		"id-api":  {swarmlabel.Namespace: "shop", "team": "back", "owner": "payments", "cost-center": "42"},
+		"id-conf": {swarmlabel.Namespace: "shop", "owner": "payments", "cost-center": "42"},
+	}))
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "service shop_api\nservice shop_web\nconfig shop_conf\n"))
+}
+
+func TestLabelRemove(t *testing.T) {
+	updated := map[string]map[string]string{}
+	cli := test.NewFakeCli(labelClient(t, updated))
+	cmd := newLabelRemoveCommand(cli)
+	cmd.SetArgs([]string{"shop", "owner"})
+	assert.NilError(t, cmd.Execute())
+
+	// Only the services whose labels change are updated.
+	assert.Check(t, is.DeepEqual(updated, map[string]map[string]string{
+		"id-api": {swarmlabel.Namespace: "shop", "team": "back"},
+	}))
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "service shop_api\n"))
+}
+
+func TestLabelErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		args     []string
+		remove   bool
+		expected string
+	}{
+		{
+			name:     "unknown-stack",
+			args:     []string{"blog", "owner=payments"},
+			expected: "nothing found in stack: blog",
+		},
+		{
+			name:     "invalid-label",
+			args:     []string{"shop", "=payments"},
+			expected: `invalid label "=payments", must be KEY[=VALUE]`,
+		},
+		{
+			name:     "namespace",
+			args:     []string{"shop", swarmlabel.Namespace},
+			remove:   true,
+			expected: "label " + swarmlabel.Namespace + " cannot be removed, it ties the objects to their stack",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			updated := map[string]map[string]string{}
+			cli := test.NewFakeCli(labelClient(t, updated))
+			cmd := newLabelAddCommand(cli)
+			if tc.remove {
+				cmd = newLabelRemoveCommand(cli)
+			}
+			cmd.SetArgs(tc.args)
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			assert.Error(t, cmd.Execute(), tc.expected)
+			assert.Check(t, is.Len(updated, 0))
+		})
+	}
+}
@@ -0,0 +1,319 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/cli/opts"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-units"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// now returns the current time; tests replace it.
+var now = time.Now
+
+type listOptions struct {
+	filter opts.FilterOpt
+	counts bool
+}
+
+func newListCommand(dockerCli command.Cli) *cobra.Command {
+	options := listOptions{filter: opts.NewFilterOpt()}
+
+	cmd := &cobra.Command{
+		Use:     "ls [OPTIONS]",
+		Aliases: []string{"list"},
+		Short:   "List the deployed stacks",
+		Long: `List the deployed stacks.
+
+Stacks are found from the namespace label of their services. UPDATED is the
+last time a service of the stack was created or updated.
+
+With --counts, the running and desired tasks of the stacks are shown with
+their networks, configs and secrets. ORPHANS counts the networks, configs
+and secrets that no service of the stack uses, and the stacks that only have
+such objects left are listed too.
+
+The "name" filter matches stack names with a shell pattern ("shop*"). The
+"label" filter ("key" or "key=value") selects the stacks that have at least
+one service with the label. Several name filters select the stacks matching
+any of them, several label filters the stacks matching all of them.`,
+		Args: cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runList(cmd.Context(), dockerCli, options)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	flags := cmd.Flags()
+	flags.VarP(&options.filter, "filter", "f", `Filter output based on conditions provided ("name", "label")`)
+	flags.BoolVar(&options.counts, "counts", false, "Show the tasks, networks, configs, secrets and orphaned objects of the stacks")
+	return cmd
+}
+
+// stackSummary describes a deployed stack.
+type stackSummary struct {
+	name     string
+	services []swarm.Service
+	updated  time.Time
+
+	// Set with --counts only.
+	runningTasks uint64
+	desiredTasks uint64
+	networks     int
+	configs      int
+	secrets      int
+	orphans      int
+}
+
+func runList(ctx context.Context, dockerCli command.Cli, options listOptions) error {
+	match, err := stackMatcher(options.filter.Value())
+	if err != nil {
+		return err
+	}
+	objects, err := listStackObjects(ctx, dockerCli.Client(), options.counts)
+	if err != nil {
+		return err
+	}
+
+	summaries := make(map[string]*stackSummary)
+	summary := func(name string) *stackSummary {
+		s, ok := summaries[name]
+		if !ok {
+			s = &stackSummary{name: name}
+			summaries[name] = s
+		}
+		return s
+	}
+	for _, service := range objects.services {
+		s := summary(service.Spec.Labels[swarmlabel.Namespace])
+		s.services = append(s.services, service)
+		if service.UpdatedAt.After(s.updated) {
+			s.updated = service.UpdatedAt
+		}
+		if service.ServiceStatus != nil {
+			s.runningTasks += service.ServiceStatus.RunningTasks
+			s.desiredTasks += service.ServiceStatus.DesiredTasks
+		}
+	}
+	for _, n := range objects.networks {
+		s := summary(n.Labels[swarmlabel.Namespace])
+		s.networks++
+		if !usesNetwork(s.services, n) {
+			s.orphans++
+		}
+	}
+	for _, c := range objects.configs {
+		s := summary(c.Spec.Labels[swarmlabel.Namespace])
+		s.configs++
+		if !usesConfig(s.services, c.ID) {
+			s.orphans++
+		}
+	}
+	for _, secret := range objects.secrets {
+		s := summary(secret.Spec.Labels[swarmlabel.Namespace])
+		s.secrets++
+		if !usesSecret(s.services, secret.ID) {
+			s.orphans++
+		}
+	}
+
+	var stacks []stackSummary
+	for name, s := range summaries {
+		if match(name, s.services) {
+			stacks = append(stacks, *s)
+		}
+	}
+	sort.Slice(stacks, func(i, j int) bool { return stacks[i].name < stacks[j].name })
+	if options.counts {
+		return printStackCounts(dockerCli.Out(), stacks)
+	}
+	return printStacks(dockerCli.Out(), stacks)
+}
+
+// stackObjects are the objects carrying a stack namespace label.
+type stackObjects struct {
+	services []swarm.Service
+	networks []types.NetworkResource
+	configs  []swarm.Config
+	secrets  []swarm.Secret
+}
+
+// listStackObjects lists the services of the stacks, and their networks,
+// configs and secrets if withCounts is set. The lists are made concurrently.
+func listStackObjects(ctx context.Context, apiClient client.APIClient, withCounts bool) (stackObjects, error) {
+	f := filters.NewArgs(filters.Arg("label", swarmlabel.Namespace))
+	if !withCounts {
+		services, err := apiClient.ServiceList(ctx, types.ServiceListOptions{Filters: f})
+		return stackObjects{services: services}, err
+	}
+
+	var (
+		objects stackObjects
+		wg      sync.WaitGroup
+		errs    = make([]error, 4)
+	)
+	lists := []func() error{
+		func() (err error) {
+			objects.services, err = apiClient.ServiceList(ctx, types.ServiceListOptions{Filters: f, Status: true})
+			return err
+		},
+		func() (err error) {
+			objects.networks, err = apiClient.NetworkList(ctx, types.NetworkListOptions{Filters: f})
+			return err
+		},
+		func() (err error) {
+			objects.configs, err = apiClient.ConfigList(ctx, types.ConfigListOptions{Filters: f})
+			return err
+		},
+		func() (err error) {
+			objects.secrets, err = apiClient.SecretList(ctx, types.SecretListOptions{Filters: f})
+			return err
+		},
+	}
+	for i, list := range lists {
+		wg.Add(1)
+		go func(i int, list func() error) {
+			defer wg.Done()
+			errs[i] = list()
+		}(i, list)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return stackObjects{}, err
+		}
+	}
+	return objects, nil
+}
+
+// usesNetwork returns whether one of the services is attached to the
+// network, given by ID or name in the service specs.
+func usesNetwork(services []swarm.Service, n types.NetworkResource) bool {
+	for _, s := range services {
+		for _, a := range s.Spec.TaskTemplate.Networks {
+			if a.Target == n.ID || a.Target == n.Name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func usesConfig(services []swarm.Service, id string) bool {
+	for _, s := range services {
+		if s.Spec.TaskTemplate.ContainerSpec == nil {
+			continue
+		}
+		for _, c := range s.Spec.TaskTemplate.ContainerSpec.Configs {
+			if c.ConfigID == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func usesSecret(services []swarm.Service, id string) bool {
+	for _, s := range services {
+		if s.Spec.TaskTemplate.ContainerSpec == nil {
+			continue
+		}
+		for _, secret := range s.Spec.TaskTemplate.ContainerSpec.Secrets {
+			if secret.SecretID == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// stackMatcher returns a function telling whether a stack, given by its
+// name and services, matches the filters.
+func stackMatcher(args filters.Args) (func(name string, services []swarm.Service) bool, error) {
+	for _, key := range args.Keys() {
+		if key != "name" && key != "label" {
+			return nil, errors.Errorf("invalid filter '%s'", key)
+		}
+	}
+	patterns := args.Get("name")
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, errors.Errorf("invalid name filter %q: %s", pattern, err)
+		}
+	}
+	return func(name string, services []swarm.Service) bool {
+		if len(patterns) > 0 && !matchAny(patterns, name) {
+			return false
+		}
+		for _, label := range args.Get("label") {
+			if !anyServiceLabeled(services, label) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// anyServiceLabeled returns whether one of the services has the label,
+// given as "key" or "key=value".
+func anyServiceLabeled(services []swarm.Service, label string) bool {
+	key, value, hasValue := strings.Cut(label, "=")
+	for _, s := range services {
+		if v, ok := s.Spec.Labels[key]; ok && (!hasValue || v == value) {
+			return true
+		}
+	}
+	return false
+}
+
+func printStacks(out io.Writer, stacks []stackSummary) error {
+	w := tabwriter.NewWriter(out, 10, 1, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSERVICES\tUPDATED")
+	for _, s := range stacks {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", s.name, len(s.services), formatUpdated(s.updated))
+	}
+	return w.Flush()
+}
+
+func printStackCounts(out io.Writer, stacks []stackSummary) error {
+	w := tabwriter.NewWriter(out, 10, 1, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSERVICES\tTASKS\tNETWORKS\tCONFIGS\tSECRETS\tORPHANS\tUPDATED")
+	for _, s := range stacks {
+		fmt.Fprintf(w, "%s\t%d\t%d/%d\t%d\t%d\t%d\t%d\t%s\n", s.name, len(s.services), s.runningTasks, s.desiredTasks,
+			s.networks, s.configs, s.secrets, s.orphans, formatUpdated(s.updated))
+	}
+	return w.Flush()
+}
+
+func formatUpdated(updated time.Time) string {
+	if updated.IsZero() {
+		return "-"
+	}
+	return units.HumanDuration(now().Sub(updated)) + " ago"
+}
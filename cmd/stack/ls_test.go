@@ -0,0 +1,139 @@
+package stack
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"github.com/moby/swarmctl/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+var listNow = time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func listService(id, stack, name string, updated time.Duration, labels map[string]string) swarm.Service {
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[swarmlabel.Namespace] = stack
+	return swarm.Service{
+		ID:   id,
+		Meta: swarm.Meta{UpdatedAt: listNow.Add(-updated)},
+		Spec: swarm.ServiceSpec{Annotations: swarm.Annotations{Name: stack + "_" + name, Labels: labels}},
+	}
+}
+
+// listClient returns a client for the shop and blog stacks.
+func listClient(t *testing.T) *fakeClient {
+	return &fakeClient{
+		serviceListFunc: func(options types.ServiceListOptions) ([]swarm.Service, error) {
+			assert.Check(t, is.DeepEqual(options.Filters.Get("label"), []string{swarmlabel.Namespace}))
+			return []swarm.Service{
+				listService("id-shop-web", "shop", "web", 2*time.Hour, map[string]string{"team": "front"}),
+				listService("id-blog-web", "blog", "web", 72*time.Hour, nil),
+				listService("id-shop-api", "shop", "api", 30*time.Minute, map[string]string{"team": "back"}),
+				listService("id-shopify-web", "shopify", "web", 5*time.Minute, map[string]string{"team": "front"}),
+			}, nil
+		},
+	}
+}
+
+func TestList(t *testing.T) {
+	defer func() { now = time.Now }()
+	now = func() time.Time { return listNow }
+
+	testCases := []struct {
+		name    string
+		filters []string
+	}{
+		{name: "all"},
+		{name: "name", filters: []string{"name=shop*", "name=blog"}},
+		{name: "label", filters: []string{"label=team", "label=team=back"}},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			cli := test.NewFakeCli(listClient(t))
+			cmd := newListCommand(cli)
+			args := []string{}
+			for _, f := range tc.filters {
+				args = append(args, "--filter", f)
+			}
+			cmd.SetArgs(args)
+			assert.NilError(t, cmd.Execute())
+			test.AssertGolden(t, cli.OutBuffer().String(), "ls-"+tc.name+".golden")
+		})
+	}
+}
+
+func TestListErrors(t *testing.T) {
+	testCases := []struct {
+		filter   string
+		expected string
+	}{
+		{filter: "mode=global", expected: "invalid filter 'mode'"},
+		{filter: "name=[shop", expected: `invalid name filter "[shop": syntax error in pattern`},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.filter, func(t *testing.T) {
+			cmd := newListCommand(test.NewFakeCli(listClient(t)))
+			cmd.SetArgs([]string{"--filter", tc.filter})
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			assert.Error(t, cmd.Execute(), tc.expected)
+		})
+	}
+}
+
+func TestListCounts(t *testing.T) {
+	defer func() { now = time.Now }()
+	now = func() time.Time { return listNow }
+
+	client := listClient(t)
+	list := client.serviceListFunc
+	client.serviceListFunc = func(options types.ServiceListOptions) ([]swarm.Service, error) {
+		assert.Check(t, options.Status)
+		services, err := list(options)
+		for i := range services {
+			services[i].ServiceStatus = &swarm.ServiceStatus{RunningTasks: 1, DesiredTasks: 2}
+		}
+		// shop_web uses the shop_front network, by name, and the shop_nginx config.
+		services[0].Spec.TaskTemplate = swarm.TaskSpec{
+			ContainerSpec: &swarm.ContainerSpec{Configs: []*swarm.ConfigReference{{ConfigID: "id-nginx"}}},
+			Networks:      []swarm.NetworkAttachmentConfig{{Target: "shop_front"}},
+		}
+		return services, err
+	}
+	stack := func(name string) map[string]string {
+		return map[string]string{swarmlabel.Namespace: name}
+	}
+	client.networkListFunc = func(types.NetworkListOptions) ([]types.NetworkResource, error) {
+		return []types.NetworkResource{
+			{ID: "id-front", Name: "shop_front", Labels: stack("shop")},
+			{ID: "id-back", Name: "shop_back", Labels: stack("shop")},
+			{ID: "id-legacy", Name: "legacy_default", Labels: stack("legacy")},
+		}, nil
+	}
+	client.configListFunc = func(types.ConfigListOptions) ([]swarm.Config, error) {
+		return []swarm.Config{
+			{ID: "id-nginx", Spec: swarm.ConfigSpec{Annotations: swarm.Annotations{Name: "shop_nginx", Labels: stack("shop")}}},
+		}, nil
+	}
+	client.secretListFunc = func(types.SecretListOptions) ([]swarm.Secret, error) {
+		return []swarm.Secret{
+			{ID: "id-key", Spec: swarm.SecretSpec{Annotations: swarm.Annotations{Name: "shop_key", Labels: stack("shop")}}},
+			{ID: "id-legacy-db", Spec: swarm.SecretSpec{Annotations: swarm.Annotations{Name: "legacy_db", Labels: stack("legacy")}}},
+		}, nil
+	}
+
+	cli := test.NewFakeCli(client)
+	cmd := newListCommand(cli)
+	cmd.SetArgs([]string{"--counts"})
+	assert.NilError(t, cmd.Execute())
+	test.AssertGolden(t, cli.OutBuffer().String(), "ls-counts.golden")
+}
@@ -0,0 +1,199 @@
+package stack
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	"github.com/moby/swarmctl/internal/freeze"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type pruneOptions struct {
+	stacks         []string
+	all            bool
+	dryRun         bool
+	force          bool
+	overrideFreeze bool
+}
+
+func newPruneCommand(dockerCli command.Cli) *cobra.Command {
+	opts := pruneOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "prune [OPTIONS] STACK [STACK...]|--all",
+		Short: "Remove the networks, configs and secrets left by stacks without services",
+		Long: `Remove the networks, configs and secrets left by stacks without services.
+
+Objects are left behind when the services of a stack are removed one by one,
+or when "stack rm" fails half way. The objects of a stack are only removed
+once it has no service left. They are listed and a confirmation is asked
+before removing them, unless --force is set.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 && opts.all {
+				return errors.New("stacks and --all cannot be used together")
+			}
+			if len(args) == 0 && !opts.all {
+				return errors.New("a stack or --all is required")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.stacks = args
+			return runPrune(cmd.Context(), dockerCli, opts)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVarP(&opts.all, "all", "a", false, "Prune every stack without services")
+	flags.BoolVar(&opts.dryRun, "dry-run", false, "Only list the objects that would be removed")
+	flags.BoolVarP(&opts.force, "force", "f", false, "Do not prompt for confirmation")
+	freeze.AddFlag(flags, &opts.overrideFreeze)
+	return cmd
+}
+
+// danglingObject is an object of a stack that has no service left.
+type danglingObject struct {
+	kind   string
+	id     string
+	name   string
+	stack  string
+	labels map[string]string
+	remove func(ctx context.Context) error
+}
+
+func runPrune(ctx context.Context, dockerCli command.Cli, opts pruneOptions) error {
+	objects, err := danglingObjects(ctx, dockerCli.Client(), opts.stacks)
+	if err != nil {
+		return err
+	}
+	if len(objects) == 0 {
+		fmt.Fprintln(dockerCli.Err(), "Nothing to prune")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(dockerCli.Out(), 10, 1, 3, ' ', 0)
+	fmt.Fprintln(w, "STACK\tTYPE\tNAME")
+	for _, o := range objects {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", o.stack, o.kind, o.name)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if opts.dryRun {
+		return nil
+	}
+	// Networks cannot carry the freeze label, the configs and secrets of
+	// their stack tell whether it is frozen.
+	for _, o := range objects {
+		if err := freeze.Check(o.kind, o.name, o.labels, opts.overrideFreeze); err != nil {
+			return err
+		}
+	}
+	if !opts.force && !command.PromptForConfirmation(dockerCli.In(), dockerCli.Out(), fmt.Sprintf("\nThe %d objects above will be removed.\nAre you sure you want to continue?", len(objects))) {
+		return errors.New("aborted, nothing was removed")
+	}
+
+	var errs []string
+	removed := 0
+	for _, o := range objects {
+		if err := o.remove(ctx); err != nil {
+			errs = append(errs, fmt.Sprintf("unable to remove %s %s: %s", o.kind, o.name, err))
+			continue
+		}
+		removed++
+	}
+	fmt.Fprintf(dockerCli.Err(), "Removed %d objects\n", removed)
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// danglingObjects returns the networks, configs and secrets of the stacks,
+// or of every stack if there are none, that have no service left, ordered
+// by stack, kind and name.
+func danglingObjects(ctx context.Context, apiClient client.APIClient, stacks []string) ([]danglingObject, error) {
+	f := filters.NewArgs(filters.Arg("label", swarmlabel.Namespace))
+	services, err := apiClient.ServiceList(ctx, types.ServiceListOptions{Filters: f})
+	if err != nil {
+		return nil, err
+	}
+	live := make(map[string]bool)
+	for _, s := range services {
+		live[s.Spec.Labels[swarmlabel.Namespace]] = true
+	}
+	selected := func(stack string) bool {
+		if stack == "" || live[stack] {
+			return false
+		}
+		if len(stacks) == 0 {
+			return true
+		}
+		for _, s := range stacks {
+			if s == stack {
+				return true
+			}
+		}
+		return false
+	}
+
+	var objects []danglingObject
+	networks, err := apiClient.NetworkList(ctx, types.NetworkListOptions{Filters: f})
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range networks {
+		if stack := n.Labels[swarmlabel.Namespace]; selected(stack) {
+			id := n.ID
+			objects = append(objects, danglingObject{kind: "network", id: id, name: n.Name, stack: stack, labels: n.Labels, remove: func(ctx context.Context) error {
+				return apiClient.NetworkRemove(ctx, id)
+			}})
+		}
+	}
+	configs, err := apiClient.ConfigList(ctx, types.ConfigListOptions{Filters: f})
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range configs {
+		if stack := c.Spec.Labels[swarmlabel.Namespace]; selected(stack) {
+			id := c.ID
+			objects = append(objects, danglingObject{kind: "config", id: id, name: c.Spec.Name, stack: stack, labels: c.Spec.Labels, remove: func(ctx context.Context) error {
+				return apiClient.ConfigRemove(ctx, id)
+			}})
+		}
+	}
+	secrets, err := apiClient.SecretList(ctx, types.SecretListOptions{Filters: f})
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range secrets {
+		if stack := s.Spec.Labels[swarmlabel.Namespace]; selected(stack) {
+			id := s.ID
+			objects = append(objects, danglingObject{kind: "secret", id: id, name: s.Spec.Name, stack: stack, labels: s.Spec.Labels, remove: func(ctx context.Context) error {
+				return apiClient.SecretRemove(ctx, id)
+			}})
+		}
+	}
+
+	sort.SliceStable(objects, func(i, j int) bool {
+		if objects[i].stack != objects[j].stack {
+			return objects[i].stack < objects[j].stack
+		}
+		if objects[i].kind != objects[j].kind {
+			return objects[i].kind < objects[j].kind
+		}
+		return objects[i].name < objects[j].name
+	})
+	return objects, nil
+}
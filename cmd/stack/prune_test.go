@@ -0,0 +1,84 @@
+package stack
+
+import (
+	"io"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"github.com/moby/swarmctl/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+// pruneClient returns a client for a live shop stack, and a blog stack and
+// a wiki stack whose services were removed.
+func pruneClient() *fakeClient {
+	annotations := func(name, stack string) swarm.Annotations {
+		return swarm.Annotations{Name: name, Labels: map[string]string{swarmlabel.Namespace: stack}}
+	}
+	return &fakeClient{
+		serviceListFunc: func(types.ServiceListOptions) ([]swarm.Service, error) {
+			return []swarm.Service{{ID: "id-web", Spec: swarm.ServiceSpec{Annotations: annotations("shop_web", "shop")}}}, nil
+		},
+		networkListFunc: func(types.NetworkListOptions) ([]types.NetworkResource, error) {
+			return []types.NetworkResource{
+				{ID: "id-shop-net", Name: "shop_default", Labels: map[string]string{swarmlabel.Namespace: "shop"}},
+				{ID: "id-blog-net", Name: "blog_default", Labels: map[string]string{swarmlabel.Namespace: "blog"}},
+			}, nil
+		},
+		configListFunc: func(types.ConfigListOptions) ([]swarm.Config, error) {
+			return []swarm.Config{
+				{ID: "id-shop-conf", Spec: swarm.ConfigSpec{Annotations: annotations("shop_nginx", "shop")}},
+				{ID: "id-wiki-conf", Spec: swarm.ConfigSpec{Annotations: annotations("wiki_nginx", "wiki")}},
+			}, nil
+		},
+		secretListFunc: func(types.SecretListOptions) ([]swarm.Secret, error) {
+			return []swarm.Secret{
+				{ID: "id-blog-secret", Spec: swarm.SecretSpec{Annotations: annotations("blog_db_password", "blog")}},
+			}, nil
+		},
+	}
+}
+
+func TestPruneAll(t *testing.T) {
+	client := pruneClient()
+	cli := test.NewFakeCli(client)
+	cli.SetInputScript("y")
+	cmd := newPruneCommand(cli)
+	cmd.SetArgs([]string{"--all"})
+	assert.NilError(t, cmd.Execute())
+	test.AssertGolden(t, cli.OutBuffer().String(), "prune-all.golden")
+	assert.Check(t, is.DeepEqual(client.removed, []string{"network id-blog-net", "secret id-blog-secret", "config id-wiki-conf"}))
+}
+
+func TestPruneStack(t *testing.T) {
+	client := pruneClient()
+	cli := test.NewFakeCli(client)
+	cmd := newPruneCommand(cli)
+	cmd.SetArgs([]string{"wiki", "shop", "--force"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.DeepEqual(client.removed, []string{"config id-wiki-conf"}))
+}
+
+func TestPruneDryRun(t *testing.T) {
+	client := pruneClient()
+	cli := test.NewFakeCli(client)
+	cmd := newPruneCommand(cli)
+	cmd.SetArgs([]string{"--all", "--dry-run"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Len(client.removed, 0))
+}
+
+func TestPruneAborted(t *testing.T) {
+	client := pruneClient()
+	cli := test.NewFakeCli(client)
+	cli.SetInputScript("n")
+	cmd := newPruneCommand(cli)
+	cmd.SetArgs([]string{"--all"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "aborted, nothing was removed")
+	assert.Check(t, is.Len(client.removed, 0))
+}
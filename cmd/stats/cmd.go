@@ -0,0 +1,21 @@
+package stats
+
+import (
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/spf13/cobra"
+)
+
+// NewStatsCommand returns a cobra command for `stats` subcommands
+func NewStatsCommand(dockerCli command.Cli) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show statistics about swarmctl itself",
+		Args:  cli.NoArgs,
+		RunE:  command.ShowHelp(dockerCli.Err()),
+	}
+	cmd.AddCommand(
+		newUsageCommand(dockerCli),
+	)
+	return cmd
+}
@@ -0,0 +1,117 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/moby/swarmctl/internal/usage"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// usageLog is the log of the commands run; tests replace it.
+var usageLog = usage.Default()
+
+// now returns the current time; tests replace it.
+var now = time.Now
+
+type usageOptions struct {
+	enable  bool
+	disable bool
+	since   time.Duration
+	export  bool
+}
+
+func newUsageCommand(dockerCli command.Cli) *cobra.Command {
+	opts := usageOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "usage [OPTIONS]",
+		Short: "Show how often swarmctl commands are run and how long they take",
+		Long: `Show how often swarmctl commands are run and how long they take.
+
+Commands are only recorded once enabled with --enable, to a log in the docker
+configuration directory that is never sent anywhere. Only the path of the
+commands is recorded, not their arguments or flags. --disable stops recording
+and removes the log. --anonymous-export prints the aggregate numbers as JSON,
+without the time of the runs, to share them.`,
+		Args: cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUsage(dockerCli, opts)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVar(&opts.enable, "enable", false, "Start recording the commands run")
+	flags.BoolVar(&opts.disable, "disable", false, "Stop recording the commands run and remove the log")
+	flags.DurationVar(&opts.since, "since", 0, "Only count the commands run within this duration (e.g. 168h)")
+	flags.BoolVar(&opts.export, "anonymous-export", false, "Print the aggregate numbers as JSON")
+	return cmd
+}
+
+// exportedStat is a command in the output of --anonymous-export.
+type exportedStat struct {
+	Command        string  `json:"command"`
+	Count          int     `json:"count"`
+	Failures       int     `json:"failures"`
+	AverageSeconds float64 `json:"average_seconds"`
+}
+
+func runUsage(dockerCli command.Cli, opts usageOptions) error {
+	switch {
+	case opts.enable && opts.disable:
+		return errors.New("--enable and --disable cannot be used together")
+	case opts.enable:
+		if err := usageLog.Enable(); err != nil {
+			return errors.Wrap(err, "unable to enable the usage log")
+		}
+		fmt.Fprintf(dockerCli.Err(), "Recording the commands run to %s\n", usageLog.Path())
+		return nil
+	case opts.disable:
+		if err := usageLog.Disable(); err != nil {
+			return errors.Wrap(err, "unable to disable the usage log")
+		}
+		fmt.Fprintln(dockerCli.Err(), "Stopped recording the commands run, the usage log was removed")
+		return nil
+	}
+
+	if !usageLog.Enabled() {
+		return errors.New(`the commands run are not recorded, enable it with "swarmctl stats usage --enable"`)
+	}
+	var since time.Time
+	if opts.since > 0 {
+		since = now().Add(-opts.since)
+	}
+	stats, err := usageLog.Stats(since)
+	if err != nil {
+		return err
+	}
+
+	if opts.export {
+		exported := make([]exportedStat, 0, len(stats))
+		for _, s := range stats {
+			exported = append(exported, exportedStat{
+				Command:        s.Command,
+				Count:          s.Count,
+				Failures:       s.Failures,
+				AverageSeconds: s.Average().Round(time.Millisecond).Seconds(),
+			})
+		}
+		enc := json.NewEncoder(dockerCli.Out())
+		enc.SetIndent("", "    ")
+		return enc.Encode(exported)
+	}
+
+	w := tabwriter.NewWriter(dockerCli.Out(), 10, 1, 3, ' ', 0)
+	fmt.Fprintln(w, "COMMAND\tRUNS\tFAILED\tAVERAGE\tTOTAL")
+	for _, s := range stats {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\n", s.Command, s.Count, s.Failures, s.Average().Round(time.Millisecond), s.Total.Round(time.Millisecond))
+	}
+	return w.Flush()
+}
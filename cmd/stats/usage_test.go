@@ -0,0 +1,64 @@
+package stats
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/moby/swarmctl/internal/test"
+	"github.com/moby/swarmctl/internal/usage"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestUsage(t *testing.T) {
+	defer func(l *usage.Log) { usageLog = l }(usageLog)
+	usageLog = usage.New(filepath.Join(t.TempDir(), "usage.jsonl"))
+	defer func(f func() time.Time) { now = f }(now)
+	start := time.Date(2023, 1, 2, 15, 0, 0, 0, time.UTC)
+	now = func() time.Time { return start.Add(3 * time.Hour) }
+
+	cli := test.NewFakeCli(nil)
+	cmd := newUsageCommand(cli)
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), `the commands run are not recorded, enable it with "swarmctl stats usage --enable"`)
+
+	cmd = newUsageCommand(cli)
+	cmd.SetArgs([]string{"--enable"})
+	assert.NilError(t, cmd.Execute())
+	for _, e := range []usage.Entry{
+		{Time: start, Command: "swarmctl version", Duration: time.Second},
+		{Time: start.Add(time.Hour), Command: "swarmctl get", Duration: 1500 * time.Millisecond},
+		{Time: start.Add(2 * time.Hour), Command: "swarmctl get", Duration: 2500 * time.Millisecond, Failed: true},
+	} {
+		assert.NilError(t, usageLog.Record(e))
+	}
+
+	cmd = newUsageCommand(cli)
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), `COMMAND            RUNS      FAILED    AVERAGE   TOTAL
+swarmctl get       2         1         2s        4s
+swarmctl version   1         0         1s        1s
+`))
+
+	cli = test.NewFakeCli(nil)
+	cmd = newUsageCommand(cli)
+	cmd.SetArgs([]string{"--anonymous-export", "--since", "90m"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), `[
+    {
+        "command": "swarmctl get",
+        "count": 1,
+        "failures": 1,
+        "average_seconds": 2.5
+    }
+]
+`))
+
+	cmd = newUsageCommand(cli)
+	cmd.SetArgs([]string{"--disable"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, !usageLog.Enabled())
+}
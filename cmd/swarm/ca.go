@@ -9,9 +9,8 @@ import (
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/cli/cli/command/completion"
-	"github.com/docker/cli/cli/command/swarm/progress"
 	"github.com/docker/docker/api/types/swarm"
-	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/moby/swarmctl/cmd/swarm/progress"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -24,6 +23,7 @@ type caOptions struct {
 	rotate     bool
 	detach     bool
 	quiet      bool
+	progress   string
 }
 
 func newCACommand(dockerCli command.Cli) *cobra.Command {
@@ -34,7 +34,7 @@ func newCACommand(dockerCli command.Cli) *cobra.Command {
 		Short: "Display and rotate the root CA",
 		Args:  cli.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runCA(dockerCli, cmd.Flags(), opts)
+			return runCA(cmd.Context(), dockerCli, cmd.Flags(), opts)
 		},
 		Annotations: map[string]string{
 			"version": "1.30",
@@ -51,12 +51,12 @@ func newCACommand(dockerCli command.Cli) *cobra.Command {
 
 	flags.BoolVarP(&opts.detach, "detach", "d", false, "Exit immediately instead of waiting for the root rotation to converge")
 	flags.BoolVarP(&opts.quiet, "quiet", "q", false, "Suppress progress output")
+	flags.StringVar(&opts.progress, flagProgress, progress.ModeAuto, `Set type of progress output ("auto"|"tty"|"plain"|"json")`)
 	return cmd
 }
 
-func runCA(dockerCli command.Cli, flags *pflag.FlagSet, opts caOptions) error {
+func runCA(ctx context.Context, dockerCli command.Cli, flags *pflag.FlagSet, opts caOptions) error {
 	client := dockerCli.Client()
-	ctx := context.Background()
 
 	swarmInspect, err := client.SwarmInspect(ctx)
 	if err != nil {
@@ -83,6 +83,11 @@ func runCA(dockerCli command.Cli, flags *pflag.FlagSet, opts caOptions) error {
 			flagCACert, flagCAKey, flagExternalCA)
 	}
 
+	renderer, err := progress.NewRenderer(opts.progress, dockerCli.Out())
+	if err != nil {
+		return err
+	}
+
 	updateSwarmSpec(&swarmInspect.Spec, flags, opts)
 	if err := client.SwarmUpdate(ctx, swarmInspect.Version, swarmInspect.Spec, swarm.UpdateFlags{}); err != nil {
 		return err
@@ -91,7 +96,7 @@ func runCA(dockerCli command.Cli, flags *pflag.FlagSet, opts caOptions) error {
 	if opts.detach {
 		return nil
 	}
-	return attach(ctx, dockerCli, opts)
+	return attach(ctx, dockerCli, renderer, opts)
 }
 
 func updateSwarmSpec(spec *swarm.Spec, flags *pflag.FlagSet, opts caOptions) {
@@ -107,7 +112,7 @@ func updateSwarmSpec(spec *swarm.Spec, flags *pflag.FlagSet, opts caOptions) {
 	}
 }
 
-func attach(ctx context.Context, dockerCli command.Cli, opts caOptions) error {
+func attach(ctx context.Context, dockerCli command.Cli, renderer progress.Renderer, opts caOptions) error {
 	client := dockerCli.Client()
 	errChan := make(chan error, 1)
 	pipeReader, pipeWriter := io.Pipe()
@@ -121,7 +126,7 @@ func attach(ctx context.Context, dockerCli command.Cli, opts caOptions) error {
 		return <-errChan
 	}
 
-	err := jsonmessage.DisplayJSONMessagesToStream(pipeReader, dockerCli.Out(), nil)
+	err := renderer.Render(pipeReader)
 	if err == nil {
 		err = <-errChan
 	}
@@ -129,6 +134,11 @@ func attach(ctx context.Context, dockerCli command.Cli, opts caOptions) error {
 		return err
 	}
 
+	// The trust root is not a progress event; keep the json stream parseable.
+	if opts.progress == progress.ModeJSON {
+		return nil
+	}
+
 	swarmInspect, err := client.SwarmInspect(ctx)
 	if err != nil {
 		return err
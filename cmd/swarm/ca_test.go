@@ -7,8 +7,8 @@ import (
 	"testing"
 	"time"
 
-	"github.com/docker/cli/internal/test"
 	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/test"
 	"gotest.tools/v3/assert"
 	is "gotest.tools/v3/assert/cmp"
 )
@@ -128,6 +128,13 @@ func TestDisplayTrustRootInvalidFlags(t *testing.T) {
 			},
 			errorMsg: "the --ca-cert flag requires that a --ca-key flag and/or --external-ca flag be provided as well",
 		},
+		{
+			args: []string{
+				"--rotate",
+				"--progress=fancy",
+			},
+			errorMsg: `invalid progress mode "fancy", must be one of auto, tty, plain, json`,
+		},
 	}
 
 	for _, testCase := range errorTestCases {
@@ -14,6 +14,7 @@ type fakeClient struct {
 	swarmInitFunc         func() (string, error)
 	swarmInspectFunc      func() (swarm.Swarm, error)
 	nodeInspectFunc       func() (swarm.Node, []byte, error)
+	nodeListFunc          func(options types.NodeListOptions) ([]swarm.Node, error)
 	swarmGetUnlockKeyFunc func() (types.SwarmUnlockKeyResponse, error)
 	swarmJoinFunc         func() error
 	swarmLeaveFunc        func() error
@@ -35,6 +36,13 @@ func (cli *fakeClient) NodeInspectWithRaw(ctx context.Context, ref string) (swar
 	return swarm.Node{}, []byte{}, nil
 }
 
+func (cli *fakeClient) NodeList(ctx context.Context, options types.NodeListOptions) ([]swarm.Node, error) {
+	if cli.nodeListFunc != nil {
+		return cli.nodeListFunc(options)
+	}
+	return []swarm.Node{}, nil
+}
+
 func (cli *fakeClient) SwarmInit(ctx context.Context, req swarm.InitRequest) (string, error) {
 	if cli.swarmInitFunc != nil {
 		return cli.swarmInitFunc()
@@ -28,6 +28,7 @@ func NewSwarmCommand(dockerCli command.Cli) *cobra.Command {
 		newLeaveCommand(dockerCli),
 		newUnlockCommand(dockerCli),
 		newCACommand(dockerCli),
+		newRaftStatusCommand(dockerCli),
 	)
 	return cmd
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/cli/cli/command/completion"
 	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/i18n"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -38,7 +39,7 @@ func newInitCommand(dockerCli command.Cli) *cobra.Command {
 		Short: "Initialize a swarm",
 		Args:  cli.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runInit(dockerCli, cmd.Flags(), opts)
+			return runInit(cmd.Context(), dockerCli, cmd.Flags(), opts)
 		},
 		Annotations: map[string]string{
 			"version": "1.24",
@@ -65,11 +66,10 @@ func newInitCommand(dockerCli command.Cli) *cobra.Command {
 	return cmd
 }
 
-func runInit(dockerCli command.Cli, flags *pflag.FlagSet, opts initOptions) error {
+func runInit(ctx context.Context, dockerCli command.Cli, flags *pflag.FlagSet, opts initOptions) error {
 	var defaultAddrPool []string
 
 	client := dockerCli.Client()
-	ctx := context.Background()
 
 	for _, p := range opts.defaultAddrPools {
 		defaultAddrPool = append(defaultAddrPool, p.String())
@@ -103,13 +103,13 @@ func runInit(dockerCli command.Cli, flags *pflag.FlagSet, opts initOptions) erro
 		return err
 	}
 
-	fmt.Fprintf(dockerCli.Out(), "Swarm initialized: current node (%s) is now a manager.\n\n", nodeID)
+	fmt.Fprintf(dockerCli.Out(), "%s\n\n", i18n.Sprintf("Swarm initialized: current node (%s) is now a manager.", nodeID))
 
 	if err := printJoinCommand(ctx, dockerCli, nodeID, true, false); err != nil {
 		return err
 	}
 
-	fmt.Fprint(dockerCli.Out(), "To add a manager to this swarm, run 'docker swarm join-token manager' and follow the instructions.\n\n")
+	fmt.Fprintf(dockerCli.Out(), "%s\n\n", i18n.T("To add a manager to this swarm, run 'docker swarm join-token manager' and follow the instructions."))
 
 	if req.AutoLockManagers {
 		unlockKeyResp, err := client.SwarmGetUnlockKey(ctx)
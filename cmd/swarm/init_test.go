@@ -5,12 +5,11 @@ import (
 	"io"
 	"testing"
 
-	"github.com/docker/cli/internal/test"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/test"
 	"github.com/pkg/errors"
 	"gotest.tools/v3/assert"
-	"gotest.tools/v3/golden"
 )
 
 func TestSwarmInitErrorOnAPIFailure(t *testing.T) {
@@ -120,6 +119,6 @@ func TestSwarmInit(t *testing.T) {
 			cmd.Flags().Set(key, value)
 		}
 		assert.NilError(t, cmd.Execute())
-		golden.Assert(t, cli.OutBuffer().String(), fmt.Sprintf("init-%s.golden", tc.name))
+		test.AssertGolden(t, cli.OutBuffer().String(), fmt.Sprintf("init-%s.golden", tc.name))
 	}
 }
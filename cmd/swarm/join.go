@@ -8,6 +8,7 @@ import (
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/i18n"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -34,7 +35,7 @@ func newJoinCommand(dockerCli command.Cli) *cobra.Command {
 		Args:  cli.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			opts.remote = args[0]
-			return runJoin(dockerCli, cmd.Flags(), opts)
+			return runJoin(cmd.Context(), dockerCli, cmd.Flags(), opts)
 		},
 		Annotations: map[string]string{
 			"version": "1.24",
@@ -52,9 +53,8 @@ func newJoinCommand(dockerCli command.Cli) *cobra.Command {
 	return cmd
 }
 
-func runJoin(dockerCli command.Cli, flags *pflag.FlagSet, opts joinOptions) error {
+func runJoin(ctx context.Context, dockerCli command.Cli, flags *pflag.FlagSet, opts joinOptions) error {
 	client := dockerCli.Client()
-	ctx := context.Background()
 
 	req := swarm.JoinRequest{
 		JoinToken:     opts.token,
@@ -84,9 +84,9 @@ func runJoin(dockerCli command.Cli, flags *pflag.FlagSet, opts joinOptions) erro
 	}
 
 	if info.Swarm.ControlAvailable {
-		fmt.Fprintln(dockerCli.Out(), "This node joined a swarm as a manager.")
+		fmt.Fprintln(dockerCli.Out(), i18n.T("This node joined a swarm as a manager."))
 	} else {
-		fmt.Fprintln(dockerCli.Out(), "This node joined a swarm as a worker.")
+		fmt.Fprintln(dockerCli.Out(), i18n.T("This node joined a swarm as a worker."))
 	}
 	return nil
 }
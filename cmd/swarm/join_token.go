@@ -7,6 +7,7 @@ import (
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/i18n"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
@@ -26,7 +27,7 @@ func newJoinTokenCommand(dockerCli command.Cli) *cobra.Command {
 		Args:  cli.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			opts.role = args[0]
-			return runJoinToken(dockerCli, opts)
+			return runJoinToken(cmd.Context(), dockerCli, opts)
 		},
 		Annotations: map[string]string{
 			"version": "1.24",
@@ -41,7 +42,7 @@ func newJoinTokenCommand(dockerCli command.Cli) *cobra.Command {
 	return cmd
 }
 
-func runJoinToken(dockerCli command.Cli, opts joinTokenOptions) error {
+func runJoinToken(ctx context.Context, dockerCli command.Cli, opts joinTokenOptions) error {
 	worker := opts.role == "worker"
 	manager := opts.role == "manager"
 
@@ -50,7 +51,6 @@ func runJoinToken(dockerCli command.Cli, opts joinTokenOptions) error {
 	}
 
 	client := dockerCli.Client()
-	ctx := context.Background()
 
 	if opts.rotate {
 		flags := swarm.UpdateFlags{
@@ -68,7 +68,7 @@ func runJoinToken(dockerCli command.Cli, opts joinTokenOptions) error {
 		}
 
 		if !opts.quiet {
-			fmt.Fprintf(dockerCli.Out(), "Successfully rotated %s join token.\n\n", opts.role)
+			fmt.Fprintf(dockerCli.Out(), "%s\n\n", i18n.Sprintf("Successfully rotated %s join token.", opts.role))
 		}
 	}
 
@@ -26,6 +26,7 @@ const (
 	flagDefaultAddrPool           = "default-addr-pool"
 	flagDefaultAddrPoolMaskLength = "default-addr-pool-mask-length"
 	flagQuiet                     = "quiet"
+	flagProgress                  = "progress"
 	flagRotate                    = "rotate"
 	flagToken                     = "token"
 	flagTaskHistoryLimit          = "task-history-limit"
@@ -0,0 +1,170 @@
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/docker/cli/cli/streams"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/moby/swarmctl/internal/i18n"
+	"github.com/pkg/errors"
+)
+
+// Modes accepted by the --progress flag.
+const (
+	ModeAuto  = "auto"
+	ModeTTY   = "tty"
+	ModePlain = "plain"
+	ModeJSON  = "json"
+)
+
+// Modes lists the valid progress modes, in the order they are documented.
+var Modes = []string{ModeAuto, ModeTTY, ModePlain, ModeJSON}
+
+// Renderer displays a stream of JSON progress messages, such as the one
+// written by RootRotationProgress.
+type Renderer interface {
+	Render(in io.Reader) error
+}
+
+// NewRenderer returns the Renderer for the given mode. In auto mode, the
+// tty renderer is used if out is a terminal, and the plain renderer otherwise.
+func NewRenderer(mode string, out *streams.Out) (Renderer, error) {
+	switch mode {
+	case ModeAuto:
+		if out.IsTerminal() {
+			return &ttyRenderer{out: out}, nil
+		}
+		return &plainRenderer{out: out}, nil
+	case ModeTTY:
+		return &ttyRenderer{out: out}, nil
+	case ModePlain:
+		return &plainRenderer{out: out}, nil
+	case ModeJSON:
+		return &jsonRenderer{out: out}, nil
+	default:
+		return nil, errors.Errorf("invalid progress mode %q, must be one of %s", mode, strings.Join(Modes, ", "))
+	}
+}
+
+// ttyRenderer redraws progress bars in place.
+type ttyRenderer struct {
+	out *streams.Out
+}
+
+func (r *ttyRenderer) Render(in io.Reader) error {
+	pipeReader, pipeWriter := io.Pipe()
+	defer pipeReader.Close()
+	go func() {
+		enc := json.NewEncoder(pipeWriter)
+		pipeWriter.CloseWithError(decodeChanges(in, func(msg jsonmessage.JSONMessage) error {
+			localize(&msg)
+			return enc.Encode(msg)
+		}))
+	}()
+	return jsonmessage.DisplayJSONMessagesStream(pipeReader, r.out, r.out.FD(), true, nil)
+}
+
+// plainRenderer writes one line per progress change, which keeps the output
+// readable when it is captured in a log file.
+type plainRenderer struct {
+	out io.Writer
+}
+
+func (r *plainRenderer) Render(in io.Reader) error {
+	return decodeChanges(in, func(msg jsonmessage.JSONMessage) error {
+		localize(&msg)
+		line := strings.TrimSpace(msg.Status)
+		if p := msg.Progress; p != nil && p.Total > 0 {
+			line = strings.TrimSpace(fmt.Sprintf("%s %d/%d %s", line, p.Current, p.Total, p.Units))
+		}
+		if id := strings.TrimSpace(msg.ID); id != "" {
+			line = id + ": " + line
+		}
+		_, err := fmt.Fprintln(r.out, strings.TrimSpace(line))
+		return err
+	})
+}
+
+// jsonRenderer writes one JSON object per progress change (NDJSON). Its
+// events are not translated, so that they can be parsed in any language.
+type jsonRenderer struct {
+	out io.Writer
+}
+
+type jsonEvent struct {
+	ID       string             `json:"id,omitempty"`
+	Status   string             `json:"status,omitempty"`
+	Progress *jsonEventProgress `json:"progress,omitempty"`
+}
+
+type jsonEventProgress struct {
+	Current int64  `json:"current"`
+	Total   int64  `json:"total"`
+	Units   string `json:"units,omitempty"`
+}
+
+func (r *jsonRenderer) Render(in io.Reader) error {
+	enc := json.NewEncoder(r.out)
+	return decodeChanges(in, func(msg jsonmessage.JSONMessage) error {
+		ev := jsonEvent{
+			ID:     strings.TrimSpace(msg.ID),
+			Status: strings.TrimSpace(msg.Status),
+		}
+		if p := msg.Progress; p != nil && p.Total > 0 {
+			ev.Progress = &jsonEventProgress{Current: p.Current, Total: p.Total, Units: p.Units}
+		}
+		return enc.Encode(ev)
+	})
+}
+
+// decodeChanges calls fn for every message in the stream that differs from
+// the previous message with the same ID. Progress functions poll and re-emit
+// unchanged state, which is only useful when redrawing a terminal.
+func decodeChanges(in io.Reader, fn func(jsonmessage.JSONMessage) error) error {
+	dec := json.NewDecoder(in)
+	last := make(map[string]string)
+	for {
+		var msg jsonmessage.JSONMessage
+		if err := dec.Decode(&msg); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+		key, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		if prev, ok := last[msg.ID]; ok && prev == string(key) {
+			continue
+		}
+		last[msg.ID] = string(key)
+		if err := fn(msg); err != nil {
+			return err
+		}
+	}
+}
+
+// localize translates the texts of msg to the language selected with
+// SWARMCTL_LANG. The padding that aligns the progress bars is kept.
+func localize(msg *jsonmessage.JSONMessage) {
+	msg.ID = translatePadded(msg.ID)
+	msg.Status = translatePadded(msg.Status)
+	if msg.Progress != nil {
+		msg.Progress.Units = i18n.T(msg.Progress.Units)
+	}
+}
+
+func translatePadded(s string) string {
+	text := strings.TrimLeft(s, " ")
+	if text == "" {
+		return s
+	}
+	return s[:len(s)-len(text)] + i18n.T(text)
+}
@@ -0,0 +1,81 @@
+package progress
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/docker/cli/cli/streams"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/pkg/streamformatter"
+	"github.com/moby/swarmctl/internal/i18n"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func rotationStream(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	out := streamformatter.NewJSONProgressOutput(buf, false)
+	nodes := []swarm.Node{
+		{Description: swarm.NodeDescription{TLSInfo: swarm.TLSInfo{TrustRoot: "new"}}},
+		{Description: swarm.NodeDescription{TLSInfo: swarm.TLSInfo{TrustRoot: "old"}}},
+	}
+	desired := swarm.TLSInfo{TrustRoot: "new"}
+	updateProgress(out, desired, nodes, false)
+	// polling again without any change must not produce new lines
+	updateProgress(out, desired, nodes, false)
+	nodes[1].Description.TLSInfo.TrustRoot = "new"
+	updateProgress(out, desired, nodes, false)
+	return buf
+}
+
+func TestNewRendererInvalidMode(t *testing.T) {
+	_, err := NewRenderer("fancy", streams.NewOut(new(bytes.Buffer)))
+	assert.Error(t, err, `invalid progress mode "fancy", must be one of auto, tty, plain, json`)
+}
+
+func TestNewRendererAutoWithoutTerminal(t *testing.T) {
+	r, err := NewRenderer(ModeAuto, streams.NewOut(new(bytes.Buffer)))
+	assert.NilError(t, err)
+	_, ok := r.(*plainRenderer)
+	assert.Check(t, ok)
+}
+
+func TestPlainRenderer(t *testing.T) {
+	out := new(bytes.Buffer)
+	r, err := NewRenderer(ModePlain, streams.NewOut(out))
+	assert.NilError(t, err)
+	assert.NilError(t, r.Render(rotationStream(t)))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Check(t, is.Len(lines, 4))
+	assert.Check(t, strings.HasPrefix(lines[0], "desired root digest: sha256:"))
+	assert.Check(t, is.Equal(lines[1], "rotated TLS certificates: 2/2 nodes"))
+	assert.Check(t, is.Equal(lines[2], "rotated CA certificates: 1/2 nodes"))
+	assert.Check(t, is.Equal(lines[3], "rotated CA certificates: 2/2 nodes"))
+}
+
+func TestJSONRenderer(t *testing.T) {
+	out := new(bytes.Buffer)
+	r, err := NewRenderer(ModeJSON, streams.NewOut(out))
+	assert.NilError(t, err)
+	assert.NilError(t, r.Render(rotationStream(t)))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Check(t, is.Len(lines, 4))
+	assert.Check(t, is.Equal(lines[3], `{"id":"rotated CA certificates","progress":{"current":2,"total":2,"units":"nodes"}}`))
+}
+
+func TestPlainRendererLocalized(t *testing.T) {
+	t.Setenv(i18n.EnvLang, "fr")
+	out := new(bytes.Buffer)
+	r, err := NewRenderer(ModePlain, streams.NewOut(out))
+	assert.NilError(t, err)
+	assert.NilError(t, r.Render(rotationStream(t)))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Check(t, is.Len(lines, 4))
+	assert.Check(t, strings.HasPrefix(lines[0], "empreinte de la racine souhaitée: sha256:"))
+	assert.Check(t, is.Equal(lines[3], "certificats d'AC renouvelés: 2/2 nœuds"))
+}
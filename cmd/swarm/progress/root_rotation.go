@@ -17,6 +17,7 @@ import (
 )
 
 const (
+	desiredRootStr  = "desired root digest"
 	certsRotatedStr = "  rotated TLS certificates"
 	rootsRotatedStr = "  rotated CA certificates"
 	// rootsAction has a single space because rootsRotatedStr is one character shorter than certsRotatedStr.
@@ -36,7 +37,7 @@ func RootRotationProgress(ctx context.Context, dclient client.APIClient, progres
 	defer signal.Stop(sigint)
 
 	// draw 2 progress bars, 1 for nodes with the correct cert, 1 for nodes with the correct trust root
-	progress.Update(progressOut, "desired root digest", "")
+	progress.Update(progressOut, desiredRootStr, "")
 	progress.Update(progressOut, certsRotatedStr, certsAction)
 	progress.Update(progressOut, rootsRotatedStr, rootsAction)
 
@@ -74,7 +75,7 @@ func RootRotationProgress(ctx context.Context, dclient client.APIClient, progres
 func updateProgress(progressOut progress.Output, desiredTLSInfo swarm.TLSInfo, nodes []swarm.Node, rootRotationInProgress bool) bool {
 	// write the current desired root cert's digest, because the desired root certs might be too long
 	progressOut.WriteProgress(progress.Progress{
-		ID:     "desired root digest",
+		ID:     desiredRootStr,
 		Action: digest.FromBytes([]byte(desiredTLSInfo.TrustRoot)).String(),
 	})
 
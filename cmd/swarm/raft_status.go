@@ -0,0 +1,191 @@
+package swarm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/engine"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// managerStatus is the raft membership of a manager, as the swarm reports it,
+// and the state of the manager as its own engine reports it.
+type managerStatus struct {
+	hostname     string
+	id           string
+	addr         string
+	leader       bool
+	reachability swarm.Reachability
+	// localState is empty when the engine of the manager could not be
+	// reached.
+	localState       swarm.LocalNodeState
+	controlAvailable bool
+	err              error
+}
+
+func newRaftStatusCommand(dockerCli command.Cli) *cobra.Command {
+	return &cobra.Command{
+		Use:   "raft-status",
+		Short: "Display the raft status of the managers",
+		Long: `Display the raft status of the managers.
+
+The membership of every manager is read from the swarm, and the state of the
+manager is read from its own engine, reached through the docker context named
+after the node (see "node info"). The engine API does not expose the raft term,
+commit indexes or snapshots of the managers.`,
+		Args: cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRaftStatus(cmd.Context(), dockerCli)
+		},
+		Annotations: map[string]string{
+			"version": "1.24",
+			"swarm":   "manager",
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+}
+
+func runRaftStatus(ctx context.Context, dockerCli command.Cli) error {
+	client := dockerCli.Client()
+
+	sw, err := client.SwarmInspect(ctx)
+	if err != nil {
+		return err
+	}
+	nodes, err := client.NodeList(ctx, types.NodeListOptions{
+		Filters: filters.NewArgs(filters.Arg("role", "manager")),
+	})
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		return errors.New("no manager found")
+	}
+
+	resolver, err := engine.NewResolver(ctx, dockerCli)
+	if err != nil {
+		return err
+	}
+	defer resolver.Close()
+
+	managers := collectManagerStatus(ctx, resolver, nodes)
+	for _, m := range managers {
+		if m.err != nil {
+			fmt.Fprintf(dockerCli.Err(), "WARNING: %s\n", m.err)
+		}
+	}
+	return printRaftStatus(dockerCli.Out(), dockerCli.Err(), managers, sw.Spec.Raft)
+}
+
+// collectManagerStatus queries the engines of the managers concurrently.
+func collectManagerStatus(ctx context.Context, resolver *engine.Resolver, nodes []swarm.Node) []managerStatus {
+	managers := make([]managerStatus, len(nodes))
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		managers[i] = managerStatus{
+			hostname: node.Description.Hostname,
+			id:       node.ID,
+		}
+		if node.ManagerStatus != nil {
+			managers[i].addr = node.ManagerStatus.Addr
+			managers[i].leader = node.ManagerStatus.Leader
+			managers[i].reachability = node.ManagerStatus.Reachability
+		}
+
+		wg.Add(1)
+		go func(m *managerStatus, node swarm.Node) {
+			defer wg.Done()
+			c, err := resolver.Client(node)
+			if err != nil {
+				m.err = err
+				return
+			}
+			info, err := c.Info(ctx)
+			if err != nil {
+				m.err = errors.Wrapf(err, "unable to get info of manager %s", m.hostname)
+				return
+			}
+			m.localState = info.Swarm.LocalNodeState
+			m.controlAvailable = info.Swarm.ControlAvailable
+			if info.Swarm.Error != "" {
+				m.err = errors.Errorf("manager %s reports an error: %s", m.hostname, info.Swarm.Error)
+			}
+		}(&managers[i], node)
+	}
+	wg.Wait()
+
+	sort.SliceStable(managers, func(i, j int) bool {
+		return managers[i].hostname < managers[j].hostname
+	})
+	return managers
+}
+
+func printRaftStatus(out, errOut io.Writer, managers []managerStatus, raft swarm.RaftConfig) error {
+	var (
+		leader    string
+		reachable int
+	)
+	w := tabwriter.NewWriter(out, 10, 1, 3, ' ', 0)
+	fmt.Fprintln(w, "HOSTNAME\tID\tADDRESS\tLEADER\tREACHABILITY\tSTATE\tCONTROL")
+	for _, m := range managers {
+		isLeader := ""
+		if m.leader {
+			isLeader = "yes"
+			leader = m.hostname
+		}
+		if m.reachability == swarm.ReachabilityReachable {
+			reachable++
+		}
+		state, control := "-", "-"
+		if m.localState != "" {
+			state = string(m.localState)
+			control = "unavailable"
+			if m.controlAvailable {
+				control = "available"
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			m.hostname, m.id, orDash(m.addr), orDash(isLeader), orDash(string(m.reachability)), state, control)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	quorum := len(managers)/2 + 1
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "Managers:  %d (quorum %d, %d reachable, tolerates %d failure(s))\n", len(managers), quorum, reachable, (len(managers)-1)/2)
+	fmt.Fprintf(out, "Leader:    %s\n", orDash(leader))
+	fmt.Fprintf(out, "Raft:      snapshot interval %d, %d log entries for slow followers, election tick %d, heartbeat tick %d\n",
+		raft.SnapshotInterval, raft.LogEntriesForSlowFollowers, raft.ElectionTick, raft.HeartbeatTick)
+
+	switch {
+	case reachable < quorum:
+		fmt.Fprintf(errOut, "WARNING: quorum lost, %d of the %d managers needed are reachable\n", reachable, quorum)
+	case leader == "":
+		fmt.Fprintln(errOut, "WARNING: no manager is the leader, an election may be in progress")
+	case len(managers) > 1 && reachable == quorum:
+		fmt.Fprintln(errOut, "WARNING: the quorum is at risk, losing another manager will lose it")
+	}
+	if len(managers)%2 == 0 {
+		fmt.Fprintf(errOut, "WARNING: %d managers tolerate as many failures as %d, use an odd number of managers\n", len(managers), len(managers)-1)
+	}
+	return nil
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
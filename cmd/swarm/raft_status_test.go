@@ -0,0 +1,116 @@
+package swarm
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/test"
+	"github.com/pkg/errors"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func manager(id, hostname string, leader bool, reachability swarm.Reachability) swarm.Node {
+	return swarm.Node{
+		ID:          id,
+		Description: swarm.NodeDescription{Hostname: hostname},
+		ManagerStatus: &swarm.ManagerStatus{
+			Leader:       leader,
+			Reachability: reachability,
+			Addr:         hostname + ":2377",
+		},
+	}
+}
+
+func TestRaftStatus(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{
+		swarmInspectFunc: func() (swarm.Swarm, error) {
+			return swarm.Swarm{ClusterInfo: swarm.ClusterInfo{Spec: swarm.Spec{Raft: swarm.RaftConfig{
+				SnapshotInterval:           10000,
+				LogEntriesForSlowFollowers: 500,
+				ElectionTick:               10,
+				HeartbeatTick:              1,
+			}}}}, nil
+		},
+		nodeListFunc: func(options types.NodeListOptions) ([]swarm.Node, error) {
+			assert.Check(t, is.DeepEqual(options.Filters.Get("role"), []string{"manager"}))
+			return []swarm.Node{
+				manager("id3", "manager3", false, swarm.ReachabilityUnreachable),
+				manager("id1", "manager1", true, swarm.ReachabilityReachable),
+				manager("id2", "manager2", false, swarm.ReachabilityReachable),
+			}, nil
+		},
+		infoFunc: func() (types.Info, error) {
+			return types.Info{Swarm: swarm.Info{
+				NodeID:           "id1",
+				LocalNodeState:   swarm.LocalNodeStateActive,
+				ControlAvailable: true,
+			}}, nil
+		},
+	})
+	cmd := newRaftStatusCommand(cli)
+	cmd.SetArgs([]string{})
+	assert.NilError(t, cmd.Execute())
+	test.AssertGolden(t, cli.OutBuffer().String(), "raft-status.golden")
+	assert.Check(t, is.Equal(cli.ErrBuffer().String(),
+		"WARNING: no context store available to reach node manager2\n"+
+			"WARNING: no context store available to reach node manager3\n"+
+			"WARNING: the quorum is at risk, losing another manager will lose it\n"))
+}
+
+func TestRaftStatusWarnings(t *testing.T) {
+	testCases := []struct {
+		name     string
+		managers []managerStatus
+		expected string
+	}{
+		{
+			name: "quorum lost",
+			managers: []managerStatus{
+				{hostname: "manager1", reachability: swarm.ReachabilityReachable},
+				{hostname: "manager2", reachability: swarm.ReachabilityUnreachable},
+				{hostname: "manager3", reachability: swarm.ReachabilityUnreachable},
+			},
+			expected: "WARNING: quorum lost, 1 of the 2 managers needed are reachable\n",
+		},
+		{
+			name: "no leader",
+			managers: []managerStatus{
+				{hostname: "manager1", reachability: swarm.ReachabilityReachable},
+			},
+			expected: "WARNING: no manager is the leader, an election may be in progress\n",
+		},
+		{
+			name: "even managers",
+			managers: []managerStatus{
+				{hostname: "manager1", leader: true, reachability: swarm.ReachabilityReachable},
+				{hostname: "manager2", reachability: swarm.ReachabilityReachable},
+				{hostname: "manager3", reachability: swarm.ReachabilityReachable},
+				{hostname: "manager4", reachability: swarm.ReachabilityReachable},
+			},
+			expected: "WARNING: 4 managers tolerate as many failures as 3, use an odd number of managers\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			errOut := new(strings.Builder)
+			assert.NilError(t, printRaftStatus(io.Discard, errOut, tc.managers, swarm.RaftConfig{}))
+			assert.Check(t, is.Equal(errOut.String(), tc.expected))
+		})
+	}
+}
+
+func TestRaftStatusNoManager(t *testing.T) {
+	cmd := newRaftStatusCommand(test.NewFakeCli(&fakeClient{
+		nodeListFunc: func(types.NodeListOptions) ([]swarm.Node, error) {
+			return nil, errors.New("error listing nodes")
+		},
+	}))
+	cmd.SetArgs([]string{})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "error listing nodes")
+}
@@ -2,10 +2,11 @@ package swarm
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"fmt"
 	"io"
-	"strings"
+	"os"
 
 	"github.com/docker/cli/cli"
 	"github.com/docker/cli/cli/command"
@@ -17,13 +18,29 @@ import (
 	"golang.org/x/term"
 )
 
+// envUnlockKey is the environment variable the unlock key is read from when
+// it is not given with --key-file or --key-stdin.
+const envUnlockKey = "SWARMCTL_UNLOCK_KEY"
+
+type unlockOptions struct {
+	keyFile  string
+	keyStdin bool
+}
+
 func newUnlockCommand(dockerCli command.Cli) *cobra.Command {
+	opts := unlockOptions{}
+
 	cmd := &cobra.Command{
-		Use:   "unlock",
+		Use:   "unlock [OPTIONS]",
 		Short: "Unlock swarm",
-		Args:  cli.NoArgs,
+		Long: `Unlock swarm.
+
+The unlock key is read from the file given with --key-file, from the standard
+input with --key-stdin, or from the SWARMCTL_UNLOCK_KEY environment variable.
+Otherwise it is prompted for.`,
+		Args: cli.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runUnlock(dockerCli)
+			return runUnlock(cmd.Context(), dockerCli, opts)
 		},
 		Annotations: map[string]string{
 			"version": "1.24",
@@ -32,12 +49,17 @@ func newUnlockCommand(dockerCli command.Cli) *cobra.Command {
 		ValidArgsFunction: completion.NoComplete,
 	}
 
+	flags := cmd.Flags()
+	flags.StringVar(&opts.keyFile, "key-file", "", "Read the unlock key from a file")
+	flags.BoolVar(&opts.keyStdin, "key-stdin", false, "Read the unlock key from the standard input")
 	return cmd
 }
 
-func runUnlock(dockerCli command.Cli) error {
+func runUnlock(ctx context.Context, dockerCli command.Cli, opts unlockOptions) error {
+	if opts.keyFile != "" && opts.keyStdin {
+		return errors.New("--key-file and --key-stdin are mutually exclusive")
+	}
 	client := dockerCli.Client()
-	ctx := context.Background()
 
 	// First see if the node is actually part of a swarm, and if it is actually locked first.
 	// If it's in any other state than locked, don't ask for the key.
@@ -55,27 +77,57 @@ func runUnlock(dockerCli command.Cli) error {
 		return errors.New("Error: swarm is not locked")
 	}
 
-	key, err := readKey(dockerCli.In(), "Please enter unlock key: ")
+	buf, err := unlockKey(dockerCli.In(), opts)
+	// The key can only be zeroed in the buffers read here: the request
+	// holds it as a string.
+	defer zero(buf)
 	if err != nil {
 		return err
 	}
+	key := bytes.TrimSpace(buf)
+	if len(key) == 0 && (opts.keyFile != "" || opts.keyStdin) {
+		return errors.New("the unlock key is empty")
+	}
 	req := swarm.UnlockRequest{
-		UnlockKey: key,
+		UnlockKey: string(key),
 	}
 
 	return client.SwarmUnlock(ctx, req)
 }
 
-func readKey(in *streams.In, prompt string) (string, error) {
+// unlockKey reads the unlock key from the source selected by opts.
+func unlockKey(in *streams.In, opts unlockOptions) ([]byte, error) {
+	switch {
+	case opts.keyFile != "":
+		key, err := os.ReadFile(opts.keyFile)
+		return key, errors.Wrap(err, "unable to read the unlock key")
+	case opts.keyStdin:
+		key, err := io.ReadAll(in)
+		return key, errors.Wrap(err, "unable to read the unlock key from the standard input")
+	case os.Getenv(envUnlockKey) != "":
+		return []byte(os.Getenv(envUnlockKey)), nil
+	default:
+		return readKey(in, "Please enter unlock key: ")
+	}
+}
+
+func readKey(in *streams.In, prompt string) ([]byte, error) {
 	if in.IsTerminal() {
 		fmt.Print(prompt)
 		dt, err := term.ReadPassword(int(in.FD()))
 		fmt.Println()
-		return string(dt), err
+		return dt, err
 	}
-	key, err := bufio.NewReader(in).ReadString('\n')
+	key, err := bufio.NewReader(in).ReadBytes('\n')
 	if err == io.EOF {
 		err = nil
 	}
-	return strings.TrimSpace(key), err
+	return key, err
+}
+
+// zero overwrites b, so that the secret it holds does not linger in memory.
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
 }
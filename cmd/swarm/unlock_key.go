@@ -9,6 +9,7 @@ import (
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/cli/cli/command/completion"
 	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/i18n"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
@@ -26,7 +27,7 @@ func newUnlockKeyCommand(dockerCli command.Cli) *cobra.Command {
 		Short: "Manage the unlock key",
 		Args:  cli.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runUnlockKey(dockerCli, opts)
+			return runUnlockKey(cmd.Context(), dockerCli, opts)
 		},
 		Annotations: map[string]string{
 			"version": "1.24",
@@ -42,9 +43,8 @@ func newUnlockKeyCommand(dockerCli command.Cli) *cobra.Command {
 	return cmd
 }
 
-func runUnlockKey(dockerCli command.Cli, opts unlockKeyOptions) error {
+func runUnlockKey(ctx context.Context, dockerCli command.Cli, opts unlockKeyOptions) error {
 	client := dockerCli.Client()
-	ctx := context.Background()
 
 	if opts.rotate {
 		flags := swarm.UpdateFlags{RotateManagerUnlockKey: true}
@@ -63,7 +63,7 @@ func runUnlockKey(dockerCli command.Cli, opts unlockKeyOptions) error {
 		}
 
 		if !opts.quiet {
-			fmt.Fprintf(dockerCli.Out(), "Successfully rotated manager unlock key.\n\n")
+			fmt.Fprintf(dockerCli.Out(), "%s\n\n", i18n.T("Successfully rotated manager unlock key."))
 		}
 	}
 
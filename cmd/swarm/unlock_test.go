@@ -2,15 +2,18 @@ package swarm
 
 import (
 	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/docker/cli/cli/streams"
-	"github.com/docker/cli/internal/test"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/test"
 	"github.com/pkg/errors"
 	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
 )
 
 func TestSwarmUnlockErrors(t *testing.T) {
@@ -26,6 +29,23 @@ func TestSwarmUnlockErrors(t *testing.T) {
 			args:          []string{"foo"},
 			expectedError: "accepts no arguments",
 		},
+		{
+			name:          "key-file-and-key-stdin",
+			args:          []string{"--key-file", "key", "--key-stdin"},
+			expectedError: "--key-file and --key-stdin are mutually exclusive",
+		},
+		{
+			name: "missing-key-file",
+			args: []string{"--key-file", "/nonexistent/key"},
+			infoFunc: func() (types.Info, error) {
+				return types.Info{
+					Swarm: swarm.Info{
+						LocalNodeState: swarm.LocalNodeStateLocked,
+					},
+				}, nil
+			},
+			expectedError: "unable to read the unlock key: open /nonexistent/key",
+		},
 		{
 			name: "is-not-part-of-a-swarm",
 			infoFunc: func() (types.Info, error) {
@@ -96,3 +116,60 @@ func TestSwarmUnlock(t *testing.T) {
 	cmd := newUnlockCommand(dockerCli)
 	assert.NilError(t, cmd.Execute())
 }
+
+func TestSwarmUnlockNonInteractive(t *testing.T) {
+	const key = "SWMKEY-1-unlock"
+	keyFile := filepath.Join(t.TempDir(), "key")
+	assert.NilError(t, os.WriteFile(keyFile, []byte(key+"\n"), 0o600))
+
+	testCases := []struct {
+		name  string
+		args  []string
+		stdin string
+		env   string
+	}{
+		{name: "key-file", args: []string{"--key-file", keyFile}},
+		{name: "key-stdin", args: []string{"--key-stdin"}, stdin: key + "\n"},
+		{name: "env", env: key},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(envUnlockKey, tc.env)
+			var unlocked string
+			dockerCli := test.NewFakeCli(&fakeClient{
+				infoFunc: func() (types.Info, error) {
+					return types.Info{Swarm: swarm.Info{LocalNodeState: swarm.LocalNodeStateLocked}}, nil
+				},
+				swarmUnlockFunc: func(req swarm.UnlockRequest) error {
+					unlocked = req.UnlockKey
+					return nil
+				},
+			})
+			dockerCli.SetIn(streams.NewIn(io.NopCloser(strings.NewReader(tc.stdin))))
+			cmd := newUnlockCommand(dockerCli)
+			cmd.SetArgs(tc.args)
+			assert.NilError(t, cmd.Execute())
+			assert.Check(t, is.Equal(unlocked, key))
+		})
+	}
+}
+
+func TestSwarmUnlockEmptyKey(t *testing.T) {
+	dockerCli := test.NewFakeCli(&fakeClient{
+		infoFunc: func() (types.Info, error) {
+			return types.Info{Swarm: swarm.Info{LocalNodeState: swarm.LocalNodeStateLocked}}, nil
+		},
+	})
+	dockerCli.SetIn(streams.NewIn(io.NopCloser(strings.NewReader("\n"))))
+	cmd := newUnlockCommand(dockerCli)
+	cmd.SetArgs([]string{"--key-stdin"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "the unlock key is empty")
+}
+
+func TestZero(t *testing.T) {
+	b := []byte("secret")
+	zero(b)
+	assert.Check(t, is.DeepEqual(b, make([]byte, 6)))
+}
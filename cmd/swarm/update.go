@@ -8,6 +8,7 @@ import (
 	"github.com/docker/cli/cli/command"
 	"github.com/docker/cli/cli/command/completion"
 	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/i18n"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -21,7 +22,7 @@ func newUpdateCommand(dockerCli command.Cli) *cobra.Command {
 		Short: "Update the swarm",
 		Args:  cli.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runUpdate(dockerCli, cmd.Flags(), opts)
+			return runUpdate(cmd.Context(), dockerCli, cmd.Flags(), opts)
 		},
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			if cmd.Flags().NFlag() == 0 {
@@ -41,9 +42,8 @@ func newUpdateCommand(dockerCli command.Cli) *cobra.Command {
 	return cmd
 }
 
-func runUpdate(dockerCli command.Cli, flags *pflag.FlagSet, opts swarmOptions) error {
+func runUpdate(ctx context.Context, dockerCli command.Cli, flags *pflag.FlagSet, opts swarmOptions) error {
 	client := dockerCli.Client()
-	ctx := context.Background()
 
 	var updateFlags swarm.UpdateFlags
 
@@ -63,7 +63,7 @@ func runUpdate(dockerCli command.Cli, flags *pflag.FlagSet, opts swarmOptions) e
 		return err
 	}
 
-	fmt.Fprintln(dockerCli.Out(), "Swarm updated.")
+	fmt.Fprintln(dockerCli.Out(), i18n.T("Swarm updated."))
 
 	if curAutoLock && !prevAutoLock {
 		unlockKeyResp, err := client.SwarmGetUnlockKey(ctx)
@@ -0,0 +1,140 @@
+package system
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/moby/swarmctl/internal/apiclient"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type apiOptions struct {
+	method string
+	path   string
+	query  []string
+	data   string
+}
+
+// NewAPICommand creates a new cobra.Command for `swarmctl api`
+func NewAPICommand(dockerCli command.Cli) *cobra.Command {
+	opts := apiOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "api [OPTIONS] [METHOD] PATH",
+		Short: "Send a raw request to the Engine API",
+		Long: `Send a raw request to the Engine API.
+
+The request goes through the same client as the other commands, with the
+docker context, TLS settings and HTTP headers of the configuration file. PATH
+is prefixed with the negotiated API version, unless it starts with one. JSON
+responses are indented, the others are printed as they are received. METHOD
+defaults to GET.`,
+		Example: `  $ swarmctl api /services --query 'filters={"label":["com.docker.stack.namespace=shop"]}'
+  $ swarmctl api POST /nodes/worker1/update --query version=42 --data @node.json`,
+		Args: cli.RequiresRangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.method, opts.path = http.MethodGet, args[0]
+			if len(args) == 2 {
+				opts.method, opts.path = strings.ToUpper(args[0]), args[1]
+			}
+			return runAPI(cmd.Context(), dockerCli, opts)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	flags := cmd.Flags()
+	flags.StringArrayVarP(&opts.query, "query", "q", nil, "Add a query parameter (KEY=VALUE)")
+	flags.StringVarP(&opts.data, "data", "d", "", `Send a request body, read from a file with "@FILE" or from the standard input with "@-"`)
+	return cmd
+}
+
+func runAPI(ctx context.Context, dockerCli command.Cli, opts apiOptions) error {
+	switch opts.method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodDelete:
+	default:
+		return errors.Errorf("invalid method %q, must be one of GET, HEAD, POST, PUT, DELETE", opts.method)
+	}
+	if !strings.HasPrefix(opts.path, "/") {
+		return errors.Errorf("invalid path %q, must start with /", opts.path)
+	}
+	query := url.Values{}
+	for _, q := range opts.query {
+		key, value, ok := strings.Cut(q, "=")
+		if !ok || key == "" {
+			return errors.Errorf("invalid query parameter %q, must be KEY=VALUE", q)
+		}
+		query.Add(key, value)
+	}
+	body, err := requestBody(dockerCli, opts.data)
+	if err != nil {
+		return err
+	}
+
+	resp, err := apiclient.Request(ctx, dockerCli, opts.method, opts.path, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != "" {
+			data = []byte(apiErr.Message)
+		}
+		return errors.Errorf("%s %s: %s: %s", opts.method, opts.path, resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	out := dockerCli.Out()
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "application/json" {
+		_, err := io.Copy(out, resp.Body)
+		return err
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, data, "", "    "); err != nil {
+		// Streamed responses, such as events, are several JSON documents.
+		_, err := out.Write(data)
+		return err
+	}
+	fmt.Fprintln(out, indented.String())
+	return nil
+}
+
+// requestBody returns the body given with --data, or nil if there is none.
+func requestBody(dockerCli command.Cli, data string) (io.Reader, error) {
+	switch {
+	case data == "":
+		return nil, nil
+	case data == "@-":
+		return dockerCli.In(), nil
+	case strings.HasPrefix(data, "@"):
+		content, err := os.ReadFile(data[1:])
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read the request body")
+		}
+		return bytes.NewReader(content), nil
+	default:
+		return strings.NewReader(data), nil
+	}
+}
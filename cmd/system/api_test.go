@@ -0,0 +1,96 @@
+package system
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/client"
+	"github.com/moby/swarmctl/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+// newAPICli returns a cli whose API client sends its requests to a daemon
+// recording them in requests, as their method, URI and body.
+func newAPICli(t *testing.T, requests *[]string) *test.FakeCli {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		*requests = append(*requests, strings.TrimSpace(r.Method+" "+r.URL.RequestURI()+" "+string(body)))
+		switch r.URL.Path {
+		case "/v1.41/services":
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `[{"ID":"svc1","Spec":{"Name":"web"}}]`)
+		case "/v1.41/_ping":
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			io.WriteString(w, "OK")
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"message":"node worker9 not found"}`)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	apiClient, err := client.NewClientWithOpts(client.WithHost("tcp://"+srv.Listener.Addr().String()), client.WithVersion("1.41"))
+	assert.NilError(t, err)
+	return test.NewFakeCli(apiClient)
+}
+
+func TestAPI(t *testing.T) {
+	var requests []string
+	cli := newAPICli(t, &requests)
+	cmd := NewAPICommand(cli)
+	cmd.SetArgs([]string{"/services", "--query", `filters={"label":["app"]}`})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), `[
+    {
+        "ID": "svc1",
+        "Spec": {
+            "Name": "web"
+        }
+    }
+]
+`))
+
+	cli = newAPICli(t, &requests)
+	cmd = NewAPICommand(cli)
+	cmd.SetArgs([]string{"/v1.41/_ping"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "OK"))
+
+	cmd = NewAPICommand(cli)
+	cmd.SetArgs([]string{"post", "/nodes/worker9/update", "-q", "version=42", "-d", `{"Role":"manager"}`})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "POST /nodes/worker9/update: 404 Not Found: node worker9 not found")
+
+	assert.Check(t, is.DeepEqual(requests, []string{
+		"GET /v1.41/services?filters=%7B%22label%22%3A%5B%22app%22%5D%7D",
+		"GET /v1.41/_ping",
+		`POST /v1.41/nodes/worker9/update?version=42 {"Role":"manager"}`,
+	}))
+}
+
+func TestAPIInvalid(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{args: []string{"PATCH", "/services"}, expected: `invalid method "PATCH", must be one of GET, HEAD, POST, PUT, DELETE`},
+		{args: []string{"services"}, expected: `invalid path "services", must start with /`},
+		{args: []string{"/services", "-q", "filters"}, expected: `invalid query parameter "filters", must be KEY=VALUE`},
+		{args: []string{"/services", "-d", "@" + t.TempDir() + "/missing.json"}, expected: "unable to read the request body"},
+	}
+	for _, tc := range testCases {
+		var requests []string
+		cmd := NewAPICommand(newAPICli(t, &requests))
+		cmd.SetArgs(tc.args)
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		assert.Check(t, is.ErrorContains(cmd.Execute(), tc.expected))
+		assert.Check(t, is.Len(requests, 0))
+	}
+}
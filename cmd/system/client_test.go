@@ -0,0 +1,146 @@
+package system
+
+import (
+	"context"
+	"io"
+
+	"github.com/docker/docker/api"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+)
+
+type fakeClient struct {
+	client.Client
+	apiVersion      string
+	eventsFn        func(context.Context, types.EventsOptions) (<-chan events.Message, <-chan error)
+	serverVersionFn func(context.Context) (types.Version, error)
+	serviceListFn   func(context.Context, types.ServiceListOptions) ([]swarm.Service, error)
+	nodeListFn      func(context.Context, types.NodeListOptions) ([]swarm.Node, error)
+	taskListFn      func(context.Context, types.TaskListOptions) ([]swarm.Task, error)
+	networkListFn   func(context.Context, types.NetworkListOptions) ([]types.NetworkResource, error)
+	configListFn    func(context.Context, types.ConfigListOptions) ([]swarm.Config, error)
+	secretListFn    func(context.Context, types.SecretListOptions) ([]swarm.Secret, error)
+	infoFn          func(context.Context) (types.Info, error)
+
+	serviceInspectFn func(context.Context, string) (swarm.Service, error)
+	serviceUpdateFn  func(context.Context, string, swarm.Version, swarm.ServiceSpec) error
+	nodeInspectFn    func(context.Context, string) (swarm.Node, error)
+	nodeUpdateFn     func(context.Context, string, swarm.Version, swarm.NodeSpec) error
+	configInspectFn  func(context.Context, string) (swarm.Config, error)
+	configUpdateFn   func(context.Context, string, swarm.Version, swarm.ConfigSpec) error
+
+	serviceLogsFn func(context.Context, string, types.ContainerLogsOptions) (io.ReadCloser, error)
+	taskLogsFn    func(context.Context, string, types.ContainerLogsOptions) (io.ReadCloser, error)
+	taskInspectFn func(context.Context, string) (swarm.Task, error)
+}
+
+func (cli *fakeClient) ClientVersion() string {
+	if cli.apiVersion != "" {
+		return cli.apiVersion
+	}
+	return api.DefaultVersion
+}
+
+func (cli *fakeClient) NegotiateAPIVersion(context.Context) {}
+
+func (cli *fakeClient) Events(ctx context.Context, options types.EventsOptions) (<-chan events.Message, <-chan error) {
+	return cli.eventsFn(ctx, options)
+}
+
+func (cli *fakeClient) ServerVersion(ctx context.Context) (types.Version, error) {
+	if cli.serverVersionFn != nil {
+		return cli.serverVersionFn(ctx)
+	}
+	return types.Version{}, nil
+}
+
+func (cli *fakeClient) ServiceList(ctx context.Context, options types.ServiceListOptions) ([]swarm.Service, error) {
+	if cli.serviceListFn != nil {
+		return cli.serviceListFn(ctx, options)
+	}
+	return nil, nil
+}
+
+func (cli *fakeClient) NodeList(ctx context.Context, options types.NodeListOptions) ([]swarm.Node, error) {
+	if cli.nodeListFn != nil {
+		return cli.nodeListFn(ctx, options)
+	}
+	return nil, nil
+}
+
+func (cli *fakeClient) TaskList(ctx context.Context, options types.TaskListOptions) ([]swarm.Task, error) {
+	if cli.taskListFn != nil {
+		return cli.taskListFn(ctx, options)
+	}
+	return nil, nil
+}
+
+func (cli *fakeClient) NetworkList(ctx context.Context, options types.NetworkListOptions) ([]types.NetworkResource, error) {
+	if cli.networkListFn != nil {
+		return cli.networkListFn(ctx, options)
+	}
+	return nil, nil
+}
+
+func (cli *fakeClient) ConfigList(ctx context.Context, options types.ConfigListOptions) ([]swarm.Config, error) {
+	if cli.configListFn != nil {
+		return cli.configListFn(ctx, options)
+	}
+	return nil, nil
+}
+
+func (cli *fakeClient) SecretList(ctx context.Context, options types.SecretListOptions) ([]swarm.Secret, error) {
+	if cli.secretListFn != nil {
+		return cli.secretListFn(ctx, options)
+	}
+	return nil, nil
+}
+
+func (cli *fakeClient) Info(ctx context.Context) (types.Info, error) {
+	if cli.infoFn != nil {
+		return cli.infoFn(ctx)
+	}
+	return types.Info{}, nil
+}
+
+func (cli *fakeClient) ServiceInspectWithRaw(ctx context.Context, serviceID string, _ types.ServiceInspectOptions) (swarm.Service, []byte, error) {
+	service, err := cli.serviceInspectFn(ctx, serviceID)
+	return service, nil, err
+}
+
+func (cli *fakeClient) ServiceUpdate(ctx context.Context, serviceID string, version swarm.Version, service swarm.ServiceSpec, _ types.ServiceUpdateOptions) (types.ServiceUpdateResponse, error) {
+	return types.ServiceUpdateResponse{}, cli.serviceUpdateFn(ctx, serviceID, version, service)
+}
+
+func (cli *fakeClient) NodeInspectWithRaw(ctx context.Context, nodeID string) (swarm.Node, []byte, error) {
+	node, err := cli.nodeInspectFn(ctx, nodeID)
+	return node, nil, err
+}
+
+func (cli *fakeClient) NodeUpdate(ctx context.Context, nodeID string, version swarm.Version, node swarm.NodeSpec) error {
+	return cli.nodeUpdateFn(ctx, nodeID, version, node)
+}
+
+func (cli *fakeClient) ConfigInspectWithRaw(ctx context.Context, id string) (swarm.Config, []byte, error) {
+	config, err := cli.configInspectFn(ctx, id)
+	return config, nil, err
+}
+
+func (cli *fakeClient) ConfigUpdate(ctx context.Context, id string, version swarm.Version, config swarm.ConfigSpec) error {
+	return cli.configUpdateFn(ctx, id, version, config)
+}
+
+func (cli *fakeClient) ServiceLogs(ctx context.Context, serviceID string, options types.ContainerLogsOptions) (io.ReadCloser, error) {
+	return cli.serviceLogsFn(ctx, serviceID, options)
+}
+
+func (cli *fakeClient) TaskLogs(ctx context.Context, taskID string, options types.ContainerLogsOptions) (io.ReadCloser, error) {
+	return cli.taskLogsFn(ctx, taskID, options)
+}
+
+func (cli *fakeClient) TaskInspectWithRaw(ctx context.Context, taskID string) (swarm.Task, []byte, error) {
+	task, err := cli.taskInspectFn(ctx, taskID)
+	return task, nil, err
+}
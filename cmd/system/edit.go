@@ -0,0 +1,162 @@
+package system
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/moby/swarmctl/internal/editor"
+	"github.com/moby/swarmctl/internal/freeze"
+	"github.com/moby/swarmctl/internal/i18n"
+	"github.com/moby/swarmctl/internal/spec"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// editFile opens a document in the user's editor; tests replace it.
+var editFile = editor.Edit
+
+// NewEditCommand creates a new cobra.Command for `swarmctl edit`
+func NewEditCommand(dockerCli command.Cli) *cobra.Command {
+	var overrideFreeze bool
+
+	cmd := &cobra.Command{
+		Use:   "edit TYPE NAME",
+		Short: "Edit the spec of a service, node or config",
+		Long: `Edit the spec of a service, node or config.
+
+The spec is opened as YAML in the editor set by the VISUAL or EDITOR
+environment variables. Once the editor exits, the changes are validated,
+displayed as a diff, and applied. If the object was updated in the meantime,
+the changes are applied again on top of its new spec.
+
+Only the labels of a config can be edited.`,
+		Args: cli.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			obj, err := lookupSpecObject(dockerCli, args[0], args[1], overrideFreeze)
+			if err != nil {
+				return err
+			}
+			return runEdit(cmd.Context(), dockerCli, obj)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			switch len(args) {
+			case 0:
+				return []string{"services", "nodes", "configs"}, cobra.ShellCompDirectiveNoFileComp
+			case 1:
+				return completeNames(cmd.Context(), dockerCli, args[0]), cobra.ShellCompDirectiveNoFileComp
+			default:
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+		},
+		Annotations: map[string]string{
+			"version": "1.30",
+			"swarm":   "manager",
+		},
+	}
+
+	freeze.AddFlag(cmd.Flags(), &overrideFreeze)
+	return cmd
+}
+
+func runEdit(ctx context.Context, dockerCli command.Cli, obj specObject) error {
+	current, err := obj.Inspect(ctx)
+	if err != nil {
+		return err
+	}
+	original, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	originalYAML, err := spec.ToYAML(current)
+	if err != nil {
+		return err
+	}
+
+	content := append(editHeader(obj, nil), originalYAML...)
+	for {
+		edited, err := editFile(ctx, "swarmctl-edit-*.yaml", content)
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(edited, content) && bytes.HasPrefix(content, []byte("# Error:")) {
+			return errors.New("edit cancelled, the spec is still invalid")
+		}
+
+		modified, editedYAML, err := parseEdited(obj, edited)
+		if err != nil {
+			// reopen the editor with the error, so that the changes are not lost
+			content = append(editHeader(obj, err), stripComments(edited)...)
+			continue
+		}
+		if modified == nil {
+			return errors.New("edit cancelled, the spec is empty")
+		}
+
+		patch, err := spec.CreateMergePatch(original, modified)
+		if err != nil {
+			return err
+		}
+		if spec.IsEmptyPatch(patch) {
+			fmt.Fprintln(dockerCli.Out(), i18n.T("Edit cancelled, no changes made."))
+			return nil
+		}
+
+		fmt.Fprint(dockerCli.Out(), spec.Diff("a/"+obj.Name(), "b/"+obj.Name(), originalYAML, editedYAML))
+		if _, err := updateSpec(ctx, dockerCli, obj, mergePatch(patch)); err != nil {
+			return err
+		}
+		fmt.Fprintln(dockerCli.Out(), i18n.Sprintf("%s edited", obj.Name()))
+		return nil
+	}
+}
+
+// parseEdited validates an edited YAML document, and returns its JSON
+// document and normalized YAML. The JSON document is nil if the YAML
+// document is empty.
+func parseEdited(obj specObject, edited []byte) ([]byte, []byte, error) {
+	doc, err := spec.YAMLToJSON(edited)
+	if err != nil {
+		return nil, nil, err
+	}
+	if string(doc) == "null" {
+		return nil, nil, nil
+	}
+	s := obj.NewSpec()
+	if err := spec.Decode(doc, s); err != nil {
+		return nil, nil, err
+	}
+	normalized, err := spec.ToYAML(s)
+	if err != nil {
+		return nil, nil, err
+	}
+	return doc, normalized, nil
+}
+
+func editHeader(obj specObject, err error) []byte {
+	var header bytes.Buffer
+	if err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(&header, "# Error: %s\n", line)
+		}
+		header.WriteString("#\n")
+	}
+	fmt.Fprintf(&header, "# Edit the spec of %s below. Lines starting with '#' are ignored,\n", obj.Name())
+	header.WriteString("# and an empty file cancels the edit.\n")
+	return header.Bytes()
+}
+
+// stripComments removes the comment lines of a YAML document.
+func stripComments(data []byte) []byte {
+	var out bytes.Buffer
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			out.WriteString(line)
+		}
+	}
+	return out.Bytes()
+}
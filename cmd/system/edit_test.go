@@ -0,0 +1,208 @@
+package system
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/freeze"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"github.com/moby/swarmctl/internal/test"
+	"github.com/pkg/errors"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+// fakeEditor replaces the user's editor with edit functions, called in
+// turn each time the editor is opened.
+func fakeEditor(t *testing.T, edits ...func(string) string) {
+	t.Helper()
+	opened := 0
+	editFile = func(_ context.Context, _ string, content []byte) ([]byte, error) {
+		if opened == len(edits) {
+			t.Fatalf("unexpected edit of:\n%s", content)
+		}
+		opened++
+		return []byte(edits[opened-1](string(content))), nil
+	}
+	t.Cleanup(func() {
+		assert.Check(t, is.Equal(opened, len(edits)), "editor opened %d times", opened)
+	})
+}
+
+func replace(old, new string) func(string) string {
+	return func(content string) string {
+		return strings.Replace(content, old, new, 1)
+	}
+}
+
+func nodeClient(node *swarm.Node, updates *[]swarm.NodeSpec) *fakeClient {
+	return &fakeClient{
+		nodeInspectFn: func(context.Context, string) (swarm.Node, error) {
+			return *node, nil
+		},
+		nodeUpdateFn: func(_ context.Context, _ string, version swarm.Version, spec swarm.NodeSpec) error {
+			if version.Index != node.Version.Index {
+				return errors.New("rpc error: code = Unknown desc = update out of sequence")
+			}
+			*updates = append(*updates, spec)
+			return nil
+		},
+	}
+}
+
+func TestEditService(t *testing.T) {
+	service := getServices()[0]
+	var updated []swarm.ServiceSpec
+	cli := test.NewFakeCli(&fakeClient{
+		serviceInspectFn: func(context.Context, string) (swarm.Service, error) {
+			return service, nil
+		},
+		serviceUpdateFn: func(_ context.Context, _ string, _ swarm.Version, spec swarm.ServiceSpec) error {
+			updated = append(updated, spec)
+			return nil
+		},
+	})
+	fakeEditor(t, func(content string) string {
+		assert.Check(t, is.Contains(content, "# Edit the spec of service web below."))
+		return replace("Replicas: 3", "Replicas: 5")(content)
+	})
+
+	cmd := NewEditCommand(cli)
+	cmd.SetArgs([]string{"service", "web"})
+	assert.NilError(t, cmd.Execute())
+	test.AssertGolden(t, cli.OutBuffer().String(), "edit-service.golden")
+	assert.Assert(t, is.Len(updated, 1))
+	assert.Check(t, is.Equal(*updated[0].Mode.Replicated.Replicas, uint64(5)))
+	assert.Check(t, is.Equal(updated[0].TaskTemplate.ContainerSpec.Image, "nginx:alpine@sha256:abcdef"))
+}
+
+func TestEditNoChanges(t *testing.T) {
+	node := swarm.Node{ID: "node1", Spec: swarm.NodeSpec{Availability: swarm.NodeAvailabilityActive}}
+	var updates []swarm.NodeSpec
+	cli := test.NewFakeCli(nodeClient(&node, &updates))
+	fakeEditor(t, func(content string) string { return content })
+
+	cmd := NewEditCommand(cli)
+	cmd.SetArgs([]string{"node", "node1"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "Edit cancelled, no changes made.\n"))
+	assert.Check(t, is.Len(updates, 0))
+}
+
+func TestEditEmpty(t *testing.T) {
+	node := swarm.Node{ID: "node1", Spec: swarm.NodeSpec{Availability: swarm.NodeAvailabilityActive}}
+	var updates []swarm.NodeSpec
+	fakeEditor(t, func(string) string { return "# nothing\n" })
+
+	cmd := NewEditCommand(test.NewFakeCli(nodeClient(&node, &updates)))
+	cmd.SetArgs([]string{"node", "node1"})
+	assert.Error(t, cmd.Execute(), "edit cancelled, the spec is empty")
+}
+
+func TestEditInvalidReopensEditor(t *testing.T) {
+	node := swarm.Node{ID: "node1", Spec: swarm.NodeSpec{Availability: swarm.NodeAvailabilityActive}}
+	var updates []swarm.NodeSpec
+	cli := test.NewFakeCli(nodeClient(&node, &updates))
+	fakeEditor(t,
+		replace("Availability: active", "Availabilty: drain"),
+		func(content string) string {
+			assert.Check(t, is.Contains(content, "# Error: invalid spec: json: unknown field \"Availabilty\"\n"))
+			return replace("Availabilty: drain", "Availability: drain")(content)
+		},
+	)
+
+	cmd := NewEditCommand(cli)
+	cmd.SetArgs([]string{"node", "node1"})
+	assert.NilError(t, cmd.Execute())
+	assert.Assert(t, is.Len(updates, 1))
+	assert.Check(t, is.Equal(updates[0].Availability, swarm.NodeAvailabilityDrain))
+}
+
+func TestEditInvalidUnchanged(t *testing.T) {
+	node := swarm.Node{ID: "node1", Spec: swarm.NodeSpec{Availability: swarm.NodeAvailabilityActive}}
+	var updates []swarm.NodeSpec
+	fakeEditor(t,
+		replace("Availability: active", "Availability: [drain"),
+		func(content string) string { return content },
+	)
+
+	cmd := NewEditCommand(test.NewFakeCli(nodeClient(&node, &updates)))
+	cmd.SetArgs([]string{"node", "node1"})
+	assert.Error(t, cmd.Execute(), "edit cancelled, the spec is still invalid")
+}
+
+func TestEditConflictRetry(t *testing.T) {
+	node := swarm.Node{
+		ID:   "node1",
+		Meta: swarm.Meta{Version: swarm.Version{Index: 1}},
+		Spec: swarm.NodeSpec{Availability: swarm.NodeAvailabilityActive},
+	}
+	var updates []swarm.NodeSpec
+	cli := test.NewFakeCli(nodeClient(&node, &updates))
+	fakeEditor(t, func(content string) string {
+		// the node is updated by someone else while the editor is open
+		node.Version.Index = 2
+		node.Spec.Labels = map[string]string{"zone": "a"}
+		return replace("Availability: active", "Availability: drain")(content)
+	})
+
+	cmd := NewEditCommand(cli)
+	cmd.SetArgs([]string{"node", "node1"})
+	assert.NilError(t, cmd.Execute())
+	assert.Assert(t, is.Len(updates, 1))
+	assert.Check(t, is.DeepEqual(updates[0], swarm.NodeSpec{
+		Annotations:  swarm.Annotations{Labels: map[string]string{"zone": "a"}},
+		Availability: swarm.NodeAvailabilityDrain,
+	}))
+}
+
+func TestEditConfigLabels(t *testing.T) {
+	config := swarm.Config{
+		ID:   "config1",
+		Spec: swarm.ConfigSpec{Annotations: swarm.Annotations{Name: "app.conf"}, Data: []byte("data")},
+	}
+	var updated swarm.ConfigSpec
+	cli := test.NewFakeCli(&fakeClient{
+		configInspectFn: func(context.Context, string) (swarm.Config, error) {
+			return config, nil
+		},
+		configUpdateFn: func(_ context.Context, _ string, _ swarm.Version, spec swarm.ConfigSpec) error {
+			updated = spec
+			return nil
+		},
+	})
+	fakeEditor(t, replace("Labels: {}", "Labels:\n  env: prod"))
+
+	cmd := NewEditCommand(cli)
+	cmd.SetArgs([]string{"config", "app.conf"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.DeepEqual(updated, swarm.ConfigSpec{
+		Annotations: swarm.Annotations{Name: "app.conf", Labels: map[string]string{"env": "prod"}},
+		Data:        []byte("data"),
+	}))
+}
+
+func TestEditFrozen(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{
+		configInspectFn: func(context.Context, string) (swarm.Config, error) {
+			return swarm.Config{
+				ID:   "config1",
+				Spec: swarm.ConfigSpec{Annotations: swarm.Annotations{Name: "shop_nginx", Labels: map[string]string{swarmlabel.Namespace: "shop", freeze.Label: ""}}},
+			}, nil
+		},
+	})
+	// The editor is not opened.
+	fakeEditor(t)
+
+	cmd := NewEditCommand(cli)
+	cmd.SetArgs([]string{"config", "shop_nginx"})
+	assert.Error(t, cmd.Execute(), "config shop_nginx belongs to frozen stack shop, use --override-freeze to change it anyway")
+}
+
+func TestEditNotEditable(t *testing.T) {
+	cmd := NewEditCommand(test.NewFakeCli(&fakeClient{}))
+	cmd.SetArgs([]string{"tasks", "web.1"})
+	assert.Error(t, cmd.Execute(), "tasks cannot be edited, must be one of services, nodes, configs")
+}
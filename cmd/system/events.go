@@ -0,0 +1,163 @@
+package system
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/cli/opts"
+	"github.com/docker/cli/templates"
+	eventtypes "github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/moby/swarmctl/internal/events"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+type eventsOptions struct {
+	since  string
+	until  string
+	filter opts.FilterOpt
+	format string
+}
+
+// NewEventsCommand creates a new cobra.Command for `swarmctl events`
+func NewEventsCommand(dockerCli command.Cli) *cobra.Command {
+	options := eventsOptions{filter: opts.NewFilterOpt()}
+
+	cmd := &cobra.Command{
+		Use:   "events [OPTIONS]",
+		Short: "Get real time events of the swarm",
+		Args:  cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runEvents(cmd.Context(), dockerCli, &options)
+		},
+		Annotations: map[string]string{
+			"version": "1.30",
+			"swarm":   "manager",
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&options.since, "since", "", "Show all events created since timestamp")
+	flags.StringVar(&options.until, "until", "", "Stream events until this timestamp")
+	flags.VarP(&options.filter, "filter", "f", `Filter output based on conditions provided ("type", "namespace", "label")`)
+	flags.StringVar(&options.format, "format", "", `Format the output using the given Go template, or "json" to print one JSON object per event`)
+	return cmd
+}
+
+func runEvents(ctx context.Context, dockerCli command.Cli, options *eventsOptions) error {
+	streamOpts, err := streamOptions(options)
+	if err != nil {
+		return err
+	}
+	streamOpts.OnReconnect = func(err error, delay time.Duration) {
+		fmt.Fprintf(dockerCli.Err(), "WARNING: lost the event stream (%s), reconnecting in %s\n", err, delay)
+	}
+
+	printEvent, err := eventPrinter(dockerCli.Out(), options.format)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	messages, errs := events.Stream(ctx, dockerCli.Client(), streamOpts)
+	for msg := range messages {
+		if err := printEvent(msg); err != nil {
+			return err
+		}
+	}
+	return <-errs
+}
+
+func streamOptions(options *eventsOptions) (events.Options, error) {
+	streamOpts := events.Options{
+		Since: options.since,
+		Until: options.until,
+	}
+	args := options.filter.Value()
+	for _, key := range args.Keys() {
+		switch key {
+		case "type":
+			streamOpts.Types = args.Get(key)
+		case "namespace":
+			namespaces := args.Get(key)
+			if len(namespaces) > 1 {
+				return events.Options{}, errors.New("only one namespace filter is supported")
+			}
+			streamOpts.Namespace = namespaces[0]
+		case "label":
+			streamOpts.Labels = args.Get(key)
+		default:
+			return events.Options{}, errors.Errorf("invalid filter '%s'", key)
+		}
+	}
+	return streamOpts, streamOpts.Validate()
+}
+
+func eventPrinter(out io.Writer, format string) (func(eventtypes.Message) error, error) {
+	switch format {
+	case "":
+		return func(msg eventtypes.Message) error {
+			writeEvent(out, msg)
+			return nil
+		}, nil
+	case "json":
+		enc := json.NewEncoder(out)
+		return func(msg eventtypes.Message) error {
+			return enc.Encode(msg)
+		}, nil
+	default:
+		tmpl, err := templates.Parse(format)
+		if err != nil {
+			return nil, errors.Wrap(err, "template parsing error")
+		}
+		return func(msg eventtypes.Message) error {
+			return executeTemplate(out, tmpl, msg)
+		}, nil
+	}
+}
+
+func executeTemplate(out io.Writer, tmpl *template.Template, msg eventtypes.Message) error {
+	if err := tmpl.Execute(out, msg); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(out)
+	return err
+}
+
+// writeEvent prints an event in the same format as `docker events`.
+func writeEvent(out io.Writer, msg eventtypes.Message) {
+	if msg.TimeNano != 0 {
+		fmt.Fprintf(out, "%s ", time.Unix(0, msg.TimeNano).Format(jsonmessage.RFC3339NanoFixed))
+	} else if msg.Time != 0 {
+		fmt.Fprintf(out, "%s ", time.Unix(msg.Time, 0).Format(jsonmessage.RFC3339NanoFixed))
+	}
+
+	fmt.Fprintf(out, "%s %s %s", msg.Type, msg.Action, msg.Actor.ID)
+
+	if len(msg.Actor.Attributes) > 0 {
+		var attrs []string
+		var keys []string
+		for k := range msg.Actor.Attributes {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			attrs = append(attrs, fmt.Sprintf("%s=%s", k, msg.Actor.Attributes[k]))
+		}
+		fmt.Fprintf(out, " (%s)", strings.Join(attrs, ", "))
+	}
+	fmt.Fprint(out, "\n")
+}
@@ -0,0 +1,96 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+	"github.com/moby/swarmctl/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestEventsFormat(t *testing.T) {
+	var evts []events.Message
+	for i, action := range []string{"create", "update", "remove"} {
+		evts = append(evts, events.Message{
+			Type:     "service",
+			Action:   action,
+			Actor:    events.Actor{ID: "abc123", Attributes: map[string]string{"name": "web", "updatestate.new": "updating"}},
+			Scope:    "swarm",
+			TimeNano: time.Unix(int64(i+1), 0).UnixNano(),
+		})
+	}
+	tests := []struct {
+		name, format string
+	}{
+		{
+			name: "default",
+		},
+		{
+			name:   "json",
+			format: "json",
+		},
+		{
+			name:   "template",
+			format: "{{.Type}} {{.Action}} {{index .Actor.Attributes \"name\"}}",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cli := test.NewFakeCli(&fakeClient{eventsFn: func(ctx context.Context, options types.EventsOptions) (<-chan events.Message, <-chan error) {
+				messages := make(chan events.Message)
+				errs := make(chan error, 1)
+				go func() {
+					for _, msg := range evts {
+						messages <- msg
+					}
+					errs <- io.EOF
+				}()
+				return messages, errs
+			}})
+			cmd := NewEventsCommand(cli)
+			cmd.SetArgs([]string{"--until", "0", "--format", tc.format})
+			assert.NilError(t, cmd.Execute())
+			out := cli.OutBuffer().String()
+			test.AssertGolden(t, out, fmt.Sprintf("events-format-%s.golden", tc.name))
+			cli.OutBuffer().Reset()
+		})
+	}
+}
+
+func TestEventsErrors(t *testing.T) {
+	testCases := []struct {
+		args          []string
+		expectedError string
+	}{
+		{
+			args:          []string{"--filter", "image=busybox"},
+			expectedError: "invalid filter 'image'",
+		},
+		{
+			args:          []string{"--filter", "type=image"},
+			expectedError: `invalid event type "image"`,
+		},
+		{
+			args:          []string{"--filter", "namespace=a", "--filter", "namespace=b"},
+			expectedError: "only one namespace filter is supported",
+		},
+		{
+			args:          []string{"--format", "{{.Type"},
+			expectedError: "template parsing error",
+		},
+	}
+	for _, tc := range testCases {
+		cmd := NewEventsCommand(test.NewFakeCli(&fakeClient{}))
+		cmd.SetArgs(tc.args)
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		assert.Check(t, is.ErrorContains(cmd.Execute(), tc.expectedError))
+	}
+}
@@ -0,0 +1,793 @@
+package system
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	timetypes "github.com/docker/docker/api/types/time"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/moby/swarmctl/internal/compcache"
+	"github.com/moby/swarmctl/internal/redact"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// Output formats of `swarmctl get`.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputName  = "name"
+)
+
+type getOptions struct {
+	kind     string
+	names    []string
+	selector []string
+	stack    string
+	output   string
+	degraded bool
+	tree     bool
+	watch    bool
+	// dataContains selects the configs whose data matches it.
+	dataContains string
+	since        string
+	until        string
+	redact       redact.Options
+}
+
+// NewGetCommand creates a new cobra.Command for `swarmctl get`
+func NewGetCommand(dockerCli command.Cli) *cobra.Command {
+	opts := getOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "get [OPTIONS] TYPE [NAME...]",
+		Short: "List swarm objects of a type",
+		Long: `List swarm objects of a type.
+
+TYPE is one of ` + strings.Join(kindNames(), ", ") + `. Objects are
+selected by name or ID prefix, and by label with --selector. In the JSON
+output, the environment variables whose names contain PASSWORD, TOKEN or KEY,
+or the patterns set with --redact, are masked.
+
+With --watch, the nodes are listed again on every node event, with their
+last state and availability transitions of the last 10 minutes and since.
+
+--data-contains inspects the configs, in parallel, to select those whose
+data matches a regular expression.`,
+		Args: cli.RequiresMinArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.kind, opts.names = args[0], args[1:]
+			return runGet(cmd.Context(), dockerCli, opts)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return completeNames(cmd.Context(), dockerCli, args[0]), cobra.ShellCompDirectiveNoFileComp
+			}
+			return kindNames(), cobra.ShellCompDirectiveNoFileComp
+		},
+		Annotations: map[string]string{
+			"version": "1.30",
+			"swarm":   "manager",
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringSliceVarP(&opts.selector, "selector", "l", nil, `Select objects by label ("key" or "key=value")`)
+	flags.StringVar(&opts.stack, "stack", "", "Only list the services, configs, secrets and networks of a stack")
+	flags.StringVarP(&opts.output, "output", "o", outputTable, `Output format ("table", "json", "name")`)
+	flags.BoolVar(&opts.degraded, "degraded", false, "Only list the services and tasks not in their desired state")
+	flags.BoolVar(&opts.tree, "tree", false, "Group the services by stack, with a subtotal per stack")
+	flags.BoolVarP(&opts.watch, "watch", "w", false, "List the nodes again on every change, with their recent transitions")
+	flags.StringVar(&opts.dataContains, "data-contains", "", "Only list the configs whose data matches a regular expression")
+	flags.StringVar(&opts.since, "since", "", `Only list the tasks updated since a timestamp (e.g. "2023-01-02T15:04:05") or relative time (e.g. "42m")`)
+	flags.StringVar(&opts.until, "until", "", `Only list the tasks created before a timestamp (e.g. "2023-01-02T15:04:05") or relative time (e.g. "42m")`)
+	redact.AddFlags(flags, &opts.redact)
+	return cmd
+}
+
+// object is a swarm object listed by `swarmctl get`.
+type object struct {
+	id      string
+	name    string
+	columns []string
+	raw     interface{}
+	// degraded is set for the objects not in their desired state.
+	degraded bool
+	created  time.Time
+	updated  time.Time
+}
+
+// kind describes a type of swarm object.
+type kind struct {
+	name    string
+	aliases []string
+	header  []string
+	list    func(ctx context.Context, apiClient client.APIClient, f filters.Args) ([]object, error)
+	// degradable is set for the kinds of objects which have a desired
+	// state.
+	degradable bool
+	// timed is set for the kinds of objects that can be selected by time
+	// with --since and --until.
+	timed bool
+	// stacked is set for the kinds of objects that `stack deploy` labels
+	// with their stack, which can be selected with --stack.
+	stacked bool
+}
+
+var kinds = []kind{
+	{
+		name:       "services",
+		aliases:    []string{"service", "svc"},
+		header:     []string{"ID", "NAME", "MODE", "REPLICAS", "UPDATE", "IMAGE"},
+		list:       listServices,
+		degradable: true,
+		stacked:    true,
+	},
+	{
+		name:    "nodes",
+		aliases: []string{"node"},
+		header:  []string{"ID", "HOSTNAME", "STATUS", "AVAILABILITY", "MANAGER STATUS"},
+		list:    listNodes,
+	},
+	{
+		name:       "tasks",
+		aliases:    []string{"task"},
+		header:     []string{"ID", "NAME", "NODE", "DESIRED STATE", "CURRENT STATE"},
+		list:       listTasks,
+		degradable: true,
+		timed:      true,
+	},
+	{
+		name:    "configs",
+		aliases: []string{"config"},
+		header:  []string{"ID", "NAME", "STACK"},
+		list:    listConfigs,
+		stacked: true,
+	},
+	{
+		name:    "secrets",
+		aliases: []string{"secret"},
+		header:  []string{"ID", "NAME", "DRIVER", "STACK"},
+		list:    listSecrets,
+		stacked: true,
+	},
+	{
+		name:    "networks",
+		aliases: []string{"network", "net"},
+		header:  []string{"ID", "NAME", "DRIVER", "SCOPE"},
+		list:    listNetworks,
+		stacked: true,
+	},
+}
+
+func kindNames() []string {
+	names := make([]string, 0, len(kinds))
+	for _, k := range kinds {
+		names = append(names, k.name)
+	}
+	return names
+}
+
+// completionCache caches the names offered by completeNames; tests replace
+// it.
+var completionCache = compcache.Default()
+
+// completeNames returns the names of the objects of a kind, for completion.
+// They are cached per docker context.
+func completeNames(ctx context.Context, dockerCli command.Cli, kindName string) []string {
+	k, err := lookupKind(kindName)
+	if err != nil {
+		return nil
+	}
+	return completionCache.Names(ctx, dockerCli.CurrentContext()+"."+k.name, func(ctx context.Context) ([]string, error) {
+		objects, err := k.list(ctx, dockerCli.Client(), filters.NewArgs())
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, 0, len(objects))
+		for _, o := range objects {
+			names = append(names, o.name)
+		}
+		return names, nil
+	})
+}
+
+func lookupKind(name string) (kind, error) {
+	for _, k := range kinds {
+		if k.name == name {
+			return k, nil
+		}
+		for _, alias := range k.aliases {
+			if alias == name {
+				return k, nil
+			}
+		}
+	}
+	return kind{}, errors.Errorf("unknown object type %q, must be one of %s", name, strings.Join(kindNames(), ", "))
+}
+
+func runGet(ctx context.Context, dockerCli command.Cli, opts getOptions) error {
+	k, err := lookupKind(opts.kind)
+	if err != nil {
+		return err
+	}
+	switch opts.output {
+	case outputTable, outputJSON, outputName:
+	default:
+		return errors.Errorf("invalid output format %q, must be one of table, json, name", opts.output)
+	}
+	if opts.degraded && !k.degradable {
+		return errors.Errorf("--degraded is not supported for %s", k.name)
+	}
+	if opts.stack != "" && !k.stacked {
+		return errors.Errorf("--stack is not supported for %s", k.name)
+	}
+	if opts.tree && k.name != "services" {
+		return errors.Errorf("--tree is not supported for %s", k.name)
+	}
+	if opts.tree && opts.output != outputTable {
+		return errors.New("--tree is only supported with the table output")
+	}
+	if opts.watch && k.name != "nodes" {
+		return errors.Errorf("--watch is not supported for %s", k.name)
+	}
+	if opts.watch && opts.output != outputTable {
+		return errors.New("--watch is only supported with the table output")
+	}
+	var dataPattern *regexp.Regexp
+	if opts.dataContains != "" {
+		if k.name != "configs" {
+			return errors.Errorf("--data-contains is not supported for %s", k.name)
+		}
+		if dataPattern, err = regexp.Compile(opts.dataContains); err != nil {
+			return errors.Wrap(err, "invalid --data-contains value")
+		}
+	}
+	if (opts.since != "" || opts.until != "") && !k.timed {
+		return errors.Errorf("--since and --until are not supported for %s", k.name)
+	}
+	now := time.Now()
+	since, err := parseTime("since", opts.since, now)
+	if err != nil {
+		return err
+	}
+	until, err := parseTime("until", opts.until, now)
+	if err != nil {
+		return err
+	}
+
+	f := filters.NewArgs()
+	for _, label := range opts.selector {
+		f.Add("label", label)
+	}
+	if opts.stack != "" {
+		f.Add("label", swarmlabel.Namespace+"="+opts.stack)
+	}
+	if opts.watch {
+		return watchNodes(ctx, dockerCli, k, f, opts.names)
+	}
+	objects, err := k.list(ctx, dockerCli.Client(), f)
+	if err != nil {
+		return err
+	}
+	objects, err = selectObjects(k, objects, opts.names)
+	if err != nil {
+		return err
+	}
+	if opts.degraded {
+		objects = degradedObjects(objects)
+	}
+	if !since.IsZero() || !until.IsZero() {
+		objects = objectsBetween(objects, since, until)
+	}
+	if dataPattern != nil {
+		if objects, err = configsMatching(ctx, dockerCli.Client(), objects, dataPattern); err != nil {
+			return err
+		}
+	}
+
+	out := dockerCli.Out()
+	switch opts.output {
+	case outputJSON:
+		r, err := opts.redact.Redactor(dockerCli.In(), dockerCli.Err())
+		if err != nil {
+			return err
+		}
+		raw := make([]interface{}, 0, len(objects))
+		for _, o := range objects {
+			raw = append(raw, redactObject(r, o.raw))
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "    ")
+		return enc.Encode(raw)
+	case outputName:
+		for _, o := range objects {
+			fmt.Fprintln(out, o.name)
+		}
+		return nil
+	default:
+		if opts.tree {
+			return printServiceTree(out, objects)
+		}
+		return printObjects(out, k, objects)
+	}
+}
+
+// redactObject returns raw with its sensitive values masked by r.
+func redactObject(r *redact.Redactor, raw interface{}) interface{} {
+	switch o := raw.(type) {
+	case swarm.Service:
+		r.Service(&o)
+		return o
+	case swarm.Task:
+		r.Task(&o)
+		return o
+	default:
+		return raw
+	}
+}
+
+// selectObjects returns the objects matching names, by full ID, name or ID
+// prefix, in the order of names and each once. All objects are returned if
+// names is empty.
+func selectObjects(k kind, objects []object, names []string) ([]object, error) {
+	if len(names) == 0 {
+		sort.Slice(objects, func(i, j int) bool { return objects[i].name < objects[j].name })
+		return objects, nil
+	}
+	var selected []object
+	seen := make(map[string]bool)
+	for _, name := range names {
+		matches, err := matchObjects(k, objects, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, o := range matches {
+			if !seen[o.id] {
+				seen[o.id] = true
+				selected = append(selected, o)
+			}
+		}
+	}
+	return selected, nil
+}
+
+// matchObjects returns the objects with the full ID or the name given, or
+// else the one whose ID starts with it. A prefix matching several IDs is an
+// error.
+func matchObjects(k kind, objects []object, name string) ([]object, error) {
+	var byName, byPrefix []object
+	for _, o := range objects {
+		switch {
+		case o.id == name:
+			return []object{o}, nil
+		case o.name == name:
+			byName = append(byName, o)
+		case strings.HasPrefix(o.id, name):
+			byPrefix = append(byPrefix, o)
+		}
+	}
+	singular := strings.TrimSuffix(k.name, "s")
+	switch {
+	case len(byName) > 0:
+		return byName, nil
+	case len(byPrefix) == 1:
+		return byPrefix, nil
+	case len(byPrefix) > 1:
+		return nil, errors.Errorf("%s ID prefix %s is ambiguous, it matches %d %s", singular, name, len(byPrefix), k.name)
+	default:
+		return nil, errors.Errorf("no such %s: %s", singular, name)
+	}
+}
+
+func degradedObjects(objects []object) []object {
+	degraded := make([]object, 0, len(objects))
+	for _, o := range objects {
+		if o.degraded {
+			degraded = append(degraded, o)
+		}
+	}
+	return degraded
+}
+
+// parseTime parses the value of the --since or --until flag, a timestamp or
+// a duration before now. The zero time is returned for an empty value.
+func parseTime(flag, value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	ts, err := timetypes.GetTimestamp(value, now)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "invalid --%s value", flag)
+	}
+	sec, nsec, err := timetypes.ParseTimestamps(ts, 0)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "invalid --%s value", flag)
+	}
+	return time.Unix(sec, nsec), nil
+}
+
+// objectsBetween returns the objects that existed between since and until:
+// those updated after since, and created before until. A zero time leaves
+// its end of the window open.
+func objectsBetween(objects []object, since, until time.Time) []object {
+	between := make([]object, 0, len(objects))
+	for _, o := range objects {
+		if !since.IsZero() && o.updated.Before(since) {
+			continue
+		}
+		if !until.IsZero() && o.created.After(until) {
+			continue
+		}
+		between = append(between, o)
+	}
+	return between
+}
+
+func printObjects(out io.Writer, k kind, objects []object) error {
+	w := tabwriter.NewWriter(out, 10, 1, 3, ' ', 0)
+	fmt.Fprintln(w, strings.Join(k.header, "\t"))
+	for _, o := range objects {
+		fmt.Fprintln(w, strings.Join(o.columns, "\t"))
+	}
+	return w.Flush()
+}
+
+// printServiceTree prints the services grouped by stack, each stack with
+// its count of services and of running and desired tasks. The services
+// without a stack are listed last.
+func printServiceTree(out io.Writer, objects []object) error {
+	stacks := make(map[string][]object)
+	for _, o := range objects {
+		stack := o.raw.(swarm.Service).Spec.Labels[swarmlabel.Namespace]
+		stacks[stack] = append(stacks[stack], o)
+	}
+	names := make([]string, 0, len(stacks))
+	for name := range stacks {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if _, ok := stacks[""]; ok {
+		names = append(names, "")
+	}
+
+	w := tabwriter.NewWriter(out, 10, 1, 3, ' ', 0)
+	fmt.Fprintln(w, "NAME\tID\tMODE\tREPLICAS\tUPDATE\tIMAGE")
+	for _, name := range names {
+		services := stacks[name]
+		var running, desired uint64
+		for _, o := range services {
+			if status := o.raw.(swarm.Service).ServiceStatus; status != nil {
+				running += status.RunningTasks
+				desired += status.DesiredTasks
+			}
+		}
+		if name == "" {
+			name = "(no stack)"
+		}
+		count := fmt.Sprintf("%d services", len(services))
+		if len(services) == 1 {
+			count = "1 service"
+		}
+		fmt.Fprintf(w, "%s\t\t%s\t%d/%d\t\t\n", name, count, running, desired)
+		for _, o := range services {
+			columns := append([]string{"  " + o.columns[1], o.columns[0]}, o.columns[2:]...)
+			fmt.Fprintln(w, strings.Join(columns, "\t"))
+		}
+	}
+	return w.Flush()
+}
+
+func listServices(ctx context.Context, apiClient client.APIClient, f filters.Args) ([]object, error) {
+	services, err := apiClient.ServiceList(ctx, types.ServiceListOptions{Filters: f, Status: true})
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]object, 0, len(services))
+	for _, s := range services {
+		update, err := serviceUpdate(ctx, apiClient, s)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, object{
+			id:       s.ID,
+			name:     s.Spec.Name,
+			columns:  []string{stringid.TruncateID(s.ID), s.Spec.Name, serviceMode(s), serviceReplicas(s), update, serviceImage(s)},
+			raw:      s,
+			degraded: !serviceSuspended(s) && s.ServiceStatus != nil && s.ServiceStatus.RunningTasks < s.ServiceStatus.DesiredTasks,
+		})
+	}
+	return objects, nil
+}
+
+func serviceMode(s swarm.Service) string {
+	switch m := s.Spec.Mode; {
+	case m.Global != nil:
+		return "global"
+	case m.ReplicatedJob != nil:
+		return "replicated-job"
+	case m.GlobalJob != nil:
+		return "global-job"
+	default:
+		return "replicated"
+	}
+}
+
+func serviceSuspended(s swarm.Service) bool {
+	_, ok := s.Spec.Labels[swarmlabel.SuspendedReplicas]
+	return ok
+}
+
+func serviceReplicas(s swarm.Service) string {
+	if replicas, ok := s.Spec.Labels[swarmlabel.SuspendedReplicas]; ok {
+		return "suspended (" + replicas + ")"
+	}
+	if s.ServiceStatus == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%d/%d", s.ServiceStatus.RunningTasks, s.ServiceStatus.DesiredTasks)
+}
+
+// serviceUpdate returns the state of the last update of the service, with
+// the share of its tasks running the new spec while it is in progress. Up to
+// date tasks are counted as done by `docker service update`.
+func serviceUpdate(ctx context.Context, apiClient client.APIClient, s swarm.Service) (string, error) {
+	if s.UpdateStatus == nil {
+		return "-", nil
+	}
+	var state string
+	switch s.UpdateStatus.State {
+	case swarm.UpdateStateUpdating:
+		state = "updating"
+	case swarm.UpdateStateRollbackStarted:
+		state = "rolling back"
+	case swarm.UpdateStatePaused:
+		return "paused", nil
+	case swarm.UpdateStateRollbackPaused:
+		return "rollback paused", nil
+	case swarm.UpdateStateRollbackCompleted:
+		return "rolled back", nil
+	default:
+		return string(s.UpdateStatus.State), nil
+	}
+	if s.ServiceStatus == nil || s.ServiceStatus.DesiredTasks == 0 {
+		return state, nil
+	}
+	tasks, err := apiClient.TaskList(ctx, types.TaskListOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("service", s.ID),
+			filters.Arg("desired-state", "running"),
+			filters.Arg("_up-to-date", "true"),
+		),
+	})
+	if err != nil {
+		return "", err
+	}
+	var updated uint64
+	for _, t := range tasks {
+		if t.Status.State == swarm.TaskStateRunning {
+			updated++
+		}
+	}
+	if updated > s.ServiceStatus.DesiredTasks {
+		updated = s.ServiceStatus.DesiredTasks
+	}
+	return fmt.Sprintf("%s %d%%", state, updated*100/s.ServiceStatus.DesiredTasks), nil
+}
+
+// serviceImage returns the image of the service without its digest.
+func serviceImage(s swarm.Service) string {
+	if s.Spec.TaskTemplate.ContainerSpec == nil {
+		return "-"
+	}
+	image, _, _ := strings.Cut(s.Spec.TaskTemplate.ContainerSpec.Image, "@")
+	return image
+}
+
+func listNodes(ctx context.Context, apiClient client.APIClient, f filters.Args) ([]object, error) {
+	nodes, err := apiClient.NodeList(ctx, types.NodeListOptions{Filters: f})
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]object, 0, len(nodes))
+	for _, n := range nodes {
+		managerStatus := "-"
+		if m := n.ManagerStatus; m != nil {
+			managerStatus = string(m.Reachability)
+			if m.Leader {
+				managerStatus = "leader"
+			}
+		}
+		objects = append(objects, object{
+			id:      n.ID,
+			name:    n.Description.Hostname,
+			columns: []string{stringid.TruncateID(n.ID), n.Description.Hostname, string(n.Status.State), string(n.Spec.Availability), managerStatus},
+			raw:     n,
+		})
+	}
+	return objects, nil
+}
+
+func listTasks(ctx context.Context, apiClient client.APIClient, f filters.Args) ([]object, error) {
+	tasks, err := apiClient.TaskList(ctx, types.TaskListOptions{Filters: f})
+	if err != nil {
+		return nil, err
+	}
+	services, err := apiClient.ServiceList(ctx, types.ServiceListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := apiClient.NodeList(ctx, types.NodeListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	serviceNames := make(map[string]string, len(services))
+	for _, s := range services {
+		serviceNames[s.ID] = s.Spec.Name
+	}
+	hostnames := make(map[string]string, len(nodes))
+	for _, n := range nodes {
+		hostnames[n.ID] = n.Description.Hostname
+	}
+
+	objects := make([]object, 0, len(tasks))
+	for _, t := range tasks {
+		serviceName := serviceNames[t.ServiceID]
+		if serviceName == "" {
+			serviceName = t.ServiceID
+		}
+		name := fmt.Sprintf("%s.%d", serviceName, t.Slot)
+		if t.Slot == 0 {
+			name = fmt.Sprintf("%s.%s", serviceName, t.NodeID)
+		}
+		node := hostnames[t.NodeID]
+		if node == "" {
+			node = "-"
+		}
+		objects = append(objects, object{
+			id:       t.ID,
+			name:     name,
+			columns:  []string{stringid.TruncateID(t.ID), name, node, string(t.DesiredState), string(t.Status.State)},
+			raw:      t,
+			degraded: taskDegraded(t),
+			created:  t.CreatedAt,
+			updated:  t.UpdatedAt,
+		})
+	}
+	return objects, nil
+}
+
+// taskDegraded returns whether a task is not in its desired state. Tasks
+// being shut down are never degraded.
+func taskDegraded(t swarm.Task) bool {
+	switch t.DesiredState {
+	case swarm.TaskStateRunning:
+		return t.Status.State != swarm.TaskStateRunning
+	case swarm.TaskStateComplete:
+		return t.Status.State == swarm.TaskStateFailed || t.Status.State == swarm.TaskStateRejected
+	default:
+		return false
+	}
+}
+
+func listConfigs(ctx context.Context, apiClient client.APIClient, f filters.Args) ([]object, error) {
+	configs, err := apiClient.ConfigList(ctx, types.ConfigListOptions{Filters: f})
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]object, 0, len(configs))
+	for _, c := range configs {
+		objects = append(objects, object{
+			id:      c.ID,
+			name:    c.Spec.Name,
+			columns: []string{stringid.TruncateID(c.ID), c.Spec.Name, stackName(c.Spec.Labels)},
+			raw:     c,
+		})
+	}
+	return objects, nil
+}
+
+// inspectConcurrency is the number of objects inspected at the same time.
+const inspectConcurrency = 8
+
+// configsMatching returns the configs whose data matches pattern, reading
+// their data from their inspection.
+func configsMatching(ctx context.Context, apiClient client.APIClient, objects []object, pattern *regexp.Regexp) ([]object, error) {
+	var (
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, inspectConcurrency)
+		matches = make([]bool, len(objects))
+		errs    = make([]error, len(objects))
+	)
+	for i, o := range objects {
+		wg.Add(1)
+		go func(i int, o object) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			config, _, err := apiClient.ConfigInspectWithRaw(ctx, o.id)
+			if err != nil {
+				errs[i] = errors.Wrapf(err, "unable to inspect config %s", o.name)
+				return
+			}
+			matches[i] = pattern.Match(config.Spec.Data)
+		}(i, o)
+	}
+	wg.Wait()
+
+	var matching []object
+	for i, o := range objects {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		if matches[i] {
+			matching = append(matching, o)
+		}
+	}
+	return matching, nil
+}
+
+func listSecrets(ctx context.Context, apiClient client.APIClient, f filters.Args) ([]object, error) {
+	secrets, err := apiClient.SecretList(ctx, types.SecretListOptions{Filters: f})
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]object, 0, len(secrets))
+	for _, s := range secrets {
+		driver := "-"
+		if s.Spec.Driver != nil {
+			driver = s.Spec.Driver.Name
+		}
+		objects = append(objects, object{
+			id:      s.ID,
+			name:    s.Spec.Name,
+			columns: []string{stringid.TruncateID(s.ID), s.Spec.Name, driver, stackName(s.Spec.Labels)},
+			raw:     s,
+		})
+	}
+	return objects, nil
+}
+
+// stackName returns the stack an object was deployed with, or "-".
+func stackName(labels map[string]string) string {
+	if name := labels[swarmlabel.Namespace]; name != "" {
+		return name
+	}
+	return "-"
+}
+
+func listNetworks(ctx context.Context, apiClient client.APIClient, f filters.Args) ([]object, error) {
+	f = f.Clone()
+	f.Add("scope", "swarm")
+	networks, err := apiClient.NetworkList(ctx, types.NetworkListOptions{Filters: f})
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]object, 0, len(networks))
+	for _, n := range networks {
+		objects = append(objects, object{
+			id:      n.ID,
+			name:    n.Name,
+			columns: []string{stringid.TruncateID(n.ID), n.Name, n.Driver, n.Scope},
+			raw:     n,
+		})
+	}
+	return objects, nil
+}
@@ -0,0 +1,471 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/cli/cli/streams"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/compcache"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"github.com/moby/swarmctl/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func getServices() []swarm.Service {
+	replicas := uint64(3)
+	return []swarm.Service{
+		{
+			ID: "svc2aaaaaaaaaaaaaaaaaaaa",
+			Spec: swarm.ServiceSpec{
+				Annotations:  swarm.Annotations{Name: "web"},
+				Mode:         swarm.ServiceMode{Replicated: &swarm.ReplicatedService{Replicas: &replicas}},
+				TaskTemplate: swarm.TaskSpec{ContainerSpec: &swarm.ContainerSpec{Image: "nginx:alpine@sha256:abcdef"}},
+			},
+			ServiceStatus: &swarm.ServiceStatus{RunningTasks: 2, DesiredTasks: 3},
+			UpdateStatus:  &swarm.UpdateStatus{State: swarm.UpdateStateUpdating},
+		},
+		{
+			ID: "svc1bbbbbbbbbbbbbbbbbbbb",
+			Spec: swarm.ServiceSpec{
+				Annotations:  swarm.Annotations{Name: "agent"},
+				Mode:         swarm.ServiceMode{Global: &swarm.GlobalService{}},
+				TaskTemplate: swarm.TaskSpec{ContainerSpec: &swarm.ContainerSpec{Image: "agent:1.0"}},
+			},
+			ServiceStatus: &swarm.ServiceStatus{RunningTasks: 1, DesiredTasks: 1},
+		},
+	}
+}
+
+func getClient() *fakeClient {
+	return &fakeClient{
+		serviceListFn: func(context.Context, types.ServiceListOptions) ([]swarm.Service, error) {
+			return getServices(), nil
+		},
+		nodeListFn: func(context.Context, types.NodeListOptions) ([]swarm.Node, error) {
+			return []swarm.Node{{
+				ID:            "node1cccccccccccccccccccc",
+				Description:   swarm.NodeDescription{Hostname: "manager1"},
+				Spec:          swarm.NodeSpec{Availability: swarm.NodeAvailabilityActive},
+				Status:        swarm.NodeStatus{State: swarm.NodeStateReady},
+				ManagerStatus: &swarm.ManagerStatus{Leader: true, Reachability: swarm.ReachabilityReachable},
+			}}, nil
+		},
+		taskListFn: func(context.Context, types.TaskListOptions) ([]swarm.Task, error) {
+			return []swarm.Task{
+				{
+					ID: "task1dddddddddddddddddddd",
+					Meta: swarm.Meta{
+						CreatedAt: time.Date(2023, time.January, 2, 10, 0, 0, 0, time.UTC),
+						UpdatedAt: time.Date(2023, time.January, 2, 10, 5, 0, 0, time.UTC),
+					},
+					ServiceID:    "svc2aaaaaaaaaaaaaaaaaaaa",
+					NodeID:       "node1cccccccccccccccccccc",
+					Slot:         1,
+					DesiredState: swarm.TaskStateRunning,
+					Status:       swarm.TaskStatus{State: swarm.TaskStateRunning},
+				},
+				{
+					ID: "task2eeeeeeeeeeeeeeeeeeee",
+					Meta: swarm.Meta{
+						CreatedAt: time.Date(2023, time.January, 2, 12, 0, 0, 0, time.UTC),
+						UpdatedAt: time.Date(2023, time.January, 2, 12, 30, 0, 0, time.UTC),
+					},
+					ServiceID:    "svc2aaaaaaaaaaaaaaaaaaaa",
+					Slot:         2,
+					DesiredState: swarm.TaskStateRunning,
+					Status:       swarm.TaskStatus{State: swarm.TaskStatePending},
+				},
+			}, nil
+		},
+	}
+}
+
+func TestGetServices(t *testing.T) {
+	cli := test.NewFakeCli(getClient())
+	cmd := NewGetCommand(cli)
+	cmd.SetArgs([]string{"services"})
+	assert.NilError(t, cmd.Execute())
+	test.AssertGolden(t, cli.OutBuffer().String(), "get-services.golden")
+}
+
+func TestGetTasks(t *testing.T) {
+	cli := test.NewFakeCli(getClient())
+	cmd := NewGetCommand(cli)
+	cmd.SetArgs([]string{"tasks"})
+	assert.NilError(t, cmd.Execute())
+	test.AssertGolden(t, cli.OutBuffer().String(), "get-tasks.golden")
+}
+
+func TestGetNodesByAlias(t *testing.T) {
+	cli := test.NewFakeCli(getClient())
+	cmd := NewGetCommand(cli)
+	cmd.SetArgs([]string{"node", "-o", "name"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "manager1\n"))
+}
+
+func TestGetByNameOrIDPrefix(t *testing.T) {
+	cli := test.NewFakeCli(getClient())
+	cmd := NewGetCommand(cli)
+	cmd.SetArgs([]string{"services", "-o", "name", "web", "svc1"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "web\nagent\n"))
+}
+
+func TestGetDegraded(t *testing.T) {
+	cli := test.NewFakeCli(getClient())
+	cmd := NewGetCommand(cli)
+	cmd.SetArgs([]string{"services", "--degraded", "-o", "name"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "web\n"))
+
+	cli = test.NewFakeCli(getClient())
+	cmd = NewGetCommand(cli)
+	cmd.SetArgs([]string{"tasks", "--degraded", "-o", "name"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "web.2\n"))
+}
+
+func TestGetCompletion(t *testing.T) {
+	defer func(c *compcache.Cache) { completionCache = c }(completionCache)
+	completionCache = compcache.New(t.TempDir(), time.Minute)
+
+	cli := test.NewFakeCli(getClient())
+	cmd := NewGetCommand(cli)
+	names, _ := cmd.ValidArgsFunction(cmd, []string{"svc"}, "")
+	assert.Check(t, is.DeepEqual(names, []string{"web", "agent"}))
+
+	// The names are cached for the current context.
+	cli = test.NewFakeCli(&fakeClient{})
+	cmd = NewGetCommand(cli)
+	names, _ = cmd.ValidArgsFunction(cmd, []string{"services"}, "")
+	assert.Check(t, is.DeepEqual(names, []string{"web", "agent"}))
+	cli.SetCurrentContext("other")
+	names, _ = cmd.ValidArgsFunction(cmd, []string{"services"}, "")
+	assert.Check(t, is.Len(names, 0))
+}
+
+func TestGetStack(t *testing.T) {
+	var labels []string
+	client := &fakeClient{
+		configListFn: func(_ context.Context, options types.ConfigListOptions) ([]swarm.Config, error) {
+			labels = options.Filters.Get("label")
+			return []swarm.Config{
+				{ID: "cfg1aaaaaaaaaaaaaaaaaaaa", Spec: swarm.ConfigSpec{Annotations: swarm.Annotations{Name: "shop_nginx", Labels: map[string]string{swarmlabel.Namespace: "shop"}}}},
+				{ID: "cfg2bbbbbbbbbbbbbbbbbbbb", Spec: swarm.ConfigSpec{Annotations: swarm.Annotations{Name: "standalone"}}},
+			}, nil
+		},
+	}
+
+	cli := test.NewFakeCli(client)
+	cmd := NewGetCommand(cli)
+	cmd.SetArgs([]string{"configs", "--stack", "shop"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.DeepEqual(labels, []string{swarmlabel.Namespace + "=shop"}))
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), `ID             NAME         STACK
+cfg1aaaaaaaa   shop_nginx   shop
+cfg2bbbbbbbb   standalone   -
+`))
+
+	cli = test.NewFakeCli(client)
+	cmd = NewGetCommand(cli)
+	cmd.SetArgs([]string{"nodes", "--stack", "shop"})
+	cmd.SetOut(io.Discard)
+	cmd.SetErr(io.Discard)
+	assert.Error(t, cmd.Execute(), "--stack is not supported for nodes")
+}
+
+func TestGetDataContains(t *testing.T) {
+	data := map[string]string{
+		"cfg1aaaaaaaaaaaaaaaaaaaa": "upstream db-old.example.com:5432",
+		"cfg2bbbbbbbbbbbbbbbbbbbb": "upstream db.example.com:5432",
+		"cfg3cccccccccccccccccccc": "DB_HOST=db-old.example.com",
+	}
+	client := &fakeClient{
+		configListFn: func(context.Context, types.ConfigListOptions) ([]swarm.Config, error) {
+			return []swarm.Config{
+				{ID: "cfg1aaaaaaaaaaaaaaaaaaaa", Spec: swarm.ConfigSpec{Annotations: swarm.Annotations{Name: "nginx"}}},
+				{ID: "cfg2bbbbbbbbbbbbbbbbbbbb", Spec: swarm.ConfigSpec{Annotations: swarm.Annotations{Name: "nginx-v2"}}},
+				{ID: "cfg3cccccccccccccccccccc", Spec: swarm.ConfigSpec{Annotations: swarm.Annotations{Name: "env"}}},
+			}, nil
+		},
+		configInspectFn: func(_ context.Context, id string) (swarm.Config, error) {
+			return swarm.Config{ID: id, Spec: swarm.ConfigSpec{Data: []byte(data[id])}}, nil
+		},
+	}
+
+	cli := test.NewFakeCli(client)
+	cmd := NewGetCommand(cli)
+	cmd.SetArgs([]string{"configs", "-o", "name", "--data-contains", `db-old\.example\.com`})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "env\nnginx\n"))
+}
+
+func TestGetTree(t *testing.T) {
+	client := getClient()
+	client.serviceListFn = func(context.Context, types.ServiceListOptions) ([]swarm.Service, error) {
+		services := getServices()
+		for i, name := range []string{"shop_api", "shop_db"} {
+			s := services[0]
+			s.ID = fmt.Sprintf("svc%dffffffffffffffffffff", i+4)
+			s.Spec.Annotations = swarm.Annotations{Name: name, Labels: map[string]string{swarmlabel.Namespace: "shop"}}
+			s.UpdateStatus = nil
+			s.ServiceStatus = &swarm.ServiceStatus{RunningTasks: 3, DesiredTasks: 3}
+			services = append(services, s)
+		}
+		services[0].Spec.Labels = map[string]string{swarmlabel.Namespace: "blog"}
+		return services, nil
+	}
+
+	cli := test.NewFakeCli(client)
+	cmd := NewGetCommand(cli)
+	cmd.SetArgs([]string{"services", "--tree"})
+	assert.NilError(t, cmd.Execute())
+	test.AssertGolden(t, cli.OutBuffer().String(), "get-services-tree.golden")
+}
+
+func TestGetSuspended(t *testing.T) {
+	replicas := uint64(0)
+	client := &fakeClient{
+		serviceListFn: func(context.Context, types.ServiceListOptions) ([]swarm.Service, error) {
+			return []swarm.Service{{
+				ID: "svc3cccccccccccccccccccc",
+				Spec: swarm.ServiceSpec{
+					Annotations:  swarm.Annotations{Name: "worker", Labels: map[string]string{swarmlabel.SuspendedReplicas: "4"}},
+					Mode:         swarm.ServiceMode{Replicated: &swarm.ReplicatedService{Replicas: &replicas}},
+					TaskTemplate: swarm.TaskSpec{ContainerSpec: &swarm.ContainerSpec{Image: "worker:2"}},
+				},
+				ServiceStatus: &swarm.ServiceStatus{},
+			}}, nil
+		},
+	}
+
+	cli := test.NewFakeCli(client)
+	cmd := NewGetCommand(cli)
+	cmd.SetArgs([]string{"services"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Contains(cli.OutBuffer().String(), "suspended (4)"))
+
+	cli = test.NewFakeCli(client)
+	cmd = NewGetCommand(cli)
+	cmd.SetArgs([]string{"services", "--degraded", "-o", "name"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), ""))
+}
+
+func TestGetSinceUntil(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{args: []string{"--since", "2023-01-02T11:00:00Z"}, expected: "web.2\n"},
+		{args: []string{"--until", "2023-01-02T11:00:00Z"}, expected: "web.1\n"},
+		{args: []string{"--since", "2023-01-02T10:01:00Z", "--until", "2023-01-02T12:00:00Z"}, expected: "web.1\nweb.2\n"},
+		{args: []string{"--since", "2023-01-02T12:31:00Z"}, expected: ""},
+	}
+	for _, tc := range testCases {
+		cli := test.NewFakeCli(getClient())
+		cmd := NewGetCommand(cli)
+		cmd.SetArgs(append([]string{"tasks", "-o", "name"}, tc.args...))
+		assert.NilError(t, cmd.Execute())
+		assert.Check(t, is.Equal(cli.OutBuffer().String(), tc.expected), "%v", tc.args)
+	}
+}
+
+func TestTaskDegraded(t *testing.T) {
+	testCases := []struct {
+		desired  swarm.TaskState
+		current  swarm.TaskState
+		expected bool
+	}{
+		{desired: swarm.TaskStateRunning, current: swarm.TaskStateRunning, expected: false},
+		{desired: swarm.TaskStateRunning, current: swarm.TaskStateStarting, expected: true},
+		{desired: swarm.TaskStateComplete, current: swarm.TaskStateComplete, expected: false},
+		{desired: swarm.TaskStateComplete, current: swarm.TaskStateFailed, expected: true},
+		{desired: swarm.TaskStateShutdown, current: swarm.TaskStateFailed, expected: false},
+	}
+	for _, tc := range testCases {
+		task := swarm.Task{DesiredState: tc.desired, Status: swarm.TaskStatus{State: tc.current}}
+		assert.Check(t, is.Equal(taskDegraded(task), tc.expected), "%s/%s", tc.desired, tc.current)
+	}
+}
+
+func TestGetNotFound(t *testing.T) {
+	cli := test.NewFakeCli(getClient())
+	cmd := NewGetCommand(cli)
+	cmd.SetArgs([]string{"services", "db"})
+	assert.Error(t, cmd.Execute(), "no such service: db")
+}
+
+func TestGetSelector(t *testing.T) {
+	var filters []string
+	cli := test.NewFakeCli(&fakeClient{
+		networkListFn: func(_ context.Context, options types.NetworkListOptions) ([]types.NetworkResource, error) {
+			filters = append(filters, options.Filters.Get("label")...)
+			filters = append(filters, options.Filters.Get("scope")...)
+			return []types.NetworkResource{{ID: "net1", Name: "backend", Driver: "overlay", Scope: "swarm"}}, nil
+		},
+	})
+	cmd := NewGetCommand(cli)
+	cmd.SetArgs([]string{"networks", "-l", "tier=back", "-o", "json"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.DeepEqual(filters, []string{"tier=back", "swarm"}))
+	assert.Check(t, is.Contains(cli.OutBuffer().String(), `"Name": "backend"`))
+}
+
+func TestGetRedacted(t *testing.T) {
+	client := &fakeClient{
+		serviceListFn: func(context.Context, types.ServiceListOptions) ([]swarm.Service, error) {
+			services := getServices()
+			services[0].Spec.TaskTemplate.ContainerSpec.Env = []string{"DB_PASSWORD=hunter2", "PORT=80"}
+			return services, nil
+		},
+	}
+	cli := test.NewFakeCli(client)
+	cmd := NewGetCommand(cli)
+	cmd.SetArgs([]string{"services", "web", "-o", "json"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Contains(cli.OutBuffer().String(), `"DB_PASSWORD=[redacted]",`))
+	assert.Check(t, is.Contains(cli.OutBuffer().String(), `"PORT=80"`))
+
+	cli = test.NewFakeCli(client)
+	cli.SetIn(streams.NewIn(io.NopCloser(strings.NewReader("y\n"))))
+	cmd = NewGetCommand(cli)
+	cmd.SetArgs([]string{"services", "web", "-o", "json", "--no-redact"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Contains(cli.OutBuffer().String(), `"DB_PASSWORD=hunter2"`))
+	assert.Check(t, is.Contains(cli.ErrBuffer().String(), "Are you sure you want to continue? [y/N]"))
+}
+
+func TestGetSelectNameBeforeIDPrefix(t *testing.T) {
+	apiClient := getClient()
+	apiClient.serviceListFn = func(context.Context, types.ServiceListOptions) ([]swarm.Service, error) {
+		services := getServices()
+		services[0].Spec.Name = "svc1"
+		return services, nil
+	}
+	cli := test.NewFakeCli(apiClient)
+	cmd := NewGetCommand(cli)
+	// svc1 is the name of a service and the ID prefix of another one
+	cmd.SetArgs([]string{"services", "-o", "name", "svc1", "agent", "svc1"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "svc1\nagent\n"))
+}
+
+func TestGetInvalid(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{
+			args:     []string{"volumes"},
+			expected: `unknown object type "volumes", must be one of services, nodes, tasks, configs, secrets, networks`,
+		},
+		{
+			args:     []string{"services", "svc"},
+			expected: "service ID prefix svc is ambiguous, it matches 2 services",
+		},
+		{
+			args:     []string{"services", "db"},
+			expected: "no such service: db",
+		},
+		{
+			args:     []string{"services", "-o", "yaml"},
+			expected: `invalid output format "yaml", must be one of table, json, name`,
+		},
+		{
+			args:     []string{"nodes", "--degraded"},
+			expected: "--degraded is not supported for nodes",
+		},
+		{
+			args:     []string{"tasks", "--tree"},
+			expected: "--tree is not supported for tasks",
+		},
+		{
+			args:     []string{"services", "--tree", "-o", "json"},
+			expected: "--tree is only supported with the table output",
+		},
+		{
+			args:     []string{"services", "--watch"},
+			expected: "--watch is not supported for services",
+		},
+		{
+			args:     []string{"nodes", "--watch", "-o", "json"},
+			expected: "--watch is only supported with the table output",
+		},
+		{
+			args:     []string{"secrets", "--data-contains", "db"},
+			expected: "--data-contains is not supported for secrets",
+		},
+		{
+			args:     []string{"configs", "--data-contains", "db("},
+			expected: "invalid --data-contains value: error parsing regexp: missing closing ): `db(`",
+		},
+		{
+			args:     []string{"services", "--since", "1h"},
+			expected: "--since and --until are not supported for services",
+		},
+		{
+			args:     []string{"tasks", "--until", "yesterday"},
+			expected: `invalid --until value: failed to parse value as time or duration: "yesterday"`,
+		},
+	}
+	for _, tc := range testCases {
+		cmd := NewGetCommand(test.NewFakeCli(getClient()))
+		cmd.SetArgs(tc.args)
+		assert.Error(t, cmd.Execute(), tc.expected)
+	}
+}
+
+func TestGetNodesWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	nodeEvent := func(ago time.Duration, attrs map[string]string) events.Message {
+		return events.Message{
+			Type:     events.NodeEventType,
+			Action:   "update",
+			Actor:    events.Actor{ID: "node1cccccccccccccccccccc", Attributes: attrs},
+			TimeNano: time.Now().Add(-ago).UnixNano(),
+		}
+	}
+	apiClient := getClient()
+	apiClient.eventsFn = func(_ context.Context, options types.EventsOptions) (<-chan events.Message, <-chan error) {
+		messages := make(chan events.Message)
+		errs := make(chan error, 1)
+		go func() {
+			if options.Until != "" {
+				messages <- nodeEvent(2*time.Minute, map[string]string{"state.old": "ready", "state.new": "down"})
+				messages <- nodeEvent(time.Minute, map[string]string{"state.old": "down", "state.new": "ready"})
+				errs <- io.EOF
+				return
+			}
+			messages <- nodeEvent(0, map[string]string{"availability.old": "active", "availability.new": "drain"})
+			messages <- nodeEvent(0, map[string]string{"name": "manager1"})
+			<-ctx.Done()
+			errs <- ctx.Err()
+		}()
+		return messages, errs
+	}
+	nodeList := apiClient.nodeListFn
+	lists := 0
+	apiClient.nodeListFn = func(ctx context.Context, options types.NodeListOptions) ([]swarm.Node, error) {
+		// the initial list, then one per event
+		if lists++; lists == 3 {
+			cancel()
+		}
+		return nodeList(ctx, options)
+	}
+	cli := test.NewFakeCli(apiClient)
+	cmd := NewGetCommand(cli)
+	cmd.SetArgs([]string{"nodes", "--watch"})
+	assert.NilError(t, cmd.ExecuteContext(ctx))
+	test.AssertGolden(t, cli.OutBuffer().String(), "get-nodes-watch.golden")
+}
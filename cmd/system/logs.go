@@ -0,0 +1,549 @@
+package system
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/docker/docker/pkg/stringid"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// Context attributes set by the daemon on the log lines of swarm tasks.
+const (
+	logNodeID    = "com.docker.swarm.node.id"
+	logServiceID = "com.docker.swarm.service.id"
+	logTaskID    = "com.docker.swarm.task.id"
+)
+
+// Orders of the log lines, set by --sort.
+const (
+	logSortArrival = "arrival"
+	logSortTime    = "time"
+)
+
+type logsOptions struct {
+	ref        string
+	services   []string
+	sort       string
+	follow     bool
+	since      string
+	tail       string
+	timestamps bool
+	details    bool
+	raw        bool
+	mergeJSON  bool
+}
+
+// NewLogsCommand creates a new cobra.Command for `swarmctl logs`
+func NewLogsCommand(dockerCli command.Cli) *cobra.Command {
+	opts := logsOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "logs [OPTIONS] [stack/NAME|service/NAME|task/ID]",
+		Short: "Fetch the logs of a stack, a service or a task",
+		Long: `Fetch the logs of a stack, a service or a task.
+
+Each line is prefixed with the task and the node it comes from. A reference
+without type is a service. --services fetches the logs of several services
+instead of a reference.
+
+The streams are printed as their lines are received. With --sort time, the
+lines of all the streams are printed once they ended, ordered by timestamp,
+with their prefixes aligned. It cannot be used with --follow.
+
+With --merge-json, the lines of stdout and stderr are printed to stdout as
+JSON objects, with a "swarm" object added holding the service, task, node
+and stream of the line, and its timestamp with --timestamps. Lines that are
+not JSON objects are printed in the "message" field.`,
+		Example: `  $ swarmctl logs stack/shop --since 10m
+  $ swarmctl logs --services shop_web,shop_api --since 10m --sort time`,
+		Args: cli.RequiresMaxArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 {
+				opts.ref = args[0]
+			}
+			return runLogs(cmd.Context(), dockerCli, opts)
+		},
+		ValidArgsFunction: completion.NoComplete,
+		Annotations: map[string]string{
+			"version": "1.29",
+			"swarm":   "manager",
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringSliceVar(&opts.services, "services", nil, "Fetch the logs of these services instead of a reference")
+	flags.StringVar(&opts.sort, "sort", logSortArrival, `Order of the lines of all the streams ("arrival", "time")`)
+	flags.BoolVarP(&opts.follow, "follow", "f", false, "Follow log output")
+	flags.StringVar(&opts.since, "since", "", `Show logs since timestamp (e.g. "2013-01-02T13:23:37Z") or relative (e.g. "42m" for 42 minutes)`)
+	flags.StringVarP(&opts.tail, "tail", "n", "all", "Number of lines to show from the end of the logs")
+	flags.BoolVarP(&opts.timestamps, "timestamps", "t", false, "Show timestamps")
+	flags.BoolVar(&opts.details, "details", false, "Show extra details provided to logs")
+	flags.BoolVar(&opts.raw, "raw", false, "Do not prefix lines with the task and node")
+	flags.BoolVar(&opts.mergeJSON, "merge-json", false, "Print the lines as JSON objects with their service, task and node (NDJSON)")
+	return cmd
+}
+
+// logSource is a stream of logs.
+type logSource struct {
+	name string
+	tty  bool
+	open func(ctx context.Context, options types.ContainerLogsOptions) (io.ReadCloser, error)
+}
+
+func runLogs(ctx context.Context, dockerCli command.Cli, opts logsOptions) error {
+	switch {
+	case opts.ref == "" && len(opts.services) == 0:
+		return errors.New("requires a reference or --services")
+	case opts.ref != "" && len(opts.services) > 0:
+		return errors.New("--services cannot be used with a reference")
+	}
+	var sorted *sortedLogs
+	switch opts.sort {
+	case logSortArrival:
+	case logSortTime:
+		if opts.follow {
+			return errors.New("--sort time cannot be used with --follow")
+		}
+		sorted = &sortedLogs{}
+	default:
+		return errors.Errorf(`invalid --sort value %q, must be "arrival" or "time"`, opts.sort)
+	}
+
+	apiClient := dockerCli.Client()
+	var (
+		sources []logSource
+		err     error
+	)
+	if opts.ref != "" {
+		sources, err = logSources(ctx, apiClient, opts.ref)
+	} else {
+		sources, err = serviceLogSources(ctx, apiClient, opts.services)
+	}
+	if err != nil {
+		return err
+	}
+
+	options := types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Since:      opts.since,
+		// lines are sorted by their timestamp
+		Timestamps: opts.timestamps || sorted != nil,
+		Follow:     opts.follow,
+		Tail:       opts.tail,
+		// the details carry the task and node of each line
+		Details: true,
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs = make([]error, len(sources))
+	)
+	p := &logPrefixer{apiClient: apiClient, tasks: map[string]logOrigin{}, nodes: map[string]string{}}
+	for i, source := range sources {
+		wg.Add(1)
+		go func(i int, source logSource) {
+			defer wg.Done()
+			stdout := &logWriter{ctx: ctx, mu: &mu, out: dockerCli.Out(), stream: "stdout", prefixer: p, sorted: sorted, opts: opts}
+			stderr := &logWriter{ctx: ctx, mu: &mu, out: dockerCli.Err(), stream: "stderr", prefixer: p, sorted: sorted, opts: opts}
+			if opts.mergeJSON {
+				stderr.out = dockerCli.Out()
+			}
+			errs[i] = streamLogs(ctx, source, options, stdout, stderr)
+			if errs[i] != nil {
+				errs[i] = errors.Wrap(errs[i], source.name)
+			}
+		}(i, source)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	if sorted != nil {
+		return sorted.print()
+	}
+	return nil
+}
+
+// serviceLogSources returns the log streams of services, in the given
+// order.
+func serviceLogSources(ctx context.Context, apiClient client.APIClient, names []string) ([]logSource, error) {
+	sources := make([]logSource, 0, len(names))
+	for _, name := range names {
+		service, _, err := apiClient.ServiceInspectWithRaw(ctx, name, types.ServiceInspectOptions{})
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, serviceLogSource(apiClient, service))
+	}
+	return sources, nil
+}
+
+// logSources resolves a reference to the log streams to fetch.
+func logSources(ctx context.Context, apiClient client.APIClient, ref string) ([]logSource, error) {
+	kind, name, ok := strings.Cut(ref, "/")
+	if !ok {
+		kind, name = "service", ref
+	}
+	if name == "" {
+		return nil, errors.Errorf("invalid reference %q, must be stack/NAME, service/NAME or task/ID", ref)
+	}
+
+	switch kind {
+	case "stack":
+		services, err := apiClient.ServiceList(ctx, types.ServiceListOptions{
+			Filters: filters.NewArgs(filters.Arg("label", swarmlabel.Namespace+"="+name)),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(services) == 0 {
+			return nil, errors.Errorf("nothing found in stack: %s", name)
+		}
+		sort.Slice(services, func(i, j int) bool { return services[i].Spec.Name < services[j].Spec.Name })
+		sources := make([]logSource, 0, len(services))
+		for _, service := range services {
+			sources = append(sources, serviceLogSource(apiClient, service))
+		}
+		return sources, nil
+	case "service", "svc":
+		service, _, err := apiClient.ServiceInspectWithRaw(ctx, name, types.ServiceInspectOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return []logSource{serviceLogSource(apiClient, service)}, nil
+	case "task":
+		task, _, err := apiClient.TaskInspectWithRaw(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		return []logSource{{
+			name: "task " + task.ID,
+			tty:  task.Spec.ContainerSpec != nil && task.Spec.ContainerSpec.TTY,
+			open: func(ctx context.Context, options types.ContainerLogsOptions) (io.ReadCloser, error) {
+				return apiClient.TaskLogs(ctx, task.ID, options)
+			},
+		}}, nil
+	default:
+		return nil, errors.Errorf("invalid reference %q, must be stack/NAME, service/NAME or task/ID", ref)
+	}
+}
+
+func serviceLogSource(apiClient client.APIClient, service swarm.Service) logSource {
+	return logSource{
+		name: "service " + service.Spec.Name,
+		tty:  service.Spec.TaskTemplate.ContainerSpec != nil && service.Spec.TaskTemplate.ContainerSpec.TTY,
+		open: func(ctx context.Context, options types.ContainerLogsOptions) (io.ReadCloser, error) {
+			return apiClient.ServiceLogs(ctx, service.ID, options)
+		},
+	}
+}
+
+func streamLogs(ctx context.Context, source logSource, options types.ContainerLogsOptions, stdout, stderr *logWriter) error {
+	responseBody, err := source.open(ctx, options)
+	if err != nil {
+		return err
+	}
+	defer responseBody.Close()
+
+	if source.tty {
+		_, err = io.Copy(stdout, responseBody)
+	} else {
+		_, err = stdcopy.StdCopy(stdout, stderr, responseBody)
+	}
+	if err == nil {
+		err = stdout.Flush()
+	}
+	if err == nil {
+		err = stderr.Flush()
+	}
+	return err
+}
+
+// logPrefixer names the task and node of log lines, from the IDs in their
+// details. Names are looked up once, and shared by all the streams.
+type logPrefixer struct {
+	apiClient client.APIClient
+	mu        sync.Mutex
+	tasks     map[string]logOrigin
+	nodes     map[string]string
+}
+
+// logOrigin names the service, task and node a log line comes from.
+type logOrigin struct {
+	Service string `json:"service"`
+	Task    string `json:"task"`
+	Node    string `json:"node"`
+}
+
+func (p *logPrefixer) origin(ctx context.Context, attrs map[string]string) logOrigin {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	taskID, nodeID := attrs[logTaskID], attrs[logNodeID]
+	origin, ok := p.tasks[taskID]
+	if !ok {
+		origin = logOrigin{Service: stringid.TruncateID(attrs[logServiceID]), Task: stringid.TruncateID(taskID)}
+		if t, _, err := p.apiClient.TaskInspectWithRaw(ctx, taskID); err == nil {
+			origin.Service = t.ServiceID
+			if s, _, err := p.apiClient.ServiceInspectWithRaw(ctx, t.ServiceID, types.ServiceInspectOptions{}); err == nil {
+				origin.Service = s.Spec.Name
+			}
+			if t.Slot != 0 {
+				origin.Task = fmt.Sprintf("%s.%d.%s", origin.Service, t.Slot, origin.Task)
+			} else {
+				origin.Task = fmt.Sprintf("%s.%s", origin.Service, origin.Task)
+			}
+		}
+		p.tasks[taskID] = origin
+	}
+	node, ok := p.nodes[nodeID]
+	if !ok {
+		node = stringid.TruncateID(nodeID)
+		if n, _, err := p.apiClient.NodeInspectWithRaw(ctx, nodeID); err == nil {
+			node = n.Description.Hostname
+		}
+		p.nodes[nodeID] = node
+	}
+	origin.Node = node
+	return origin
+}
+
+func (p *logPrefixer) prefix(ctx context.Context, attrs map[string]string) string {
+	origin := p.origin(ctx, attrs)
+	return origin.Task + "@" + origin.Node
+}
+
+// logWriter rewrites the lines of a log stream with their prefix, and
+// writes them whole so that concurrent streams do not interleave. With
+// sorted set, the lines are collected instead.
+type logWriter struct {
+	ctx      context.Context
+	mu       *sync.Mutex
+	out      io.Writer
+	stream   string
+	prefixer *logPrefixer
+	sorted   *sortedLogs
+	opts     logsOptions
+	buf      []byte
+}
+
+// logLine is a rewritten log line.
+type logLine struct {
+	time      time.Time
+	out       io.Writer
+	timestamp string
+	prefix    string
+	text      []byte
+}
+
+// format returns the line, with its prefix padded to width.
+func (l logLine) format(width int) []byte {
+	var out bytes.Buffer
+	if l.timestamp != "" {
+		out.WriteString(l.timestamp + " ")
+	}
+	if l.prefix != "" {
+		fmt.Fprintf(&out, "%-*s    | ", width, l.prefix)
+	}
+	out.Write(l.text)
+	return out.Bytes()
+}
+
+// sortedLogs collects the lines of all the streams with --sort time, to
+// print them by timestamp once the streams ended.
+type sortedLogs struct {
+	mu    sync.Mutex
+	lines []logLine
+}
+
+func (s *sortedLogs) add(line logLine) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lines = append(s.lines, line)
+}
+
+// print writes the lines ordered by timestamp, those of a stream with the
+// same timestamp in the order they were received.
+func (s *sortedLogs) print() error {
+	sort.SliceStable(s.lines, func(i, j int) bool { return s.lines[i].time.Before(s.lines[j].time) })
+	width := 0
+	for _, line := range s.lines {
+		if len(line.prefix) > width {
+			width = len(line.prefix)
+		}
+	}
+	for _, line := range s.lines {
+		if _, err := line.out.Write(line.format(width)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := w.buf[:i]
+		if err := w.writeLine(line); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[i+1:]
+	}
+}
+
+// Flush writes the last line of the stream if it has no newline.
+func (w *logWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	err := w.writeLine(w.buf)
+	w.buf = nil
+	return err
+}
+
+func (w *logWriter) writeLine(line []byte) error {
+	var timestamp string
+	if w.opts.timestamps || w.sorted != nil {
+		ts, rest, ok := bytes.Cut(line, []byte(" "))
+		if !ok {
+			return errors.Errorf("invalid log line: %q", line)
+		}
+		timestamp, line = string(ts), rest
+	}
+	details, message, ok := bytes.Cut(line, []byte(" "))
+	if !ok {
+		return errors.Errorf("invalid log line: %q", line)
+	}
+	attrs, extra, err := parseLogDetails(string(details))
+	if err != nil {
+		return err
+	}
+
+	l := logLine{out: w.out}
+	if w.sorted != nil {
+		if l.time, err = time.Parse(time.RFC3339Nano, timestamp); err != nil {
+			return errors.Errorf("invalid log timestamp: %q", timestamp)
+		}
+		if !w.opts.timestamps {
+			timestamp = ""
+		}
+	}
+	if w.opts.mergeJSON {
+		if l.text, err = w.formatJSON(timestamp, attrs, extra, message); err != nil {
+			return err
+		}
+	} else {
+		l.timestamp = timestamp
+		if !w.opts.raw {
+			l.prefix = w.prefixer.prefix(w.ctx, attrs)
+		}
+		var text bytes.Buffer
+		if w.opts.details && extra != "" {
+			text.WriteString(extra + " ")
+		}
+		text.Write(message)
+		text.WriteByte('\n')
+		l.text = text.Bytes()
+	}
+	if w.sorted != nil {
+		w.sorted.add(l)
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err = w.out.Write(l.format(0))
+	return err
+}
+
+// jsonLogOrigin is the "swarm" object added to the lines printed with
+// --merge-json.
+type jsonLogOrigin struct {
+	logOrigin
+	Stream    string `json:"stream"`
+	Timestamp string `json:"timestamp,omitempty"`
+	Details   string `json:"details,omitempty"`
+}
+
+// formatJSON returns a line as a JSON object with its origin. The fields of
+// lines that are JSON objects are kept, numbers as they were written.
+func (w *logWriter) formatJSON(timestamp string, attrs map[string]string, extra string, message []byte) ([]byte, error) {
+	origin := jsonLogOrigin{logOrigin: w.prefixer.origin(w.ctx, attrs), Stream: w.stream, Timestamp: timestamp}
+	if w.opts.details {
+		origin.Details = extra
+	}
+	var fields map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(message))
+	dec.UseNumber()
+	if dec.Decode(&fields) != nil || fields == nil || dec.More() {
+		fields = map[string]interface{}{"message": string(message)}
+	}
+	fields["swarm"] = origin
+	out, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// parseLogDetails returns the swarm attributes of a log line, and its other
+// details as sent by the daemon.
+func parseLogDetails(details string) (map[string]string, string, error) {
+	attrs := make(map[string]string)
+	var extra []string
+	if details == "" {
+		return attrs, "", nil
+	}
+	for _, kv := range strings.Split(details, ",") {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			return nil, "", errors.Errorf("invalid log details: %q", details)
+		}
+		key, err := url.QueryUnescape(k)
+		if err != nil {
+			return nil, "", err
+		}
+		value, err := url.QueryUnescape(v)
+		if err != nil {
+			return nil, "", err
+		}
+		switch key {
+		case logNodeID, logServiceID, logTaskID:
+			attrs[key] = value
+		default:
+			extra = append(extra, kv)
+		}
+	}
+	return attrs, strings.Join(extra, ","), nil
+}
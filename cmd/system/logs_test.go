@@ -0,0 +1,200 @@
+package system
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"github.com/moby/swarmctl/internal/test"
+	"github.com/pkg/errors"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+// logStream returns a multiplexed log stream with the details of a task.
+func logStream(taskID string, stdout, stderr []string) io.ReadCloser {
+	buf := new(bytes.Buffer)
+	details := fmt.Sprintf("com.docker.swarm.node.id=node1,com.docker.swarm.service.id=svc-%s,com.docker.swarm.task.id=%s", taskID, taskID)
+	for _, line := range stdout {
+		fmt.Fprintf(stdcopy.NewStdWriter(buf, stdcopy.Stdout), "%s %s\n", details, line)
+	}
+	for _, line := range stderr {
+		fmt.Fprintf(stdcopy.NewStdWriter(buf, stdcopy.Stderr), "%s %s\n", details, line)
+	}
+	return io.NopCloser(buf)
+}
+
+func logsClient(t *testing.T) *fakeClient {
+	return &fakeClient{
+		serviceListFn: func(_ context.Context, options types.ServiceListOptions) ([]swarm.Service, error) {
+			assert.Check(t, is.DeepEqual(options.Filters.Get("label"), []string{swarmlabel.Namespace + "=shop"}))
+			return []swarm.Service{
+				{ID: "svc-web", Spec: swarm.ServiceSpec{Annotations: swarm.Annotations{Name: "shop_web"}}},
+				{ID: "svc-api", Spec: swarm.ServiceSpec{Annotations: swarm.Annotations{Name: "shop_api"}}},
+			}, nil
+		},
+		serviceInspectFn: func(_ context.Context, ref string) (swarm.Service, error) {
+			switch ref {
+			case "shop_web", "svc-web":
+				return swarm.Service{ID: "svc-web", Spec: swarm.ServiceSpec{Annotations: swarm.Annotations{Name: "shop_web"}}}, nil
+			case "shop_api", "svc-api":
+				return swarm.Service{ID: "svc-api", Spec: swarm.ServiceSpec{Annotations: swarm.Annotations{Name: "shop_api"}}}, nil
+			}
+			return swarm.Service{}, errors.Errorf("no such service: %s", ref)
+		},
+		serviceLogsFn: func(_ context.Context, serviceID string, options types.ContainerLogsOptions) (io.ReadCloser, error) {
+			assert.Check(t, options.Details)
+			switch serviceID {
+			case "svc-web":
+				return logStream("web", []string{"GET /", "GET /cart"}, []string{"oops"}), nil
+			default:
+				return logStream("api", []string{"ready"}, nil), nil
+			}
+		},
+		taskInspectFn: func(_ context.Context, taskID string) (swarm.Task, error) {
+			return swarm.Task{ID: taskID, ServiceID: "svc-" + taskID, Slot: 1}, nil
+		},
+		nodeInspectFn: func(context.Context, string) (swarm.Node, error) {
+			return swarm.Node{Description: swarm.NodeDescription{Hostname: "worker1"}}, nil
+		},
+	}
+}
+
+func TestLogsService(t *testing.T) {
+	cli := test.NewFakeCli(logsClient(t))
+	cmd := NewLogsCommand(cli)
+	cmd.SetArgs([]string{"service/shop_web"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "shop_web.1.web@worker1    | GET /\nshop_web.1.web@worker1    | GET /cart\n"))
+	assert.Check(t, is.Equal(cli.ErrBuffer().String(), "shop_web.1.web@worker1    | oops\n"))
+}
+
+func TestLogsStack(t *testing.T) {
+	cli := test.NewFakeCli(logsClient(t))
+	cmd := NewLogsCommand(cli)
+	cmd.SetArgs([]string{"stack/shop", "--raw"})
+	assert.NilError(t, cmd.Execute())
+	// streams are concurrent, only the order of the lines of each is known
+	out := cli.OutBuffer().String()
+	assert.Check(t, is.Contains(out, "GET /\nGET /cart\n"))
+	assert.Check(t, is.Contains(out, "ready\n"))
+	assert.Check(t, is.Len(out, len("GET /\nGET /cart\nready\n")))
+}
+
+func TestLogsTaskTimestamps(t *testing.T) {
+	apiClient := logsClient(t)
+	apiClient.taskLogsFn = func(_ context.Context, taskID string, options types.ContainerLogsOptions) (io.ReadCloser, error) {
+		assert.Check(t, options.Timestamps)
+		assert.Check(t, is.Equal(options.Tail, "10"))
+		buf := new(bytes.Buffer)
+		fmt.Fprintf(stdcopy.NewStdWriter(buf, stdcopy.Stdout), "2024-01-31T03:00:00.000000000Z com.docker.swarm.task.id=%s,com.docker.swarm.node.id=node1,env=prod started", taskID)
+		return io.NopCloser(buf), nil
+	}
+	cli := test.NewFakeCli(apiClient)
+	cmd := NewLogsCommand(cli)
+	cmd.SetArgs([]string{"task/web", "-t", "--tail", "10", "--details"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "2024-01-31T03:00:00.000000000Z shop_web.1.web@worker1    | env=prod started\n"))
+}
+
+func TestLogsInvalid(t *testing.T) {
+	testCases := []struct {
+		ref      string
+		expected string
+	}{
+		{ref: "volume/data", expected: `invalid reference "volume/data", must be stack/NAME, service/NAME or task/ID`},
+		{ref: "stack/", expected: `invalid reference "stack/", must be stack/NAME, service/NAME or task/ID`},
+		{ref: "db", expected: "no such service: db"},
+	}
+	for _, tc := range testCases {
+		cmd := NewLogsCommand(test.NewFakeCli(logsClient(t)))
+		cmd.SetArgs([]string{tc.ref})
+		assert.Check(t, is.Error(cmd.Execute(), tc.expected))
+	}
+}
+
+func TestLogsEmptyStack(t *testing.T) {
+	apiClient := logsClient(t)
+	apiClient.serviceListFn = func(context.Context, types.ServiceListOptions) ([]swarm.Service, error) {
+		return nil, nil
+	}
+	cmd := NewLogsCommand(test.NewFakeCli(apiClient))
+	cmd.SetArgs([]string{"stack/shop"})
+	assert.Error(t, cmd.Execute(), "nothing found in stack: shop")
+}
+
+func TestLogsMergeJSON(t *testing.T) {
+	apiClient := logsClient(t)
+	apiClient.serviceLogsFn = func(_ context.Context, serviceID string, options types.ContainerLogsOptions) (io.ReadCloser, error) {
+		return logStream("web", []string{`{"level":"info","msg":"GET /","duration":0.10}`, "plain text"}, []string{`{"level":"error","swarm":"overwritten"}`}), nil
+	}
+	cli := test.NewFakeCli(apiClient)
+	cmd := NewLogsCommand(cli)
+	cmd.SetArgs([]string{"service/shop_web", "--merge-json"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), `{"duration":0.10,"level":"info","msg":"GET /","swarm":{"service":"shop_web","task":"shop_web.1.web","node":"worker1","stream":"stdout"}}
+{"message":"plain text","swarm":{"service":"shop_web","task":"shop_web.1.web","node":"worker1","stream":"stdout"}}
+{"level":"error","swarm":{"service":"shop_web","task":"shop_web.1.web","node":"worker1","stream":"stderr"}}
+`))
+	assert.Check(t, is.Equal(cli.ErrBuffer().String(), ""))
+}
+
+func TestLogsServicesSortTime(t *testing.T) {
+	apiClient := logsClient(t)
+	apiClient.serviceLogsFn = func(_ context.Context, serviceID string, options types.ContainerLogsOptions) (io.ReadCloser, error) {
+		assert.Check(t, options.Timestamps)
+		assert.Check(t, is.Equal(options.Since, "10m"))
+		buf := new(bytes.Buffer)
+		write := func(stream stdcopy.StdType, ts, task, line string) {
+			fmt.Fprintf(stdcopy.NewStdWriter(buf, stream), "2024-01-31T03:00:%sZ com.docker.swarm.node.id=node1,com.docker.swarm.task.id=%s %s\n", ts, task, line)
+		}
+		switch serviceID {
+		case "svc-web":
+			write(stdcopy.Stdout, "01.5", "web", "GET /cart")
+			write(stdcopy.Stdout, "03", "web", "200 /cart")
+			write(stdcopy.Stderr, "02.25", "web", "slow upstream")
+		default:
+			write(stdcopy.Stdout, "02", "api", "GET /items")
+		}
+		return io.NopCloser(buf), nil
+	}
+	apiClient.taskInspectFn = func(_ context.Context, taskID string) (swarm.Task, error) {
+		slot := 1
+		if taskID == "api" {
+			slot = 12
+		}
+		return swarm.Task{ID: taskID, ServiceID: "svc-" + taskID, Slot: slot}, nil
+	}
+	cli := test.NewFakeCli(apiClient)
+	cmd := NewLogsCommand(cli)
+	cmd.SetArgs([]string{"--services", "shop_web,shop_api", "--since", "10m", "--sort", "time"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), `shop_web.1.web@worker1     | GET /cart
+shop_api.12.api@worker1    | GET /items
+shop_web.1.web@worker1     | 200 /cart
+`))
+	assert.Check(t, is.Equal(cli.ErrBuffer().String(), "shop_web.1.web@worker1     | slow upstream\n"))
+}
+
+func TestLogsOptionsInvalid(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{args: []string{}, expected: "requires a reference or --services"},
+		{args: []string{"stack/shop", "--services", "shop_web"}, expected: "--services cannot be used with a reference"},
+		{args: []string{"stack/shop", "--sort", "time", "--follow"}, expected: "--sort time cannot be used with --follow"},
+		{args: []string{"stack/shop", "--sort", "name"}, expected: `invalid --sort value "name", must be "arrival" or "time"`},
+	}
+	for _, tc := range testCases {
+		cmd := NewLogsCommand(test.NewFakeCli(logsClient(t)))
+		cmd.SetArgs(tc.args)
+		assert.Check(t, is.Error(cmd.Execute(), tc.expected), tc.args)
+	}
+}
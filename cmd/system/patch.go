@@ -0,0 +1,123 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/moby/swarmctl/internal/freeze"
+	"github.com/moby/swarmctl/internal/i18n"
+	"github.com/moby/swarmctl/internal/spec"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// Patch types of `swarmctl patch`.
+const (
+	patchTypeMerge = "merge"
+	patchTypeJSON  = "json"
+)
+
+type patchOptions struct {
+	patchType      string
+	patch          string
+	patchFile      string
+	overrideFreeze bool
+}
+
+// NewPatchCommand creates a new cobra.Command for `swarmctl patch`
+func NewPatchCommand(dockerCli command.Cli) *cobra.Command {
+	opts := patchOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "patch [OPTIONS] TYPE NAME",
+		Short: "Patch the spec of a service, node or config",
+		Long: `Patch the spec of a service, node or config.
+
+The patch is either a JSON merge patch (RFC 7386) or a JSON patch (RFC 6902),
+and applies to the spec as displayed by "docker inspect", for example:
+
+  swarmctl patch service web -p '{"TaskTemplate":{"ContainerSpec":{"Image":"nginx:1.25"}}}'
+  swarmctl patch service web --type json -p '[{"op":"add","path":"/TaskTemplate/ContainerSpec/Env/-","value":"DEBUG=1"}]'
+
+If the object was updated in the meantime, the patch is applied again to its
+new spec. Only the labels of a config can be patched.`,
+		Args: cli.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			obj, err := lookupSpecObject(dockerCli, args[0], args[1], opts.overrideFreeze)
+			if err != nil {
+				return err
+			}
+			return runPatch(cmd.Context(), dockerCli, obj, opts)
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			switch len(args) {
+			case 0:
+				return []string{"services", "nodes", "configs"}, cobra.ShellCompDirectiveNoFileComp
+			case 1:
+				return completeNames(cmd.Context(), dockerCli, args[0]), cobra.ShellCompDirectiveNoFileComp
+			default:
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+		},
+		Annotations: map[string]string{
+			"version": "1.30",
+			"swarm":   "manager",
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.patchType, "type", patchTypeMerge, `Type of patch ("merge", "json")`)
+	flags.StringVarP(&opts.patch, "patch", "p", "", "The patch to apply")
+	flags.StringVar(&opts.patchFile, "patch-file", "", `Read the patch from a file, or from stdin with "-"`)
+	freeze.AddFlag(flags, &opts.overrideFreeze)
+	return cmd
+}
+
+func runPatch(ctx context.Context, dockerCli command.Cli, obj specObject, opts patchOptions) error {
+	patch, err := readPatch(dockerCli.In(), opts)
+	if err != nil {
+		return err
+	}
+
+	var apply patchFunc
+	switch opts.patchType {
+	case patchTypeMerge:
+		apply = mergePatch(patch)
+	case patchTypeJSON:
+		apply = func(doc []byte) ([]byte, error) {
+			return spec.JSONPatch(doc, patch)
+		}
+	default:
+		return errors.Errorf("invalid patch type %q, must be one of merge, json", opts.patchType)
+	}
+
+	changed, err := updateSpec(ctx, dockerCli, obj, apply)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		fmt.Fprintln(dockerCli.Out(), i18n.Sprintf("%s patched (no change)", obj.Name()))
+		return nil
+	}
+	fmt.Fprintln(dockerCli.Out(), i18n.Sprintf("%s patched", obj.Name()))
+	return nil
+}
+
+func readPatch(in io.Reader, opts patchOptions) ([]byte, error) {
+	switch {
+	case opts.patch != "" && opts.patchFile != "":
+		return nil, errors.New("--patch and --patch-file cannot be used together")
+	case opts.patch != "":
+		return []byte(opts.patch), nil
+	case opts.patchFile == "-":
+		return io.ReadAll(in)
+	case opts.patchFile != "":
+		return os.ReadFile(opts.patchFile)
+	default:
+		return nil, errors.New("a patch is required, use --patch or --patch-file")
+	}
+}
@@ -0,0 +1,162 @@
+package system
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/cli/cli/streams"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/freeze"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"github.com/moby/swarmctl/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func patchServiceClient(updated *[]swarm.ServiceSpec) *fakeClient {
+	service := getServices()[0]
+	return &fakeClient{
+		serviceInspectFn: func(context.Context, string) (swarm.Service, error) {
+			return service, nil
+		},
+		serviceUpdateFn: func(_ context.Context, _ string, _ swarm.Version, spec swarm.ServiceSpec) error {
+			*updated = append(*updated, spec)
+			return nil
+		},
+	}
+}
+
+func TestPatchMerge(t *testing.T) {
+	var updated []swarm.ServiceSpec
+	cli := test.NewFakeCli(patchServiceClient(&updated))
+	cmd := NewPatchCommand(cli)
+	cmd.SetArgs([]string{"service", "web", "-p", `{"TaskTemplate":{"ContainerSpec":{"Image":"nginx:1.25"}}}`})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "service web patched\n"))
+	assert.Assert(t, is.Len(updated, 1))
+	assert.Check(t, is.Equal(updated[0].TaskTemplate.ContainerSpec.Image, "nginx:1.25"))
+	assert.Check(t, is.Equal(*updated[0].Mode.Replicated.Replicas, uint64(3)))
+}
+
+func TestPatchJSON(t *testing.T) {
+	var updated []swarm.ServiceSpec
+	cli := test.NewFakeCli(patchServiceClient(&updated))
+	cmd := NewPatchCommand(cli)
+	cmd.SetArgs([]string{"service", "web", "--type", "json", "-p", `[
+		{"op":"add","path":"/TaskTemplate/ContainerSpec/Env","value":["A=1"]},
+		{"op":"add","path":"/TaskTemplate/ContainerSpec/Env/-","value":"B=2"}
+	]`})
+	assert.NilError(t, cmd.Execute())
+	assert.Assert(t, is.Len(updated, 1))
+	assert.Check(t, is.DeepEqual(updated[0].TaskTemplate.ContainerSpec.Env, []string{"A=1", "B=2"}))
+}
+
+func TestPatchNoChange(t *testing.T) {
+	var updated []swarm.ServiceSpec
+	cli := test.NewFakeCli(patchServiceClient(&updated))
+	cmd := NewPatchCommand(cli)
+	cmd.SetArgs([]string{"service", "web", "-p", `{"Name":"web"}`})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.OutBuffer().String(), "service web patched (no change)\n"))
+	assert.Check(t, is.Len(updated, 0))
+}
+
+func TestPatchFromStdin(t *testing.T) {
+	node := swarm.Node{ID: "node1", Spec: swarm.NodeSpec{Availability: swarm.NodeAvailabilityActive}}
+	var updates []swarm.NodeSpec
+	cli := test.NewFakeCli(nodeClient(&node, &updates))
+	cli.SetIn(streams.NewIn(io.NopCloser(strings.NewReader(`{"Availability":"drain","Labels":{"zone":"a"}}`))))
+	cmd := NewPatchCommand(cli)
+	cmd.SetArgs([]string{"node", "node1", "--patch-file", "-"})
+	assert.NilError(t, cmd.Execute())
+	assert.Assert(t, is.Len(updates, 1))
+	assert.Check(t, is.DeepEqual(updates[0], swarm.NodeSpec{
+		Annotations:  swarm.Annotations{Labels: map[string]string{"zone": "a"}},
+		Availability: swarm.NodeAvailabilityDrain,
+	}))
+}
+
+func TestPatchFromFile(t *testing.T) {
+	patchFile := filepath.Join(t.TempDir(), "patch.json")
+	assert.NilError(t, os.WriteFile(patchFile, []byte(`[{"op":"replace","path":"/Availability","value":"pause"}]`), 0o644))
+	node := swarm.Node{ID: "node1", Spec: swarm.NodeSpec{Availability: swarm.NodeAvailabilityActive}}
+	var updates []swarm.NodeSpec
+	cmd := NewPatchCommand(test.NewFakeCli(nodeClient(&node, &updates)))
+	cmd.SetArgs([]string{"node", "node1", "--type", "json", "--patch-file", patchFile})
+	assert.NilError(t, cmd.Execute())
+	assert.Assert(t, is.Len(updates, 1))
+	assert.Check(t, is.Equal(updates[0].Availability, swarm.NodeAvailabilityPause))
+}
+
+func TestPatchInvalid(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{
+			args:     []string{"service", "web"},
+			expected: "a patch is required, use --patch or --patch-file",
+		},
+		{
+			args:     []string{"service", "web", "-p", "{}", "--patch-file", "patch.json"},
+			expected: "--patch and --patch-file cannot be used together",
+		},
+		{
+			args:     []string{"service", "web", "-p", "{}", "--type", "strategic"},
+			expected: `invalid patch type "strategic", must be one of merge, json`,
+		},
+		{
+			args:     []string{"service", "web", "-p", `{"TaskTemplate":{"ContainerSpec":{"Imgae":"x"}}}`},
+			expected: `invalid spec: json: unknown field "Imgae"`,
+		},
+	}
+	for _, tc := range testCases {
+		var updated []swarm.ServiceSpec
+		cmd := NewPatchCommand(test.NewFakeCli(patchServiceClient(&updated)))
+		cmd.SetArgs(tc.args)
+		assert.Check(t, is.Error(cmd.Execute(), tc.expected), strings.Join(tc.args, " "))
+	}
+}
+
+func TestPatchQuotaExceeded(t *testing.T) {
+	quotaFile := filepath.Join(t.TempDir(), "quotas.yml")
+	assert.NilError(t, os.WriteFile(quotaFile, []byte("namespaces:\n  shop:\n    replicas: 4\n"), 0o644))
+	t.Setenv("SWARMCTL_QUOTA_FILE", quotaFile)
+
+	var updated []swarm.ServiceSpec
+	apiClient := patchServiceClient(&updated)
+	apiClient.serviceListFn = func(context.Context, types.ServiceListOptions) ([]swarm.Service, error) {
+		service := getServices()[0]
+		service.Spec.Labels = map[string]string{swarmlabel.Namespace: "shop"}
+		return []swarm.Service{service}, nil
+	}
+	cmd := NewPatchCommand(test.NewFakeCli(apiClient))
+	cmd.SetArgs([]string{"service", "web", "-p", `{"Labels":{"` + swarmlabel.Namespace + `":"shop"},"Mode":{"Replicated":{"Replicas":5}}}`})
+	assert.Error(t, cmd.Execute(), "quota exceeded for namespace shop: replicas 5/4")
+	assert.Check(t, is.Len(updated, 0))
+}
+
+func TestPatchFrozen(t *testing.T) {
+	var updated []swarm.ServiceSpec
+	apiClient := patchServiceClient(&updated)
+	apiClient.serviceInspectFn = func(context.Context, string) (swarm.Service, error) {
+		service := getServices()[0]
+		service.Spec.Labels = map[string]string{swarmlabel.Namespace: "shop", freeze.Label: "release"}
+		return service, nil
+	}
+	patch := `{"TaskTemplate":{"ContainerSpec":{"Image":"nginx:1.25"}}}`
+	cmd := NewPatchCommand(test.NewFakeCli(apiClient))
+	cmd.SetArgs([]string{"service", "web", "-p", patch})
+	assert.Error(t, cmd.Execute(), "service web belongs to frozen stack shop (release), use --override-freeze to change it anyway")
+	assert.Check(t, is.Len(updated, 0))
+
+	cmd = NewPatchCommand(test.NewFakeCli(apiClient))
+	cmd.SetArgs([]string{"service", "web", "-p", patch, "--override-freeze"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Len(updated, 1))
+}
@@ -0,0 +1,58 @@
+package system
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/docker/client"
+	"github.com/moby/swarmctl/internal/apiclient"
+	"github.com/moby/swarmctl/internal/recording"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// NewReplayCommand creates a new cobra.Command for `swarmctl replay`. newRoot
+// builds the command tree the recorded command is run with.
+func NewReplayCommand(dockerCli command.Cli, newRoot func(command.Cli) *cobra.Command) *cobra.Command {
+	return &cobra.Command{
+		Use:   "replay DIR",
+		Short: "Run a command recorded with --record against its recorded API responses",
+		Long: `Run a command recorded with --record against its recorded API responses.
+
+The command is rendered again offline, without contacting the daemon. Keep in
+mind that recordings contain everything the daemon returned, including join
+tokens and other secrets.`,
+		Args: cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runReplay(cmd.Context(), dockerCli, newRoot, args[0])
+		},
+	}
+}
+
+func runReplay(ctx context.Context, dockerCli command.Cli, newRoot func(command.Cli) *cobra.Command, dir string) error {
+	rec, err := recording.Load(dir)
+	if err != nil {
+		return err
+	}
+	if len(rec.Command.Args) > 0 && rec.Command.Args[0] == "replay" {
+		return errors.New("cannot replay a recording of the replay command")
+	}
+
+	apiClient, err := client.NewClientWithOpts(
+		client.WithHTTPClient(&http.Client{Transport: rec.Transport()}),
+		client.WithAPIVersionNegotiation(),
+	)
+	if err != nil {
+		return err
+	}
+	replayCli := apiclient.NewCli(dockerCli)
+	replayCli.SetClient(apiClient)
+
+	root := newRoot(replayCli)
+	root.SetArgs(rec.Command.Args)
+	root.SilenceErrors = true
+	root.SilenceUsage = true
+	return root.ExecuteContext(ctx)
+}
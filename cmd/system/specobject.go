@@ -0,0 +1,211 @@
+package system
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/freeze"
+	"github.com/moby/swarmctl/internal/quota"
+	"github.com/moby/swarmctl/internal/spec"
+	"github.com/pkg/errors"
+)
+
+// maxUpdateAttempts bounds the retries of an update that conflicts with a
+// concurrent update of the same object.
+const maxUpdateAttempts = 3
+
+// specObject is a swarm object whose spec can be edited and patched.
+type specObject interface {
+	// Name returns the object type and name, such as "service web".
+	Name() string
+	// Inspect fetches the current version of the object, and returns its
+	// editable spec.
+	Inspect(ctx context.Context) (interface{}, error)
+	// NewSpec returns a pointer to an empty editable spec.
+	NewSpec() interface{}
+	// Update replaces the spec of the object at the version returned by the
+	// last call to Inspect, and returns the warnings of the daemon.
+	Update(ctx context.Context, spec interface{}) ([]string, error)
+}
+
+// lookupSpecObject returns the object to edit, from its type and name.
+// Only services, nodes and configs can be edited; the labels are the only
+// editable part of a config. The services and configs of frozen stacks
+// cannot be inspected for edition unless overrideFreeze is set.
+func lookupSpecObject(dockerCli command.Cli, kindName, name string, overrideFreeze bool) (specObject, error) {
+	k, err := lookupKind(kindName)
+	if err != nil {
+		return nil, err
+	}
+	switch k.name {
+	case "services":
+		return &serviceObject{dockerCli: dockerCli, ref: name, overrideFreeze: overrideFreeze}, nil
+	case "nodes":
+		return &nodeObject{dockerCli: dockerCli, ref: name}, nil
+	case "configs":
+		return &configObject{dockerCli: dockerCli, ref: name, overrideFreeze: overrideFreeze}, nil
+	default:
+		return nil, errors.Errorf("%s cannot be edited, must be one of services, nodes, configs", k.name)
+	}
+}
+
+type serviceObject struct {
+	dockerCli      command.Cli
+	ref            string
+	overrideFreeze bool
+	service        swarm.Service
+}
+
+func (o *serviceObject) Name() string { return "service " + o.ref }
+
+func (o *serviceObject) Inspect(ctx context.Context) (interface{}, error) {
+	service, _, err := o.dockerCli.Client().ServiceInspectWithRaw(ctx, o.ref, types.ServiceInspectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if err := freeze.Check("service", service.Spec.Name, service.Spec.Labels, o.overrideFreeze); err != nil {
+		return nil, err
+	}
+	o.service = service
+	return service.Spec, nil
+}
+
+func (o *serviceObject) NewSpec() interface{} { return &swarm.ServiceSpec{} }
+
+func (o *serviceObject) Update(ctx context.Context, s interface{}) ([]string, error) {
+	serviceSpec := *s.(*swarm.ServiceSpec)
+	quotas, err := quota.Load(quota.File())
+	if err != nil {
+		return nil, err
+	}
+	if err := quotas.CheckServiceUpdate(ctx, o.dockerCli.Client(), o.service, serviceSpec); err != nil {
+		return nil, err
+	}
+	response, err := o.dockerCli.Client().ServiceUpdate(ctx, o.service.ID, o.service.Version, serviceSpec, types.ServiceUpdateOptions{})
+	return response.Warnings, err
+}
+
+type nodeObject struct {
+	dockerCli command.Cli
+	ref       string
+	node      swarm.Node
+}
+
+func (o *nodeObject) Name() string { return "node " + o.ref }
+
+func (o *nodeObject) Inspect(ctx context.Context) (interface{}, error) {
+	node, _, err := o.dockerCli.Client().NodeInspectWithRaw(ctx, o.ref)
+	if err != nil {
+		return nil, err
+	}
+	o.node = node
+	return node.Spec, nil
+}
+
+func (o *nodeObject) NewSpec() interface{} { return &swarm.NodeSpec{} }
+
+func (o *nodeObject) Update(ctx context.Context, s interface{}) ([]string, error) {
+	return nil, o.dockerCli.Client().NodeUpdate(ctx, o.node.ID, o.node.Version, *s.(*swarm.NodeSpec))
+}
+
+// configLabels is the editable part of a config: its data is immutable.
+type configLabels struct {
+	Labels map[string]string `json:"Labels"`
+}
+
+type configObject struct {
+	dockerCli      command.Cli
+	ref            string
+	overrideFreeze bool
+	config         swarm.Config
+}
+
+func (o *configObject) Name() string { return "config " + o.ref }
+
+func (o *configObject) Inspect(ctx context.Context) (interface{}, error) {
+	config, _, err := o.dockerCli.Client().ConfigInspectWithRaw(ctx, o.ref)
+	if err != nil {
+		return nil, err
+	}
+	if err := freeze.Check("config", config.Spec.Name, config.Spec.Labels, o.overrideFreeze); err != nil {
+		return nil, err
+	}
+	o.config = config
+	labels := config.Spec.Labels
+	if labels == nil {
+		// show an empty map to edit rather than an empty document
+		labels = map[string]string{}
+	}
+	return configLabels{Labels: labels}, nil
+}
+
+func (o *configObject) NewSpec() interface{} { return &configLabels{} }
+
+func (o *configObject) Update(ctx context.Context, s interface{}) ([]string, error) {
+	configSpec := o.config.Spec
+	configSpec.Labels = s.(*configLabels).Labels
+	return nil, o.dockerCli.Client().ConfigUpdate(ctx, o.config.ID, o.config.Version, configSpec)
+}
+
+// patchFunc returns a patched copy of a JSON spec.
+type patchFunc func(doc []byte) ([]byte, error)
+
+// mergePatch returns the patchFunc applying a JSON merge patch.
+func mergePatch(patch []byte) patchFunc {
+	return func(doc []byte) ([]byte, error) {
+		return spec.MergePatch(doc, patch)
+	}
+}
+
+// updateSpec patches the current spec of obj and updates the object. If the
+// object is updated concurrently, the patch is applied again to its new
+// spec, so that only the changes of the patch are written. It returns false
+// if the patch does not change the spec.
+func updateSpec(ctx context.Context, dockerCli command.Cli, obj specObject, patch patchFunc) (bool, error) {
+	for attempt := 1; ; attempt++ {
+		current, err := obj.Inspect(ctx)
+		if err != nil {
+			return false, err
+		}
+		doc, err := json.Marshal(current)
+		if err != nil {
+			return false, err
+		}
+		patched, err := patch(doc)
+		if err != nil {
+			return false, err
+		}
+		if changes, err := spec.CreateMergePatch(doc, patched); err != nil {
+			return false, err
+		} else if spec.IsEmptyPatch(changes) {
+			return false, nil
+		}
+		updated := obj.NewSpec()
+		if err := spec.Decode(patched, updated); err != nil {
+			return false, err
+		}
+		warnings, err := obj.Update(ctx, updated)
+		if isUpdateConflict(err) && attempt < maxUpdateAttempts {
+			fmt.Fprintf(dockerCli.Err(), "WARNING: %s was updated concurrently, retrying\n", obj.Name())
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		for _, warning := range warnings {
+			fmt.Fprintln(dockerCli.Err(), warning)
+		}
+		return true, nil
+	}
+}
+
+// isUpdateConflict returns whether err is the error returned by the daemon
+// when an object is updated from an outdated version.
+func isUpdateConflict(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "update out of sequence")
+}
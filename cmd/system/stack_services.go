@@ -0,0 +1,110 @@
+package system
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/cli/opts"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+// Values of the "state" filter of `swarmctl stack services`.
+const (
+	stateConverged = "converged"
+	stateDegraded  = "degraded"
+)
+
+type stackServicesOptions struct {
+	stack    string
+	filter   opts.FilterOpt
+	degraded bool
+}
+
+// NewStackServicesCommand creates a new cobra.Command for `swarmctl stack
+// services`. It lives here to list the services as `swarmctl get services`
+// does.
+func NewStackServicesCommand(dockerCli command.Cli) *cobra.Command {
+	options := stackServicesOptions{filter: opts.NewFilterOpt()}
+
+	cmd := &cobra.Command{
+		Use:   "services [OPTIONS] STACK",
+		Short: "List the services of a stack",
+		Long: `List the services of a stack.
+
+The "id", "label", "mode" and "name" filters are those of "docker service ls".
+They are sent to the engine with the namespace of the stack. The "state"
+filter is applied locally: "converged" selects the services running all their
+desired tasks or suspended with "swarmctl service suspend", and "degraded" the
+others. --degraded is a shorthand for --filter state=degraded.`,
+		Args: cli.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			options.stack = args[0]
+			return runStackServices(cmd.Context(), dockerCli, options)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	flags := cmd.Flags()
+	flags.VarP(&options.filter, "filter", "f", `Filter output based on conditions provided ("id", "label", "mode", "name", "state")`)
+	flags.BoolVar(&options.degraded, "degraded", false, "Only list the services not in their desired state")
+	return cmd
+}
+
+func runStackServices(ctx context.Context, dockerCli command.Cli, options stackServicesOptions) error {
+	f := options.filter.Value().Clone()
+	var states []string
+	for _, key := range f.Keys() {
+		switch key {
+		case "id", "label", "mode", "name":
+		case "state":
+			states = f.Get(key)
+			for _, state := range states {
+				if state != stateConverged && state != stateDegraded {
+					return errors.Errorf("invalid state filter %q, must be %s or %s", state, stateConverged, stateDegraded)
+				}
+				f.Del(key, state)
+			}
+		default:
+			return errors.Errorf("invalid filter '%s'", key)
+		}
+	}
+	f.Add("label", swarmlabel.Namespace+"="+options.stack)
+
+	k, err := lookupKind("services")
+	if err != nil {
+		return err
+	}
+	objects, err := k.list(ctx, dockerCli.Client(), f)
+	if err != nil {
+		return err
+	}
+	if len(states) > 0 {
+		selected := objects[:0]
+		for _, o := range objects {
+			for _, state := range states {
+				if o.degraded == (state == stateDegraded) {
+					selected = append(selected, o)
+					break
+				}
+			}
+		}
+		objects = selected
+	}
+	if options.degraded {
+		objects = degradedObjects(objects)
+	}
+	if len(objects) == 0 {
+		fmt.Fprintf(dockerCli.Err(), "Nothing found in stack: %s\n", options.stack)
+		return nil
+	}
+	objects, err = selectObjects(k, objects, nil)
+	if err != nil {
+		return err
+	}
+	return printObjects(dockerCli.Out(), k, objects)
+}
@@ -0,0 +1,141 @@
+package system
+
+import (
+	"context"
+	"io"
+	"sort"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"github.com/moby/swarmctl/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+// stackServicesClient returns a client listing the services of the shop
+// stack, checking the filters sent with the namespace filter. expected holds
+// the sorted values of each filter.
+func stackServicesClient(t *testing.T, expected map[string][]string) *fakeClient {
+	service := func(id, name string, running, desired uint64) swarm.Service {
+		return swarm.Service{
+			ID: id + "0123456789abcdef",
+			Spec: swarm.ServiceSpec{
+				Annotations:  swarm.Annotations{Name: name, Labels: map[string]string{swarmlabel.Namespace: "shop"}},
+				TaskTemplate: swarm.TaskSpec{ContainerSpec: &swarm.ContainerSpec{Image: "shop/" + name + ":1.2@sha256:c0ffee"}},
+			},
+			ServiceStatus: &swarm.ServiceStatus{RunningTasks: running, DesiredTasks: desired},
+		}
+	}
+	return &fakeClient{
+		serviceListFn: func(_ context.Context, options types.ServiceListOptions) ([]swarm.Service, error) {
+			assert.Check(t, options.Status)
+			for _, key := range options.Filters.Keys() {
+				values := options.Filters.Get(key)
+				sort.Strings(values)
+				assert.Check(t, is.DeepEqual(values, expected[key]), key)
+			}
+			assert.Check(t, is.Equal(options.Filters.Len(), len(expected)))
+			worker := service("worker", "shop_worker", 1, 2)
+			worker.Spec.Mode.Global = &swarm.GlobalService{}
+			return []swarm.Service{worker, service("web", "shop_web", 3, 3), service("api", "shop_api", 2, 2)}, nil
+		},
+	}
+}
+
+func TestStackServices(t *testing.T) {
+	testCases := []struct {
+		name     string
+		filters  []string
+		expected map[string][]string
+	}{
+		{
+			name:     "all",
+			expected: map[string][]string{"label": {swarmlabel.Namespace + "=shop"}},
+		},
+		{
+			name:     "pushdown",
+			filters:  []string{"mode=replicated", "label=team=front"},
+			expected: map[string][]string{"label": {swarmlabel.Namespace + "=shop", "team=front"}, "mode": {"replicated"}},
+		},
+		{
+			name:     "degraded",
+			filters:  []string{"state=degraded"},
+			expected: map[string][]string{"label": {swarmlabel.Namespace + "=shop"}},
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			cli := test.NewFakeCli(stackServicesClient(t, tc.expected))
+			cmd := NewStackServicesCommand(cli)
+			args := []string{"shop"}
+			for _, f := range tc.filters {
+				args = append(args, "--filter", f)
+			}
+			cmd.SetArgs(args)
+			assert.NilError(t, cmd.Execute())
+			test.AssertGolden(t, cli.OutBuffer().String(), "stack-services-"+tc.name+".golden")
+		})
+	}
+}
+
+func TestStackServicesDegraded(t *testing.T) {
+	cli := test.NewFakeCli(stackServicesClient(t, map[string][]string{"label": {swarmlabel.Namespace + "=shop"}}))
+	cmd := NewStackServicesCommand(cli)
+	cmd.SetArgs([]string{"shop", "--degraded"})
+	assert.NilError(t, cmd.Execute())
+	test.AssertGolden(t, cli.OutBuffer().String(), "stack-services-degraded.golden")
+}
+
+func TestStackServicesSuspended(t *testing.T) {
+	apiClient := stackServicesClient(t, map[string][]string{"label": {swarmlabel.Namespace + "=shop"}})
+	list := apiClient.serviceListFn
+	apiClient.serviceListFn = func(ctx context.Context, options types.ServiceListOptions) ([]swarm.Service, error) {
+		services, err := list(ctx, options)
+		// shop_web is suspended: it runs no task, and is not degraded.
+		services[1].Spec.Labels[swarmlabel.SuspendedReplicas] = "3"
+		services[1].ServiceStatus = &swarm.ServiceStatus{}
+		return services, err
+	}
+	cli := test.NewFakeCli(apiClient)
+	cmd := NewStackServicesCommand(cli)
+	cmd.SetArgs([]string{"shop"})
+	assert.NilError(t, cmd.Execute())
+	test.AssertGolden(t, cli.OutBuffer().String(), "stack-services-suspended.golden")
+
+	cli = test.NewFakeCli(apiClient)
+	cmd = NewStackServicesCommand(cli)
+	cmd.SetArgs([]string{"shop", "--degraded"})
+	assert.NilError(t, cmd.Execute())
+	test.AssertGolden(t, cli.OutBuffer().String(), "stack-services-degraded.golden")
+}
+
+func TestStackServicesNothingFound(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{})
+	cmd := NewStackServicesCommand(cli)
+	cmd.SetArgs([]string{"blog"})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.ErrBuffer().String(), "Nothing found in stack: blog\n"))
+}
+
+func TestStackServicesErrors(t *testing.T) {
+	testCases := []struct {
+		filter   string
+		expected string
+	}{
+		{filter: "node=node1", expected: "invalid filter 'node'"},
+		{filter: "state=running", expected: `invalid state filter "running", must be converged or degraded`},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.filter, func(t *testing.T) {
+			cmd := NewStackServicesCommand(test.NewFakeCli(&fakeClient{}))
+			cmd.SetArgs([]string{"shop", "--filter", tc.filter})
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			assert.Error(t, cmd.Execute(), tc.expected)
+		})
+	}
+}
@@ -0,0 +1,393 @@
+package system
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/docker/api/types"
+	eventtypes "github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/capability"
+	"github.com/moby/swarmctl/internal/events"
+	"github.com/moby/swarmctl/internal/redact"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"github.com/moby/swarmctl/internal/version"
+	"github.com/spf13/cobra"
+)
+
+type supportBundleOptions struct {
+	output    string
+	since     string
+	hashNames bool
+	redact    redact.Options
+}
+
+// NewSupportBundleCommand creates a new cobra.Command for `swarmctl support-bundle`
+func NewSupportBundleCommand(dockerCli command.Cli) *cobra.Command {
+	opts := supportBundleOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "support-bundle [OPTIONS]",
+		Short: "Collect the state of the swarm into an archive for support requests",
+		Long: `Collect the state of the swarm into an archive for support requests.
+
+The archive holds the versions of swarmctl, the engine and the nodes, the
+swarm status, the services, nodes, tasks, networks, configs and secrets, and
+the recent events, as JSON files. The payloads of configs and secrets are
+left out, and the sensitive environment variables masked as with "get -o
+json". With --hash-names, the names of the objects are replaced by hashes,
+the same name always giving the same hash.`,
+		Args: cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSupportBundle(cmd.Context(), dockerCli, opts)
+		},
+		ValidArgsFunction: completion.NoComplete,
+		Annotations: map[string]string{
+			"version": "1.30",
+			"swarm":   "manager",
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&opts.output, "output", "o", "", `Write the archive to a file, or to the standard output with "-" (default "swarmctl-support-TIMESTAMP.tar.gz")`)
+	flags.StringVar(&opts.since, "since", "1h", "Collect the events created since a timestamp or relative time")
+	flags.BoolVar(&opts.hashNames, "hash-names", false, "Replace the names of the objects by hashes")
+	redact.AddFlags(flags, &opts.redact)
+	return cmd
+}
+
+// bundleFile is a file of a support bundle.
+type bundleFile struct {
+	name string
+	data []byte
+}
+
+func runSupportBundle(ctx context.Context, dockerCli command.Cli, opts supportBundleOptions) error {
+	r, err := opts.redact.Redactor(dockerCli.In(), dockerCli.Err())
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	files, err := collectBundle(ctx, dockerCli, opts.hashNames, r, opts.since, now)
+	if err != nil {
+		return err
+	}
+
+	if opts.output == "-" {
+		return writeBundle(dockerCli.Out(), files, now)
+	}
+	if opts.output == "" {
+		opts.output = "swarmctl-support-" + now.UTC().Format("20060102T150405Z") + ".tar.gz"
+	}
+	f, err := os.OpenFile(opts.output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := writeBundle(f, files, now); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(dockerCli.Err(), "Support bundle written to %s\n", opts.output)
+	return nil
+}
+
+// nodeVersion is the entry of a node in the version matrix of a bundle.
+type nodeVersion struct {
+	Hostname      string `json:"hostname"`
+	Role          string `json:"role"`
+	EngineVersion string `json:"engineVersion"`
+	Platform      string `json:"platform"`
+}
+
+// collectBundle returns the files of a support bundle.
+func collectBundle(ctx context.Context, dockerCli command.Cli, hashNames bool, r *redact.Redactor, since string, now time.Time) ([]bundleFile, error) {
+	client := dockerCli.Client()
+	server, err := client.ServerVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	info, err := client.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	services, err := client.ServiceList(ctx, types.ServiceListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := client.NodeList(ctx, types.NodeListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := client.TaskList(ctx, types.TaskListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	networks, err := client.NetworkList(ctx, types.NetworkListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	configs, err := client.ConfigList(ctx, types.ConfigListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	secrets, err := client.SecretList(ctx, types.SecretListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	messages, err := collectEvents(ctx, dockerCli, since, now)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range configs {
+		configs[i].Spec.Data = nil
+	}
+	for i := range secrets {
+		secrets[i].Spec.Data = nil
+	}
+	for i := range services {
+		r.Service(&services[i])
+	}
+	for i := range tasks {
+		r.Task(&tasks[i])
+	}
+	if hashNames {
+		h, err := newNameHasher()
+		if err != nil {
+			return nil, err
+		}
+		h.hashServices(services)
+		h.hashNodes(nodes)
+		h.hashTasks(tasks)
+		h.hashNetworks(networks)
+		h.hashConfigs(configs)
+		h.hashSecrets(secrets)
+		h.hashEvents(messages)
+		h.hashClusterInfo(&info.Swarm)
+	}
+
+	versions := struct {
+		Client map[string]string `json:"client"`
+		Server types.Version     `json:"server"`
+		Nodes  []nodeVersion     `json:"nodes"`
+	}{
+		Client: map[string]string{
+			"version":    version.Version,
+			"apiVersion": capability.APIVersion(ctx, client),
+			"goVersion":  runtime.Version(),
+			"platform":   runtime.GOOS + "/" + runtime.GOARCH,
+		},
+		Server: server,
+		Nodes:  make([]nodeVersion, 0, len(nodes)),
+	}
+	for _, n := range nodes {
+		versions.Nodes = append(versions.Nodes, nodeVersion{
+			Hostname:      n.Description.Hostname,
+			Role:          string(n.Spec.Role),
+			EngineVersion: n.Description.Engine.EngineVersion,
+			Platform:      n.Description.Platform.OS + "/" + n.Description.Platform.Architecture,
+		})
+	}
+
+	var files []bundleFile
+	for _, content := range []struct {
+		name  string
+		value interface{}
+	}{
+		{"versions.json", versions},
+		{"swarm.json", info.Swarm},
+		{"services.json", services},
+		{"nodes.json", nodes},
+		{"tasks.json", tasks},
+		{"networks.json", networks},
+		{"configs.json", configs},
+		{"secrets.json", secrets},
+		{"events.json", messages},
+	} {
+		data, err := json.MarshalIndent(content.value, "", "    ")
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, bundleFile{name: content.name, data: append(data, '\n')})
+	}
+	return files, nil
+}
+
+// collectEvents returns the swarm events created since since, until now.
+func collectEvents(ctx context.Context, dockerCli command.Cli, since string, now time.Time) ([]eventtypes.Message, error) {
+	messages, errs := events.Stream(ctx, dockerCli.Client(), events.Options{
+		Types: events.Types,
+		Since: since,
+		Until: strconv.FormatInt(now.Unix(), 10),
+	})
+	collected := []eventtypes.Message{}
+	for msg := range messages {
+		collected = append(collected, msg)
+	}
+	if err := <-errs; err != nil {
+		return nil, err
+	}
+	return collected, nil
+}
+
+// writeBundle writes files to out as a gzipped tar archive.
+func writeBundle(out io.Writer, files []bundleFile, now time.Time) error {
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+	for _, f := range files {
+		hdr := &tar.Header{
+			Name:    "swarmctl-support/" + f.name,
+			Mode:    0o600,
+			Size:    int64(len(f.data)),
+			ModTime: now,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// nameHasher replaces the names of swarm objects by hashes, so that the
+// objects of a bundle can be told apart without revealing what they are.
+// The hashes are keyed with a random key that is not written to the bundle,
+// so that common names cannot be found by hashing guesses.
+type nameHasher struct {
+	key    []byte
+	hashed map[string]string
+}
+
+// hashKey returns the key of the name hashes of a bundle.
+var hashKey = func() ([]byte, error) {
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	return key, err
+}
+
+func newNameHasher() (nameHasher, error) {
+	key, err := hashKey()
+	if err != nil {
+		return nameHasher{}, err
+	}
+	return nameHasher{key: key, hashed: make(map[string]string)}, nil
+}
+
+func (h nameHasher) hash(name string) string {
+	if name == "" {
+		return ""
+	}
+	if hashed, ok := h.hashed[name]; ok {
+		return hashed
+	}
+	mac := hmac.New(sha256.New, h.key)
+	mac.Write([]byte(name))
+	hashed := "name-" + hex.EncodeToString(mac.Sum(nil))[:12]
+	h.hashed[name] = hashed
+	return hashed
+}
+
+func (h nameHasher) hashLabel(labels map[string]string, key string) {
+	if value, ok := labels[key]; ok {
+		labels[key] = h.hash(value)
+	}
+}
+
+func (h nameHasher) hashServices(services []swarm.Service) {
+	for i := range services {
+		s := &services[i]
+		s.Spec.Name = h.hash(s.Spec.Name)
+		h.hashLabel(s.Spec.Labels, swarmlabel.Namespace)
+		h.hashContainerSpec(s.Spec.TaskTemplate.ContainerSpec)
+		if s.PreviousSpec != nil {
+			s.PreviousSpec.Name = h.hash(s.PreviousSpec.Name)
+			h.hashLabel(s.PreviousSpec.Labels, swarmlabel.Namespace)
+			h.hashContainerSpec(s.PreviousSpec.TaskTemplate.ContainerSpec)
+		}
+	}
+}
+
+func (h nameHasher) hashTasks(tasks []swarm.Task) {
+	for i := range tasks {
+		tasks[i].Name = h.hash(tasks[i].Name)
+		h.hashContainerSpec(tasks[i].Spec.ContainerSpec)
+	}
+}
+
+func (h nameHasher) hashContainerSpec(c *swarm.ContainerSpec) {
+	if c == nil {
+		return
+	}
+	h.hashLabel(c.Labels, swarmlabel.Namespace)
+	for _, config := range c.Configs {
+		config.ConfigName = h.hash(config.ConfigName)
+	}
+	for _, secret := range c.Secrets {
+		secret.SecretName = h.hash(secret.SecretName)
+	}
+}
+
+func (h nameHasher) hashNodes(nodes []swarm.Node) {
+	for i := range nodes {
+		nodes[i].Spec.Name = h.hash(nodes[i].Spec.Name)
+		nodes[i].Description.Hostname = h.hash(nodes[i].Description.Hostname)
+	}
+}
+
+func (h nameHasher) hashNetworks(networks []types.NetworkResource) {
+	for i := range networks {
+		networks[i].Name = h.hash(networks[i].Name)
+		h.hashLabel(networks[i].Labels, swarmlabel.Namespace)
+	}
+}
+
+func (h nameHasher) hashConfigs(configs []swarm.Config) {
+	for i := range configs {
+		configs[i].Spec.Name = h.hash(configs[i].Spec.Name)
+		h.hashLabel(configs[i].Spec.Labels, swarmlabel.Namespace)
+	}
+}
+
+func (h nameHasher) hashSecrets(secrets []swarm.Secret) {
+	for i := range secrets {
+		secrets[i].Spec.Name = h.hash(secrets[i].Spec.Name)
+		h.hashLabel(secrets[i].Spec.Labels, swarmlabel.Namespace)
+	}
+}
+
+func (h nameHasher) hashEvents(messages []eventtypes.Message) {
+	for _, msg := range messages {
+		for _, key := range []string{"name", "com.docker.swarm.service.name", "com.docker.swarm.node.name", swarmlabel.Namespace} {
+			h.hashLabel(msg.Actor.Attributes, key)
+		}
+	}
+}
+
+func (h nameHasher) hashClusterInfo(info *swarm.Info) {
+	if info.Cluster != nil {
+		info.Cluster.Spec.Name = h.hash(info.Cluster.Spec.Name)
+	}
+}
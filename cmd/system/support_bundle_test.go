@@ -0,0 +1,133 @@
+package system
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"github.com/moby/swarmctl/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func bundleClient() *fakeClient {
+	return &fakeClient{
+		serviceListFn: func(context.Context, types.ServiceListOptions) ([]swarm.Service, error) {
+			return []swarm.Service{{
+				ID: "service1",
+				Spec: swarm.ServiceSpec{
+					Annotations: swarm.Annotations{Name: "shop_web", Labels: map[string]string{swarmlabel.Namespace: "shop"}},
+					TaskTemplate: swarm.TaskSpec{ContainerSpec: &swarm.ContainerSpec{
+						Image:   "nginx",
+						Env:     []string{"API_TOKEN=abc"},
+						Secrets: []*swarm.SecretReference{{SecretName: "shop_password"}},
+					}},
+				},
+			}}, nil
+		},
+		nodeListFn: func(context.Context, types.NodeListOptions) ([]swarm.Node, error) {
+			return []swarm.Node{{
+				ID:   "node1",
+				Spec: swarm.NodeSpec{Role: swarm.NodeRoleManager},
+				Description: swarm.NodeDescription{
+					Hostname: "manager1",
+					Platform: swarm.Platform{OS: "linux", Architecture: "x86_64"},
+					Engine:   swarm.EngineDescription{EngineVersion: "23.0.0"},
+				},
+			}}, nil
+		},
+		configListFn: func(context.Context, types.ConfigListOptions) ([]swarm.Config, error) {
+			return []swarm.Config{{ID: "config1", Spec: swarm.ConfigSpec{Annotations: swarm.Annotations{Name: "shop_nginx.conf"}, Data: []byte("server {}")}}}, nil
+		},
+		secretListFn: func(context.Context, types.SecretListOptions) ([]swarm.Secret, error) {
+			return []swarm.Secret{{ID: "secret1", Spec: swarm.SecretSpec{Annotations: swarm.Annotations{Name: "shop_password"}, Data: []byte("hunter2")}}}, nil
+		},
+		eventsFn: func(ctx context.Context, options types.EventsOptions) (<-chan events.Message, <-chan error) {
+			messages := make(chan events.Message)
+			errs := make(chan error, 1)
+			go func() {
+				messages <- events.Message{Type: events.ServiceEventType, Action: "update", Actor: events.Actor{ID: "service1", Attributes: map[string]string{"name": "shop_web"}}}
+				errs <- io.EOF
+			}()
+			return messages, errs
+		},
+	}
+}
+
+// readBundle returns the files of the bundle at path.
+func readBundle(t *testing.T, path string) map[string]string {
+	t.Helper()
+	f, err := os.Open(path)
+	assert.NilError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	assert.NilError(t, err)
+	files := make(map[string]string)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		assert.NilError(t, err)
+		data, err := io.ReadAll(tr)
+		assert.NilError(t, err)
+		files[strings.TrimPrefix(hdr.Name, "swarmctl-support/")] = string(data)
+	}
+}
+
+func TestSupportBundle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	cli := test.NewFakeCli(bundleClient())
+	cmd := NewSupportBundleCommand(cli)
+	cmd.SetArgs([]string{"-o", path})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Equal(cli.ErrBuffer().String(), "Support bundle written to "+path+"\n"))
+
+	files := readBundle(t, path)
+	assert.Check(t, is.Len(files, 9))
+	assert.Check(t, is.Contains(files["services.json"], `"Name": "shop_web"`))
+	assert.Check(t, is.Contains(files["services.json"], `"API_TOKEN=[redacted]"`))
+	assert.Check(t, is.Contains(files["events.json"], `"Action": "update"`))
+	assert.Check(t, !strings.Contains(files["configs.json"], `"Data"`))
+	assert.Check(t, !strings.Contains(files["secrets.json"], `"Data"`))
+
+	var versions struct {
+		Nodes []nodeVersion `json:"nodes"`
+	}
+	assert.NilError(t, json.Unmarshal([]byte(files["versions.json"]), &versions))
+	assert.Check(t, is.DeepEqual(versions.Nodes, []nodeVersion{{Hostname: "manager1", Role: "manager", EngineVersion: "23.0.0", Platform: "linux/x86_64"}}))
+}
+
+func TestSupportBundleHashNames(t *testing.T) {
+	defer func(f func() ([]byte, error)) { hashKey = f }(hashKey)
+	hashKey = func() ([]byte, error) { return []byte("0123456789abcdef0123456789abcdef"), nil }
+
+	path := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	cmd := NewSupportBundleCommand(test.NewFakeCli(bundleClient()))
+	cmd.SetArgs([]string{"-o", path, "--hash-names"})
+	assert.NilError(t, cmd.Execute())
+
+	h, err := newNameHasher()
+	assert.NilError(t, err)
+	files := readBundle(t, path)
+	for name, content := range files {
+		for _, secret := range []string{"shop_web", "shop_password", "manager1", "shop_nginx.conf"} {
+			assert.Check(t, !strings.Contains(content, `"`+secret+`"`), "%s contains %s", name, secret)
+		}
+	}
+	assert.Check(t, is.Contains(files["services.json"], `"Name": "`+h.hash("shop_web")+`"`))
+	assert.Check(t, is.Contains(files["services.json"], `"SecretName": "`+h.hash("shop_password")+`"`))
+	assert.Check(t, is.Contains(files["secrets.json"], `"Name": "`+h.hash("shop_password")+`"`))
+	assert.Check(t, is.Contains(files["events.json"], `"name": "`+h.hash("shop_web")+`"`))
+}
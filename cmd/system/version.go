@@ -0,0 +1,93 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"text/tabwriter"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/command/completion"
+	"github.com/docker/docker/api"
+	"github.com/moby/swarmctl/internal/capability"
+	"github.com/moby/swarmctl/internal/version"
+	"github.com/spf13/cobra"
+)
+
+type versionOptions struct {
+	checkCompatibility bool
+}
+
+// NewVersionCommand creates a new cobra.Command for `swarmctl version`
+func NewVersionCommand(dockerCli command.Cli) *cobra.Command {
+	opts := versionOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "version [OPTIONS]",
+		Short: "Show the swarmctl and engine version information",
+		Args:  cli.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVersion(cmd.Context(), dockerCli, opts)
+		},
+		ValidArgsFunction: completion.NoComplete,
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVar(&opts.checkCompatibility, "check-compatibility", false, "Show which engine features are available to swarmctl")
+	return cmd
+}
+
+func runVersion(ctx context.Context, dockerCli command.Cli, opts versionOptions) error {
+	client := dockerCli.Client()
+
+	server, err := client.ServerVersion(ctx)
+	if err != nil {
+		return err
+	}
+	apiVersion := capability.APIVersion(ctx, client)
+
+	out := dockerCli.Out()
+	w := tabwriter.NewWriter(out, 0, 1, 1, ' ', 0)
+	fmt.Fprintln(w, "Client:")
+	fmt.Fprintf(w, " Version:\t%s\n", version.Version)
+	if apiVersion != api.DefaultVersion {
+		fmt.Fprintf(w, " API version:\t%s (downgraded from %s)\n", apiVersion, api.DefaultVersion)
+	} else {
+		fmt.Fprintf(w, " API version:\t%s\n", apiVersion)
+	}
+	fmt.Fprintf(w, " Go version:\t%s\n", runtime.Version())
+	fmt.Fprintf(w, " OS/Arch:\t%s/%s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Server:")
+	fmt.Fprintf(w, " Engine version:\t%s\n", server.Version)
+	fmt.Fprintf(w, " API version:\t%s (minimum version %s)\n", server.APIVersion, server.MinAPIVersion)
+	fmt.Fprintf(w, " OS/Arch:\t%s/%s\n", server.Os, server.Arch)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if !opts.checkCompatibility {
+		return nil
+	}
+	fmt.Fprintln(out)
+	return printCompatibility(out, apiVersion)
+}
+
+func printCompatibility(out io.Writer, apiVersion string) error {
+	w := tabwriter.NewWriter(out, 10, 1, 3, ' ', 0)
+	fmt.Fprintln(w, "FEATURE\tAPI VERSION\tSTATUS\tUSED BY")
+	for _, f := range capability.Features {
+		status := "available"
+		if !f.Supported(apiVersion) {
+			status = "unavailable"
+		}
+		usedBy := f.UsedBy
+		if usedBy == "" {
+			usedBy = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", f.Name, f.MinAPIVersion, status, usedBy)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,32 @@
+package system
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/moby/swarmctl/internal/test"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestVersionDowngraded(t *testing.T) {
+	cli := test.NewFakeCli(&fakeClient{
+		apiVersion: "1.41",
+		serverVersionFn: func(context.Context) (types.Version, error) {
+			return types.Version{Version: "20.10.24", APIVersion: "1.41", MinAPIVersion: "1.12", Os: "linux", Arch: "amd64"}, nil
+		},
+	})
+	cmd := NewVersionCommand(cli)
+	cmd.SetArgs([]string{})
+	assert.NilError(t, cmd.Execute())
+	assert.Check(t, is.Contains(cli.OutBuffer().String(), " API version: 1.41 (downgraded from 1.42)\n"))
+	assert.Check(t, is.Contains(cli.OutBuffer().String(), " Engine version: 20.10.24\n"))
+}
+
+func TestVersionCheckCompatibility(t *testing.T) {
+	out := new(bytes.Buffer)
+	assert.NilError(t, printCompatibility(out, "1.41"))
+	test.AssertGolden(t, out.String(), "version-check-compatibility.golden")
+}
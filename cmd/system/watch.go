@@ -0,0 +1,138 @@
+package system
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/docker/cli/cli/command"
+	eventtypes "github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	units "github.com/docker/go-units"
+	"github.com/moby/swarmctl/internal/events"
+)
+
+// watchHistory is how far back the transitions of the nodes are read when
+// starting to watch them.
+const watchHistory = 10 * time.Minute
+
+// watchTransitions is the number of recent transitions shown per node.
+const watchTransitions = 3
+
+// nodeTransition is a change of the state or availability of a node.
+type nodeTransition struct {
+	change string
+	at     time.Time
+}
+
+// nodeTransitions holds the recent transitions of the nodes, newest first,
+// from their update events.
+type nodeTransitions map[string][]nodeTransition
+
+func (t nodeTransitions) add(msg eventtypes.Message) {
+	if msg.Type != eventtypes.NodeEventType || msg.Action != "update" {
+		return
+	}
+	at := time.Unix(0, msg.TimeNano)
+	if msg.TimeNano == 0 {
+		at = time.Unix(msg.Time, 0)
+	}
+	attrs := msg.Actor.Attributes
+	for _, field := range []string{"state", "availability"} {
+		from, to := attrs[field+".old"], attrs[field+".new"]
+		if from == "" || to == "" || from == to {
+			continue
+		}
+		change := from + "→" + to
+		if field != "state" {
+			change = field + " " + change
+		}
+		recent := append([]nodeTransition{{change: change, at: at}}, t[msg.Actor.ID]...)
+		if len(recent) > watchTransitions {
+			recent = recent[:watchTransitions]
+		}
+		t[msg.Actor.ID] = recent
+	}
+}
+
+// column returns the recent transitions of a node, with their age.
+func (t nodeTransitions) column(nodeID string, now time.Time) string {
+	recent := t[nodeID]
+	if len(recent) == 0 {
+		return "-"
+	}
+	changes := make([]string, 0, len(recent))
+	for _, tr := range recent {
+		changes = append(changes, tr.change+" "+units.HumanDuration(now.Sub(tr.at))+" ago")
+	}
+	return strings.Join(changes, ", ")
+}
+
+// watchNodes lists the nodes, and lists them again on every node event,
+// annotated with their recent transitions, until ctx is done.
+func watchNodes(ctx context.Context, dockerCli command.Cli, k kind, f filters.Args, names []string) error {
+	apiClient := dockerCli.Client()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	start := time.Now()
+	transitions := nodeTransitions{}
+	history, errs := events.Stream(ctx, apiClient, events.Options{
+		Types: []string{eventtypes.NodeEventType},
+		Since: unixTime(start.Add(-watchHistory)),
+		Until: unixTime(start),
+	})
+	for msg := range history {
+		transitions.add(msg)
+	}
+	if err := <-errs; err != nil {
+		return err
+	}
+
+	printNodes := func() error {
+		objects, err := k.list(ctx, apiClient, f)
+		if err != nil {
+			return err
+		}
+		if objects, err = selectObjects(k, objects, names); err != nil {
+			return err
+		}
+		return printWatchedNodes(dockerCli.Out(), k, objects, transitions, time.Now())
+	}
+	if err := printNodes(); err != nil {
+		return err
+	}
+
+	messages, errs := events.Stream(ctx, apiClient, events.Options{
+		Types: []string{eventtypes.NodeEventType},
+		Since: unixTime(start),
+		OnReconnect: func(err error, delay time.Duration) {
+			fmt.Fprintf(dockerCli.Err(), "WARNING: lost the event stream (%s), reconnecting in %s\n", err, delay)
+		},
+	})
+	for msg := range messages {
+		transitions.add(msg)
+		fmt.Fprintln(dockerCli.Out())
+		if err := printNodes(); err != nil {
+			return err
+		}
+	}
+	return <-errs
+}
+
+func printWatchedNodes(out io.Writer, k kind, objects []object, transitions nodeTransitions, now time.Time) error {
+	w := tabwriter.NewWriter(out, 10, 1, 3, ' ', 0)
+	fmt.Fprintln(w, strings.Join(k.header, "\t")+"\tTRANSITIONS")
+	for _, o := range objects {
+		fmt.Fprintln(w, strings.Join(o.columns, "\t")+"\t"+transitions.column(o.id, now))
+	}
+	return w.Flush()
+}
+
+// unixTime formats t as the timestamps of the events API.
+func unixTime(t time.Time) string {
+	return fmt.Sprintf("%d.%09d", t.Unix(), t.Nanosecond())
+}
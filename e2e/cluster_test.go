@@ -0,0 +1,247 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
+	"github.com/pkg/errors"
+)
+
+const (
+	// labelE2E marks the containers and network of the test swarm, so that
+	// leftovers of an interrupted run can be found.
+	labelE2E    = "swarmctl.e2e"
+	networkName = "swarmctl-e2e"
+	enginePort  = nat.Port("2375/tcp")
+)
+
+// node is an engine of the test swarm, running in a container.
+type node struct {
+	name    string
+	manager bool
+	// host is the address of the engine API, published on the loopback
+	// interface of the host.
+	host string
+	// ip is the address of the node on the network of the swarm.
+	ip     string
+	client client.APIClient
+}
+
+// cluster is a swarm of docker-in-docker nodes.
+type cluster struct {
+	client client.APIClient
+	nodes  []*node
+}
+
+// startCluster starts a swarm of managers and workers running image.
+func startCluster(ctx context.Context, apiClient client.APIClient, image string, managers, workers int) (*cluster, error) {
+	if managers < 1 {
+		return nil, errors.New("the swarm needs at least one manager")
+	}
+	c := &cluster{client: apiClient}
+	if err := pullImage(ctx, apiClient, image); err != nil {
+		return nil, err
+	}
+	_, err := apiClient.NetworkCreate(ctx, networkName, types.NetworkCreate{
+		CheckDuplicate: true,
+		Labels:         map[string]string{labelE2E: "true"},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the network of the swarm")
+	}
+
+	for i := 1; i <= managers+workers; i++ {
+		name := fmt.Sprintf("swarmctl-e2e-manager%d", i)
+		if i > managers {
+			name = fmt.Sprintf("swarmctl-e2e-worker%d", i-managers)
+		}
+		n, err := c.startNode(ctx, image, name, i <= managers)
+		if err != nil {
+			return c, err
+		}
+		c.nodes = append(c.nodes, n)
+	}
+	if err := c.form(ctx); err != nil {
+		return c, err
+	}
+	return c, nil
+}
+
+func pullImage(ctx context.Context, apiClient client.APIClient, image string) error {
+	responseBody, err := apiClient.ImagePull(ctx, image, types.ImagePullOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to pull %s", image)
+	}
+	defer responseBody.Close()
+	_, err = io.Copy(io.Discard, responseBody)
+	return err
+}
+
+// startNode starts the container of a node, and waits for its engine to
+// answer.
+func (c *cluster) startNode(ctx context.Context, image, name string, manager bool) (*node, error) {
+	created, err := c.client.ContainerCreate(ctx,
+		&container.Config{
+			Image:        image,
+			Hostname:     name,
+			Env:          []string{"DOCKER_TLS_CERTDIR="},
+			Cmd:          []string{"--host=unix:///var/run/docker.sock", "--host=tcp://0.0.0.0:2375", "--tls=false"},
+			ExposedPorts: nat.PortSet{enginePort: struct{}{}},
+			Labels:       map[string]string{labelE2E: "true"},
+		},
+		&container.HostConfig{
+			Privileged:   true,
+			PortBindings: nat.PortMap{enginePort: []nat.PortBinding{{HostIP: "127.0.0.1"}}},
+		},
+		&network.NetworkingConfig{EndpointsConfig: map[string]*network.EndpointSettings{networkName: {}}},
+		nil, name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create node %s", name)
+	}
+	if err := c.client.ContainerStart(ctx, created.ID, types.ContainerStartOptions{}); err != nil {
+		return nil, errors.Wrapf(err, "failed to start node %s", name)
+	}
+	info, err := c.client.ContainerInspect(ctx, created.ID)
+	if err != nil {
+		return nil, err
+	}
+	bindings := info.NetworkSettings.Ports[enginePort]
+	if len(bindings) == 0 {
+		return nil, errors.Errorf("the engine port of node %s is not published", name)
+	}
+	endpoint, ok := info.NetworkSettings.Networks[networkName]
+	if !ok {
+		return nil, errors.Errorf("node %s is not connected to network %s", name, networkName)
+	}
+
+	n := &node{
+		name:    name,
+		manager: manager,
+		host:    "tcp://" + net.JoinHostPort(bindings[0].HostIP, bindings[0].HostPort),
+		ip:      endpoint.IPAddress,
+	}
+	if n.client, err = client.NewClientWithOpts(client.WithHost(n.host), client.WithAPIVersionNegotiation()); err != nil {
+		return nil, err
+	}
+	err = poll(ctx, time.Minute, func() (bool, error) {
+		_, err := n.client.Ping(ctx)
+		return err == nil, nil
+	})
+	return n, errors.Wrapf(err, "the engine of node %s did not start", name)
+}
+
+// form initializes the swarm on the first manager, joins the other nodes to
+// it, and waits for all of them to be ready.
+func (c *cluster) form(ctx context.Context) error {
+	first := c.nodes[0]
+	_, err := first.client.SwarmInit(ctx, swarm.InitRequest{ListenAddr: "0.0.0.0:2377", AdvertiseAddr: first.ip})
+	if err != nil {
+		return errors.Wrap(err, "failed to initialize the swarm")
+	}
+	sw, err := first.client.SwarmInspect(ctx)
+	if err != nil {
+		return err
+	}
+	for _, n := range c.nodes[1:] {
+		token := sw.JoinTokens.Worker
+		if n.manager {
+			token = sw.JoinTokens.Manager
+		}
+		err := n.client.SwarmJoin(ctx, swarm.JoinRequest{
+			ListenAddr:    "0.0.0.0:2377",
+			AdvertiseAddr: n.ip,
+			RemoteAddrs:   []string{net.JoinHostPort(first.ip, "2377")},
+			JoinToken:     token,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "node %s failed to join the swarm", n.name)
+		}
+	}
+	return poll(ctx, 2*time.Minute, func() (bool, error) {
+		nodes, err := first.client.NodeList(ctx, types.NodeListOptions{})
+		if err != nil {
+			return false, err
+		}
+		var ready int
+		for _, n := range nodes {
+			if n.Status.State == swarm.NodeStateReady {
+				ready++
+			}
+		}
+		return ready == len(c.nodes), nil
+	})
+}
+
+// manager returns the first manager of the swarm.
+func (c *cluster) manager() *node {
+	return c.nodes[0]
+}
+
+// workers returns the worker nodes of the swarm.
+func (c *cluster) workers() []*node {
+	var workers []*node
+	for _, n := range c.nodes {
+		if !n.manager {
+			workers = append(workers, n)
+		}
+	}
+	return workers
+}
+
+// remove removes the containers and the network of the swarm, including
+// those left by previous runs.
+func (c *cluster) remove(ctx context.Context) error {
+	containers, err := c.client.ContainerList(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", labelE2E)),
+	})
+	if err != nil {
+		return err
+	}
+	for _, ctr := range containers {
+		if err := c.client.ContainerRemove(ctx, ctr.ID, types.ContainerRemoveOptions{Force: true, RemoveVolumes: true}); err != nil {
+			return err
+		}
+	}
+	networks, err := c.client.NetworkList(ctx, types.NetworkListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", labelE2E)),
+	})
+	if err != nil {
+		return err
+	}
+	for _, nw := range networks {
+		if err := c.client.NetworkRemove(ctx, nw.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// poll calls cond until it returns true or an error, or until timeout.
+func poll(ctx context.Context, timeout time.Duration, cond func() (bool, error)) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for {
+		done, err := cond()
+		if err != nil || done {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return errors.Errorf("timed out after %s", timeout)
+		case <-time.After(time.Second):
+		}
+	}
+}
@@ -0,0 +1,11 @@
+// Package e2e runs swarmctl against a real swarm, made of docker-in-docker
+// containers started on the local engine.
+//
+// The tests are built with the e2e build tag, and need an engine able to run
+// privileged containers:
+//
+//	go test -tags e2e -v ./e2e
+//
+// The -e2e.managers and -e2e.workers flags set the size of the swarm, and
+// -e2e.keep leaves its containers running after the tests, for debugging.
+package e2e
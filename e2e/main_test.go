@@ -0,0 +1,91 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/client"
+)
+
+var (
+	dindImage = flag.String("e2e.dind-image", "docker:23.0-dind", "Image of the docker-in-docker nodes")
+	managers  = flag.Int("e2e.managers", 1, "Number of managers of the swarm")
+	workers   = flag.Int("e2e.workers", 2, "Number of workers of the swarm")
+	keep      = flag.Bool("e2e.keep", false, "Leave the swarm running after the tests")
+)
+
+var (
+	testCluster *cluster
+	// swarmctlBinary is the path of the swarmctl binary built for the tests.
+	swarmctlBinary string
+	// configDir is an empty docker configuration directory, so that the
+	// defaults set with "swarmctl config" on the host do not apply.
+	configDir string
+)
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	os.Exit(run(m))
+}
+
+func run(m *testing.M) int {
+	ctx := context.Background()
+	tmpDir, err := os.MkdirTemp("", "swarmctl-e2e")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer os.RemoveAll(tmpDir)
+
+	swarmctlBinary = filepath.Join(tmpDir, "swarmctl")
+	if out, err := exec.Command("go", "build", "-o", swarmctlBinary, "../cmd").CombinedOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to build swarmctl: %v\n%s", err, out)
+		return 1
+	}
+	configDir = filepath.Join(tmpDir, "config")
+	if err := os.Mkdir(configDir, 0o700); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	apiClient, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	testCluster, err = startCluster(ctx, apiClient, *dindImage, *managers, *workers)
+	if !*keep && testCluster != nil {
+		defer func() {
+			if err := testCluster.remove(ctx); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to remove the swarm: %v\n", err)
+			}
+		}()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to start the swarm: %v\n", err)
+		return 1
+	}
+	return m.Run()
+}
+
+// swarmctl runs swarmctl against the first manager of the swarm, and returns
+// its combined output.
+func swarmctl(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	cmd := exec.Command(swarmctlBinary, args...)
+	cmd.Env = append(os.Environ(), "DOCKER_HOST="+testCluster.manager().host, "DOCKER_CONFIG="+configDir)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	err := cmd.Run()
+	t.Logf("swarmctl %v:\n%s", args, out.String())
+	return out.String(), err
+}
@@ -0,0 +1,121 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+// createService creates a replicated nginx service, and waits for its tasks
+// to run.
+func createService(t *testing.T, name string, replicas uint64) swarm.Service {
+	t.Helper()
+	ctx := context.Background()
+	apiClient := testCluster.manager().client
+	response, err := apiClient.ServiceCreate(ctx, swarm.ServiceSpec{
+		Annotations:  swarm.Annotations{Name: name},
+		Mode:         swarm.ServiceMode{Replicated: &swarm.ReplicatedService{Replicas: &replicas}},
+		TaskTemplate: swarm.TaskSpec{ContainerSpec: &swarm.ContainerSpec{Image: "nginx:alpine"}},
+	}, types.ServiceCreateOptions{})
+	assert.NilError(t, err)
+	t.Cleanup(func() {
+		assert.Check(t, apiClient.ServiceRemove(ctx, response.ID))
+	})
+	assert.NilError(t, poll(ctx, 3*time.Minute, func() (bool, error) {
+		return len(runningTasks(t, filters.Arg("service", response.ID))) == int(replicas), nil
+	}))
+	service, _, err := apiClient.ServiceInspectWithRaw(ctx, response.ID, types.ServiceInspectOptions{})
+	assert.NilError(t, err)
+	return service
+}
+
+func runningTasks(t *testing.T, args ...filters.KeyValuePair) []swarm.Task {
+	t.Helper()
+	tasks, err := testCluster.manager().client.TaskList(context.Background(), types.TaskListOptions{
+		Filters: filters.NewArgs(append(args, filters.Arg("desired-state", "running"))...),
+	})
+	assert.NilError(t, err)
+	var running []swarm.Task
+	for _, task := range tasks {
+		if task.Status.State == swarm.TaskStateRunning {
+			running = append(running, task)
+		}
+	}
+	return running
+}
+
+func TestServicePublishConverges(t *testing.T) {
+	service := createService(t, "e2e-publish", 2)
+
+	_, err := swarmctl(t, "service", "publish", "--progress", "plain", service.Spec.Name, "8080:80")
+	assert.NilError(t, err)
+
+	service, _, err = testCluster.manager().client.ServiceInspectWithRaw(context.Background(), service.ID, types.ServiceInspectOptions{})
+	assert.NilError(t, err)
+	assert.Assert(t, is.Len(service.Endpoint.Spec.Ports, 1))
+	assert.Check(t, is.Equal(service.Endpoint.Spec.Ports[0].PublishedPort, uint32(8080)))
+	assert.Check(t, is.Len(runningTasks(t, filters.Arg("service", service.ID)), 2))
+}
+
+func TestCARotation(t *testing.T) {
+	ctx := context.Background()
+	apiClient := testCluster.manager().client
+	before, err := apiClient.SwarmInspect(ctx)
+	assert.NilError(t, err)
+
+	_, err = swarmctl(t, "swarm", "ca", "--rotate", "--progress", "plain")
+	assert.NilError(t, err)
+
+	after, err := apiClient.SwarmInspect(ctx)
+	assert.NilError(t, err)
+	assert.Check(t, after.TLSInfo.TrustRoot != before.TLSInfo.TrustRoot, "the root CA was not rotated")
+	nodes, err := apiClient.NodeList(ctx, types.NodeListOptions{})
+	assert.NilError(t, err)
+	for _, n := range nodes {
+		assert.Check(t, is.Equal(n.Description.TLSInfo.TrustRoot, after.TLSInfo.TrustRoot), "node %s", n.Description.Hostname)
+	}
+}
+
+func TestUpgradeNodeDrains(t *testing.T) {
+	ctx := context.Background()
+	apiClient := testCluster.manager().client
+	workers := testCluster.workers()
+	if len(workers) < 2 {
+		t.Skip("needs two workers to move the tasks of the drained one")
+	}
+	worker := workers[0]
+	service := createService(t, "e2e-drain", 4)
+
+	nodeInfo, err := worker.client.Info(ctx)
+	assert.NilError(t, err)
+	nodeID := nodeInfo.Swarm.NodeID
+	t.Cleanup(func() {
+		n, _, err := apiClient.NodeInspectWithRaw(ctx, nodeID)
+		assert.NilError(t, err)
+		n.Spec.Availability = swarm.NodeAvailabilityActive
+		n.Spec.Labels = nil
+		assert.Check(t, apiClient.NodeUpdate(ctx, nodeID, n.Version, n.Spec))
+	})
+
+	// The engine of the node is not upgraded, so the command gives up
+	// waiting for it once the node is drained.
+	out, err := swarmctl(t, "cluster", "upgrade-node", "--drain-timeout", "2m", "--return-timeout", "5s", worker.name)
+	assert.Check(t, err != nil)
+	assert.Check(t, is.Contains(out, "is left drained"))
+
+	n, _, err := apiClient.NodeInspectWithRaw(ctx, nodeID)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(n.Spec.Availability, swarm.NodeAvailabilityDrain))
+	assert.Check(t, is.Len(runningTasks(t, filters.Arg("node", nodeID)), 0))
+	assert.NilError(t, poll(ctx, 2*time.Minute, func() (bool, error) {
+		return len(runningTasks(t, filters.Arg("service", service.ID))) == 4, nil
+	}))
+}
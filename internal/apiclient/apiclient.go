@@ -0,0 +1,75 @@
+// Package apiclient creates the API client used by swarmctl commands when the
+// HTTP transport needs to be wrapped, for instance to record the requests.
+package apiclient
+
+import (
+	"net/http"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/docker/client"
+)
+
+// Middleware wraps the HTTP transport used to talk to the daemon.
+type Middleware func(http.RoundTripper) http.RoundTripper
+
+// Cli is a command.Cli whose API client can be replaced after the CLI has
+// been initialized.
+type Cli struct {
+	command.Cli
+	client client.APIClient
+}
+
+// NewCli wraps dockerCli. Until SetClient is called, the client of dockerCli
+// is used.
+func NewCli(dockerCli command.Cli) *Cli {
+	return &Cli{Cli: dockerCli}
+}
+
+// Client returns the API client.
+func (c *Cli) Client() client.APIClient {
+	if c.client != nil {
+		return c.client
+	}
+	return c.Cli.Client()
+}
+
+// SetClient replaces the API client.
+func (c *Cli) SetClient(apiClient client.APIClient) {
+	c.client = apiClient
+}
+
+// NewClient returns a client for the endpoint the CLI was initialized with,
+// with its transport wrapped by the given middlewares. The first middleware
+// is the outermost one.
+func NewClient(dockerCli command.Cli, middlewares ...Middleware) (client.APIClient, error) {
+	ep := dockerCli.DockerEndpoint()
+	opts, err := ep.ClientOpts()
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(opts, client.WithHTTPHeaders(headers(dockerCli)), client.WithAPIVersionNegotiation(), withMiddlewares(middlewares))
+	return client.NewClientWithOpts(opts...)
+}
+
+// headers returns the HTTP headers sent to the daemon: the user agent and
+// the headers of the configuration file.
+func headers(dockerCli command.Cli) map[string]string {
+	headers := map[string]string{"User-Agent": command.UserAgent()}
+	for k, v := range dockerCli.ConfigFile().HTTPHeaders {
+		headers[k] = v
+	}
+	return headers
+}
+
+// withMiddlewares wraps the transport once the other options configured it
+// for the endpoint's host and TLS settings.
+func withMiddlewares(middlewares []Middleware) client.Opt {
+	return func(c *client.Client) error {
+		httpClient := c.HTTPClient()
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			httpClient.Transport = middlewares[i](httpClient.Transport)
+		}
+		return client.WithHTTPClient(httpClient)(c)
+	}
+}
@@ -0,0 +1,135 @@
+package apiclient
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// pingTimeout bounds the health checks of the managers failed over to.
+const pingTimeout = 5 * time.Second
+
+// Failover returns a middleware sending the requests to the first of the
+// managers at addrs, given as host:port, and failing over to the next
+// healthy one when a manager cannot be reached, is not a manager anymore, or
+// has no leader. The manager failed over to is used for the next requests.
+// Requests failing after the connection was made are only sent again if
+// their method is idempotent, as the manager may have processed them. All
+// the managers must accept the TLS settings of the client.
+func Failover(addrs []string) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &failoverTransport{next: next, addrs: addrs}
+	}
+}
+
+type failoverTransport struct {
+	next  http.RoundTripper
+	addrs []string
+
+	mu      sync.Mutex
+	current int
+}
+
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	current := t.current
+	t.mu.Unlock()
+
+	resp, err := t.send(req, current)
+	for i := 1; i < len(t.addrs) && t.failed(req, resp, err); i++ {
+		addr := (current + i) % len(t.addrs)
+		if !t.healthy(req, addr) {
+			continue
+		}
+		retry := req
+		if req.Body != nil {
+			body, bodyErr := getBody(req)
+			if bodyErr != nil {
+				break
+			}
+			retry = req.Clone(req.Context())
+			retry.Body = body
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+		resp, err = t.send(retry, addr)
+		if !t.failed(req, resp, err) {
+			t.mu.Lock()
+			t.current = addr
+			t.mu.Unlock()
+		}
+	}
+	return resp, err
+}
+
+// getBody returns a new copy of the body of req, if it can be sent again.
+func getBody(req *http.Request) (io.ReadCloser, error) {
+	if req.GetBody == nil {
+		return nil, errors.New("the request body cannot be sent again")
+	}
+	return req.GetBody()
+}
+
+// send sends req to the manager at index addr.
+func (t *failoverTransport) send(req *http.Request, addr int) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Host = t.addrs[addr]
+	if req.Host != "" {
+		req.Host = t.addrs[addr]
+	}
+	return t.next.RoundTrip(req)
+}
+
+// failed returns whether the manager could not serve req.
+func (t *failoverTransport) failed(req *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		return req.Context().Err() == nil && (isDialError(err) || isIdempotent(req.Method))
+	}
+	if resp.StatusCode < http.StatusInternalServerError {
+		return false
+	}
+	message := errorMessage(resp)
+	return isLeaderError(message) || strings.Contains(message, "not a swarm manager")
+}
+
+// isDialError returns whether err happened before the request was sent,
+// while connecting to the manager.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return (errors.As(err, &opErr) && opErr.Op == "dial") || errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// isIdempotent returns whether requests with the given method can be sent
+// again without changing their effect.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// healthy returns whether the manager at index addr answers pings, sent
+// like req.
+func (t *failoverTransport) healthy(req *http.Request, addr int) bool {
+	ctx, cancel := context.WithTimeout(req.Context(), pingTimeout)
+	defer cancel()
+	ping, err := http.NewRequestWithContext(ctx, http.MethodGet, req.URL.Scheme+"://"+t.addrs[addr]+"/_ping", nil)
+	if err != nil {
+		return false
+	}
+	resp, err := t.next.RoundTrip(ping)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
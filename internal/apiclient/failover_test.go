@@ -0,0 +1,95 @@
+package apiclient
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/pkg/errors"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+// managersTransport answers the requests sent to each manager with its
+// response, or a connection error for the managers without one, and records
+// the requests. The managers in reset close the connection once the
+// request is sent.
+type managersTransport struct {
+	responses map[string]response
+	reset     map[string]bool
+	requests  []string
+}
+
+func (t *managersTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests = append(t.requests, req.Method+" "+req.URL.Host+req.URL.Path)
+	if t.reset[req.URL.Host] && req.URL.Path != "/_ping" {
+		return nil, errors.New("connection reset by peer")
+	}
+	r, ok := t.responses[req.URL.Host]
+	if !ok {
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	}
+	if req.URL.Path == "/_ping" {
+		r = response{status: 200, body: "OK"}
+	}
+	return &http.Response{StatusCode: r.status, Body: io.NopCloser(strings.NewReader(r.body))}, nil
+}
+
+func TestFailover(t *testing.T) {
+	fake := &managersTransport{responses: map[string]response{
+		"m2:2376": {status: 503, body: `{"message":"This node is not a swarm manager."}`},
+		"m3:2376": {status: 200, body: "[]"},
+	}}
+	transport := Failover([]string{"m1:2376", "m2:2376", "m3:2376"})(fake)
+
+	status, body := roundTrip(t, transport, http.MethodPost, "/v1.41/services/create", `{"Name":"web"}`)
+	assert.Check(t, is.Equal(status, 200))
+	assert.Check(t, is.Equal(body, "[]"))
+	status, _ = roundTrip(t, transport, http.MethodGet, "/v1.41/services", "")
+	assert.Check(t, is.Equal(status, 200))
+	assert.Check(t, is.DeepEqual(fake.requests, []string{
+		"POST m1:2376/v1.41/services/create",
+		"GET m2:2376/_ping",
+		"POST m2:2376/v1.41/services/create",
+		"GET m3:2376/_ping",
+		"POST m3:2376/v1.41/services/create",
+		// The next requests go to the manager failed over to.
+		"GET m3:2376/v1.41/services",
+	}))
+}
+
+func TestFailoverAllDown(t *testing.T) {
+	fake := &managersTransport{}
+	transport := Failover([]string{"m1:2376", "m2:2376"})(fake)
+	req, err := http.NewRequest(http.MethodGet, "http://m1:2376/v1.41/services", nil)
+	assert.NilError(t, err)
+	_, err = transport.RoundTrip(req)
+	assert.Error(t, err, "dial tcp: connection refused")
+	assert.Check(t, is.DeepEqual(fake.requests, []string{"GET m1:2376/v1.41/services", "GET m2:2376/_ping"}))
+}
+
+func TestFailoverAfterSend(t *testing.T) {
+	fake := &managersTransport{
+		responses: map[string]response{"m2:2376": {status: 200, body: "[]"}},
+		reset:     map[string]bool{"m1:2376": true},
+	}
+	transport := Failover([]string{"m1:2376", "m2:2376"})(fake)
+
+	// m1 may have created the service, it is not created again.
+	req, err := http.NewRequest(http.MethodPost, "http://m1:2376/v1.41/services/create", strings.NewReader(`{"Name":"web"}`))
+	assert.NilError(t, err)
+	_, err = transport.RoundTrip(req)
+	assert.Error(t, err, "connection reset by peer")
+
+	status, _ := roundTrip(t, transport, http.MethodGet, "/v1.41/services", "")
+	assert.Check(t, is.Equal(status, 200))
+	assert.Check(t, is.DeepEqual(fake.requests, []string{
+		"POST m1:2376/v1.41/services/create",
+		"GET m1:2376/v1.41/services",
+		"GET m2:2376/_ping",
+		"GET m2:2376/v1.41/services",
+	}))
+}
@@ -0,0 +1,54 @@
+package apiclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/docker/client"
+)
+
+// Request sends a raw request to the daemon through the API client of
+// dockerCli, with its transport, TLS settings and HTTP headers. path is
+// prefixed with the negotiated API version, unless it already starts with
+// a version.
+func Request(ctx context.Context, dockerCli command.Cli, method, path string, query url.Values, body io.Reader) (*http.Response, error) {
+	apiClient := dockerCli.Client()
+	apiClient.NegotiateAPIVersion(ctx)
+	host, err := client.ParseHostURL(apiClient.DaemonHost())
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(path, "/v1.") {
+		path = "/v" + apiClient.ClientVersion() + path
+	}
+	u := url.URL{Scheme: "http", Host: host.Host, Path: host.Path + path, RawQuery: query.Encode()}
+	switch host.Scheme {
+	case "tcp":
+		if ep := dockerCli.DockerEndpoint(); ep.TLSData != nil || ep.SkipTLSVerify {
+			u.Scheme = "https"
+		}
+	case "http", "https":
+		u.Scheme = host.Scheme
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if host.Scheme == "unix" || host.Scheme == "npipe" {
+		// The host of local sockets is only used in the Host header, as by
+		// the API client.
+		req.Host = "docker"
+	}
+	for k, v := range headers(dockerCli) {
+		req.Header.Set(k, v)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return apiClient.HTTPClient().Do(req)
+}
@@ -0,0 +1,132 @@
+package apiclient
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// leaderErrors are the messages of the errors returned while the swarm
+// elects a leader, which requests should be retried on.
+var leaderErrors = []string{
+	"does not have a leader",
+	"no elected cluster leader",
+	"lost leadership",
+}
+
+// managerPaths are the API paths only served by swarm managers.
+var managerPaths = []string{"/swarm", "/nodes", "/services", "/tasks", "/secrets", "/configs"}
+
+const (
+	minRetryDelay = 250 * time.Millisecond
+	maxRetryDelay = 5 * time.Second
+)
+
+// RetryLeaderChanges returns a middleware retrying, with backoff and for up
+// to timeout, the requests failing because the swarm has no leader, or
+// because the node stopped being a manager after serving manager requests,
+// as happens while leadership changes. Requests whose body cannot be sent
+// again are not retried.
+func RetryLeaderChanges(timeout time.Duration) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &retryTransport{next: next, timeout: timeout}
+	}
+}
+
+type retryTransport struct {
+	next    http.RoundTripper
+	timeout time.Duration
+	// manager is set once the node served a manager request.
+	manager int32
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	orig := req
+	var deadline time.Time
+	delay := minRetryDelay
+	for {
+		resp, err := t.next.RoundTrip(req)
+		if err != nil || !t.retryable(req, resp) {
+			return resp, err
+		}
+		if deadline.IsZero() {
+			deadline = time.Now().Add(t.timeout)
+		}
+		if time.Now().Add(delay).After(deadline) {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxRetryDelay {
+			delay = maxRetryDelay
+		}
+		if orig.Body != nil {
+			body, err := orig.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = orig.Clone(orig.Context())
+			req.Body = body
+		}
+	}
+}
+
+// retryable returns whether the request failed on a leader change. The
+// error body of resp is restored once read.
+func (t *retryTransport) retryable(req *http.Request, resp *http.Response) bool {
+	if resp.StatusCode < http.StatusInternalServerError {
+		if resp.StatusCode < http.StatusBadRequest && isManagerPath(req.URL.Path) {
+			atomic.StoreInt32(&t.manager, 1)
+		}
+		return false
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return false
+	}
+	message := errorMessage(resp)
+	return isLeaderError(message) || (strings.Contains(message, "not a swarm manager") && atomic.LoadInt32(&t.manager) == 1)
+}
+
+// errorMessage returns the body of resp, in lower case, and restores it.
+func errorMessage(resp *http.Response) string {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(string(body))
+}
+
+func isLeaderError(message string) bool {
+	for _, e := range leaderErrors {
+		if strings.Contains(message, e) {
+			return true
+		}
+	}
+	return false
+}
+
+// isManagerPath returns whether path, which may have a version prefix such
+// as /v1.41, is only served by managers.
+func isManagerPath(path string) bool {
+	if strings.HasPrefix(path, "/v") {
+		if i := strings.Index(path[1:], "/"); i >= 0 {
+			path = path[i+1:]
+		}
+	}
+	for _, p := range managerPaths {
+		if path == p || strings.HasPrefix(path, p+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package apiclient
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+type response struct {
+	status int
+	body   string
+}
+
+// fakeTransport answers requests with responses, in order, and records the
+// bodies of the requests.
+type fakeTransport struct {
+	responses []response
+	bodies    []string
+}
+
+func (t *fakeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		body, _ := io.ReadAll(req.Body)
+		t.bodies = append(t.bodies, string(body))
+	}
+	r := t.responses[0]
+	t.responses = t.responses[1:]
+	return &http.Response{StatusCode: r.status, Body: io.NopCloser(strings.NewReader(r.body))}, nil
+}
+
+func roundTrip(t *testing.T, transport http.RoundTripper, method, path, body string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, "http://docker"+path, strings.NewReader(body))
+	assert.NilError(t, err)
+	resp, err := transport.RoundTrip(req)
+	assert.NilError(t, err)
+	data, err := io.ReadAll(resp.Body)
+	assert.NilError(t, err)
+	return resp.StatusCode, string(data)
+}
+
+func TestRetryNoLeader(t *testing.T) {
+	fake := &fakeTransport{responses: []response{
+		{status: 503, body: `{"message":"rpc error: code = Unknown desc = The swarm does not have a leader."}`},
+		{status: 500, body: `{"message":"rpc error: code = Unknown desc = raft: lost leadership"}`},
+		{status: 201, body: `{"ID":"abc"}`},
+	}}
+	transport := RetryLeaderChanges(time.Minute)(fake)
+	req, err := http.NewRequest(http.MethodPost, "http://docker/v1.41/services/create", strings.NewReader(`{"Name":"web"}`))
+	assert.NilError(t, err)
+	reqBody := req.Body
+	resp, err := transport.RoundTrip(req)
+	assert.NilError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(resp.StatusCode, 201))
+	assert.Check(t, is.Equal(string(body), `{"ID":"abc"}`))
+	assert.Check(t, is.DeepEqual(fake.bodies, []string{`{"Name":"web"}`, `{"Name":"web"}`, `{"Name":"web"}`}))
+	// The request of the caller is left as is.
+	assert.Check(t, req.Body == reqBody)
+}
+
+func TestRetryNotManager(t *testing.T) {
+	notManager := response{status: 503, body: `{"message":"This node is not a swarm manager."}`}
+
+	// A worker is not retried.
+	fake := &fakeTransport{responses: []response{notManager}}
+	transport := RetryLeaderChanges(time.Minute)(fake)
+	status, body := roundTrip(t, transport, http.MethodGet, "/v1.41/services", "")
+	assert.Check(t, is.Equal(status, 503))
+	assert.Check(t, is.Equal(body, notManager.body))
+
+	// A manager being demoted is.
+	fake = &fakeTransport{responses: []response{{status: 200, body: "[]"}, notManager, {status: 200, body: "[]"}}}
+	transport = RetryLeaderChanges(time.Minute)(fake)
+	status, _ = roundTrip(t, transport, http.MethodGet, "/v1.41/nodes", "")
+	assert.Check(t, is.Equal(status, 200))
+	status, _ = roundTrip(t, transport, http.MethodGet, "/v1.41/services", "")
+	assert.Check(t, is.Equal(status, 200))
+	assert.Check(t, is.Len(fake.responses, 0))
+}
+
+func TestRetryTimeout(t *testing.T) {
+	noLeader := response{status: 503, body: `{"message":"The swarm does not have a leader."}`}
+	fake := &fakeTransport{responses: []response{noLeader, noLeader}}
+	transport := RetryLeaderChanges(300 * time.Millisecond)(fake)
+	status, body := roundTrip(t, transport, http.MethodGet, "/v1.41/services", "")
+	assert.Check(t, is.Equal(status, 503))
+	assert.Check(t, is.Equal(body, noLeader.body))
+	assert.Check(t, is.Len(fake.responses, 0))
+}
@@ -0,0 +1,106 @@
+// Package capability maps the engine features swarmctl relies on to the API
+// version that introduced them, so that commands can report a missing
+// feature precisely instead of failing with the daemon's error.
+package capability
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/versions"
+	"github.com/docker/docker/client"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Feature is an engine feature available from a given API version.
+type Feature struct {
+	Name          string
+	MinAPIVersion string
+	// UsedBy is the swarmctl command or flag depending on the feature, if any.
+	UsedBy string
+}
+
+// Supported returns whether the feature is available at apiVersion.
+func (f Feature) Supported(apiVersion string) bool {
+	return versions.GreaterThanOrEqualTo(apiVersion, f.MinAPIVersion)
+}
+
+// Engine features, in the order of the API version introducing them.
+var (
+	SwarmEvents = Feature{Name: "swarm events", MinAPIVersion: "1.30", UsedBy: "events"}
+	CARotation  = Feature{Name: "CA rotation", MinAPIVersion: "1.30", UsedBy: "swarm ca"}
+	AddrPools   = Feature{Name: "default address pools", MinAPIVersion: "1.39", UsedBy: "swarm init --default-addr-pool"}
+	DataPort    = Feature{Name: "data path port", MinAPIVersion: "1.40", UsedBy: "swarm init --data-path-port"}
+	Sysctls     = Feature{Name: "service sysctls", MinAPIVersion: "1.40"}
+	Jobs        = Feature{Name: "swarm jobs", MinAPIVersion: "1.41", UsedBy: "cron"}
+	CSI         = Feature{Name: "cluster volumes (CSI)", MinAPIVersion: "1.42"}
+)
+
+// Features lists the known engine features.
+var Features = []Feature{SwarmEvents, CARotation, AddrPools, DataPort, Sysctls, Jobs, CSI}
+
+// APIVersion returns the API version used to talk to the daemon, negotiating
+// it first if needed.
+func APIVersion(ctx context.Context, apiClient client.APIClient) string {
+	apiClient.NegotiateAPIVersion(ctx)
+	return apiClient.ClientVersion()
+}
+
+// CheckCommand returns an error if the daemon does not support the API
+// version required by cmd, one of its parents, or one of the flags set on the
+// command line, as declared by their "version" annotation. The API version is
+// only negotiated if one of them has the annotation.
+func CheckCommand(ctx context.Context, apiClient client.APIClient, cmd *cobra.Command) error {
+	var apiVersion string
+	check := func(name, minVersion string) error {
+		if minVersion == "" {
+			return nil
+		}
+		if apiVersion == "" {
+			apiVersion = APIVersion(ctx, apiClient)
+		}
+		if versions.LessThan(apiVersion, minVersion) {
+			return errors.Errorf("%s requires API %s+, the daemon supports API %s", featureName(name), minVersion, apiVersion)
+		}
+		return nil
+	}
+
+	var err error
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if v := f.Annotations["version"]; err == nil && len(v) > 0 {
+			err = check(commandName(cmd)+" --"+f.Name, v[0])
+		}
+	})
+	if err != nil {
+		return err
+	}
+	for c := cmd; c != nil; c = c.Parent() {
+		if err := check(commandName(c), c.Annotations["version"]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commandName returns the path of cmd without the root command.
+func commandName(cmd *cobra.Command) string {
+	path := cmd.CommandPath()
+	if _, name, ok := strings.Cut(path, " "); ok {
+		return name
+	}
+	return path
+}
+
+// featureName returns the name of the feature used by a command or flag, or
+// the quoted command or flag if it is not in the feature map.
+func featureName(usedBy string) string {
+	for _, f := range Features {
+		if f.UsedBy == usedBy {
+			return f.Name
+		}
+	}
+	return strconv.Quote(usedBy)
+}
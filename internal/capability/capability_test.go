@@ -0,0 +1,95 @@
+package capability
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/docker/client"
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+type fakeClient struct {
+	client.Client
+	apiVersion string
+	negotiated bool
+}
+
+func (cli *fakeClient) NegotiateAPIVersion(context.Context) {
+	cli.negotiated = true
+}
+
+func (cli *fakeClient) ClientVersion() string {
+	return cli.apiVersion
+}
+
+func commandTree() (root, initCmd *cobra.Command) {
+	root = &cobra.Command{Use: "swarmctl COMMAND"}
+	swarm := &cobra.Command{Use: "swarm", Annotations: map[string]string{"version": "1.24"}}
+	initCmd = &cobra.Command{Use: "init", Run: func(*cobra.Command, []string) {}}
+	initCmd.Flags().Uint32("data-path-port", 0, "")
+	initCmd.Flags().SetAnnotation("data-path-port", "version", []string{"1.40"})
+	initCmd.Flags().String("listen-addr", "", "")
+	initCmd.Flags().SetAnnotation("listen-addr", "version", []string{"1.99"})
+	swarm.AddCommand(initCmd)
+	root.AddCommand(swarm, &cobra.Command{Use: "replay", Run: func(*cobra.Command, []string) {}})
+	return root, initCmd
+}
+
+func TestCheckCommand(t *testing.T) {
+	tests := []struct {
+		doc        string
+		args       []string
+		apiVersion string
+		expected   string
+	}{
+		{
+			doc:        "supported",
+			args:       []string{"--data-path-port", "4789"},
+			apiVersion: "1.41",
+		},
+		{
+			doc:        "flag in the feature map",
+			args:       []string{"--data-path-port", "4789"},
+			apiVersion: "1.39",
+			expected:   "data path port requires API 1.40+, the daemon supports API 1.39",
+		},
+		{
+			doc:        "flag not in the feature map",
+			args:       []string{"--listen-addr", "0.0.0.0"},
+			apiVersion: "1.41",
+			expected:   `"swarm init --listen-addr" requires API 1.99+, the daemon supports API 1.41`,
+		},
+		{
+			doc:        "unset flag",
+			apiVersion: "1.39",
+		},
+		{
+			doc:        "parent command",
+			apiVersion: "1.12",
+			expected:   `"swarm" requires API 1.24+, the daemon supports API 1.12`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.doc, func(t *testing.T) {
+			_, initCmd := commandTree()
+			assert.NilError(t, initCmd.ParseFlags(tc.args))
+			err := CheckCommand(context.Background(), &fakeClient{apiVersion: tc.apiVersion}, initCmd)
+			if tc.expected == "" {
+				assert.Check(t, err)
+			} else {
+				assert.Check(t, is.Error(err, tc.expected))
+			}
+		})
+	}
+}
+
+func TestCheckCommandWithoutAnnotation(t *testing.T) {
+	root, _ := commandTree()
+	replay, _, err := root.Find([]string{"replay"})
+	assert.NilError(t, err)
+	cli := &fakeClient{}
+	assert.Check(t, CheckCommand(context.Background(), cli, replay))
+	assert.Check(t, !cli.negotiated, "API version must not be negotiated")
+}
@@ -0,0 +1,147 @@
+// Package compcache caches the names of swarm objects offered by shell
+// completion, so that completing them stays instant on large swarms.
+//
+// Names are cached in the user cache directory ($XDG_CACHE_HOME/swarmctl on
+// Linux) for a TTL. Once stale, they are still offered while a detached
+// copy of the process, run with EnvRefresh set, fetches them again.
+package compcache
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// EnvRefresh is set in the environment of the process refreshing the cache,
+// to fetch the names instead of reading them from the cache.
+const EnvRefresh = "SWARMCTL_COMPLETION_REFRESH"
+
+// DefaultTTL is how long cached names are considered fresh.
+const DefaultTTL = 30 * time.Second
+
+// Cache caches names in a directory. A nil Cache fetches the names every
+// time.
+type Cache struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+	// refresh starts the refresh of stale names; tests replace it.
+	refresh func() error
+}
+
+// New returns a Cache storing names in dir for ttl.
+func New(dir string, ttl time.Duration) *Cache {
+	return &Cache{dir: dir, ttl: ttl, now: time.Now, refresh: spawnRefresh}
+}
+
+// Default returns a Cache in the user cache directory, or nil if there is
+// none.
+func Default() *Cache {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil
+	}
+	return New(filepath.Join(dir, "swarmctl", "completion"), DefaultTTL)
+}
+
+// entry is the cached content of a key.
+type entry struct {
+	Fetched time.Time `json:"fetched"`
+	// Refreshing is set when a refresh of the names was started, so that
+	// it is not started again by every completion until it completes.
+	Refreshing time.Time `json:"refreshing"`
+	Names      []string  `json:"names"`
+}
+
+// Names returns the names cached for key, fetching them on a miss. Errors
+// are not reported, as completion has no way to show them: there is just
+// nothing to complete.
+func (c *Cache) Names(ctx context.Context, key string, fetch func(context.Context) ([]string, error)) []string {
+	if c == nil {
+		names, _ := fetch(ctx)
+		return names
+	}
+	if os.Getenv(EnvRefresh) == "" {
+		if e, ok := c.read(key); ok {
+			now := c.now()
+			if now.Sub(e.Fetched) >= c.ttl && now.Sub(e.Refreshing) >= c.ttl {
+				e.Refreshing = now
+				if c.write(key, e) == nil {
+					_ = c.refresh()
+				}
+			}
+			return e.Names
+		}
+	}
+	names, err := fetch(ctx)
+	if err != nil {
+		return nil
+	}
+	_ = c.write(key, entry{Fetched: c.now(), Names: names})
+	return names
+}
+
+// unsafeChars matches the characters of keys not kept in file names.
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, unsafeChars.ReplaceAllString(key, "_")+".json")
+}
+
+func (c *Cache) read(key string) (entry, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return entry{}, false
+	}
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return entry{}, false
+	}
+	return e, true
+}
+
+// write writes an entry atomically, as completions and refreshes may run
+// at the same time.
+func (c *Cache) write(key string, e entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), c.path(key))
+}
+
+// spawnRefresh runs the same completion again in a detached process, with
+// EnvRefresh set so that it fetches the names and caches them. Its output
+// is discarded.
+func spawnRefresh() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Env = append(os.Environ(), EnvRefresh+"=1")
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
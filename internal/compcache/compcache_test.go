@@ -0,0 +1,65 @@
+package compcache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestNames(t *testing.T) {
+	now := time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC)
+	refreshes := 0
+	c := New(t.TempDir(), time.Minute)
+	c.now = func() time.Time { return now }
+	c.refresh = func() error {
+		refreshes++
+		return nil
+	}
+	fetches := 0
+	fetch := func(context.Context) ([]string, error) {
+		fetches++
+		return []string{"web", "api"}, nil
+	}
+
+	// A miss fetches the names, and caches them.
+	assert.Check(t, is.DeepEqual(c.Names(context.Background(), "default/services", fetch), []string{"web", "api"}))
+	assert.Check(t, is.Equal(fetches, 1))
+	now = now.Add(30 * time.Second)
+	assert.Check(t, is.DeepEqual(c.Names(context.Background(), "default/services", fetch), []string{"web", "api"}))
+	assert.Check(t, is.Equal(fetches, 1))
+	assert.Check(t, is.Equal(refreshes, 0))
+
+	// Stale names are returned while they are refreshed, once.
+	now = now.Add(time.Minute)
+	assert.Check(t, is.DeepEqual(c.Names(context.Background(), "default/services", fetch), []string{"web", "api"}))
+	assert.Check(t, is.DeepEqual(c.Names(context.Background(), "default/services", fetch), []string{"web", "api"}))
+	assert.Check(t, is.Equal(fetches, 1))
+	assert.Check(t, is.Equal(refreshes, 1))
+
+	// The refreshing process fetches the names.
+	t.Setenv(EnvRefresh, "1")
+	assert.Check(t, is.DeepEqual(c.Names(context.Background(), "default/services", fetch), []string{"web", "api"}))
+	assert.Check(t, is.Equal(fetches, 2))
+}
+
+func TestNamesError(t *testing.T) {
+	c := New(t.TempDir(), time.Minute)
+	names := c.Names(context.Background(), "default/services", func(context.Context) ([]string, error) {
+		return nil, errors.New("connection refused")
+	})
+	assert.Check(t, is.Len(names, 0))
+	_, ok := c.read("default/services")
+	assert.Check(t, !ok, "errors must not be cached")
+}
+
+func TestNilCache(t *testing.T) {
+	var c *Cache
+	names := c.Names(context.Background(), "default/services", func(context.Context) ([]string, error) {
+		return []string{"web"}, nil
+	})
+	assert.Check(t, is.DeepEqual(names, []string{"web"}))
+}
@@ -0,0 +1,179 @@
+// Package cron parses cron schedules.
+//
+// Schedules have the five fields of crontab(5): minute, hour, day of month,
+// month and day of week. Fields are lists of values, ranges ("1-5") and
+// steps ("*/15", "0-30/10"); months and days of week may be given by their
+// three-letter English names. The @yearly, @monthly, @weekly, @daily and
+// @hourly macros are supported too.
+package cron
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var fields = []field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}},
+	// 7 is Sunday too
+	{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}},
+}
+
+// Schedule is a parsed cron schedule.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	// a day matches either of the day fields if both are restricted
+	domAny, dowAny bool
+}
+
+// Parse parses a cron schedule.
+func Parse(expr string) (*Schedule, error) {
+	spec := strings.TrimSpace(expr)
+	if macro, ok := macros[spec]; ok {
+		spec = macro
+	}
+	parts := strings.Fields(spec)
+	if len(parts) != len(fields) {
+		return nil, errors.Errorf("invalid schedule %q: expected 5 fields, got %d", expr, len(parts))
+	}
+	var sets [5]uint64
+	for i, part := range parts {
+		set, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid schedule %q", expr)
+		}
+		sets[i] = set
+	}
+	// Sunday is both 0 and 7
+	if sets[4]&(1<<7) != 0 {
+		sets[4] |= 1
+	}
+	return &Schedule{
+		minute: sets[0],
+		hour:   sets[1],
+		dom:    sets[2],
+		month:  sets[3],
+		dow:    sets[4],
+		// as in crontab(5), a field starting with "*", such as */2, is not
+		// restricted
+		domAny: strings.HasPrefix(parts[2], "*"),
+		dowAny: strings.HasPrefix(parts[4], "*"),
+	}, nil
+}
+
+func parseField(s string, f field) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(s, ",") {
+		rng, stepStr, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepStr); err != nil || step <= 0 {
+				return 0, errors.Errorf("invalid step %q in %s", stepStr, f.name)
+			}
+		}
+		lo, hi := f.min, f.max
+		if rng != "*" {
+			from, to, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = parseValue(from, f); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = parseValue(to, f); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				hi = f.max
+			}
+			if lo > hi {
+				return 0, errors.Errorf("invalid range %q in %s", rng, f.name)
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+func parseValue(s string, f field) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, errors.Errorf("invalid %s %q, must be between %d and %d", f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
+
+func has(set uint64, v int) bool {
+	return set&(1<<uint(v)) != 0
+}
+
+// Matches returns whether the schedule runs at the minute of t.
+func (s *Schedule) Matches(t time.Time) bool {
+	return has(s.minute, t.Minute()) && has(s.hour, t.Hour()) && s.matchesDay(t)
+}
+
+func (s *Schedule) matchesDay(t time.Time) bool {
+	if !has(s.month, int(t.Month())) {
+		return false
+	}
+	dom, dow := has(s.dom, t.Day()), has(s.dow, int(t.Weekday()))
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// maxSearch bounds the search of the next run of a schedule that never
+// runs, such as on February 30.
+const maxSearch = 5 * 366 * 24 * time.Hour
+
+// Next returns the first minute after t at which the schedule runs, or the
+// zero time if it never runs.
+func (s *Schedule) Next(t time.Time) time.Time {
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	for limit := t.Add(maxSearch); next.Before(limit); {
+		switch {
+		case !s.matchesDay(next):
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, next.Location())
+		case !has(s.hour, next.Hour()):
+			next = next.Truncate(time.Hour).Add(time.Hour)
+		case !has(s.minute, next.Minute()):
+			next = next.Add(time.Minute)
+		default:
+			return next
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,69 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestNext(t *testing.T) {
+	testCases := []struct {
+		schedule string
+		after    string
+		expected string
+	}{
+		{schedule: "0 3 * * *", after: "2024-01-31 02:59", expected: "2024-01-31 03:00"},
+		{schedule: "0 3 * * *", after: "2024-01-31 03:00", expected: "2024-02-01 03:00"},
+		{schedule: "*/15 * * * *", after: "2024-01-01 10:16", expected: "2024-01-01 10:30"},
+		{schedule: "30 9-17/4 * * mon-fri", after: "2024-01-05 17:31", expected: "2024-01-08 09:30"},
+		{schedule: "0 0 29 feb *", after: "2024-03-01 00:00", expected: "2028-02-29 00:00"},
+		// both day fields are restricted: either matches
+		{schedule: "0 0 13 * fri", after: "2024-01-01 00:00", expected: "2024-01-05 00:00"},
+		// a stepped "*" is not a restriction: both match
+		{schedule: "0 0 */2 * mon", after: "2024-01-01 00:00", expected: "2024-01-15 00:00"},
+		{schedule: "0 12 * * 7", after: "2024-01-01 00:00", expected: "2024-01-07 12:00"},
+		{schedule: "@monthly", after: "2024-01-15 00:00", expected: "2024-02-01 00:00"},
+		{schedule: "5,10 0 1 JAN *", after: "2024-01-01 00:05", expected: "2024-01-01 00:10"},
+	}
+	for _, tc := range testCases {
+		s, err := Parse(tc.schedule)
+		assert.NilError(t, err, tc.schedule)
+		next := s.Next(date(tc.after))
+		assert.Check(t, is.Equal(next, date(tc.expected)), tc.schedule)
+		assert.Check(t, s.Matches(next), tc.schedule)
+	}
+}
+
+func TestNextNever(t *testing.T) {
+	s, err := Parse("0 0 30 2 *")
+	assert.NilError(t, err)
+	assert.Check(t, s.Next(date("2024-01-01 00:00")).IsZero())
+}
+
+func TestParseInvalid(t *testing.T) {
+	testCases := []struct {
+		schedule string
+		expected string
+	}{
+		{schedule: "* * * *", expected: `invalid schedule "* * * *": expected 5 fields, got 4`},
+		{schedule: "60 * * * *", expected: `invalid schedule "60 * * * *": invalid minute "60", must be between 0 and 59`},
+		{schedule: "* * 0 * *", expected: `invalid schedule "* * 0 * *": invalid day of month "0", must be between 1 and 31`},
+		{schedule: "*/0 * * * *", expected: `invalid schedule "*/0 * * * *": invalid step "0" in minute`},
+		{schedule: "* 5-1 * * *", expected: `invalid schedule "* 5-1 * * *": invalid range "5-1" in hour`},
+		{schedule: "* * * foo *", expected: `invalid schedule "* * * foo *": invalid month "foo", must be between 1 and 12`},
+	}
+	for _, tc := range testCases {
+		_, err := Parse(tc.schedule)
+		assert.Check(t, is.Error(err, tc.expected))
+	}
+}
@@ -0,0 +1,54 @@
+// Package editor opens documents in the user's text editor.
+package editor
+
+import (
+	"context"
+	"os"
+	"os/exec"
+
+	"github.com/pkg/errors"
+)
+
+// defaultEditor is used when neither VISUAL nor EDITOR is set.
+const defaultEditor = "vi"
+
+// Command returns the editor command line, from the VISUAL or EDITOR
+// environment variables. It may contain arguments, such as "code --wait".
+func Command() string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if editor := os.Getenv(env); editor != "" {
+			return editor
+		}
+	}
+	return defaultEditor
+}
+
+// Edit writes content to a temporary file named after pattern (see
+// os.CreateTemp), opens it in the user's editor and returns the content
+// of the file once the editor exits.
+func Edit(ctx context.Context, pattern string, content []byte) ([]byte, error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	editor := Command()
+	// run through the shell so that the editor command may have arguments
+	cmd := exec.CommandContext(ctx, "sh", "-c", editor+` "$1"`, "--", f.Name())
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.Wrapf(err, "editor %q failed", editor)
+	}
+	return os.ReadFile(f.Name())
+}
@@ -0,0 +1,117 @@
+// Package engine resolves API clients for the engines running on individual
+// swarm nodes.
+//
+// The swarm API only exposes what managers know about the cluster. Anything
+// that has to be asked of a node's own engine (info, stats, archives of task
+// containers) needs a connection to that engine, which is looked up in the
+// docker context store.
+package engine
+
+import (
+	"context"
+	"sync"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/context/docker"
+	"github.com/docker/cli/cli/context/store"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+	"github.com/pkg/errors"
+)
+
+// LabelContext is the node label naming the docker context to use to reach
+// the node's engine. Nodes without the label are looked up by hostname.
+const LabelContext = "swarmctl.context"
+
+// Resolver returns API clients for the engines of swarm nodes. Clients are
+// created on demand and cached; Close releases them.
+type Resolver struct {
+	dockerCli   command.Cli
+	localNodeID string
+
+	mu      sync.Mutex
+	clients map[string]client.APIClient
+}
+
+// NewResolver returns a Resolver. The engine the CLI is connected to is
+// reused for its own node, so single-node clusters work without any context.
+func NewResolver(ctx context.Context, dockerCli command.Cli) (*Resolver, error) {
+	info, err := dockerCli.Client().Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Resolver{
+		dockerCli:   dockerCli,
+		localNodeID: info.Swarm.NodeID,
+		clients:     make(map[string]client.APIClient),
+	}, nil
+}
+
+// ContextName returns the name of the docker context used to reach the
+// engine of node.
+func ContextName(node swarm.Node) string {
+	if name := node.Spec.Labels[LabelContext]; name != "" {
+		return name
+	}
+	return node.Description.Hostname
+}
+
+// Client returns an API client connected to the engine of node.
+func (r *Resolver) Client(node swarm.Node) (client.APIClient, error) {
+	if node.ID != "" && node.ID == r.localNodeID {
+		return r.dockerCli.Client(), nil
+	}
+
+	name := ContextName(node)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.clients[name]; ok {
+		return c, nil
+	}
+
+	s := r.dockerCli.ContextStore()
+	if s == nil {
+		return nil, errors.Errorf("no context store available to reach node %s", node.Description.Hostname)
+	}
+	c, err := newClient(s, name)
+	if err != nil {
+		if store.IsErrContextDoesNotExist(err) {
+			return nil, errors.Errorf("no docker context %q to reach node %s, create one or set the %s label on the node", name, node.Description.Hostname, LabelContext)
+		}
+		return nil, errors.Wrapf(err, "unable to reach node %s", node.Description.Hostname)
+	}
+	r.clients[name] = c
+	return c, nil
+}
+
+// Close closes the clients created by the resolver.
+func (r *Resolver) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name, c := range r.clients {
+		_ = c.Close()
+		delete(r.clients, name)
+	}
+	return nil
+}
+
+func newClient(s store.Reader, name string) (client.APIClient, error) {
+	meta, err := s.GetMetadata(name)
+	if err != nil {
+		return nil, err
+	}
+	epMeta, err := docker.EndpointFromContext(meta)
+	if err != nil {
+		return nil, err
+	}
+	ep, err := docker.WithTLSData(s, name, epMeta)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := ep.ClientOpts()
+	if err != nil {
+		return nil, err
+	}
+	// the engines of a cluster being upgraded run different API versions
+	return client.NewClientWithOpts(append(opts, client.WithAPIVersionNegotiation())...)
+}
@@ -0,0 +1,50 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+)
+
+// Stats returns a single sample of the statistics of a container. The
+// engine samples the CPU usage twice, so CPUPercent can be computed from it.
+func Stats(ctx context.Context, apiClient client.APIClient, containerID string) (*types.StatsJSON, error) {
+	response, err := apiClient.ContainerStats(ctx, containerID, false)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	var v types.StatsJSON
+	if err := json.NewDecoder(response.Body).Decode(&v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// CPUPercent is computed as `docker stats` does on Linux: 100% is one CPU.
+func CPUPercent(v *types.StatsJSON) float64 {
+	cpuDelta := float64(v.CPUStats.CPUUsage.TotalUsage) - float64(v.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(v.CPUStats.SystemUsage) - float64(v.PreCPUStats.SystemUsage)
+	onlineCPUs := float64(v.CPUStats.OnlineCPUs)
+	if onlineCPUs == 0 {
+		onlineCPUs = float64(len(v.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if systemDelta > 0 && cpuDelta > 0 {
+		return cpuDelta / systemDelta * onlineCPUs * 100
+	}
+	return 0
+}
+
+// MemoryUsage excludes the page cache, as `docker stats` does.
+func MemoryUsage(mem types.MemoryStats) uint64 {
+	if v, ok := mem.Stats["total_inactive_file"]; ok && v < mem.Usage {
+		return mem.Usage - v
+	}
+	if v := mem.Stats["inactive_file"]; v < mem.Usage {
+		return mem.Usage - v
+	}
+	return mem.Usage
+}
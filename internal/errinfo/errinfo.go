@@ -0,0 +1,145 @@
+// Package errinfo describes command errors as structured objects, for the
+// wrappers that present them to their users.
+package errinfo
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/pkg/errors"
+)
+
+// Error codes, from the class of the error returned by the daemon.
+const (
+	CodeNotFound         = "not_found"
+	CodeConflict         = "conflict"
+	CodeInvalidParameter = "invalid_parameter"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeUnavailable      = "unavailable"
+	CodeNotImplemented   = "not_implemented"
+	CodeTimeout          = "timeout"
+	CodeCancelled        = "cancelled"
+	CodeConnection       = "connection_failed"
+	CodeSystem           = "system"
+	CodeUnknown          = "unknown"
+)
+
+// Info is the structured description of an error.
+type Info struct {
+	Code     string `json:"code"`
+	Resource string `json:"resource,omitempty"`
+	Message  string `json:"message"`
+	Hint     string `json:"hint,omitempty"`
+}
+
+// hint suggests how to fix the errors whose message matches pattern.
+type hint struct {
+	pattern *regexp.Regexp
+	code    string
+	hint    string
+}
+
+// hints for common daemon errors, tried in order.
+var hints = []hint{
+	{
+		pattern: regexp.MustCompile(`name conflicts with an existing object|already exists`),
+		code:    CodeConflict,
+		hint:    "choose another name, or remove the existing object first (with --force where supported)",
+	},
+	{
+		pattern: regexp.MustCompile(`update out of sequence`),
+		code:    CodeConflict,
+		hint:    "the object was updated concurrently; run the command again",
+	},
+	{
+		pattern: regexp.MustCompile(`This node is not a swarm manager`),
+		code:    CodeUnavailable,
+		hint:    "connect to a manager node, with --host or --context",
+	},
+	{
+		pattern: regexp.MustCompile(`This node is not part of a swarm`),
+		code:    CodeUnavailable,
+		hint:    `initialize a swarm with "swarmctl swarm init", or join one`,
+	},
+	{
+		pattern: regexp.MustCompile(`requires API \S+, the daemon supports API`),
+		code:    CodeNotImplemented,
+		hint:    "upgrade the engine, or connect to a newer daemon",
+	},
+	{
+		pattern: regexp.MustCompile(`(?i)no such (\w+)`),
+		code:    CodeNotFound,
+		hint:    `check the name or ID, for example with "swarmctl get"`,
+	},
+}
+
+// resourcePattern extracts the object of "no such service: web" errors.
+var resourcePattern = regexp.MustCompile(`(?i)no such (\w+):\s*(\S+)`)
+
+// Describe returns the structured description of err.
+func Describe(err error) Info {
+	info := Info{Code: code(err), Message: message(err)}
+	if m := resourcePattern.FindStringSubmatch(info.Message); m != nil {
+		info.Resource = strings.ToLower(m[1]) + "/" + m[2]
+	}
+	for _, h := range hints {
+		if h.pattern.MatchString(info.Message) {
+			if info.Code == CodeUnknown || info.Code == CodeSystem {
+				info.Code = h.code
+			}
+			info.Hint = h.hint
+			break
+		}
+	}
+	switch {
+	case info.Hint != "":
+	case info.Code == CodeTimeout:
+		info.Hint = "the command timed out; raise --timeout, or check the health of the managers"
+	case info.Code == CodeConnection:
+		info.Hint = "check that the daemon is running, and that DOCKER_HOST or the current context point to it"
+	}
+	return info
+}
+
+func message(err error) string {
+	if sterr, ok := err.(cli.StatusError); ok && sterr.Status != "" {
+		return sterr.Status
+	}
+	return err.Error()
+}
+
+// code classifies err. The errdefs helpers follow the causes of wrapped
+// errors themselves.
+func code(err error) string {
+	switch {
+	case errdefs.IsDeadline(err) || errors.Is(err, context.DeadlineExceeded):
+		return CodeTimeout
+	case errdefs.IsCancelled(err) || errors.Is(err, context.Canceled):
+		return CodeCancelled
+	case client.IsErrConnectionFailed(errors.Cause(err)):
+		return CodeConnection
+	case errdefs.IsNotFound(err):
+		return CodeNotFound
+	case errdefs.IsConflict(err):
+		return CodeConflict
+	case errdefs.IsInvalidParameter(err):
+		return CodeInvalidParameter
+	case errdefs.IsUnauthorized(err):
+		return CodeUnauthorized
+	case errdefs.IsForbidden(err):
+		return CodeForbidden
+	case errdefs.IsUnavailable(err):
+		return CodeUnavailable
+	case errdefs.IsNotImplemented(err):
+		return CodeNotImplemented
+	case errdefs.IsSystem(err):
+		return CodeSystem
+	default:
+		return CodeUnknown
+	}
+}
@@ -0,0 +1,70 @@
+package errinfo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/docker/errdefs"
+	"github.com/pkg/errors"
+	"gotest.tools/v3/assert"
+)
+
+func TestDescribe(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected Info
+	}{
+		{
+			name: "not found",
+			err:  errdefs.NotFound(errors.New("no such service: web")),
+			expected: Info{
+				Code:     CodeNotFound,
+				Resource: "service/web",
+				Message:  "no such service: web",
+				Hint:     `check the name or ID, for example with "swarmctl get"`,
+			},
+		},
+		{
+			name: "name conflict",
+			err:  errors.Wrap(errdefs.Conflict(errors.New("rpc error: code = AlreadyExists desc = name conflicts with an existing object")), "creating config"),
+			expected: Info{
+				Code:    CodeConflict,
+				Message: "creating config: rpc error: code = AlreadyExists desc = name conflicts with an existing object",
+				Hint:    "choose another name, or remove the existing object first (with --force where supported)",
+			},
+		},
+		{
+			name: "hint upgrades an unknown code",
+			err:  errors.New("rpc error: code = Unknown desc = update out of sequence"),
+			expected: Info{
+				Code:    CodeConflict,
+				Message: "rpc error: code = Unknown desc = update out of sequence",
+				Hint:    "the object was updated concurrently; run the command again",
+			},
+		},
+		{
+			name: "timeout",
+			err:  errors.Wrap(errdefs.Deadline(context.DeadlineExceeded), "command timed out after 5s"),
+			expected: Info{
+				Code:    CodeTimeout,
+				Message: "command timed out after 5s: context deadline exceeded",
+				Hint:    "the command timed out; raise --timeout, or check the health of the managers",
+			},
+		},
+		{
+			name: "status error",
+			err:  cli.StatusError{Status: "service web did not converge", StatusCode: 3},
+			expected: Info{
+				Code:    CodeUnknown,
+				Message: "service web did not converge",
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.DeepEqual(t, Describe(tc.err), tc.expected)
+		})
+	}
+}
@@ -0,0 +1,212 @@
+// Package events streams swarm events from the daemon, with filters that the
+// events API does not support natively (tasks, stack namespaces) and
+// transparent reconnection when the connection to the daemon is lost.
+package events
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	eventtypes "github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"github.com/pkg/errors"
+)
+
+// TypeTask is the pseudo event type for swarm tasks. The daemon reports
+// task lifecycle as events of the task's container.
+const TypeTask = "task"
+
+// labelTaskID is set on the containers backing swarm tasks.
+const labelTaskID = "com.docker.swarm.task.id"
+
+// Types lists the event types that can be selected, in display order.
+var Types = []string{
+	eventtypes.ServiceEventType,
+	eventtypes.NodeEventType,
+	TypeTask,
+	eventtypes.ConfigEventType,
+	eventtypes.SecretEventType,
+	eventtypes.NetworkEventType,
+}
+
+// defaultTypes are the types streamed when no type is selected.
+var defaultTypes = []string{
+	eventtypes.ServiceEventType,
+	eventtypes.NodeEventType,
+	TypeTask,
+	eventtypes.ConfigEventType,
+	eventtypes.SecretEventType,
+}
+
+// Bounds of the delay between reconnection attempts.
+var (
+	minBackoff = time.Second
+	maxBackoff = 30 * time.Second
+)
+
+// Options selects the events to stream.
+type Options struct {
+	// Types of events to stream, from Types. Defaults to all swarm
+	// object types but networks.
+	Types []string
+	// Namespace restricts events to the objects of a stack.
+	Namespace string
+	// Labels restricts events to actors with the given labels, in the
+	// "key" or "key=value" form.
+	Labels []string
+	// Since and Until bound the stream in time, in any format accepted by
+	// the events API (timestamps, RFC 3339 dates, or durations).
+	Since string
+	Until string
+	// OnReconnect, if set, is called before reconnecting after err.
+	OnReconnect func(err error, delay time.Duration)
+}
+
+// Validate checks that opts only selects known event types.
+func (opts Options) Validate() error {
+	for _, t := range opts.Types {
+		if !isKnownType(t) {
+			return errors.Errorf("invalid event type %q, must be one of %s", t, strings.Join(Types, ", "))
+		}
+	}
+	return nil
+}
+
+func isKnownType(t string) bool {
+	for _, known := range Types {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Stream returns the events selected by opts. The stream reconnects when the
+// connection to the daemon is lost, resuming after the last event received.
+// The messages channel is closed when ctx is done, when Until is reached, or
+// when a non-recoverable error is sent on the error channel.
+func Stream(ctx context.Context, apiClient client.APIClient, opts Options) (<-chan eventtypes.Message, <-chan error) {
+	messages := make(chan eventtypes.Message)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(messages)
+		defer close(errs)
+
+		if err := opts.Validate(); err != nil {
+			errs <- err
+			return
+		}
+
+		since := opts.Since
+		backoff := minBackoff
+		for {
+			msgs, streamErrs := apiClient.Events(ctx, types.EventsOptions{
+				Since:   since,
+				Until:   opts.Until,
+				Filters: opts.apiFilters(),
+			})
+
+			err := forward(ctx, msgs, streamErrs, messages, opts, func(msg eventtypes.Message) {
+				since = fmt.Sprintf("%d.%09d", msg.TimeNano/int64(time.Second), msg.TimeNano%int64(time.Second)+1)
+				backoff = minBackoff
+			})
+			switch {
+			case ctx.Err() != nil:
+				return
+			case err == io.EOF && opts.Until != "":
+				return
+			case !isRecoverable(err):
+				errs <- err
+				return
+			}
+
+			if opts.OnReconnect != nil {
+				opts.OnReconnect(err, backoff)
+			}
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return
+			}
+			if backoff *= 2; backoff > maxBackoff {
+				backoff = maxBackoff
+			}
+		}
+	}()
+
+	return messages, errs
+}
+
+// forward sends matching messages to out until the stream fails, and
+// returns the stream error.
+func forward(ctx context.Context, in <-chan eventtypes.Message, errs <-chan error, out chan<- eventtypes.Message, opts Options, seen func(eventtypes.Message)) error {
+	for {
+		select {
+		case msg := <-in:
+			seen(msg)
+			if !opts.matches(msg) {
+				continue
+			}
+			select {
+			case out <- msg:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		case err := <-errs:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func isRecoverable(err error) bool {
+	return !errdefs.IsInvalidParameter(err) && !errdefs.IsUnauthorized(err) && !errdefs.IsForbidden(err)
+}
+
+func (opts Options) types() []string {
+	if len(opts.Types) == 0 {
+		return defaultTypes
+	}
+	return opts.Types
+}
+
+func (opts Options) apiFilters() filters.Args {
+	args := filters.NewArgs()
+	for _, t := range opts.types() {
+		if t == TypeTask {
+			t = eventtypes.ContainerEventType
+		}
+		args.Add("type", t)
+	}
+	for _, l := range opts.Labels {
+		args.Add("label", l)
+	}
+	return args
+}
+
+// matches applies the filters the events API cannot apply.
+func (opts Options) matches(msg eventtypes.Message) bool {
+	if msg.Type == eventtypes.ContainerEventType {
+		if _, ok := msg.Actor.Attributes[labelTaskID]; !ok {
+			return false
+		}
+	}
+	if opts.Namespace == "" {
+		return true
+	}
+	if msg.Actor.Attributes[swarmlabel.Namespace] == opts.Namespace {
+		return true
+	}
+	// Service, network, config and secret events only carry the object
+	// name, which is prefixed by the namespace for stack objects.
+	return msg.Type != eventtypes.NodeEventType && strings.HasPrefix(msg.Actor.Attributes["name"], opts.Namespace+"_")
+}
@@ -0,0 +1,130 @@
+package events
+
+import (
+	"context"
+	"io"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	eventtypes "github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"github.com/pkg/errors"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+// fakeClient replays one batch of messages per call to Events, each batch
+// ending with the corresponding error.
+type fakeClient struct {
+	client.Client
+	batches [][]eventtypes.Message
+	errs    []error
+	options []types.EventsOptions
+}
+
+func (cli *fakeClient) Events(ctx context.Context, options types.EventsOptions) (<-chan eventtypes.Message, <-chan error) {
+	call := len(cli.options)
+	cli.options = append(cli.options, options)
+
+	messages := make(chan eventtypes.Message)
+	errs := make(chan error, 1)
+	go func() {
+		if call < len(cli.batches) {
+			for _, msg := range cli.batches[call] {
+				messages <- msg
+			}
+		}
+		if call < len(cli.errs) {
+			errs <- cli.errs[call]
+			return
+		}
+		<-ctx.Done()
+		errs <- ctx.Err()
+	}()
+	return messages, errs
+}
+
+func collect(t *testing.T, messages <-chan eventtypes.Message, errs <-chan error) ([]eventtypes.Message, error) {
+	t.Helper()
+	var received []eventtypes.Message
+	for msg := range messages {
+		received = append(received, msg)
+	}
+	return received, <-errs
+}
+
+func TestStreamFilters(t *testing.T) {
+	apiClient := &fakeClient{
+		batches: [][]eventtypes.Message{{
+			{Type: "container", Action: "start", Actor: eventtypes.Actor{ID: "plain"}},
+			{Type: "container", Action: "start", Actor: eventtypes.Actor{ID: "task", Attributes: map[string]string{
+				labelTaskID:          "t1",
+				swarmlabel.Namespace: "web",
+			}}},
+			{Type: "service", Action: "update", Actor: eventtypes.Actor{ID: "s1", Attributes: map[string]string{"name": "web_front"}}},
+			{Type: "service", Action: "update", Actor: eventtypes.Actor{ID: "s2", Attributes: map[string]string{"name": "other_front"}}},
+			{Type: "node", Action: "update", Actor: eventtypes.Actor{ID: "n1", Attributes: map[string]string{"name": "web_node"}}},
+		}},
+		errs: []error{io.EOF},
+	}
+	messages, errs := Stream(context.Background(), apiClient, Options{Namespace: "web", Until: "10m"})
+	received, err := collect(t, messages, errs)
+	assert.NilError(t, err)
+
+	assert.Assert(t, is.Len(received, 2))
+	assert.Check(t, is.Equal(received[0].Actor.ID, "task"))
+	assert.Check(t, is.Equal(received[1].Actor.ID, "s1"))
+
+	assert.Assert(t, is.Len(apiClient.options, 1))
+	filterTypes := apiClient.options[0].Filters.Get("type")
+	sort.Strings(filterTypes)
+	assert.Check(t, is.DeepEqual(filterTypes, []string{"config", "container", "node", "secret", "service"}))
+}
+
+func TestStreamReconnects(t *testing.T) {
+	defer func(min time.Duration) { minBackoff = min }(minBackoff)
+	minBackoff = time.Millisecond
+
+	apiClient := &fakeClient{
+		batches: [][]eventtypes.Message{
+			{{Type: "service", Action: "create", TimeNano: 1500000000}},
+			{{Type: "service", Action: "remove", TimeNano: 2000000000}},
+		},
+		errs: []error{errors.New("connection reset"), io.EOF},
+	}
+	var reconnects int
+	messages, errs := Stream(context.Background(), apiClient, Options{
+		Types: []string{"service"},
+		Until: "10m",
+		OnReconnect: func(err error, delay time.Duration) {
+			reconnects++
+			assert.Check(t, is.Error(err, "connection reset"))
+		},
+	})
+	received, err := collect(t, messages, errs)
+	assert.NilError(t, err)
+
+	assert.Check(t, is.Len(received, 2))
+	assert.Check(t, is.Equal(reconnects, 1))
+	assert.Assert(t, is.Len(apiClient.options, 2))
+	assert.Check(t, is.Equal(apiClient.options[1].Since, "1.500000001"))
+}
+
+func TestStreamFatalError(t *testing.T) {
+	apiClient := &fakeClient{
+		errs: []error{errdefs.InvalidParameter(errors.New("invalid filter"))},
+	}
+	messages, errs := Stream(context.Background(), apiClient, Options{})
+	_, err := collect(t, messages, errs)
+	assert.Check(t, is.Error(err, "invalid filter"))
+}
+
+func TestStreamInvalidType(t *testing.T) {
+	messages, errs := Stream(context.Background(), &fakeClient{}, Options{Types: []string{"image"}})
+	_, err := collect(t, messages, errs)
+	assert.Check(t, is.Error(err, `invalid event type "image", must be one of service, node, task, config, secret, network`))
+}
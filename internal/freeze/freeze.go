@@ -0,0 +1,36 @@
+// Package freeze guards the objects of frozen stacks. "stack freeze" sets
+// the freeze label on the services, configs and secrets of a stack, and the
+// swarmctl commands changing them fail while it is set, unless
+// --override-freeze is given. Like quotas, the freeze only holds for the
+// changes made through swarmctl.
+package freeze
+
+import (
+	"github.com/docker/docker/errdefs"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+// Label marks the objects of a frozen stack. Its value is the reason of the
+// freeze, and may be empty.
+const Label = "swarmctl.freeze"
+
+// AddFlag adds the --override-freeze flag to flags.
+func AddFlag(flags *pflag.FlagSet, override *bool) {
+	flags.BoolVar(override, "override-freeze", false, "Change the objects of frozen stacks")
+}
+
+// Check returns a forbidden error if the labels of an object, given by its
+// kind and name, mark it as frozen, unless override is set.
+func Check(kind, name string, labels map[string]string, override bool) error {
+	reason, frozen := labels[Label]
+	if !frozen || override {
+		return nil
+	}
+	message := kind + " " + name + " belongs to frozen stack " + labels[swarmlabel.Namespace]
+	if reason != "" {
+		message += " (" + reason + ")"
+	}
+	return errdefs.Forbidden(errors.New(message + ", use --override-freeze to change it anyway"))
+}
@@ -0,0 +1,25 @@
+package freeze
+
+import (
+	"testing"
+
+	"github.com/docker/docker/errdefs"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestCheck(t *testing.T) {
+	frozen := map[string]string{swarmlabel.Namespace: "shop", Label: "black friday"}
+
+	err := Check("service", "shop_web", frozen, false)
+	assert.Check(t, is.Error(err, "service shop_web belongs to frozen stack shop (black friday), use --override-freeze to change it anyway"))
+	assert.Check(t, errdefs.IsForbidden(err))
+
+	err = Check("config", "shop_nginx", map[string]string{swarmlabel.Namespace: "shop", Label: ""}, false)
+	assert.Check(t, is.Error(err, "config shop_nginx belongs to frozen stack shop, use --override-freeze to change it anyway"))
+
+	assert.Check(t, Check("service", "shop_web", frozen, true))
+	assert.Check(t, Check("service", "shop_web", map[string]string{swarmlabel.Namespace: "shop"}, false))
+	assert.Check(t, Check("service", "web", nil, false))
+}
@@ -0,0 +1,173 @@
+// Package graph renders graphs of swarm objects in the Graphviz dot
+// language, as Mermaid flowcharts, or as JSON.
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Kinds of nodes, which set their shape.
+const (
+	KindNode    = "node"
+	KindService = "service"
+	KindNetwork = "network"
+	KindConfig  = "config"
+	KindSecret  = "secret"
+)
+
+// Output formats.
+const (
+	FormatDot     = "dot"
+	FormatMermaid = "mermaid"
+	FormatJSON    = "json"
+)
+
+// Node is a node of a graph.
+type Node struct {
+	ID    string `json:"id"`
+	Kind  string `json:"kind"`
+	Label string `json:"label"`
+}
+
+// Edge is an edge of a graph. Dashed edges are drawn for weaker links, such
+// as the configs used by a service.
+type Edge struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Label  string `json:"label,omitempty"`
+	Dashed bool   `json:"dashed,omitempty"`
+}
+
+// Graph is a directed graph.
+type Graph struct {
+	Name  string `json:"name"`
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+
+	seen map[string]bool
+}
+
+// AddNode adds a node of a kind, once, and returns its ID.
+func (g *Graph) AddNode(kind, name, label string) string {
+	id := kind + ":" + name
+	if g.seen == nil {
+		g.seen = make(map[string]bool)
+	}
+	if !g.seen[id] {
+		g.seen[id] = true
+		g.Nodes = append(g.Nodes, Node{ID: id, Kind: kind, Label: label})
+	}
+	return id
+}
+
+// AddEdge adds an edge between the nodes of IDs from and to.
+func (g *Graph) AddEdge(from, to, label string, dashed bool) {
+	g.Edges = append(g.Edges, Edge{From: from, To: to, Label: label, Dashed: dashed})
+}
+
+// Sort sorts the nodes and edges by ID, so that the output is stable.
+func (g *Graph) Sort() {
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].ID < g.Nodes[j].ID })
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		return g.Edges[i].To < g.Edges[j].To
+	})
+}
+
+// Write writes g to out in format.
+func Write(out io.Writer, g *Graph, format string) error {
+	switch format {
+	case FormatDot:
+		writeDot(out, g)
+		return nil
+	case FormatMermaid:
+		writeMermaid(out, g)
+		return nil
+	case FormatJSON:
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "    ")
+		return enc.Encode(g)
+	default:
+		return errors.Errorf("unknown graph format %q", format)
+	}
+}
+
+// dotShapes are the Graphviz shapes of the kinds of nodes.
+var dotShapes = map[string]string{
+	KindNode:    "box3d",
+	KindService: "box",
+	KindNetwork: "ellipse",
+	KindConfig:  "note",
+	KindSecret:  "octagon",
+}
+
+func writeDot(out io.Writer, g *Graph) {
+	fmt.Fprintf(out, "digraph %q {\n", g.Name)
+	fmt.Fprintln(out, "\trankdir=LR;")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(out, "\t%q [label=%q, shape=%s];\n", n.ID, n.Label, dotShapes[n.Kind])
+	}
+	for _, e := range g.Edges {
+		var attrs []string
+		if e.Label != "" {
+			attrs = append(attrs, fmt.Sprintf("label=%q", e.Label))
+		}
+		if e.Dashed {
+			attrs = append(attrs, "style=dashed")
+		}
+		if len(attrs) == 0 {
+			fmt.Fprintf(out, "\t%q -> %q;\n", e.From, e.To)
+		} else {
+			fmt.Fprintf(out, "\t%q -> %q [%s];\n", e.From, e.To, strings.Join(attrs, ", "))
+		}
+	}
+	fmt.Fprintln(out, "}")
+}
+
+// mermaidShapes are the opening and closing delimiters of the Mermaid
+// shapes of the kinds of nodes.
+var mermaidShapes = map[string][2]string{
+	KindNode:    {"[(", ")]"},
+	KindService: {"[", "]"},
+	KindNetwork: {"((", "))"},
+	KindConfig:  {"[/", "/]"},
+	KindSecret:  {"{{", "}}"},
+}
+
+// mermaidInvalid matches the characters Mermaid does not allow in node IDs.
+var mermaidInvalid = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+func mermaidID(id string) string {
+	return mermaidInvalid.ReplaceAllString(id, "_")
+}
+
+func mermaidText(s string) string {
+	return strings.ReplaceAll(s, `"`, "#quot;")
+}
+
+func writeMermaid(out io.Writer, g *Graph) {
+	fmt.Fprintln(out, "flowchart LR")
+	for _, n := range g.Nodes {
+		shape := mermaidShapes[n.Kind]
+		fmt.Fprintf(out, "    %s%s\"%s\"%s\n", mermaidID(n.ID), shape[0], mermaidText(n.Label), shape[1])
+	}
+	for _, e := range g.Edges {
+		arrow := "-->"
+		if e.Dashed {
+			arrow = "-.->"
+		}
+		if e.Label != "" {
+			arrow += "|\"" + mermaidText(e.Label) + "\"|"
+		}
+		fmt.Fprintf(out, "    %s %s %s\n", mermaidID(e.From), arrow, mermaidID(e.To))
+	}
+}
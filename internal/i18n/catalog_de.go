@@ -0,0 +1,25 @@
+package i18n
+
+var de = map[string]string{
+	// progress
+	"desired root digest":                 "gewünschter Root-Digest",
+	"rotated TLS certificates":            "erneuerte TLS-Zertifikate",
+	"rotated CA certificates":             "erneuerte CA-Zertifikate",
+	"nodes":                               "Knoten",
+	"Operation continuing in background.": "Der Vorgang wird im Hintergrund fortgesetzt.",
+	"Use `swarmctl cluster inspect default` to check progress.": "Verwenden Sie `swarmctl cluster inspect default`, um den Fortschritt zu prüfen.",
+
+	// status
+	"Swarm initialized: current node (%s) is now a manager.":                                             "Swarm initialisiert: der aktuelle Knoten (%s) ist jetzt ein Manager.",
+	"To add a manager to this swarm, run 'docker swarm join-token manager' and follow the instructions.": "Um diesem Swarm einen Manager hinzuzufügen, führen Sie 'docker swarm join-token manager' aus und folgen Sie den Anweisungen.",
+	"Successfully rotated %s join token.":                                                                "Join-Token für %s erfolgreich erneuert.",
+	"Successfully rotated manager unlock key.":                                                           "Entsperrschlüssel der Manager erfolgreich erneuert.",
+	"Swarm updated.":                         "Swarm aktualisiert.",
+	"Node left the swarm.":                   "Der Knoten hat den Swarm verlassen.",
+	"This node joined a swarm as a manager.": "Dieser Knoten ist einem Swarm als Manager beigetreten.",
+	"This node joined a swarm as a worker.":  "Dieser Knoten ist einem Swarm als Worker beigetreten.",
+	"Edit cancelled, no changes made.":       "Bearbeitung abgebrochen, keine Änderungen vorgenommen.",
+	"%s edited":                              "%s bearbeitet",
+	"%s patched":                             "%s gepatcht",
+	"%s patched (no change)":                 "%s gepatcht (keine Änderung)",
+}
@@ -0,0 +1,25 @@
+package i18n
+
+var fr = map[string]string{
+	// progress
+	"desired root digest":                 "empreinte de la racine souhaitée",
+	"rotated TLS certificates":            "certificats TLS renouvelés",
+	"rotated CA certificates":             "certificats d'AC renouvelés",
+	"nodes":                               "nœuds",
+	"Operation continuing in background.": "L'opération continue en arrière-plan.",
+	"Use `swarmctl cluster inspect default` to check progress.": "Utilisez `swarmctl cluster inspect default` pour suivre la progression.",
+
+	// status
+	"Swarm initialized: current node (%s) is now a manager.":                                             "Swarm initialisé : le nœud actuel (%s) est maintenant un manager.",
+	"To add a manager to this swarm, run 'docker swarm join-token manager' and follow the instructions.": "Pour ajouter un manager à ce swarm, exécutez 'docker swarm join-token manager' et suivez les instructions.",
+	"Successfully rotated %s join token.":                                                                "Jeton d'adhésion %s renouvelé.",
+	"Successfully rotated manager unlock key.":                                                           "Clé de déverrouillage des managers renouvelée.",
+	"Swarm updated.":                         "Swarm mis à jour.",
+	"Node left the swarm.":                   "Le nœud a quitté le swarm.",
+	"This node joined a swarm as a manager.": "Ce nœud a rejoint un swarm en tant que manager.",
+	"This node joined a swarm as a worker.":  "Ce nœud a rejoint un swarm en tant que worker.",
+	"Edit cancelled, no changes made.":       "Modification annulée, aucun changement effectué.",
+	"%s edited":                              "%s modifié",
+	"%s patched":                             "correctif appliqué à %s",
+	"%s patched (no change)":                 "correctif appliqué à %s (aucun changement)",
+}
@@ -0,0 +1,63 @@
+// Package i18n translates the messages swarmctl prints for its users.
+//
+// Messages are looked up by their English text, so that untranslated
+// messages, and the messages of unsupported languages, are printed in
+// English. Machine-readable output, such as JSON, is never translated.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// EnvLang is the environment variable selecting the language, such as "fr"
+// or "fr_FR.UTF-8".
+const EnvLang = "SWARMCTL_LANG"
+
+// DefaultLanguage is the language messages are written in.
+const DefaultLanguage = "en"
+
+// catalogs maps the supported languages to their translations.
+var catalogs = map[string]map[string]string{
+	"de": de,
+	"fr": fr,
+}
+
+// Language returns the language selected with SWARMCTL_LANG, or
+// DefaultLanguage if it is unset or not supported.
+func Language() string {
+	lang := strings.ToLower(os.Getenv(EnvLang))
+	if i := strings.IndexAny(lang, "_-."); i >= 0 {
+		lang = lang[:i]
+	}
+	if _, ok := catalogs[lang]; ok {
+		return lang
+	}
+	return DefaultLanguage
+}
+
+// Languages returns the supported languages, sorted.
+func Languages() []string {
+	langs := []string{DefaultLanguage}
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// T returns the translation of msg in the selected language, or msg if it
+// has none.
+func T(msg string) string {
+	if translated, ok := catalogs[Language()][msg]; ok {
+		return translated
+	}
+	return msg
+}
+
+// Sprintf formats according to the translation of format.
+func Sprintf(format string, a ...interface{}) string {
+	return fmt.Sprintf(T(format), a...)
+}
@@ -0,0 +1,47 @@
+package i18n
+
+import (
+	"regexp"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestLanguage(t *testing.T) {
+	testCases := []struct {
+		env      string
+		expected string
+	}{
+		{env: "", expected: "en"},
+		{env: "fr", expected: "fr"},
+		{env: "fr_FR.UTF-8", expected: "fr"},
+		{env: "DE-de", expected: "de"},
+		{env: "ja_JP", expected: "en"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.env, func(t *testing.T) {
+			t.Setenv(EnvLang, tc.env)
+			assert.Check(t, is.Equal(Language(), tc.expected))
+		})
+	}
+}
+
+func TestSprintf(t *testing.T) {
+	t.Setenv(EnvLang, "fr")
+	assert.Check(t, is.Equal(Sprintf("%s edited", "service/web"), "service/web modifié"))
+	assert.Check(t, is.Equal(T("not translated"), "not translated"))
+
+	t.Setenv(EnvLang, "")
+	assert.Check(t, is.Equal(Sprintf("%s edited", "service/web"), "service/web edited"))
+}
+
+var verbPattern = regexp.MustCompile(`%[a-z%]`)
+
+func TestCatalogsKeepVerbs(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for msg, translated := range catalog {
+			assert.Check(t, is.DeepEqual(verbPattern.FindAllString(translated, -1), verbPattern.FindAllString(msg, -1)), "%s: %q", lang, msg)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+// Package placement evaluates the placement constraints of services against
+// nodes, the way the swarm scheduler does.
+package placement
+
+import (
+	"strings"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/pkg/errors"
+)
+
+// Constraint is a placement constraint, such as "node.role==worker".
+type Constraint struct {
+	key   string
+	equal bool
+	value string
+}
+
+// Parse parses placement constraints, in the syntax of the --constraint flag
+// of `docker service create`.
+func Parse(exprs []string) ([]Constraint, error) {
+	constraints := make([]Constraint, 0, len(exprs))
+	for _, expr := range exprs {
+		c := Constraint{}
+		var found bool
+		if c.key, c.value, found = strings.Cut(expr, "=="); found {
+			c.equal = true
+		} else if c.key, c.value, found = strings.Cut(expr, "!="); !found {
+			return nil, errors.Errorf("invalid constraint %q, must be KEY==VALUE or KEY!=VALUE", expr)
+		}
+		c.key, c.value = strings.TrimSpace(c.key), strings.TrimSpace(c.value)
+		if _, ok := nodeAttribute(swarm.Node{}, c.key); !ok && !isLabelKey(c.key) {
+			return nil, errors.Errorf("invalid constraint %q: unknown key %q", expr, c.key)
+		}
+		constraints = append(constraints, c)
+	}
+	return constraints, nil
+}
+
+func isLabelKey(key string) bool {
+	return strings.HasPrefix(key, "node.labels.") || strings.HasPrefix(key, "engine.labels.")
+}
+
+// nodeAttribute returns the value of the attribute of node that key refers
+// to, and whether the node has it.
+func nodeAttribute(node swarm.Node, key string) (string, bool) {
+	switch {
+	case key == "node.id":
+		return node.ID, true
+	case key == "node.hostname":
+		return node.Description.Hostname, true
+	case key == "node.role":
+		return string(node.Spec.Role), true
+	case key == "node.platform.os":
+		return node.Description.Platform.OS, true
+	case key == "node.platform.arch":
+		return node.Description.Platform.Architecture, true
+	case strings.HasPrefix(key, "node.labels."):
+		value, ok := node.Spec.Labels[strings.TrimPrefix(key, "node.labels.")]
+		return value, ok
+	case strings.HasPrefix(key, "engine.labels."):
+		value, ok := node.Description.Engine.Labels[strings.TrimPrefix(key, "engine.labels.")]
+		return value, ok
+	default:
+		return "", false
+	}
+}
+
+// Match returns whether node satisfies all the constraints. Values are
+// compared regardless of case, like the swarm scheduler does.
+func Match(node swarm.Node, constraints []Constraint) bool {
+	for _, c := range constraints {
+		value, ok := nodeAttribute(node, c.key)
+		if (ok && strings.EqualFold(value, c.value)) != c.equal {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizedArch maps the architectures reported by engines to the names
+// used in image manifests.
+var normalizedArch = map[string]string{
+	"x86_64":  "amd64",
+	"aarch64": "arm64",
+}
+
+func normalizeArch(arch string) string {
+	arch = strings.ToLower(arch)
+	if a, ok := normalizedArch[arch]; ok {
+		return a
+	}
+	return arch
+}
+
+// MatchPlatforms returns whether node runs one of the platforms, which the
+// placement of a service gets from the manifest of its image. Any node
+// matches when there is no platform, and an empty OS or architecture
+// matches any.
+func MatchPlatforms(node swarm.Node, platforms []swarm.Platform) bool {
+	if len(platforms) == 0 {
+		return true
+	}
+	for _, p := range platforms {
+		if p.OS != "" && !strings.EqualFold(p.OS, node.Description.Platform.OS) {
+			continue
+		}
+		if p.Architecture != "" && normalizeArch(p.Architecture) != normalizeArch(node.Description.Platform.Architecture) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// ConstrainsOS returns whether p restricts the operating system of the nodes,
+// with platforms or a node.platform.os constraint.
+func ConstrainsOS(p *swarm.Placement) bool {
+	if p == nil {
+		return false
+	}
+	for _, platform := range p.Platforms {
+		if platform.OS != "" {
+			return true
+		}
+	}
+	for _, c := range p.Constraints {
+		if strings.HasPrefix(strings.TrimSpace(c), "node.platform.os") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package placement
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/swarm"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestMatch(t *testing.T) {
+	node := swarm.Node{
+		ID:          "id1",
+		Spec:        swarm.NodeSpec{Role: swarm.NodeRoleWorker, Annotations: swarm.Annotations{Labels: map[string]string{"zone": "eu-1"}}},
+		Description: swarm.NodeDescription{Hostname: "worker1", Platform: swarm.Platform{OS: "linux", Architecture: "x86_64"}},
+	}
+	testCases := []struct {
+		constraints []string
+		expected    bool
+	}{
+		{constraints: nil, expected: true},
+		{constraints: []string{"node.role==worker"}, expected: true},
+		{constraints: []string{"node.role == manager"}, expected: false},
+		{constraints: []string{"node.hostname!=worker1"}, expected: false},
+		{constraints: []string{"node.labels.zone==EU-1", "node.platform.os==linux"}, expected: true},
+		{constraints: []string{"node.labels.ssd==true"}, expected: false},
+		{constraints: []string{"node.labels.ssd!=true"}, expected: true},
+		{constraints: []string{"engine.labels.gpu==true"}, expected: false},
+	}
+	for _, tc := range testCases {
+		constraints, err := Parse(tc.constraints)
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(Match(node, constraints), tc.expected), "%v", tc.constraints)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	_, err := Parse([]string{"node.role=worker"})
+	assert.Error(t, err, `invalid constraint "node.role=worker", must be KEY==VALUE or KEY!=VALUE`)
+	_, err = Parse([]string{"node.zone==eu"})
+	assert.Error(t, err, `invalid constraint "node.zone==eu": unknown key "node.zone"`)
+}
+
+func TestMatchPlatforms(t *testing.T) {
+	node := swarm.Node{Description: swarm.NodeDescription{Platform: swarm.Platform{OS: "linux", Architecture: "x86_64"}}}
+	testCases := []struct {
+		platforms []swarm.Platform
+		expected  bool
+	}{
+		{platforms: nil, expected: true},
+		{platforms: []swarm.Platform{{OS: "linux", Architecture: "amd64"}}, expected: true},
+		{platforms: []swarm.Platform{{OS: "windows", Architecture: "amd64"}}, expected: false},
+		{platforms: []swarm.Platform{{OS: "linux", Architecture: "arm64"}, {OS: "linux"}}, expected: true},
+		{platforms: []swarm.Platform{{OS: "linux", Architecture: "arm64"}}, expected: false},
+	}
+	for _, tc := range testCases {
+		assert.Check(t, is.Equal(MatchPlatforms(node, tc.platforms), tc.expected), "%v", tc.platforms)
+	}
+}
+
+func TestConstrainsOS(t *testing.T) {
+	assert.Check(t, !ConstrainsOS(nil))
+	assert.Check(t, !ConstrainsOS(&swarm.Placement{Constraints: []string{"node.role==worker"}, Platforms: []swarm.Platform{{Architecture: "amd64"}}}))
+	assert.Check(t, ConstrainsOS(&swarm.Placement{Constraints: []string{"node.platform.os == linux"}}))
+	assert.Check(t, ConstrainsOS(&swarm.Placement{Platforms: []swarm.Platform{{OS: "linux", Architecture: "amd64"}}}))
+}
@@ -0,0 +1,238 @@
+// Package profile loads the organization profile of swarmctl, which a
+// platform team publishes to share defaults of command flags and block
+// flags across its users.
+//
+// The profile is a YAML document, keyed like the flag defaults of the
+// settings package:
+//
+//	defaults:
+//	  get.output: json
+//	blocked:
+//	  - service.cp.archive
+//
+// It must be signed: the base64 ed25519 signature of the document is
+// fetched from its URL with ".sig" appended, and checked against the public
+// key set by SWARMCTL_PROFILE_KEY. The last profile verified is cached for
+// an hour, and used when its URL cannot be reached.
+package profile
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/moby/swarmctl/internal/settings"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// EnvURL sets the URL of the profile, unless --profile-url is set.
+	EnvURL = "SWARMCTL_PROFILE_URL"
+	// EnvKey sets the base64 ed25519 public key the profile is signed with.
+	EnvKey = "SWARMCTL_PROFILE_KEY"
+)
+
+// DefaultTTL is how long a cached profile is used before it is fetched
+// again.
+const DefaultTTL = time.Hour
+
+// maxSize bounds the size of the profile and of its signature.
+const maxSize = 1 << 20
+
+// Profile holds the defaults and blocked flags of an organization.
+type Profile struct {
+	// Defaults are the defaults of flags, applied after those set with
+	// "swarmctl config set".
+	Defaults map[string]string `yaml:"defaults"`
+	// Blocked are the keys of the flags that cannot be set, on the command
+	// line or with "swarmctl config set".
+	Blocked []string `yaml:"blocked"`
+}
+
+// ParseKey parses a base64 ed25519 public key.
+func ParseKey(s string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, errors.Errorf("invalid %s, must be a base64 ed25519 public key", EnvKey)
+	}
+	return key, nil
+}
+
+// Loader fetches a profile, and caches it.
+type Loader struct {
+	URL      string
+	Key      ed25519.PublicKey
+	CacheDir string
+	TTL      time.Duration
+	Client   *http.Client
+	// Err receives the warnings, such as a profile that could not be
+	// refreshed.
+	Err io.Writer
+
+	now func() time.Time
+}
+
+// cached is a profile in the cache, with its signature to verify it again
+// when it is read.
+type cached struct {
+	Fetched   time.Time `json:"fetched"`
+	Document  string    `json:"document"`
+	Signature string    `json:"signature"`
+}
+
+// Load returns the profile, from the cache while it is fresh. A stale
+// profile is used, with a warning, when it cannot be fetched again.
+func (l *Loader) Load(ctx context.Context) (*Profile, error) {
+	now := time.Now
+	if l.now != nil {
+		now = l.now
+	}
+	c, cacheErr := l.readCache()
+	if cacheErr == nil && now().Sub(c.Fetched) < l.TTL {
+		return l.parse(c)
+	}
+
+	fetched, err := l.fetch(ctx)
+	if err == nil {
+		fetched.Fetched = now()
+		var p *Profile
+		if p, err = l.parse(fetched); err == nil {
+			if err := l.writeCache(fetched); err != nil {
+				fmt.Fprintf(l.Err, "WARNING: unable to cache the organization profile: %s\n", err)
+			}
+			return p, nil
+		}
+	}
+	if cacheErr != nil {
+		return nil, errors.Wrapf(err, "unable to load the organization profile from %s", l.URL)
+	}
+	fmt.Fprintf(l.Err, "WARNING: unable to refresh the organization profile from %s, using the one fetched %s: %s\n", l.URL, c.Fetched.Format(time.RFC3339), err)
+	return l.parse(c)
+}
+
+// parse verifies the signature of a profile and parses it.
+func (l *Loader) parse(c cached) (*Profile, error) {
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(c.Signature))
+	if err != nil || !ed25519.Verify(l.Key, []byte(c.Document), signature) {
+		return nil, errors.New("the signature of the organization profile does not match its key")
+	}
+	p := &Profile{}
+	if err := yaml.UnmarshalStrict([]byte(c.Document), p); err != nil {
+		return nil, errors.Wrap(err, "invalid organization profile")
+	}
+	return p, nil
+}
+
+func (l *Loader) fetch(ctx context.Context) (cached, error) {
+	document, err := l.get(ctx, l.URL)
+	if err != nil {
+		return cached{}, err
+	}
+	signature, err := l.get(ctx, l.URL+".sig")
+	if err != nil {
+		return cached{}, err
+	}
+	return cached{Document: document, Signature: signature}, nil
+}
+
+func (l *Loader) get(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := l.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", errors.Errorf("GET %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// cachePath returns the path of the cached profile, per URL.
+func (l *Loader) cachePath() string {
+	sum := sha256.Sum256([]byte(l.URL))
+	return filepath.Join(l.CacheDir, hex.EncodeToString(sum[:8])+".json")
+}
+
+func (l *Loader) readCache() (cached, error) {
+	var c cached
+	data, err := os.ReadFile(l.cachePath())
+	if err != nil {
+		return c, err
+	}
+	return c, json.Unmarshal(data, &c)
+}
+
+func (l *Loader) writeCache(c cached) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(l.CacheDir, 0o700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(l.CacheDir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), l.cachePath())
+}
+
+// Apply fails if a flag of cmd that is set is blocked, then sets the flags
+// that are not set to the defaults of the profile. It is called once the
+// defaults of the settings package are applied, which override those of
+// the profile.
+func (p *Profile) Apply(cmd *cobra.Command) error {
+	blocked := make(map[string]bool, len(p.Blocked))
+	for _, key := range p.Blocked {
+		blocked[key] = true
+	}
+	var err error
+	cmd.LocalFlags().VisitAll(func(flag *pflag.Flag) {
+		if err != nil {
+			return
+		}
+		key := settings.Key(cmd, flag.Name)
+		if flag.Changed && blocked[key] {
+			err = errors.Errorf("flag --%s of %q is blocked by the organization profile", flag.Name, cmd.CommandPath())
+			return
+		}
+		value, ok := p.Defaults[key]
+		if !ok || flag.Changed {
+			return
+		}
+		if setErr := cmd.Flags().Set(flag.Name, value); setErr != nil {
+			err = errors.Wrapf(setErr, "invalid default for %s in the organization profile", key)
+		}
+	})
+	return err
+}
@@ -0,0 +1,144 @@
+package profile
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+const testDocument = `defaults:
+  swarm.ca.progress: plain
+blocked:
+  - swarm.ca.rotate
+`
+
+// profileServer serves document, signed with key, and counts the requests
+// it gets. It answers 503 while down is set.
+type profileServer struct {
+	document  string
+	signature string
+	down      bool
+	requests  int
+}
+
+func (s *profileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.requests++
+	switch {
+	case s.down:
+		w.WriteHeader(http.StatusServiceUnavailable)
+	case r.URL.Path == "/profile.yml":
+		w.Write([]byte(s.document))
+	case r.URL.Path == "/profile.yml.sig":
+		w.Write([]byte(s.signature))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestLoader(t *testing.T, document string) (*Loader, *profileServer, *time.Time, *bytes.Buffer) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(nil)
+	assert.NilError(t, err)
+	fake := &profileServer{document: document, signature: base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(document)))}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+
+	now := time.Date(2023, 1, 2, 15, 0, 0, 0, time.UTC)
+	errOut := new(bytes.Buffer)
+	return &Loader{
+		URL:      srv.URL + "/profile.yml",
+		Key:      pub,
+		CacheDir: t.TempDir(),
+		TTL:      DefaultTTL,
+		Client:   srv.Client(),
+		Err:      errOut,
+		now:      func() time.Time { return now },
+	}, fake, &now, errOut
+}
+
+func TestLoad(t *testing.T) {
+	loader, fake, now, errOut := newTestLoader(t, testDocument)
+	expected := &Profile{Defaults: map[string]string{"swarm.ca.progress": "plain"}, Blocked: []string{"swarm.ca.rotate"}}
+
+	p, err := loader.Load(context.Background())
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(p, expected))
+	assert.Check(t, is.Equal(fake.requests, 2))
+
+	*now = now.Add(30 * time.Minute)
+	p, err = loader.Load(context.Background())
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(p, expected))
+	assert.Check(t, is.Equal(fake.requests, 2), "a fresh profile must be read from the cache")
+
+	*now = now.Add(time.Hour)
+	fake.down = true
+	p, err = loader.Load(context.Background())
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(p, expected))
+	assert.Check(t, is.Contains(errOut.String(), "WARNING: unable to refresh the organization profile from "+loader.URL+", using the one fetched 2023-01-02T15:00:00Z"))
+}
+
+func TestLoadErrors(t *testing.T) {
+	loader, fake, _, _ := newTestLoader(t, testDocument)
+	fake.down = true
+	_, err := loader.Load(context.Background())
+	assert.Check(t, is.ErrorContains(err, "unable to load the organization profile from "+loader.URL+": GET "+loader.URL+": 503 Service Unavailable"))
+
+	loader, fake, _, _ = newTestLoader(t, testDocument)
+	fake.document += "  - swarm.ca.detach\n"
+	_, err = loader.Load(context.Background())
+	assert.Check(t, is.ErrorContains(err, "the signature of the organization profile does not match its key"))
+
+	loader, _, _, _ = newTestLoader(t, "registries: [registry.example.com]\n")
+	_, err = loader.Load(context.Background())
+	assert.Check(t, is.ErrorContains(err, "invalid organization profile"))
+}
+
+func TestApply(t *testing.T) {
+	newCommand := func() *cobra.Command {
+		root := &cobra.Command{Use: "swarmctl"}
+		swarm := &cobra.Command{Use: "swarm"}
+		ca := &cobra.Command{Use: "ca"}
+		ca.Flags().String("progress", "auto", "")
+		ca.Flags().Bool("rotate", false, "")
+		swarm.AddCommand(ca)
+		root.AddCommand(swarm)
+		return ca
+	}
+	p := &Profile{Defaults: map[string]string{"swarm.ca.progress": "plain"}, Blocked: []string{"swarm.ca.rotate"}}
+
+	cmd := newCommand()
+	assert.NilError(t, p.Apply(cmd))
+	progress, _ := cmd.Flags().GetString("progress")
+	assert.Check(t, is.Equal(progress, "plain"))
+
+	cmd = newCommand()
+	assert.NilError(t, cmd.Flags().Set("progress", "tty"))
+	assert.NilError(t, p.Apply(cmd))
+	progress, _ = cmd.Flags().GetString("progress")
+	assert.Check(t, is.Equal(progress, "tty"))
+
+	cmd = newCommand()
+	assert.NilError(t, cmd.Flags().Set("rotate", "true"))
+	assert.Error(t, p.Apply(cmd), `flag --rotate of "swarmctl swarm ca" is blocked by the organization profile`)
+}
+
+func TestParseKey(t *testing.T) {
+	_, err := ParseKey("")
+	assert.Check(t, is.Error(err, "invalid SWARMCTL_PROFILE_KEY, must be a base64 ed25519 public key"))
+	pub, _, err := ed25519.GenerateKey(nil)
+	assert.NilError(t, err)
+	key, err := ParseKey(base64.StdEncoding.EncodeToString(pub) + "\n")
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(key, pub))
+}
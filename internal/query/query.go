@@ -0,0 +1,71 @@
+// Package query evaluates jq expressions over the JSON output of commands,
+// with an embedded engine so that jq does not need to be installed.
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/itchyny/gojq"
+	"github.com/pkg/errors"
+)
+
+// Query is a compiled jq expression.
+type Query struct {
+	code *gojq.Code
+}
+
+// Parse compiles a jq expression.
+func Parse(expr string) (*Query, error) {
+	q, err := gojq.Parse(expr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid query %q", expr)
+	}
+	code, err := gojq.Compile(q)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid query %q", expr)
+	}
+	return &Query{code: code}, nil
+}
+
+// Run evaluates q over each JSON value read from in, as it is read, and
+// writes the results to out one per line. Strings are written as is, like
+// jq --raw-output does, and the other values as indented JSON.
+func (q *Query) Run(out io.Writer, in io.Reader) error {
+	dec := json.NewDecoder(in)
+	for {
+		var v interface{}
+		if err := dec.Decode(&v); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return errors.Wrap(err, "the output of the command is not JSON")
+		}
+		iter := q.code.Run(v)
+		for {
+			result, ok := iter.Next()
+			if !ok {
+				break
+			}
+			if err, ok := result.(error); ok {
+				return err
+			}
+			if err := write(out, result); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func write(out io.Writer, v interface{}) error {
+	if s, ok := v.(string); ok {
+		_, err := fmt.Fprintln(out, s)
+		return err
+	}
+	data, err := json.MarshalIndent(v, "", "    ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(out, string(data))
+	return err
+}
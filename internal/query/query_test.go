@@ -0,0 +1,66 @@
+package query
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expr     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "raw strings",
+			expr:     ".[].Spec.Name",
+			input:    `[{"Spec":{"Name":"web"}},{"Spec":{"Name":"db"}}]`,
+			expected: "web\ndb\n",
+		},
+		{
+			name:     "one object per line",
+			expr:     "select(.Action == \"create\") | .Actor.ID",
+			input:    "{\"Action\":\"create\",\"Actor\":{\"ID\":\"abc\"}}\n{\"Action\":\"remove\",\"Actor\":{\"ID\":\"def\"}}\n",
+			expected: "abc\n",
+		},
+		{
+			name:     "values as JSON",
+			expr:     "{name: .Spec.Name, cpus: .Spec.Resources.NanoCPUs}",
+			input:    `{"Spec":{"Name":"web","Resources":{"NanoCPUs":1500000000}}}`,
+			expected: "{\n    \"cpus\": 1500000000,\n    \"name\": \"web\"\n}\n",
+		},
+		{
+			name:     "empty output",
+			expr:     ".",
+			input:    "",
+			expected: "",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := Parse(tc.expr)
+			assert.NilError(t, err)
+			var out bytes.Buffer
+			assert.NilError(t, q.Run(&out, strings.NewReader(tc.input)))
+			assert.Equal(t, out.String(), tc.expected)
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	_, err := Parse(".[")
+	assert.ErrorContains(t, err, `invalid query ".["`)
+
+	q, err := Parse(".Name")
+	assert.NilError(t, err)
+	err = q.Run(&bytes.Buffer{}, strings.NewReader("ID   NAME\n"))
+	assert.ErrorContains(t, err, "the output of the command is not JSON")
+
+	err = q.Run(&bytes.Buffer{}, strings.NewReader(`["web"]`))
+	assert.ErrorContains(t, err, "expected an object but got: array")
+}
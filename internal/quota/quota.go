@@ -0,0 +1,245 @@
+// Package quota enforces client-side resource quotas on the services of a
+// stack namespace. Swarm has no quotas; these are guard rails checked by
+// swarmctl before it updates a service, so they only hold if every user of
+// the cluster goes through swarmctl with the same quota file.
+//
+// The quota file is a YAML document with the limits of each namespace:
+//
+//	namespaces:
+//	  web:
+//	    services: 10
+//	    replicas: 30
+//	    cpus: 4
+//	    memory: 8GiB
+//
+// CPUs and memory limit the reservations of the tasks of the namespace. A
+// missing or zero limit is unlimited.
+package quota
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docker/cli/cli/config"
+	"github.com/docker/cli/opts"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+	units "github.com/docker/go-units"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// envFile overrides the path of the quota file.
+const envFile = "SWARMCTL_QUOTA_FILE"
+
+// File returns the path of the quota file, from SWARMCTL_QUOTA_FILE, and
+// defaults to swarmctl/quotas.yml in the docker configuration directory.
+func File() string {
+	if path := os.Getenv(envFile); path != "" {
+		return path
+	}
+	return filepath.Join(config.Dir(), "swarmctl", "quotas.yml")
+}
+
+// Limits are the quotas of a namespace.
+type Limits struct {
+	Services int    `yaml:"services"`
+	Replicas uint64 `yaml:"replicas"`
+	CPUs     string `yaml:"cpus"`
+	Memory   string `yaml:"memory"`
+
+	nanoCPUs    int64
+	memoryBytes int64
+}
+
+// Quotas are the limits of each namespace.
+type Quotas struct {
+	Namespaces map[string]*Limits `yaml:"namespaces"`
+}
+
+// Load reads a quota file. A missing file defines no quotas.
+func Load(path string) (*Quotas, error) {
+	q := &Quotas{}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.UnmarshalStrict(data, q); err != nil {
+		return nil, errors.Wrapf(err, "invalid quota file %s", path)
+	}
+	for namespace, limits := range q.Namespaces {
+		if limits == nil {
+			continue
+		}
+		if limits.CPUs != "" {
+			if limits.nanoCPUs, err = opts.ParseCPUs(limits.CPUs); err != nil {
+				return nil, errors.Wrapf(err, "invalid quota file %s: namespace %s", path, namespace)
+			}
+		}
+		if limits.Memory != "" {
+			if limits.memoryBytes, err = units.RAMInBytes(limits.Memory); err != nil {
+				return nil, errors.Wrapf(err, "invalid quota file %s: namespace %s", path, namespace)
+			}
+		}
+	}
+	return q, nil
+}
+
+// Limits returns the limits of a namespace, or nil if it has none.
+func (q *Quotas) Limits(namespace string) *Limits {
+	if namespace == "" {
+		return nil
+	}
+	return q.Namespaces[namespace]
+}
+
+// Usage is the consumption of the services of a namespace.
+type Usage struct {
+	Services    int
+	Replicas    uint64
+	NanoCPUs    int64
+	MemoryBytes int64
+}
+
+// Add adds the consumption of a service. The replicas of global services
+// are read from the service status, which must have been requested.
+func (u *Usage) Add(service swarm.Service) {
+	replicas := Replicas(service)
+	u.Services++
+	u.Replicas += replicas
+	if r := service.Spec.TaskTemplate.Resources; r != nil && r.Reservations != nil {
+		u.NanoCPUs += r.Reservations.NanoCPUs * int64(replicas)
+		u.MemoryBytes += r.Reservations.MemoryBytes * int64(replicas)
+	}
+}
+
+// Replicas returns the number of tasks of a service.
+func Replicas(service swarm.Service) uint64 {
+	switch mode := service.Spec.Mode; {
+	case mode.Replicated != nil && mode.Replicated.Replicas != nil:
+		return *mode.Replicated.Replicas
+	case mode.ReplicatedJob != nil && mode.ReplicatedJob.MaxConcurrent != nil:
+		return *mode.ReplicatedJob.MaxConcurrent
+	case service.ServiceStatus != nil:
+		return service.ServiceStatus.DesiredTasks
+	default:
+		return 0
+	}
+}
+
+// Namespace returns the stack namespace of a service.
+func Namespace(spec swarm.ServiceSpec) string {
+	return spec.Labels[swarmlabel.Namespace]
+}
+
+// Consumption returns the usage of each namespace.
+func Consumption(services []swarm.Service) map[string]Usage {
+	usage := make(map[string]Usage)
+	for _, service := range services {
+		namespace := Namespace(service.Spec)
+		if namespace == "" {
+			continue
+		}
+		u := usage[namespace]
+		u.Add(service)
+		usage[namespace] = u
+	}
+	return usage
+}
+
+// Check returns an error if the usage of a namespace goes over its limits.
+// Limits that were already exceeded are only enforced if the usage grows,
+// so that a namespace over quota can be scaled down.
+func (q *Quotas) Check(namespace string, before, after Usage) error {
+	limits := q.Limits(namespace)
+	if limits == nil {
+		return nil
+	}
+	var exceeded []string
+	if limits.Services > 0 && after.Services > limits.Services && after.Services > before.Services {
+		exceeded = append(exceeded, fmt.Sprintf("services %d/%d", after.Services, limits.Services))
+	}
+	if limits.Replicas > 0 && after.Replicas > limits.Replicas && after.Replicas > before.Replicas {
+		exceeded = append(exceeded, fmt.Sprintf("replicas %d/%d", after.Replicas, limits.Replicas))
+	}
+	if limits.nanoCPUs > 0 && after.NanoCPUs > limits.nanoCPUs && after.NanoCPUs > before.NanoCPUs {
+		exceeded = append(exceeded, fmt.Sprintf("cpus %s/%s", FormatCPUs(after.NanoCPUs), FormatCPUs(limits.nanoCPUs)))
+	}
+	if limits.memoryBytes > 0 && after.MemoryBytes > limits.memoryBytes && after.MemoryBytes > before.MemoryBytes {
+		exceeded = append(exceeded, fmt.Sprintf("memory %s/%s", FormatMemory(after.MemoryBytes), FormatMemory(limits.memoryBytes)))
+	}
+	if len(exceeded) > 0 {
+		return errors.Errorf("quota exceeded for namespace %s: %s", namespace, strings.Join(exceeded, ", "))
+	}
+	return nil
+}
+
+// CheckServiceUpdate returns an error if updating the spec of a service
+// exceeds the quotas of its namespace. A service moved to another namespace
+// is checked against the quotas of its new namespace.
+func (q *Quotas) CheckServiceUpdate(ctx context.Context, apiClient client.APIClient, service swarm.Service, spec swarm.ServiceSpec) error {
+	namespace := Namespace(spec)
+	if q.Limits(namespace) == nil {
+		return nil
+	}
+	services, err := apiClient.ServiceList(ctx, types.ServiceListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", swarmlabel.Namespace+"="+namespace)),
+		Status:  true,
+	})
+	if err != nil {
+		return err
+	}
+
+	var before, after Usage
+	found := false
+	for _, s := range services {
+		before.Add(s)
+		if s.ID == service.ID {
+			s.Spec = spec
+			found = true
+		}
+		after.Add(s)
+	}
+	if !found {
+		service.Spec = spec
+		after.Add(service)
+	}
+	return q.Check(namespace, before, after)
+}
+
+// CheckServiceCreate returns an error if creating a service exceeds the
+// quotas of its namespace.
+func (q *Quotas) CheckServiceCreate(ctx context.Context, apiClient client.APIClient, spec swarm.ServiceSpec) error {
+	return q.CheckServiceUpdate(ctx, apiClient, swarm.Service{}, spec)
+}
+
+// SortedNamespaces returns the namespaces with quotas, sorted by name.
+func (q *Quotas) SortedNamespaces() []string {
+	namespaces := make([]string, 0, len(q.Namespaces))
+	for namespace := range q.Namespaces {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// FormatCPUs formats nano CPUs as a number of CPUs.
+func FormatCPUs(nanoCPUs int64) string {
+	return strconv.FormatFloat(float64(nanoCPUs)/1e9, 'f', -1, 64)
+}
+
+// FormatMemory formats a number of bytes with binary units.
+func FormatMemory(bytes int64) string {
+	return units.BytesSize(float64(bytes))
+}
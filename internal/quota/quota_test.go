@@ -0,0 +1,126 @@
+package quota
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/docker/docker/client"
+	"github.com/moby/swarmctl/internal/swarmlabel"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func writeQuotas(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "quotas.yml")
+	assert.NilError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func testService(id, namespace string, replicas uint64, cpus, memory int64) swarm.Service {
+	return swarm.Service{
+		ID: id,
+		Spec: swarm.ServiceSpec{
+			Annotations: swarm.Annotations{Name: id, Labels: map[string]string{swarmlabel.Namespace: namespace}},
+			Mode:        swarm.ServiceMode{Replicated: &swarm.ReplicatedService{Replicas: &replicas}},
+			TaskTemplate: swarm.TaskSpec{Resources: &swarm.ResourceRequirements{
+				Reservations: &swarm.Resources{NanoCPUs: cpus, MemoryBytes: memory},
+			}},
+		},
+	}
+}
+
+func TestLoad(t *testing.T) {
+	quotas, err := Load(writeQuotas(t, `
+namespaces:
+  web:
+    services: 2
+    cpus: 1.5
+    memory: 1GiB
+`))
+	assert.NilError(t, err)
+	limits := quotas.Limits("web")
+	assert.Assert(t, limits != nil)
+	assert.Check(t, is.Equal(limits.Services, 2))
+	assert.Check(t, is.Equal(limits.nanoCPUs, int64(1500000000)))
+	assert.Check(t, is.Equal(limits.memoryBytes, int64(1<<30)))
+	assert.Check(t, quotas.Limits("db") == nil)
+	assert.Check(t, quotas.Limits("") == nil)
+}
+
+func TestLoadMissing(t *testing.T) {
+	quotas, err := Load(filepath.Join(t.TempDir(), "quotas.yml"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(quotas.Namespaces, 0))
+}
+
+func TestLoadInvalid(t *testing.T) {
+	_, err := Load(writeQuotas(t, "namespaces:\n  web:\n    memory: lots\n"))
+	assert.Check(t, is.ErrorContains(err, "namespace web: invalid size: 'lots'"))
+
+	_, err = Load(writeQuotas(t, "namespaces:\n  web:\n    replica: 3\n"))
+	assert.Check(t, is.ErrorContains(err, "field replica not found"))
+}
+
+func TestConsumption(t *testing.T) {
+	usage := Consumption([]swarm.Service{
+		testService("a", "web", 2, 500000000, 1<<20),
+		testService("b", "web", 1, 0, 0),
+		testService("c", "", 5, 0, 0),
+		{
+			ID:            "d",
+			Spec:          swarm.ServiceSpec{Annotations: swarm.Annotations{Labels: map[string]string{swarmlabel.Namespace: "db"}}, Mode: swarm.ServiceMode{Global: &swarm.GlobalService{}}},
+			ServiceStatus: &swarm.ServiceStatus{DesiredTasks: 3},
+		},
+	})
+	assert.Check(t, is.DeepEqual(usage, map[string]Usage{
+		"web": {Services: 2, Replicas: 3, NanoCPUs: 1000000000, MemoryBytes: 2 << 20},
+		"db":  {Services: 1, Replicas: 3},
+	}))
+}
+
+func TestCheck(t *testing.T) {
+	quotas, err := Load(writeQuotas(t, "namespaces:\n  web:\n    replicas: 4\n    memory: 1GiB\n"))
+	assert.NilError(t, err)
+
+	assert.Check(t, quotas.Check("web", Usage{Replicas: 2}, Usage{Replicas: 4}))
+	assert.Check(t, is.Error(quotas.Check("web", Usage{Replicas: 2}, Usage{Replicas: 5, MemoryBytes: 2 << 30}),
+		"quota exceeded for namespace web: replicas 5/4, memory 2GiB/1GiB"))
+	// a namespace over quota can be scaled down
+	assert.Check(t, quotas.Check("web", Usage{Replicas: 8}, Usage{Replicas: 6}))
+	assert.Check(t, quotas.Check("db", Usage{}, Usage{Replicas: 100}))
+}
+
+type fakeClient struct {
+	client.Client
+	services []swarm.Service
+}
+
+func (cli *fakeClient) ServiceList(context.Context, types.ServiceListOptions) ([]swarm.Service, error) {
+	return cli.services, nil
+}
+
+func TestCheckServiceUpdate(t *testing.T) {
+	quotas, err := Load(writeQuotas(t, "namespaces:\n  web:\n    replicas: 4\n"))
+	assert.NilError(t, err)
+	apiClient := &fakeClient{services: []swarm.Service{
+		testService("a", "web", 2, 0, 0),
+		testService("b", "web", 1, 0, 0),
+	}}
+
+	scaled := testService("a", "web", 3, 0, 0)
+	assert.Check(t, quotas.CheckServiceUpdate(context.Background(), apiClient, apiClient.services[0], scaled.Spec))
+
+	scaled = testService("a", "web", 4, 0, 0)
+	assert.Check(t, is.Error(quotas.CheckServiceUpdate(context.Background(), apiClient, apiClient.services[0], scaled.Spec),
+		"quota exceeded for namespace web: replicas 5/4"))
+
+	// a service moved into the namespace
+	moved := testService("c", "web", 2, 0, 0)
+	assert.Check(t, is.Error(quotas.CheckServiceUpdate(context.Background(), apiClient, testService("c", "", 2, 0, 0), moved.Spec),
+		"quota exceeded for namespace web: replicas 5/4"))
+}
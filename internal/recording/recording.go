@@ -0,0 +1,189 @@
+// Package recording persists the API requests and responses of a command to
+// a directory, and serves them back so that the command can be replayed
+// without a daemon.
+//
+// A recording directory contains a command.json file with the arguments of
+// the recorded command, and for every request, in the order they were sent,
+// a NNNN.json file with the request and the response metadata and a
+// NNNN.body file with the raw response body.
+package recording
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const commandFile = "command.json"
+
+// Command describes the recorded command.
+type Command struct {
+	Args    []string  `json:"args"`
+	Created time.Time `json:"created"`
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  Request   `json:"request"`
+	Response *Response `json:"response,omitempty"`
+	// Error is set when the request failed without a response.
+	Error string `json:"error,omitempty"`
+}
+
+// Request is the recorded part of a request. Request headers are not
+// recorded, as they may contain registry credentials.
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+// Response is the recorded part of a response. The body is stored in a
+// separate file, so that streamed responses are kept even if the command is
+// interrupted.
+type Response struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+	// Complete is set once the whole body has been read. Replaying an
+	// incomplete body blocks at its end until the request is canceled, like
+	// the stream it was recorded from.
+	Complete bool `json:"complete"`
+}
+
+// Recorder writes the requests sent through its middleware to a directory.
+type Recorder struct {
+	dir string
+	mu  sync.Mutex
+	seq int
+}
+
+// NewRecorder creates dir if needed and records args as the command being
+// recorded. dir must be empty, so that recordings are never mixed.
+func NewRecorder(dir string, args []string) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) > 0 {
+		return nil, errors.Errorf("recording directory %s is not empty", dir)
+	}
+	cmd := Command{Args: args, Created: time.Now().UTC()}
+	if err := writeJSON(filepath.Join(dir, commandFile), cmd); err != nil {
+		return nil, err
+	}
+	return &Recorder{dir: dir}, nil
+}
+
+// Middleware wraps next to record every request sent through it.
+func (r *Recorder) Middleware(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return r.roundTrip(next, req)
+	})
+}
+
+func (r *Recorder) roundTrip(next http.RoundTripper, req *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	r.seq++
+	name := filepath.Join(r.dir, fmt.Sprintf("%04d", r.seq))
+	r.mu.Unlock()
+
+	interaction := Interaction{Request: Request{Method: req.Method, URL: req.URL.RequestURI()}}
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		interaction.Request.Body = string(body)
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		interaction.Error = err.Error()
+		if werr := writeJSON(name+".json", interaction); werr != nil {
+			return nil, werr
+		}
+		return nil, err
+	}
+
+	interaction.Response = &Response{StatusCode: resp.StatusCode, Header: resp.Header}
+	if err := writeJSON(name+".json", interaction); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	bodyFile, err := os.OpenFile(name+".body", os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	resp.Body = &recordingBody{
+		ReadCloser:  resp.Body,
+		file:        bodyFile,
+		name:        name,
+		interaction: interaction,
+	}
+	return resp, nil
+}
+
+// recordingBody copies the response body to its file as it is read.
+type recordingBody struct {
+	io.ReadCloser
+	file        *os.File
+	name        string
+	interaction Interaction
+	once        sync.Once
+}
+
+func (b *recordingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		if _, werr := b.file.Write(p[:n]); werr != nil {
+			return n, werr
+		}
+	}
+	if err == io.EOF {
+		b.finish(true)
+	}
+	return n, err
+}
+
+func (b *recordingBody) Close() error {
+	b.finish(false)
+	return b.ReadCloser.Close()
+}
+
+func (b *recordingBody) finish(complete bool) {
+	b.once.Do(func() {
+		b.file.Close()
+		if complete {
+			b.interaction.Response.Complete = true
+			_ = writeJSON(b.name+".json", b.interaction)
+		}
+	})
+}
+
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
@@ -0,0 +1,104 @@
+package recording
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func get(ctx context.Context, t *testing.T, transport http.RoundTripper, url string) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	assert.NilError(t, err)
+	resp, err := transport.RoundTrip(req)
+	assert.NilError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	assert.NilError(t, err)
+	return resp, string(body)
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Api-Version", "1.41")
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, r.URL.Path+"?"+r.URL.RawQuery)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	recorder, err := NewRecorder(dir, []string{"node", "info"})
+	assert.NilError(t, err)
+	transport := recorder.Middleware(http.DefaultTransport)
+	get(context.Background(), t, transport, server.URL+"/v1.41/info")
+	get(context.Background(), t, transport, server.URL+"/v1.41/nodes?filters=a")
+
+	rec, err := Load(dir)
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(rec.Command.Args, []string{"node", "info"}))
+
+	replay := rec.Transport()
+	// requests are matched by URL rather than order, and fall back to the path
+	resp, body := get(context.Background(), t, replay, "http://replay/v1.41/nodes?filters=b")
+	assert.Check(t, is.Equal(body, "/v1.41/nodes?filters=a"))
+	assert.Check(t, is.Equal(resp.Header.Get("Api-Version"), "1.41"))
+	_, body = get(context.Background(), t, replay, "http://replay/v1.41/info")
+	assert.Check(t, is.Equal(body, "/v1.41/info?"))
+
+	req, err := http.NewRequest(http.MethodGet, "http://replay/v1.41/info", nil)
+	assert.NilError(t, err)
+	_, err = replay.RoundTrip(req)
+	assert.Error(t, err, "no recorded response for GET /v1.41/info")
+}
+
+func TestReplayInterruptedStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"Type":"node"}`+"\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	recorder, err := NewRecorder(dir, []string{"events"})
+	assert.NilError(t, err)
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/v1.41/events", nil)
+	assert.NilError(t, err)
+	resp, err := recorder.Middleware(http.DefaultTransport).RoundTrip(req)
+	assert.NilError(t, err)
+	line := make([]byte, 16)
+	_, err = io.ReadFull(resp.Body, line)
+	assert.NilError(t, err)
+	resp.Body.Close()
+
+	rec, err := Load(dir)
+	assert.NilError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, "http://replay/v1.41/events", nil)
+	assert.NilError(t, err)
+	resp, err = rec.Transport().RoundTrip(req)
+	assert.NilError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	assert.Check(t, is.Equal(string(body), `{"Type":"node"}`+"\n"))
+	assert.Check(t, is.ErrorIs(err, context.DeadlineExceeded))
+}
+
+func TestNewRecorderNotEmpty(t *testing.T) {
+	dir := t.TempDir()
+	_, err := NewRecorder(dir, nil)
+	assert.NilError(t, err)
+	_, err = NewRecorder(dir, nil)
+	assert.Check(t, is.ErrorContains(err, "is not empty"))
+}
+
+func TestLoadNotARecording(t *testing.T) {
+	_, err := Load(t.TempDir())
+	assert.Check(t, is.ErrorContains(err, "is not a recording directory"))
+}
@@ -0,0 +1,151 @@
+package recording
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Recording is a recording loaded from disk.
+type Recording struct {
+	Command      Command
+	interactions []recordedInteraction
+}
+
+type recordedInteraction struct {
+	Interaction
+	body []byte
+}
+
+// Load reads the recording in dir.
+func Load(dir string) (*Recording, error) {
+	var rec Recording
+	if err := readJSON(filepath.Join(dir, commandFile), &rec.Command); err != nil {
+		if os.IsNotExist(errors.Cause(err)) {
+			return nil, errors.Errorf("%s is not a recording directory", dir)
+		}
+		return nil, err
+	}
+
+	names, err := filepath.Glob(filepath.Join(dir, "[0-9]*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var ri recordedInteraction
+		if err := readJSON(name, &ri.Interaction); err != nil {
+			return nil, err
+		}
+		if ri.Response != nil {
+			ri.body, err = os.ReadFile(strings.TrimSuffix(name, ".json") + ".body")
+			if err != nil && !os.IsNotExist(err) {
+				return nil, err
+			}
+		}
+		rec.interactions = append(rec.interactions, ri)
+	}
+	return &rec, nil
+}
+
+// Transport returns a transport answering requests with the recorded
+// responses. Each recorded response is served once, to the first request
+// with the same method and URL. As URLs may contain timestamps computed when
+// the command runs, a request with no exact match gets the first unused
+// response recorded for the same method and path.
+func (r *Recording) Transport() http.RoundTripper {
+	return &replayer{interactions: r.interactions, used: make([]bool, len(r.interactions))}
+}
+
+type replayer struct {
+	mu           sync.Mutex
+	interactions []recordedInteraction
+	used         []bool
+}
+
+func (r *replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	ri, ok := r.next(req)
+	if !ok {
+		return nil, errors.Errorf("no recorded response for %s %s", req.Method, req.URL.RequestURI())
+	}
+	if ri.Error != "" {
+		return nil, errors.New(ri.Error)
+	}
+
+	var body io.ReadCloser = io.NopCloser(bytes.NewReader(ri.body))
+	if !ri.Response.Complete {
+		body = &blockingBody{Reader: bytes.NewReader(ri.body), req: req}
+	}
+	return &http.Response{
+		Status:        http.StatusText(ri.Response.StatusCode),
+		StatusCode:    ri.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        ri.Response.Header.Clone(),
+		Body:          body,
+		ContentLength: -1,
+		Request:       req,
+	}, nil
+}
+
+func (r *replayer) next(req *http.Request) (recordedInteraction, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	uri := req.URL.RequestURI()
+	match := func(sameURL func(recorded *url.URL) bool) (recordedInteraction, bool) {
+		for i, ri := range r.interactions {
+			if r.used[i] || ri.Request.Method != req.Method {
+				continue
+			}
+			recorded, err := url.ParseRequestURI(ri.Request.URL)
+			if err != nil || !sameURL(recorded) {
+				continue
+			}
+			r.used[i] = true
+			return ri, true
+		}
+		return recordedInteraction{}, false
+	}
+	if ri, ok := match(func(u *url.URL) bool { return u.RequestURI() == uri }); ok {
+		return ri, true
+	}
+	return match(func(u *url.URL) bool { return u.Path == req.URL.Path })
+}
+
+// blockingBody serves a body whose recording was interrupted, then waits for
+// the request to be canceled instead of reporting the end of the stream.
+type blockingBody struct {
+	*bytes.Reader
+	req *http.Request
+}
+
+func (b *blockingBody) Read(p []byte) (int, error) {
+	if b.Reader.Len() > 0 {
+		return b.Reader.Read(p)
+	}
+	<-b.req.Context().Done()
+	return 0, b.req.Context().Err()
+}
+
+func (b *blockingBody) Close() error {
+	return nil
+}
+
+func readJSON(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return errors.Wrapf(json.Unmarshal(data, v), "invalid recording file %s", path)
+}
@@ -0,0 +1,113 @@
+// Package redact masks the sensitive values of the swarm objects printed by
+// swarmctl, such as the passwords set in the environment of services.
+package redact
+
+import (
+	"io"
+	"strings"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+// Mask replaces the redacted values.
+const Mask = "[redacted]"
+
+// DefaultPatterns are the patterns of the names of the environment variables
+// redacted by default.
+var DefaultPatterns = []string{"PASSWORD", "TOKEN", "KEY"}
+
+// Redactor masks the environment variables whose names contain one of its
+// patterns, regardless of case. A nil Redactor masks nothing.
+type Redactor struct {
+	patterns []string
+}
+
+// New returns a Redactor for patterns.
+func New(patterns []string) *Redactor {
+	r := &Redactor{}
+	for _, p := range patterns {
+		if p = strings.TrimSpace(p); p != "" {
+			r.patterns = append(r.patterns, strings.ToUpper(p))
+		}
+	}
+	return r
+}
+
+func (r *Redactor) sensitive(name string) bool {
+	name = strings.ToUpper(name)
+	for _, p := range r.patterns {
+		if strings.Contains(name, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Env returns env, in the NAME=VALUE form, with the sensitive values masked.
+func (r *Redactor) Env(env []string) []string {
+	if r == nil || len(env) == 0 {
+		return env
+	}
+	redacted := make([]string, len(env))
+	for i, e := range env {
+		if name, _, ok := strings.Cut(e, "="); ok && r.sensitive(name) {
+			e = name + "=" + Mask
+		}
+		redacted[i] = e
+	}
+	return redacted
+}
+
+// ContainerSpec masks the sensitive values of c.
+func (r *Redactor) ContainerSpec(c *swarm.ContainerSpec) {
+	if r == nil || c == nil {
+		return
+	}
+	c.Env = r.Env(c.Env)
+}
+
+// Service masks the sensitive values of the current and previous specs of s.
+func (r *Redactor) Service(s *swarm.Service) {
+	if r == nil {
+		return
+	}
+	r.ContainerSpec(s.Spec.TaskTemplate.ContainerSpec)
+	if s.PreviousSpec != nil {
+		r.ContainerSpec(s.PreviousSpec.TaskTemplate.ContainerSpec)
+	}
+}
+
+// Task masks the sensitive values of the spec of t.
+func (r *Redactor) Task(t *swarm.Task) {
+	if r == nil {
+		return
+	}
+	r.ContainerSpec(t.Spec.ContainerSpec)
+}
+
+// Options are the redaction flags of a command.
+type Options struct {
+	patterns []string
+	noRedact bool
+}
+
+// AddFlags adds the redaction flags to flags.
+func AddFlags(flags *pflag.FlagSet, opts *Options) {
+	flags.StringSliceVar(&opts.patterns, "redact", DefaultPatterns, "Mask the environment variables whose names contain one of these patterns")
+	flags.BoolVar(&opts.noRedact, "no-redact", false, "Print the sensitive values as they are, once confirmed")
+}
+
+// Redactor returns the Redactor selected by opts. With --no-redact, the user
+// is asked to confirm, and a nil Redactor is returned once they did.
+func (opts Options) Redactor(in io.Reader, out io.Writer) (*Redactor, error) {
+	if !opts.noRedact {
+		return New(opts.patterns), nil
+	}
+	if !command.PromptForConfirmation(in, out, "WARNING: --no-redact prints passwords, tokens and keys as they are.\nAre you sure you want to continue?") {
+		return nil, errors.New("aborted, sensitive values are only printed once confirmed")
+	}
+	return nil, nil
+}
@@ -0,0 +1,55 @@
+package redact
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/spf13/pflag"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestEnv(t *testing.T) {
+	env := []string{"DB_PASSWORD=hunter2", "api_token=abc", "AWS_ACCESS_KEY_ID=AKIA", "PORT=80", "EMPTY"}
+	r := New(DefaultPatterns)
+	assert.Check(t, is.DeepEqual(r.Env(env), []string{"DB_PASSWORD=[redacted]", "api_token=[redacted]", "AWS_ACCESS_KEY_ID=[redacted]", "PORT=80", "EMPTY"}))
+	assert.Check(t, is.Equal(env[0], "DB_PASSWORD=hunter2"), "the input is not modified")
+
+	r = New([]string{"port", " "})
+	assert.Check(t, is.DeepEqual(r.Env(env), []string{"DB_PASSWORD=hunter2", "api_token=abc", "AWS_ACCESS_KEY_ID=AKIA", "PORT=[redacted]", "EMPTY"}))
+
+	var none *Redactor
+	assert.Check(t, is.DeepEqual(none.Env(env), env))
+}
+
+func TestService(t *testing.T) {
+	s := swarm.Service{
+		Spec:         swarm.ServiceSpec{TaskTemplate: swarm.TaskSpec{ContainerSpec: &swarm.ContainerSpec{Env: []string{"TOKEN=new"}}}},
+		PreviousSpec: &swarm.ServiceSpec{TaskTemplate: swarm.TaskSpec{ContainerSpec: &swarm.ContainerSpec{Env: []string{"TOKEN=old"}}}},
+	}
+	New(DefaultPatterns).Service(&s)
+	assert.Check(t, is.DeepEqual(s.Spec.TaskTemplate.ContainerSpec.Env, []string{"TOKEN=[redacted]"}))
+	assert.Check(t, is.DeepEqual(s.PreviousSpec.TaskTemplate.ContainerSpec.Env, []string{"TOKEN=[redacted]"}))
+}
+
+func TestOptions(t *testing.T) {
+	var opts Options
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	AddFlags(flags, &opts)
+	assert.NilError(t, flags.Parse([]string{"--redact", "secret"}))
+	r, err := opts.Redactor(strings.NewReader(""), &bytes.Buffer{})
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(r.Env([]string{"MY_SECRET=1", "DB_PASSWORD=2"}), []string{"MY_SECRET=[redacted]", "DB_PASSWORD=2"}))
+
+	assert.NilError(t, flags.Parse([]string{"--no-redact"}))
+	var prompt bytes.Buffer
+	_, err = opts.Redactor(strings.NewReader("n\n"), &prompt)
+	assert.Error(t, err, "aborted, sensitive values are only printed once confirmed")
+	assert.Check(t, is.Contains(prompt.String(), "Are you sure you want to continue? [y/N]"))
+
+	r, err = opts.Redactor(strings.NewReader("y\n"), &bytes.Buffer{})
+	assert.NilError(t, err)
+	assert.Check(t, r == nil)
+}
@@ -0,0 +1,120 @@
+// Package settings manages the flag defaults swarmctl reads from the plugins
+// section of the docker CLI configuration file.
+//
+// A default is keyed by the path of its command, without the root command,
+// and the name of its flag, joined with dots: "swarm.ca.progress" is the
+// default of the --progress flag of "swarmctl swarm ca".
+package settings
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// pluginName is the key of the swarmctl settings in the plugins section of
+// the configuration file.
+const pluginName = "swarmctl"
+
+// Defaults returns the flag defaults set in cfg.
+func Defaults(cfg *configfile.ConfigFile) map[string]string {
+	defaults := make(map[string]string)
+	for key, value := range cfg.Plugins[pluginName] {
+		defaults[key] = value
+	}
+	return defaults
+}
+
+// Keys returns the keys of the flag defaults set in cfg, sorted.
+func Keys(cfg *configfile.ConfigFile) []string {
+	var keys []string
+	for key := range cfg.Plugins[pluginName] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Get returns the flag default set in cfg for key.
+func Get(cfg *configfile.ConfigFile, key string) (string, bool) {
+	value, ok := cfg.Plugins[pluginName][key]
+	return value, ok
+}
+
+// Set sets the flag default for key in cfg. The configuration file must be
+// saved for the change to persist.
+func Set(cfg *configfile.ConfigFile, key, value string) {
+	if cfg.Plugins == nil {
+		cfg.Plugins = make(map[string]map[string]string)
+	}
+	if cfg.Plugins[pluginName] == nil {
+		cfg.Plugins[pluginName] = make(map[string]string)
+	}
+	cfg.Plugins[pluginName][key] = value
+}
+
+// Unset removes the flag default for key from cfg, reporting whether it was
+// set. The configuration file must be saved for the change to persist.
+func Unset(cfg *configfile.ConfigFile, key string) bool {
+	if _, ok := cfg.Plugins[pluginName][key]; !ok {
+		return false
+	}
+	delete(cfg.Plugins[pluginName], key)
+	if len(cfg.Plugins[pluginName]) == 0 {
+		delete(cfg.Plugins, pluginName)
+	}
+	return true
+}
+
+// Key returns the key of the default of the flag named name of cmd.
+func Key(cmd *cobra.Command, name string) string {
+	path := []string{name}
+	for c := cmd; c.HasParent(); c = c.Parent() {
+		path = append([]string{c.Name()}, path...)
+	}
+	return strings.Join(path, ".")
+}
+
+// Lookup returns the command of the tree rooted at root, and the flag of this
+// command, that key refers to.
+func Lookup(root *cobra.Command, key string) (*cobra.Command, *pflag.Flag, error) {
+	path := strings.Split(key, ".")
+	if len(path) < 2 {
+		return nil, nil, errors.Errorf("invalid key %q, must be the path of a command and a flag, such as swarm.ca.progress", key)
+	}
+	cmd, rest, err := root.Find(path[:len(path)-1])
+	if err != nil || len(rest) > 0 || cmd == root {
+		return nil, nil, errors.Errorf("invalid key %q: unknown command %q", key, strings.Join(path[:len(path)-1], " "))
+	}
+	name := path[len(path)-1]
+	flag := cmd.LocalFlags().Lookup(name)
+	if flag == nil || flag.Hidden || name == "help" {
+		return nil, nil, errors.Errorf("invalid key %q: unknown flag --%s for %q", key, name, cmd.CommandPath())
+	}
+	return cmd, flag, nil
+}
+
+// Apply sets the flags of cmd that are not set on the command line to their
+// defaults in cfg. Defaults are set as if they were given on the command
+// line, so that they are seen by the commands checking if a flag changed.
+func Apply(cmd *cobra.Command, cfg *configfile.ConfigFile) error {
+	defaults := cfg.Plugins[pluginName]
+	if len(defaults) == 0 {
+		return nil
+	}
+	var err error
+	cmd.LocalFlags().VisitAll(func(flag *pflag.Flag) {
+		value, ok := defaults[Key(cmd, flag.Name)]
+		if !ok || flag.Changed || err != nil {
+			return
+		}
+		if setErr := cmd.Flags().Set(flag.Name, value); setErr != nil {
+			err = errors.Wrapf(setErr, "invalid default set with \"swarmctl config set %s\"", Key(cmd, flag.Name))
+		}
+	})
+	return err
+}
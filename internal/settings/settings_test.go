@@ -0,0 +1,60 @@
+package settings
+
+import (
+	"testing"
+
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/spf13/cobra"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func newCommand() (*cobra.Command, *cobra.Command) {
+	root := &cobra.Command{Use: "swarmctl"}
+	ca := &cobra.Command{Use: "ca", RunE: func(*cobra.Command, []string) error { return nil }}
+	ca.Flags().String("progress", "auto", "")
+	ca.Flags().Bool("detach", false, "")
+	swarm := &cobra.Command{Use: "swarm"}
+	swarm.AddCommand(ca)
+	root.AddCommand(swarm)
+	return root, ca
+}
+
+func TestKey(t *testing.T) {
+	_, ca := newCommand()
+	assert.Check(t, is.Equal(Key(ca, "progress"), "swarm.ca.progress"))
+}
+
+func TestApply(t *testing.T) {
+	cfg := configfile.New("config.json")
+	Set(cfg, "swarm.ca.progress", "plain")
+	Set(cfg, "swarm.ca.detach", "true")
+
+	_, ca := newCommand()
+	assert.NilError(t, ca.ParseFlags([]string{"--progress", "json"}))
+	assert.NilError(t, Apply(ca, cfg))
+
+	progress, err := ca.Flags().GetString("progress")
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(progress, "json"), "flags set on the command line take precedence")
+	detach, err := ca.Flags().GetBool("detach")
+	assert.NilError(t, err)
+	assert.Check(t, detach)
+}
+
+func TestApplyInvalidDefault(t *testing.T) {
+	cfg := configfile.New("config.json")
+	Set(cfg, "swarm.ca.detach", "maybe")
+
+	_, ca := newCommand()
+	assert.Error(t, Apply(ca, cfg), `invalid default set with "swarmctl config set swarm.ca.detach": invalid argument "maybe" for "--detach" flag: strconv.ParseBool: parsing "maybe": invalid syntax`)
+}
+
+func TestUnset(t *testing.T) {
+	cfg := configfile.New("config.json")
+	Set(cfg, "swarm.ca.progress", "plain")
+	assert.Check(t, Unset(cfg, "swarm.ca.progress"))
+	assert.Check(t, !Unset(cfg, "swarm.ca.progress"))
+	_, ok := cfg.Plugins[pluginName]
+	assert.Check(t, !ok, "the plugin section is removed once empty")
+}
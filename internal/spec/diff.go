@@ -0,0 +1,124 @@
+package spec
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around changes.
+const diffContext = 3
+
+// Diff returns a unified diff of two texts, or an empty string if they are
+// equal.
+func Diff(oldName, newName string, a, b []byte) string {
+	x := splitLines(string(a))
+	y := splitLines(string(b))
+	ops := diffLines(x, y)
+
+	var out strings.Builder
+	for start := 0; start < len(ops); {
+		// skip to the next change
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+		begin := start - diffContext
+		if begin < 0 {
+			begin = 0
+		}
+		// extend the hunk while changes are close enough to be shown together
+		end, unchanged := start, 0
+		for end < len(ops) && unchanged <= 2*diffContext {
+			if ops[end].kind == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+			end++
+		}
+		if unchanged > diffContext {
+			end -= unchanged - diffContext
+		}
+
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+		}
+		hunk := ops[begin:end]
+		oldStart, newStart := hunk[0].x+1, hunk[0].y+1
+		var oldLen, newLen int
+		for _, op := range hunk {
+			if op.kind != '+' {
+				oldLen++
+			}
+			if op.kind != '-' {
+				newLen++
+			}
+		}
+		if oldLen == 0 {
+			oldStart--
+		}
+		if newLen == 0 {
+			newStart--
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", oldStart, oldLen, newStart, newLen)
+		for _, op := range hunk {
+			fmt.Fprintf(&out, "%c%s\n", op.kind, op.line)
+		}
+		start = end
+	}
+	return out.String()
+}
+
+type diffOp struct {
+	kind rune // ' ', '-' or '+'
+	line string
+	x, y int // line indexes in the old and new texts
+}
+
+// diffLines computes the edit script between x and y from their longest
+// common subsequence. Specs are small enough for the quadratic table.
+func diffLines(x, y []string) []diffOp {
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			ops = append(ops, diffOp{kind: ' ', line: x[i], x: i, y: j})
+			i++
+			j++
+		case j == len(y) || (i < len(x) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{kind: '-', line: x[i], x: i, y: j})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: '+', line: y[j], x: i, y: j})
+			j++
+		}
+	}
+	return ops
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
@@ -0,0 +1,70 @@
+package spec
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func FuzzYAMLToJSON(f *testing.F) {
+	for _, seed := range []string{
+		"Name: web\nLabels:\n  tier: front\n",
+		"TaskTemplate:\n  ContainerSpec:\n    Args:\n    - -g\n    - daemon off;\n",
+		"# nothing to see here\n",
+		"1: one\ntrue: yes\n~: null\n",
+		"a: &a [1, 2]\nb: *a\n",
+		"Delay: 9007199254740993\nRatio: .nan\n",
+		"- [\n",
+		"? [a, b]\n: c\n",
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		doc, err := YAMLToJSON(data)
+		if err != nil {
+			return
+		}
+		if !json.Valid(doc) {
+			t.Fatalf("invalid JSON %q for YAML %q", doc, data)
+		}
+	})
+}
+
+func FuzzMergePatch(f *testing.F) {
+	for _, seed := range [][2]string{
+		{`{"a":"b"}`, `{"a":"c"}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":1}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`},
+		{`{"Delay":9007199254740993}`, `{"Name":"web"}`},
+		{`{}`, `{"a":{"bb":{"ccc":true}}}`},
+		{`[1,2]`, `"bar"`},
+	} {
+		f.Add([]byte(seed[0]), []byte(seed[1]))
+	}
+	f.Fuzz(func(t *testing.T, original, modified []byte) {
+		// Null values cannot be set by a merge patch, so documents holding
+		// them do not round trip.
+		if bytes.Contains(original, []byte("null")) || bytes.Contains(modified, []byte("null")) {
+			return
+		}
+		patch, err := CreateMergePatch(original, modified)
+		if err != nil {
+			return
+		}
+		merged, err := MergePatch(original, patch)
+		if err != nil {
+			t.Fatalf("cannot apply patch %s to %s: %v", patch, original, err)
+		}
+		expected, err := decodeObject(modified)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, err := decodeObject(merged)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !equalJSON(actual, expected) {
+			t.Fatalf("patch %s turns %s into %s, expected %s", patch, original, merged, modified)
+		}
+	})
+}
@@ -0,0 +1,267 @@
+package spec
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// jsonPatchOp is an operation of an RFC 6902 JSON patch.
+type jsonPatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// JSONPatch applies an RFC 6902 JSON patch to a JSON document. The first
+// failing operation aborts the whole patch.
+func JSONPatch(doc, patch []byte) ([]byte, error) {
+	var ops []jsonPatchOp
+	dec := json.NewDecoder(bytes.NewReader(patch))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&ops); err != nil {
+		return nil, errors.Wrap(err, "invalid JSON patch")
+	}
+	d, err := decodeValue(doc)
+	if err != nil {
+		return nil, err
+	}
+	for i, op := range ops {
+		d, err = applyOp(d, op)
+		if err != nil {
+			return nil, errors.Wrapf(err, "operation %d (%s %s)", i, op.Op, op.Path)
+		}
+	}
+	return json.Marshal(d)
+}
+
+func applyOp(doc interface{}, op jsonPatchOp) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	switch op.Op {
+	case "add", "replace", "test":
+		if len(op.Value) == 0 {
+			return nil, errors.New(`missing "value"`)
+		}
+		if value, err = decodeValue(op.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	switch op.Op {
+	case "add":
+		return setValue(doc, path, value, false)
+	case "replace":
+		return setValue(doc, path, value, true)
+	case "remove":
+		doc, _, err = removeValue(doc, path)
+		return doc, err
+	case "test":
+		current, err := getValue(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !equalJSON(current, value) {
+			return nil, errors.New("test failed")
+		}
+		return doc, nil
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if isPrefix(from, path) && len(from) < len(path) {
+				return nil, errors.New("cannot move a value into one of its children")
+			}
+			doc, value, err = removeValue(doc, from)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			if value, err = getValue(doc, from); err != nil {
+				return nil, err
+			}
+			// copy the value, so that patching one copy does not change the other
+			data, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			if value, err = decodeValue(data); err != nil {
+				return nil, err
+			}
+		}
+		return setValue(doc, path, value, false)
+	default:
+		return nil, errors.Errorf("unknown operation %q", op.Op)
+	}
+}
+
+// parsePointer splits an RFC 6901 JSON pointer into its unescaped tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, errors.Errorf("invalid JSON pointer %q", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// arrayIndex parses the index of an array element. The index may be equal
+// to the length of the array when appending.
+func arrayIndex(token string, length int, appending bool) (int, error) {
+	if appending && token == "-" {
+		return length, nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, errors.Errorf("invalid array index %q", token)
+	}
+	limit := length - 1
+	if appending {
+		limit = length
+	}
+	if i > limit {
+		return 0, errors.Errorf("array index %d out of bounds", i)
+	}
+	return i, nil
+}
+
+func getValue(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch d := doc.(type) {
+		case map[string]interface{}:
+			v, ok := d[token]
+			if !ok {
+				return nil, errors.Errorf("path not found: %q", token)
+			}
+			doc = v
+		case []interface{}:
+			i, err := arrayIndex(token, len(d), false)
+			if err != nil {
+				return nil, err
+			}
+			doc = d[i]
+		default:
+			return nil, errors.Errorf("path not found: %q", token)
+		}
+	}
+	return doc, nil
+}
+
+// setValue adds or replaces the value at path, and returns the updated
+// document. Added array elements are inserted before the element at their
+// index.
+func setValue(doc interface{}, path []string, value interface{}, replace bool) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	token, last := path[0], len(path) == 1
+	switch d := doc.(type) {
+	case map[string]interface{}:
+		child, ok := d[token]
+		if !ok && (replace || !last) {
+			return nil, errors.Errorf("path not found: %q", token)
+		}
+		if last {
+			d[token] = value
+			return d, nil
+		}
+		child, err := setValue(child, path[1:], value, replace)
+		if err != nil {
+			return nil, err
+		}
+		d[token] = child
+		return d, nil
+	case []interface{}:
+		i, err := arrayIndex(token, len(d), last && !replace)
+		if err != nil {
+			return nil, err
+		}
+		if !last {
+			child, err := setValue(d[i], path[1:], value, replace)
+			if err != nil {
+				return nil, err
+			}
+			d[i] = child
+			return d, nil
+		}
+		if replace {
+			d[i] = value
+			return d, nil
+		}
+		d = append(d, nil)
+		copy(d[i+1:], d[i:])
+		d[i] = value
+		return d, nil
+	default:
+		return nil, errors.Errorf("path not found: %q", token)
+	}
+}
+
+// removeValue removes the value at path, and returns the updated document
+// and the removed value.
+func removeValue(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, errors.New("cannot remove the whole document")
+	}
+	token, last := path[0], len(path) == 1
+	switch d := doc.(type) {
+	case map[string]interface{}:
+		child, ok := d[token]
+		if !ok {
+			return nil, nil, errors.Errorf("path not found: %q", token)
+		}
+		if last {
+			delete(d, token)
+			return d, child, nil
+		}
+		child, removed, err := removeValue(child, path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		d[token] = child
+		return d, removed, nil
+	case []interface{}:
+		i, err := arrayIndex(token, len(d), false)
+		if err != nil {
+			return nil, nil, err
+		}
+		if last {
+			removed := d[i]
+			return append(d[:i], d[i+1:]...), removed, nil
+		}
+		child, removed, err := removeValue(d[i], path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		d[i] = child
+		return d, removed, nil
+	default:
+		return nil, nil, errors.Errorf("path not found: %q", token)
+	}
+}
@@ -0,0 +1,120 @@
+package spec
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestJSONPatch(t *testing.T) {
+	testCases := []struct {
+		doc      string
+		patch    string
+		expected string
+	}{
+		{
+			doc:      `{"foo":"bar"}`,
+			patch:    `[{"op":"add","path":"/baz","value":"qux"}]`,
+			expected: `{"baz":"qux","foo":"bar"}`,
+		},
+		{
+			doc:      `{"foo":["bar","baz"]}`,
+			patch:    `[{"op":"add","path":"/foo/1","value":"qux"}]`,
+			expected: `{"foo":["bar","qux","baz"]}`,
+		},
+		{
+			doc:      `{"foo":["bar"]}`,
+			patch:    `[{"op":"add","path":"/foo/-","value":"baz"}]`,
+			expected: `{"foo":["bar","baz"]}`,
+		},
+		{
+			doc:      `{"baz":"qux","foo":"bar"}`,
+			patch:    `[{"op":"remove","path":"/baz"}]`,
+			expected: `{"foo":"bar"}`,
+		},
+		{
+			doc:      `{"foo":["bar","qux","baz"]}`,
+			patch:    `[{"op":"remove","path":"/foo/1"}]`,
+			expected: `{"foo":["bar","baz"]}`,
+		},
+		{
+			doc:      `{"baz":"qux","foo":"bar"}`,
+			patch:    `[{"op":"replace","path":"/baz","value":"boo"}]`,
+			expected: `{"baz":"boo","foo":"bar"}`,
+		},
+		{
+			doc:      `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			patch:    `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			expected: `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`,
+		},
+		{
+			doc:      `{"foo":["all","grass","cows","eat"]}`,
+			patch:    `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+			expected: `{"foo":["all","cows","eat","grass"]}`,
+		},
+		{
+			doc:      `{"foo":{"bar":[1]}}`,
+			patch:    `[{"op":"copy","from":"/foo/bar","path":"/baz"},{"op":"add","path":"/baz/-","value":2}]`,
+			expected: `{"baz":[1,2],"foo":{"bar":[1]}}`,
+		},
+		{
+			doc:      `{"a/b":{"m~n":1}}`,
+			patch:    `[{"op":"test","path":"/a~1b/m~0n","value":1},{"op":"replace","path":"/a~1b/m~0n","value":null}]`,
+			expected: `{"a/b":{"m~n":null}}`,
+		},
+	}
+	for _, tc := range testCases {
+		patched, err := JSONPatch([]byte(tc.doc), []byte(tc.patch))
+		assert.NilError(t, err, tc.patch)
+		assert.Check(t, is.Equal(string(patched), tc.expected), tc.patch)
+	}
+}
+
+func TestJSONPatchErrors(t *testing.T) {
+	testCases := []struct {
+		patch    string
+		expected string
+	}{
+		{
+			patch:    `{"op":"add"}`,
+			expected: "invalid JSON patch: json: cannot unmarshal object into Go value of type []spec.jsonPatchOp",
+		},
+		{
+			patch:    `[{"op":"add","path":"/a/b","value":1}]`,
+			expected: `operation 0 (add /a/b): path not found: "a"`,
+		},
+		{
+			patch:    `[{"op":"replace","path":"/foo/5","value":1}]`,
+			expected: `operation 0 (replace /foo/5): array index 5 out of bounds`,
+		},
+		{
+			patch:    `[{"op":"add","path":"/foo/01","value":1}]`,
+			expected: `operation 0 (add /foo/01): invalid array index "01"`,
+		},
+		{
+			patch:    `[{"op":"add","path":"/bar"}]`,
+			expected: `operation 0 (add /bar): missing "value"`,
+		},
+		{
+			patch:    `[{"op":"remove","path":"/foo/0"},{"op":"test","path":"/foo/0","value":"x"}]`,
+			expected: `operation 1 (test /foo/0): test failed`,
+		},
+		{
+			patch:    `[{"op":"move","from":"/foo","path":"/foo/0"}]`,
+			expected: `operation 0 (move /foo/0): cannot move a value into one of its children`,
+		},
+		{
+			patch:    `[{"op":"rename","path":"/foo"}]`,
+			expected: `operation 0 (rename /foo): unknown operation "rename"`,
+		},
+		{
+			patch:    `[{"op":"remove","path":"foo"}]`,
+			expected: `operation 0 (remove foo): invalid JSON pointer "foo"`,
+		},
+	}
+	for _, tc := range testCases {
+		_, err := JSONPatch([]byte(`{"foo":["x","y"]}`), []byte(tc.patch))
+		assert.Check(t, is.Error(err, tc.expected), tc.patch)
+	}
+}
@@ -0,0 +1,225 @@
+// Package spec converts the specs of swarm objects to and from YAML, and
+// applies RFC 7386 JSON merge patches and RFC 6902 JSON patches to them.
+//
+// Specs are converted through their JSON encoding, so that the YAML
+// documents use the same field names as the API and `docker inspect`.
+package spec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// ToYAML returns the YAML document of v, with the fields in the order of
+// its JSON encoding. Null fields are left out.
+func ToYAML(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.MapSlice
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(withoutNulls(doc))
+}
+
+func withoutNulls(v interface{}) interface{} {
+	switch v := v.(type) {
+	case yaml.MapSlice:
+		m := make(yaml.MapSlice, 0, len(v))
+		for _, item := range v {
+			if item.Value != nil {
+				m = append(m, yaml.MapItem{Key: item.Key, Value: withoutNulls(item.Value)})
+			}
+		}
+		return m
+	case []interface{}:
+		for i, value := range v {
+			v[i] = withoutNulls(value)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// YAMLToJSON converts a YAML document to JSON. A document that only
+// contains comments is converted to "null".
+func YAMLToJSON(data []byte) ([]byte, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	doc, err := jsonValue(doc)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+// jsonValue converts the maps decoded by yaml.Unmarshal, which may have keys
+// of any type, to maps that can be encoded to JSON.
+func jsonValue(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			k, ok := key.(string)
+			if !ok {
+				k = fmt.Sprint(key)
+			}
+			value, err := jsonValue(value)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = value
+		}
+		return m, nil
+	case []interface{}:
+		for i, value := range v {
+			value, err := jsonValue(value)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = value
+		}
+		return v, nil
+	default:
+		return v, nil
+	}
+}
+
+// Decode decodes a JSON document into v, and fails on fields that v does
+// not have so that typos are not silently dropped.
+func Decode(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return errors.Wrap(err, "invalid spec")
+	}
+	return nil
+}
+
+// CreateMergePatch returns the merge patch that turns the original JSON
+// document into the modified one. The patch is "{}" if the documents are
+// equal.
+func CreateMergePatch(original, modified []byte) ([]byte, error) {
+	o, err := decodeObject(original)
+	if err != nil {
+		return nil, err
+	}
+	m, err := decodeObject(modified)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(diffObjects(o, m))
+}
+
+// MergePatch applies an RFC 7386 merge patch to a JSON document.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	d, err := decodeValue(doc)
+	if err != nil {
+		return nil, err
+	}
+	p, err := decodeValue(patch)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid merge patch")
+	}
+	return json.Marshal(mergeValues(d, p))
+}
+
+// IsEmptyPatch returns whether patch is a merge patch without changes.
+func IsEmptyPatch(patch []byte) bool {
+	return string(bytes.TrimSpace(patch)) == "{}"
+}
+
+func decodeValue(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func decodeObject(data []byte) (map[string]interface{}, error) {
+	v, err := decodeValue(data)
+	if err != nil {
+		return nil, err
+	}
+	switch v := v.(type) {
+	case nil:
+		return map[string]interface{}{}, nil
+	case map[string]interface{}:
+		return v, nil
+	default:
+		return nil, errors.Errorf("expected a JSON object, got %T", v)
+	}
+}
+
+func mergeValues(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+			continue
+		}
+		t[key] = mergeValues(t[key], value)
+	}
+	return t
+}
+
+func diffObjects(original, modified map[string]interface{}) map[string]interface{} {
+	patch := map[string]interface{}{}
+	for key, o := range original {
+		m, ok := modified[key]
+		switch {
+		case !ok:
+			if o != nil {
+				patch[key] = nil
+			}
+		case isObject(o) && isObject(m):
+			if sub := diffObjects(o.(map[string]interface{}), m.(map[string]interface{})); len(sub) > 0 {
+				patch[key] = sub
+			}
+		case !equalJSON(o, m):
+			patch[key] = m
+		}
+	}
+	for key, m := range modified {
+		if _, ok := original[key]; !ok {
+			patch[key] = m
+		}
+	}
+	return patch
+}
+
+func isObject(v interface{}) bool {
+	_, ok := v.(map[string]interface{})
+	return ok
+}
+
+func equalJSON(a, b interface{}) bool {
+	x, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	y, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(x, y)
+}
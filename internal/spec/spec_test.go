@@ -0,0 +1,145 @@
+package spec
+
+import (
+	"testing"
+
+	"github.com/docker/docker/api/types/swarm"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestYAMLRoundTrip(t *testing.T) {
+	replicas := uint64(3)
+	s := swarm.ServiceSpec{
+		Annotations: swarm.Annotations{Name: "web", Labels: map[string]string{"tier": "front"}},
+		Mode:        swarm.ServiceMode{Replicated: &swarm.ReplicatedService{Replicas: &replicas}},
+		TaskTemplate: swarm.TaskSpec{
+			ContainerSpec: &swarm.ContainerSpec{Image: "nginx:alpine", Args: []string{"-g", "daemon off;"}},
+		},
+	}
+	data, err := ToYAML(s)
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(data), `Name: web
+Labels:
+  tier: front
+TaskTemplate:
+  ContainerSpec:
+    Image: nginx:alpine
+    Args:
+    - -g
+    - daemon off;
+  ForceUpdate: 0
+Mode:
+  Replicated:
+    Replicas: 3
+`))
+
+	doc, err := YAMLToJSON(data)
+	assert.NilError(t, err)
+	var decoded swarm.ServiceSpec
+	assert.NilError(t, Decode(doc, &decoded))
+	assert.Check(t, is.DeepEqual(decoded, s))
+}
+
+func TestYAMLToJSONEmpty(t *testing.T) {
+	doc, err := YAMLToJSON([]byte("# nothing to see here\n"))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(doc), "null"))
+}
+
+func TestDecodeUnknownField(t *testing.T) {
+	var s swarm.NodeSpec
+	err := Decode([]byte(`{"Availabilty":"drain"}`), &s)
+	assert.Error(t, err, `invalid spec: json: unknown field "Availabilty"`)
+}
+
+func TestCreateMergePatch(t *testing.T) {
+	testCases := []struct {
+		doc      string
+		original string
+		modified string
+		expected string
+	}{
+		{
+			doc:      "equal",
+			original: `{"Name":"web","Labels":null}`,
+			modified: `{"Name":"web"}`,
+			expected: `{}`,
+		},
+		{
+			doc:      "nested change",
+			original: `{"Name":"web","Labels":{"a":"1","b":"2"}}`,
+			modified: `{"Name":"web","Labels":{"a":"1","c":"3"}}`,
+			expected: `{"Labels":{"b":null,"c":"3"}}`,
+		},
+		{
+			doc:      "arrays are replaced",
+			original: `{"Args":["a","b"],"Replicas":1}`,
+			modified: `{"Args":["a"],"Replicas":2}`,
+			expected: `{"Args":["a"],"Replicas":2}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.doc, func(t *testing.T) {
+			patch, err := CreateMergePatch([]byte(tc.original), []byte(tc.modified))
+			assert.NilError(t, err)
+			assert.Check(t, is.Equal(string(patch), tc.expected))
+
+			merged, err := MergePatch([]byte(tc.original), patch)
+			assert.NilError(t, err)
+			patch, err = CreateMergePatch(merged, []byte(tc.modified))
+			assert.NilError(t, err)
+			assert.Check(t, IsEmptyPatch(patch))
+		})
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	// examples from RFC 7386, appendix A
+	testCases := []struct {
+		doc, patch, expected string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tc := range testCases {
+		merged, err := MergePatch([]byte(tc.doc), []byte(tc.patch))
+		assert.NilError(t, err)
+		assert.Check(t, is.Equal(string(merged), tc.expected), "patch %s", tc.patch)
+	}
+}
+
+func TestMergePatchPreservesLargeNumbers(t *testing.T) {
+	merged, err := MergePatch([]byte(`{"Delay":9007199254740993}`), []byte(`{"Name":"web"}`))
+	assert.NilError(t, err)
+	assert.Check(t, is.Equal(string(merged), `{"Delay":9007199254740993,"Name":"web"}`))
+}
+
+func TestDiff(t *testing.T) {
+	a := []byte("1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n")
+	b := []byte("1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n16\n")
+	assert.Check(t, is.Equal(Diff("a", "b", a, b), `--- a
++++ b
+@@ -1,6 +1,6 @@
+ 1
+ 2
+-3
++three
+ 4
+ 5
+ 6
+@@ -13,3 +13,4 @@
+ 13
+ 14
+ 15
++16
+`))
+	assert.Check(t, is.Equal(Diff("a", "b", a, a), ""))
+}
@@ -0,0 +1,11 @@
+// Package swarmlabel defines the labels that several swarmctl commands read
+// or set on swarm objects.
+package swarmlabel
+
+// SuspendedReplicas is set by `service suspend` on the services it scales to
+// 0, to the replica count restored by `service resume`.
+const SuspendedReplicas = "swarmctl.suspend.replicas"
+
+// Namespace is set by `stack deploy` on the objects of a stack, to the name
+// of the stack.
+const Namespace = "com.docker.stack.namespace"
@@ -0,0 +1,2 @@
+// Package builders helps you create struct for your unit test while keeping them expressive.
+package builders
@@ -0,0 +1,81 @@
+package builders
+
+import (
+	"time"
+
+	"github.com/docker/docker/api/types"
+)
+
+// NetworkResource creates a network resource with default values.
+// Any number of networkResource function builder can be pass to modify the existing value.
+// feel free to add another builder func if you need to override another value
+func NetworkResource(builders ...func(resource *types.NetworkResource)) *types.NetworkResource {
+	resource := &types.NetworkResource{}
+
+	for _, builder := range builders {
+		builder(resource)
+	}
+	return resource
+}
+
+// NetworkResourceName sets the name of the resource network
+func NetworkResourceName(name string) func(networkResource *types.NetworkResource) {
+	return func(networkResource *types.NetworkResource) {
+		networkResource.Name = name
+	}
+}
+
+// NetworkResourceID sets the ID of the resource network
+func NetworkResourceID(id string) func(networkResource *types.NetworkResource) {
+	return func(networkResource *types.NetworkResource) {
+		networkResource.ID = id
+	}
+}
+
+// NetworkResourceDriver sets the driver of the resource network
+func NetworkResourceDriver(name string) func(networkResource *types.NetworkResource) {
+	return func(networkResource *types.NetworkResource) {
+		networkResource.Driver = name
+	}
+}
+
+// NetworkResourceScope sets the Scope of the resource network
+func NetworkResourceScope(scope string) func(networkResource *types.NetworkResource) {
+	return func(networkResource *types.NetworkResource) {
+		networkResource.Scope = scope
+	}
+}
+
+// Network creates a swarm scoped overlay network with default values.
+// Any number of networkResource function builder can be passed to augment it.
+func Network(builders ...func(resource *types.NetworkResource)) *types.NetworkResource {
+	defaults := []func(*types.NetworkResource){
+		NetworkResourceID("networkID"),
+		NetworkResourceName("defaultNetworkName"),
+		NetworkResourceDriver("overlay"),
+		NetworkResourceScope("swarm"),
+		NetworkResourceCreated(defaultTime),
+	}
+	return NetworkResource(append(defaults, builders...)...)
+}
+
+// NetworkResourceLabels sets the labels of the resource network
+func NetworkResourceLabels(labels map[string]string) func(networkResource *types.NetworkResource) {
+	return func(networkResource *types.NetworkResource) {
+		networkResource.Labels = labels
+	}
+}
+
+// NetworkResourceCreated sets the creation time of the resource network
+func NetworkResourceCreated(t time.Time) func(networkResource *types.NetworkResource) {
+	return func(networkResource *types.NetworkResource) {
+		networkResource.Created = t
+	}
+}
+
+// NetworkResourceAttachable makes the resource network attachable
+func NetworkResourceAttachable() func(networkResource *types.NetworkResource) {
+	return func(networkResource *types.NetworkResource) {
+		networkResource.Attachable = true
+	}
+}
@@ -0,0 +1,153 @@
+package builders
+
+import (
+	"time"
+
+	"github.com/docker/docker/api/types/swarm"
+)
+
+// Service creates a service with default values.
+// Any number of service builder functions can be passed to augment it.
+func Service(builders ...func(*swarm.Service)) *swarm.Service {
+	service := &swarm.Service{}
+	defaults := []func(*swarm.Service){ServiceID("serviceID"), ServiceName("defaultServiceName")}
+
+	for _, opt := range append(defaults, builders...) {
+		opt(service)
+	}
+
+	return service
+}
+
+// ServiceID sets the service ID
+func ServiceID(ID string) func(*swarm.Service) {
+	return func(service *swarm.Service) {
+		service.ID = ID
+	}
+}
+
+// ServiceName sets the service name
+func ServiceName(name string) func(*swarm.Service) {
+	return func(service *swarm.Service) {
+		service.Spec.Annotations.Name = name
+	}
+}
+
+// ServiceLabels sets the service's labels
+func ServiceLabels(labels map[string]string) func(*swarm.Service) {
+	return func(service *swarm.Service) {
+		service.Spec.Annotations.Labels = labels
+	}
+}
+
+// GlobalService sets the service to use "global" mode
+func GlobalService() func(*swarm.Service) {
+	return func(service *swarm.Service) {
+		service.Spec.Mode = swarm.ServiceMode{Global: &swarm.GlobalService{}}
+	}
+}
+
+// ReplicatedService sets the service to use "replicated" mode with the specified number of replicas
+func ReplicatedService(replicas uint64) func(*swarm.Service) {
+	return func(service *swarm.Service) {
+		service.Spec.Mode = swarm.ServiceMode{Replicated: &swarm.ReplicatedService{Replicas: &replicas}}
+		if service.ServiceStatus == nil {
+			service.ServiceStatus = &swarm.ServiceStatus{}
+		}
+		service.ServiceStatus.DesiredTasks = replicas
+	}
+}
+
+// ServiceStatus sets the services' ServiceStatus (API v1.41 and above)
+func ServiceStatus(desired, running uint64) func(*swarm.Service) {
+	return func(service *swarm.Service) {
+		service.ServiceStatus = &swarm.ServiceStatus{
+			RunningTasks: running,
+			DesiredTasks: desired,
+		}
+	}
+}
+
+// ServiceImage sets the service's image
+func ServiceImage(image string) func(*swarm.Service) {
+	return func(service *swarm.Service) {
+		if service.Spec.TaskTemplate.ContainerSpec == nil {
+			service.Spec.TaskTemplate.ContainerSpec = &swarm.ContainerSpec{}
+		}
+		service.Spec.TaskTemplate.ContainerSpec.Image = image
+	}
+}
+
+// ServicePort sets the service's port
+func ServicePort(port swarm.PortConfig) func(*swarm.Service) {
+	return func(service *swarm.Service) {
+		if service.Spec.EndpointSpec == nil {
+			service.Spec.EndpointSpec = &swarm.EndpointSpec{}
+		}
+		service.Spec.EndpointSpec.Ports = append(service.Spec.EndpointSpec.Ports, port)
+
+		assignedPort := port
+		if assignedPort.PublishedPort == 0 {
+			assignedPort.PublishedPort = 30000
+		}
+		service.Endpoint.Ports = append(service.Endpoint.Ports, assignedPort)
+	}
+}
+
+// JobService creates a replicated job service with default values, running
+// one task to completion.
+// Any number of service builder functions can be passed to augment it.
+func JobService(builders ...func(*swarm.Service)) *swarm.Service {
+	defaults := []func(*swarm.Service){ReplicatedJobService(1, 1)}
+	return Service(append(defaults, builders...)...)
+}
+
+// ReplicatedJobService sets the service to use "replicated-job" mode, running
+// at most maxConcurrent tasks until totalCompletions tasks completed
+func ReplicatedJobService(maxConcurrent, totalCompletions uint64) func(*swarm.Service) {
+	return func(service *swarm.Service) {
+		service.Spec.Mode = swarm.ServiceMode{ReplicatedJob: &swarm.ReplicatedJob{
+			MaxConcurrent:    &maxConcurrent,
+			TotalCompletions: &totalCompletions,
+		}}
+	}
+}
+
+// GlobalJobService sets the service to use "global-job" mode
+func GlobalJobService() func(*swarm.Service) {
+	return func(service *swarm.Service) {
+		service.Spec.Mode = swarm.ServiceMode{GlobalJob: &swarm.GlobalJob{}}
+	}
+}
+
+// JobStatus sets the job status of the service: the iteration of its last
+// execution, and when it was executed
+func JobStatus(iteration uint64, lastExecution time.Time) func(*swarm.Service) {
+	return func(service *swarm.Service) {
+		service.JobStatus = &swarm.JobStatus{
+			JobIteration:  swarm.Version{Index: iteration},
+			LastExecution: lastExecution,
+		}
+	}
+}
+
+// ServiceVersion sets the version of the service
+func ServiceVersion(v swarm.Version) func(*swarm.Service) {
+	return func(service *swarm.Service) {
+		service.Version = v
+	}
+}
+
+// ServiceCreatedAt sets the creation time of the service
+func ServiceCreatedAt(t time.Time) func(*swarm.Service) {
+	return func(service *swarm.Service) {
+		service.CreatedAt = t
+	}
+}
+
+// ServiceUpdatedAt sets the update time of the service
+func ServiceUpdatedAt(t time.Time) func(*swarm.Service) {
+	return func(service *swarm.Service) {
+		service.UpdatedAt = t
+	}
+}
@@ -0,0 +1,221 @@
+package test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/docker/cli/cli/command"
+	"github.com/docker/cli/cli/config/configfile"
+	"github.com/docker/cli/cli/context/docker"
+	"github.com/docker/cli/cli/context/store"
+	"github.com/docker/cli/cli/streams"
+	"github.com/docker/docker/client"
+)
+
+// FakeCli emulates the default DockerCli
+type FakeCli struct {
+	command.DockerCli
+	client         client.APIClient
+	configfile     *configfile.ConfigFile
+	out            *streams.Out
+	outBuffer      *bytes.Buffer
+	err            *bytes.Buffer
+	in             *streams.In
+	server         command.ServerInfo
+	contextStore   store.Store
+	currentContext string
+	dockerEndpoint docker.Endpoint
+	// promptMark is the length of the output when the last scripted
+	// answer was read.
+	promptMark int
+	transcript []Exchange
+}
+
+// Exchange is a prompt written by a command, and the scripted answer it read.
+type Exchange struct {
+	Prompt string
+	Answer string
+}
+
+// NewFakeCli returns a fake for the command.Cli interface
+func NewFakeCli(client client.APIClient, opts ...func(*FakeCli)) *FakeCli {
+	outBuffer := new(bytes.Buffer)
+	errBuffer := new(bytes.Buffer)
+	c := &FakeCli{
+		client:    client,
+		out:       streams.NewOut(outBuffer),
+		outBuffer: outBuffer,
+		err:       errBuffer,
+		in:        streams.NewIn(io.NopCloser(strings.NewReader(""))),
+		// Use an empty string for filename so that tests don't create configfiles
+		// Set cli.ConfigFile().Filename to a tempfile to support Save.
+		configfile:     configfile.New(""),
+		currentContext: command.DefaultContextName,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithTerminal makes the fake cli report its input and output streams as
+// terminals.
+func WithTerminal(c *FakeCli) {
+	c.SetTerminal(true)
+}
+
+// SetTerminal sets whether the input and output streams of the cli report
+// being terminals. It applies to the streams currently set.
+func (c *FakeCli) SetTerminal(isTerminal bool) {
+	c.in.SetIsTerminal(isTerminal)
+	c.out.SetIsTerminal(isTerminal)
+}
+
+// SetInputScript sets the input of the cli to answer the prompts of a
+// command with answers, one line per answer. Each answer is read along with
+// the output written since the previous one, which Transcript returns.
+func (c *FakeCli) SetInputScript(answers ...string) {
+	isTerminal := c.in.IsTerminal()
+	c.in = streams.NewIn(io.NopCloser(&scriptedInput{cli: c, answers: answers}))
+	c.in.SetIsTerminal(isTerminal)
+	c.promptMark = c.outBuffer.Len()
+	c.transcript = nil
+}
+
+// Transcript returns the prompts written by the command and the scripted
+// answers it read, in order.
+func (c *FakeCli) Transcript() []Exchange {
+	return c.transcript
+}
+
+// scriptedInput returns one answer per read, so that commands reading their
+// input with a buffered reader do not consume the answers to the following
+// prompts.
+type scriptedInput struct {
+	cli     *FakeCli
+	answers []string
+	pending []byte
+}
+
+func (s *scriptedInput) Read(p []byte) (int, error) {
+	if len(s.pending) == 0 {
+		if len(s.answers) == 0 {
+			return 0, io.EOF
+		}
+		answer := s.answers[0]
+		s.answers = s.answers[1:]
+		s.cli.record(answer)
+		s.pending = []byte(answer + "\n")
+	}
+	n := copy(p, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+func (c *FakeCli) record(answer string) {
+	output := c.outBuffer.String()
+	prompt := ""
+	if c.promptMark <= len(output) {
+		prompt = output[c.promptMark:]
+	}
+	c.promptMark = len(output)
+	c.transcript = append(c.transcript, Exchange{Prompt: prompt, Answer: answer})
+}
+
+// SetIn sets the input of the cli to the specified ReadCloser
+func (c *FakeCli) SetIn(in *streams.In) {
+	c.in = in
+}
+
+// SetErr sets the stderr stream for the cli to the specified io.Writer
+func (c *FakeCli) SetErr(err *bytes.Buffer) {
+	c.err = err
+}
+
+// SetOut sets the stdout stream for the cli to the specified io.Writer
+func (c *FakeCli) SetOut(out *streams.Out) {
+	c.out = out
+}
+
+// SetConfigFile sets the "fake" config file
+func (c *FakeCli) SetConfigFile(configfile *configfile.ConfigFile) {
+	c.configfile = configfile
+}
+
+// SetContextStore sets the "fake" context store
+func (c *FakeCli) SetContextStore(store store.Store) {
+	c.contextStore = store
+}
+
+// SetCurrentContext sets the "fake" current context
+func (c *FakeCli) SetCurrentContext(name string) {
+	c.currentContext = name
+}
+
+// SetDockerEndpoint sets the "fake" docker endpoint
+func (c *FakeCli) SetDockerEndpoint(ep docker.Endpoint) {
+	c.dockerEndpoint = ep
+}
+
+// Client returns a docker API client
+func (c *FakeCli) Client() client.APIClient {
+	return c.client
+}
+
+// Out returns the output stream (stdout) the cli should write on
+func (c *FakeCli) Out() *streams.Out {
+	return c.out
+}
+
+// Err returns the output stream (stderr) the cli should write on
+func (c *FakeCli) Err() io.Writer {
+	return c.err
+}
+
+// In returns the input stream the cli will use
+func (c *FakeCli) In() *streams.In {
+	return c.in
+}
+
+// ConfigFile returns the cli configfile object (to get client configuration)
+func (c *FakeCli) ConfigFile() *configfile.ConfigFile {
+	return c.configfile
+}
+
+// ContextStore returns the cli context store
+func (c *FakeCli) ContextStore() store.Store {
+	return c.contextStore
+}
+
+// CurrentContext returns the cli context
+func (c *FakeCli) CurrentContext() string {
+	return c.currentContext
+}
+
+// DockerEndpoint returns the current DockerEndpoint
+func (c *FakeCli) DockerEndpoint() docker.Endpoint {
+	return c.dockerEndpoint
+}
+
+// ServerInfo returns API server information for the server used by this client
+func (c *FakeCli) ServerInfo() command.ServerInfo {
+	return c.server
+}
+
+// OutBuffer returns the stdout buffer
+func (c *FakeCli) OutBuffer() *bytes.Buffer {
+	return c.outBuffer
+}
+
+// ErrBuffer Buffer returns the stderr buffer
+func (c *FakeCli) ErrBuffer() *bytes.Buffer {
+	return c.err
+}
+
+// ResetOutputBuffers resets the .OutBuffer() and.ErrBuffer() back to empty
+func (c *FakeCli) ResetOutputBuffers() {
+	c.outBuffer.Reset()
+	c.err.Reset()
+	c.promptMark = 0
+}
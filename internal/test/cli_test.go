@@ -0,0 +1,44 @@
+package test
+
+import (
+	"bufio"
+	"fmt"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestInputScript(t *testing.T) {
+	cli := NewFakeCli(nil)
+	fmt.Fprintln(cli.Out(), "Starting.")
+	cli.SetInputScript("web", "y")
+
+	var answers []string
+	for _, prompt := range []string{"Service name: ", "Remove it? [y/N] "} {
+		fmt.Fprint(cli.Out(), prompt)
+		// a new buffered reader per prompt must not read ahead
+		answer, err := bufio.NewReader(cli.In()).ReadString('\n')
+		assert.NilError(t, err)
+		answers = append(answers, answer)
+	}
+	assert.Check(t, is.DeepEqual(answers, []string{"web\n", "y\n"}))
+	assert.Check(t, is.DeepEqual(cli.Transcript(), []Exchange{
+		{Prompt: "Service name: ", Answer: "web"},
+		{Prompt: "Remove it? [y/N] ", Answer: "y"},
+	}))
+
+	_, err := bufio.NewReader(cli.In()).ReadString('\n')
+	assert.Check(t, is.ErrorContains(err, "EOF"))
+}
+
+func TestTerminal(t *testing.T) {
+	cli := NewFakeCli(nil)
+	assert.Check(t, !cli.In().IsTerminal())
+	assert.Check(t, !cli.Out().IsTerminal())
+
+	cli = NewFakeCli(nil, WithTerminal)
+	cli.SetInputScript("y")
+	assert.Check(t, cli.In().IsTerminal(), "scripted input keeps the terminal state")
+	assert.Check(t, cli.Out().IsTerminal())
+}
@@ -0,0 +1,3 @@
+// Package test is a test-only package that can be used by the command
+// packages to write unit tests.
+package test
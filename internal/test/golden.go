@@ -0,0 +1,60 @@
+package test
+
+import (
+	"flag"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+	"gotest.tools/v3/golden"
+)
+
+var updateGolden = flag.Bool("update-golden", false, "Update the golden files with the normalized output of the tests")
+
+var (
+	timestampPattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})`)
+	// durationPattern matches the relative times printed with
+	// units.HumanDuration, such as "About an hour ago".
+	durationPattern = regexp.MustCompile(`(Less than a second|About a minute|About an hour|\d+ (second|minute|hour|day|week|month|year)s?) ago`)
+	// paddingPattern matches the padding between the columns of a table,
+	// which depends on the width of the normalized values.
+	paddingPattern = regexp.MustCompile(`(\S) {2,}`)
+)
+
+// NormalizeGolden replaces the parts of a command output that depend on the
+// time or the environment the test runs in: timestamps, in any time zone,
+// become <timestamp>, and relative times become "<duration> ago".
+func NormalizeGolden(actual string) string {
+	actual = timestampPattern.ReplaceAllString(actual, "<timestamp>")
+	return durationPattern.ReplaceAllString(actual, "<duration> ago")
+}
+
+// normalizeColumns sets the padding between columns to three spaces and
+// removes trailing spaces, so that outputs only differing by the width of
+// their columns compare equal.
+func normalizeColumns(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = paddingPattern.ReplaceAllString(strings.TrimRight(line, " "), "$1   ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// AssertGolden compares actual, normalized with NormalizeGolden, to the
+// golden file filename in ./testdata, ignoring the width of the columns of
+// tables. The golden file is written instead when the tests run with
+// -update-golden, or with the -update flag of gotest.tools.
+func AssertGolden(t *testing.T, actual, filename string) {
+	t.Helper()
+	normalized := NormalizeGolden(actual)
+	if *updateGolden || golden.FlagUpdate() {
+		assert.NilError(t, os.WriteFile(golden.Path(filename), []byte(normalized), 0o644))
+		return
+	}
+	expected := normalizeColumns(string(golden.Get(t, filename)))
+	assert.Assert(t, is.Equal(normalizeColumns(normalized), expected),
+		"output does not match %s, run the tests with -update-golden to update it", filename)
+}
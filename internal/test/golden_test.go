@@ -0,0 +1,28 @@
+package test
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestNormalizeGolden(t *testing.T) {
+	actual := "ID    CREATED              UPDATED\n" +
+		"abc   2 hours ago          2023-01-02T03:04:05.123456789+02:00\n" +
+		"d     About a minute ago   2023-01-02T03:04:05Z\n"
+	assert.Check(t, is.Equal(NormalizeGolden(actual),
+		"ID    CREATED              UPDATED\n"+
+			"abc   <duration> ago          <timestamp>\n"+
+			"d     <duration> ago   <timestamp>\n"))
+}
+
+func TestNormalizeColumns(t *testing.T) {
+	actual := "ID    NAME\n" +
+		"abc   web   \n" +
+		"    indented  text\n"
+	assert.Check(t, is.Equal(normalizeColumns(actual),
+		"ID   NAME\n"+
+			"abc   web\n"+
+			"    indented   text\n"))
+}
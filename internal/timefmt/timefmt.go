@@ -0,0 +1,68 @@
+// Package timefmt formats the times printed by commands in the style chosen
+// with their --time flag: absolute, relative to now, or as Unix timestamps.
+package timefmt
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+)
+
+// Mode is the style of the printed times, and the value of the --time flag.
+type Mode string
+
+// Modes of the --time flag.
+const (
+	Absolute Mode = "absolute"
+	Relative Mode = "relative"
+	Unix     Mode = "unix"
+)
+
+// AddFlag adds the --time flag to flags, defaulting to absolute times.
+func AddFlag(flags *pflag.FlagSet, mode *Mode) {
+	*mode = Absolute
+	flags.Var(mode, "time", `Format of times ("absolute"|"relative"|"unix")`)
+}
+
+// String implements pflag.Value.
+func (m *Mode) String() string {
+	return string(*m)
+}
+
+// Set implements pflag.Value.
+func (m *Mode) Set(value string) error {
+	switch Mode(value) {
+	case Absolute, Relative, Unix:
+		*m = Mode(value)
+		return nil
+	default:
+		return errors.New("must be absolute, relative or unix")
+	}
+}
+
+// Type implements pflag.Value.
+func (m *Mode) Type() string {
+	return "string"
+}
+
+// Format formats t, using layout for absolute times, which are printed in
+// UTC. Relative times are relative to now. The zero time is printed as "-".
+func (m Mode) Format(t, now time.Time, layout string) string {
+	if t.IsZero() {
+		return "-"
+	}
+	switch m {
+	case Relative:
+		if d := now.Sub(t); d >= 0 {
+			return units.HumanDuration(d) + " ago"
+		}
+		return "in " + units.HumanDuration(t.Sub(now))
+	case Unix:
+		return strconv.FormatInt(t.Unix(), 10)
+	default:
+		return t.UTC().Format(layout)
+	}
+}
@@ -0,0 +1,40 @@
+package timefmt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestFormat(t *testing.T) {
+	now := time.Date(2024, time.January, 30, 12, 0, 0, 0, time.UTC)
+	layout := "2006-01-02 15:04"
+	testCases := []struct {
+		mode     Mode
+		t        time.Time
+		expected string
+	}{
+		{mode: Absolute, t: now.Add(-2 * time.Hour), expected: "2024-01-30 10:00"},
+		{mode: Absolute, t: now.In(time.FixedZone("CET", 3600)), expected: "2024-01-30 12:00"},
+		{mode: Relative, t: now.Add(-2 * time.Hour), expected: "2 hours ago"},
+		{mode: Relative, t: now.Add(15 * time.Hour), expected: "in 15 hours"},
+		{mode: Unix, t: now, expected: "1706616000"},
+		{mode: Relative, t: time.Time{}, expected: "-"},
+	}
+	for _, tc := range testCases {
+		assert.Check(t, is.Equal(tc.mode.Format(tc.t, now, layout), tc.expected), "%s %s", tc.mode, tc.t)
+	}
+}
+
+func TestFlag(t *testing.T) {
+	var mode Mode
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	AddFlag(flags, &mode)
+	assert.Check(t, is.Equal(mode, Absolute))
+	assert.NilError(t, flags.Set("time", "unix"))
+	assert.Check(t, is.Equal(mode, Unix))
+	assert.Error(t, flags.Set("time", "iso"), `invalid argument "iso" for "--time" flag: must be absolute, relative or unix`)
+}
@@ -0,0 +1,168 @@
+// Package usage keeps a local log of the swarmctl commands run, to see which
+// ones are used the most and how long they take. Nothing is recorded until
+// the log is enabled, and it is never sent anywhere.
+//
+// Only the path of the commands is recorded, such as "swarmctl service
+// stats", never their arguments or flags, which may name objects or hold
+// secrets.
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/docker/cli/cli/config"
+)
+
+// Log is a log of the commands run, one JSON entry per line. It is enabled
+// while its file exists. A nil Log records nothing.
+type Log struct {
+	path string
+}
+
+// New returns the Log stored at path.
+func New(path string) *Log {
+	return &Log{path: path}
+}
+
+// Default returns the Log stored in swarmctl/usage.jsonl in the docker
+// configuration directory.
+func Default() *Log {
+	return New(filepath.Join(config.Dir(), "swarmctl", "usage.jsonl"))
+}
+
+// Path returns the path of the file of the log.
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Entry is a command run.
+type Entry struct {
+	Time     time.Time     `json:"time"`
+	Command  string        `json:"command"`
+	Duration time.Duration `json:"duration"`
+	Failed   bool          `json:"failed,omitempty"`
+}
+
+// Enabled reports whether commands are recorded.
+func (l *Log) Enabled() bool {
+	if l == nil {
+		return false
+	}
+	_, err := os.Stat(l.path)
+	return err == nil
+}
+
+// Enable starts recording commands, keeping the entries already recorded.
+func (l *Log) Enable() error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// Disable stops recording commands, and removes the entries recorded.
+func (l *Log) Disable() error {
+	if err := os.Remove(l.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Record appends an entry to the log if it is enabled.
+func (l *Log) Record(e Entry) error {
+	if l == nil {
+		return nil
+	}
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_WRONLY, 0)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Stat aggregates the runs of a command.
+type Stat struct {
+	Command  string        `json:"command"`
+	Count    int           `json:"count"`
+	Failures int           `json:"failures"`
+	Total    time.Duration `json:"-"`
+}
+
+// Average returns the average duration of the runs of the command.
+func (s Stat) Average() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Count)
+}
+
+// Stats returns the stats of the commands recorded since the given time,
+// the most run first. Lines that cannot be parsed, as left by a write
+// that was interrupted, are skipped.
+func (l *Log) Stats(since time.Time) ([]Stat, error) {
+	f, err := os.Open(l.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stats := make(map[string]*Stat)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Entry
+		if json.Unmarshal(scanner.Bytes(), &e) != nil || e.Time.Before(since) {
+			continue
+		}
+		s, ok := stats[e.Command]
+		if !ok {
+			s = &Stat{Command: e.Command}
+			stats[e.Command] = s
+		}
+		s.Count++
+		s.Total += e.Duration
+		if e.Failed {
+			s.Failures++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]Stat, 0, len(stats))
+	for _, s := range stats {
+		result = append(result, *s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Command < result[j].Command
+	})
+	return result, nil
+}
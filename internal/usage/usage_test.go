@@ -0,0 +1,55 @@
+package usage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	is "gotest.tools/v3/assert/cmp"
+)
+
+func TestLog(t *testing.T) {
+	l := New(filepath.Join(t.TempDir(), "swarmctl", "usage.jsonl"))
+	start := time.Date(2023, 1, 2, 15, 0, 0, 0, time.UTC)
+
+	assert.NilError(t, l.Record(Entry{Time: start, Command: "swarmctl get", Duration: time.Second}))
+	assert.Check(t, !l.Enabled())
+	_, err := os.Stat(l.Path())
+	assert.Check(t, os.IsNotExist(err), "disabled log must not be created")
+
+	assert.NilError(t, l.Enable())
+	assert.Check(t, l.Enabled())
+	for i, e := range []Entry{
+		{Command: "swarmctl get", Duration: time.Second},
+		{Command: "swarmctl get", Duration: 3 * time.Second, Failed: true},
+		{Command: "swarmctl version", Duration: time.Second},
+		{Command: "swarmctl events", Duration: time.Minute},
+	} {
+		e.Time = start.Add(time.Duration(i) * time.Hour)
+		assert.NilError(t, l.Record(e))
+	}
+	f, err := os.OpenFile(l.Path(), os.O_APPEND|os.O_WRONLY, 0)
+	assert.NilError(t, err)
+	_, err = f.WriteString(`{"time":"2023-01-02T`)
+	assert.NilError(t, err)
+	assert.NilError(t, f.Close())
+
+	stats, err := l.Stats(time.Time{})
+	assert.NilError(t, err)
+	assert.Check(t, is.DeepEqual(stats, []Stat{
+		{Command: "swarmctl get", Count: 2, Failures: 1, Total: 4 * time.Second},
+		{Command: "swarmctl events", Count: 1, Total: time.Minute},
+		{Command: "swarmctl version", Count: 1, Total: time.Second},
+	}))
+	assert.Check(t, is.Equal(stats[0].Average(), 2*time.Second))
+
+	stats, err = l.Stats(start.Add(2 * time.Hour))
+	assert.NilError(t, err)
+	assert.Check(t, is.Len(stats, 2))
+
+	assert.NilError(t, l.Disable())
+	assert.Check(t, !l.Enabled())
+	assert.NilError(t, l.Disable())
+}
@@ -0,0 +1,7 @@
+// Package version holds the swarmctl version, set at build time with
+//
+//	-ldflags "-X github.com/moby/swarmctl/internal/version.Version=v0.1.0"
+package version
+
+// Version is the swarmctl version.
+var Version = "dev"